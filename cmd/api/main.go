@@ -19,6 +19,7 @@ import (
 	"github.com/georgemunganga/printa-backend/internal/modules/auth"
 	"github.com/georgemunganga/printa-backend/internal/modules/billing"
 	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/claim"
 	"github.com/georgemunganga/printa-backend/internal/modules/comms"
 	"github.com/georgemunganga/printa-backend/internal/modules/conversation"
 	"github.com/georgemunganga/printa-backend/internal/modules/delivery"
@@ -32,6 +33,7 @@ import (
 	"github.com/georgemunganga/printa-backend/internal/modules/policyconsent"
 	"github.com/georgemunganga/printa-backend/internal/modules/pos"
 	"github.com/georgemunganga/printa-backend/internal/modules/production"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
 	"github.com/georgemunganga/printa-backend/internal/modules/routing"
 	"github.com/georgemunganga/printa-backend/internal/modules/submission"
 	"github.com/georgemunganga/printa-backend/internal/modules/user"
@@ -102,14 +104,36 @@ func main() {
 	conversationRepo := conversation.NewPostgresRepository(db)
 	conversationService := conversation.NewService(conversationRepo)
 
+	promoRepo := promo.NewPostgresRepository(db)
+	promoService := promo.NewService(promoRepo)
+
+	commsRepo := comms.NewPostgresRepository(db)
+	commsService := comms.NewService(commsRepo,
+		comms.NewEmailAdapter(),
+		comms.NewSMSAdapter(),
+		comms.NewPushAdapter(),
+		comms.NewWhatsAppAdapter(),
+	)
+
 	orderRepo := order.NewPostgresRepository(db)
-	orderService := order.NewService(orderRepo)
+	checkoutRepo := order.NewCheckoutPostgresRepository(db)
+	orderService := order.NewService(orderRepo, append(orderOptions(),
+		order.WithPromotions(promoService), order.WithCheckouts(checkoutRepo), order.WithPickupCodes(commsService))...)
+	quoteRepo := order.NewQuotePostgresRepository(db)
+	quoteService := order.NewQuoteService(quoteRepo, orderRepo, orderOptions()...)
+	checkoutService := order.NewCheckoutService(checkoutRepo, orderRepo, append(orderOptions(), order.WithPromotions(promoService))...)
 
-	routingRepo := routing.NewPostgresRepository(db)
-	routingService := routing.NewService(routingRepo)
+	operatingStatusRepo := operatingstatus.NewPostgresRepository(db)
+	operatingStatusService := operatingstatus.NewService(operatingStatusRepo)
 
 	productionRepo := production.NewPostgresRepository(db)
 	productionService := production.NewService(productionRepo)
+	routingRepo := routing.NewPostgresRepository(db)
+	routingService := routing.NewService(routingRepo,
+		routing.WithOperatingHours(operatingHoursService, storeTimezone()),
+		routing.WithOperatingStatus(operatingStatusService),
+		routing.WithDeliveryZones(zoneService),
+		routing.WithResponseSLA(routingResponseSLA()))
 
 	posRepo := pos.NewPostgresRepository(db)
 	posService := pos.NewService(posRepo)
@@ -128,14 +152,8 @@ func main() {
 	adminService := admin.NewService(adminRepo)
 
 	notificationRepo := notification.NewPostgresRepository(db)
-	commsRepo := comms.NewPostgresRepository(db)
-	commsService := comms.NewService(commsRepo,
-		comms.NewEmailAdapter(),
-		comms.NewSMSAdapter(),
-		comms.NewPushAdapter(),
-		comms.NewWhatsAppAdapter(),
-	)
 	notificationService := notification.NewService(notificationRepo)
+	recurringService := order.NewRecurringService(order.NewRecurringPostgresRepository(db), orderService, notificationService)
 	authService := auth.NewService(
 		userRepo,
 		userService,
@@ -146,22 +164,11 @@ func main() {
 	attendanceService := attendance.NewService(attendanceRepo, attendance.WithPINResetMailer(commsService, os.Getenv("VENDOR_PORTAL_URL")))
 	attendanceHandler := attendance.NewHandler(attendanceService, inventoryService, vendorService)
 
-	paymentGateways := payment.GatewayRegistry{
-		payment.ProviderMTNMomo: payment.NewMTNMomoGateway(
-			os.Getenv("MTN_MOMO_API_KEY"),
-			os.Getenv("MTN_MOMO_API_SECRET"),
-			os.Getenv("MTN_MOMO_BASE_URL"),
-			os.Getenv("MTN_MOMO_ENV"),
-		),
-		payment.ProviderAirtel: payment.NewAirtelMoneyGateway(
-			os.Getenv("AIRTEL_CLIENT_ID"),
-			os.Getenv("AIRTEL_CLIENT_SECRET"),
-			os.Getenv("AIRTEL_BASE_URL"),
-			os.Getenv("AIRTEL_ENV"),
-		),
-	}
 	paymentRepo := payment.NewPostgresRepository(db)
-	paymentService := payment.NewService(paymentRepo, paymentGateways)
+	paymentService := payment.NewService(paymentRepo, payment.GatewaysFromEnv())
+
+	claimRepo := claim.NewPostgresRepository(db)
+	claimService := claim.NewService(claimRepo, orderService, productionService, paymentService, posService)
 
 	walletRepo := wallet.NewPostgresRepository(db)
 	walletService := wallet.NewService(walletRepo)
@@ -169,9 +176,6 @@ func main() {
 	policyConsentRepo := policyconsent.NewPostgresRepository(db)
 	policyConsentService := policyconsent.NewService(policyConsentRepo)
 
-	operatingStatusRepo := operatingstatus.NewPostgresRepository(db)
-	operatingStatusService := operatingstatus.NewService(operatingStatusRepo)
-
 	assetHandler, err := assets.NewHandler(db)
 	if err != nil {
 		log.Fatal("Asset storage configuration failed:", err)
@@ -195,7 +199,7 @@ func main() {
 	inventory.NewHandler(inventoryService, vendorService, userService).RegisterStorefrontRoutes(router)
 	delivery.NewZoneHandler(zoneService, inventoryService, vendorService).RegisterStorefrontRoutes(router)
 	// Payment webhooks (provider callback boundary, no JWT)
-	payment.NewHandler(paymentService, vendorService, orderService).RegisterWebhookRoutes(router)
+	payment.NewHandler(paymentService, vendorService, orderService, checkoutService).RegisterWebhookRoutes(router)
 	// Signed collection-provider callback receiver. Subscription activation still
 	// re-queries the provider and validates the server-locked checkout amount.
 	lenco.NewHandlerFromEnv(db, func(ctx context.Context, reference string) error {
@@ -229,6 +233,10 @@ func main() {
 
 		// Orders
 		order.NewHandler(orderService, db).RegisterRoutes(r)
+		order.NewQuoteHandler(quoteService, inventoryService, vendorService, db).RegisterRoutes(r)
+		order.NewCheckoutHandler(checkoutService, inventoryService, db).RegisterRoutes(r)
+		order.NewRecurringHandler(recurringService, inventoryService, vendorService, db).RegisterRoutes(r)
+		promo.NewHandler(promoService, inventoryService, vendorService).RegisterRoutes(r)
 
 		// Customer delivery locations and vendor delivery zones
 		delivery.NewHandler(deliveryService).RegisterRoutes(r)
@@ -237,11 +245,14 @@ func main() {
 		// Order-scoped in-app conversations
 		conversation.NewHandler(conversationService, orderService, inventoryService, vendorService, assetHandler.Storage()).RegisterRoutes(r)
 
+		// Reprint and refund claims on delivered orders
+		claim.NewHandler(claimService, orderService, inventoryService, vendorService, assetHandler.Storage()).RegisterRoutes(r)
+
 		// Customer-owned design assets
 		assetHandler.RegisterRoutes(r)
 
 		// Routing engine
-		routing.NewHandler(routingService).RegisterRoutes(r)
+		routing.NewHandler(routingService, inventoryService, vendorService).RegisterRoutes(r)
 
 		// Production
 		production.NewHandler(productionService, inventoryService, vendorService).RegisterRoutes(r)
@@ -261,7 +272,7 @@ func main() {
 		comms.NewHandler(commsService).RegisterRoutes(r)
 
 		// Payments (protected)
-		payment.NewHandler(paymentService, vendorService, orderService).RegisterProtectedRoutes(r)
+		payment.NewHandler(paymentService, vendorService, orderService, checkoutService).RegisterProtectedRoutes(r)
 	})
 
 	// ── Start Server ─────────────────────────────────────────
@@ -420,6 +431,33 @@ func validateStartupConfig() error {
 	return nil
 }
 
+// orderOptions returns a fresh copy of the VAT and order numbering options for each order service.
+func orderOptions() []order.ServiceOption {
+	options, err := order.TaxOptionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return append(options, order.WithStoreTimezone(storeTimezone()))
+}
+
+// storeTimezone is the zone store operating hours are published in.
+func storeTimezone() *time.Location {
+	loc, err := operatinghours.StoreTimezoneFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return loc
+}
+
+// routingResponseSLA is how long a routed store has to accept or decline; unset means no response is required.
+func routingResponseSLA() time.Duration {
+	sla, err := routing.ResponseSLAFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sla
+}
+
 func getenvDefault(key, fallback string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/notification"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatinghours"
+	"github.com/georgemunganga/printa-backend/internal/modules/order"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		log.Fatal("open database:", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatal("database connection failed:", err)
+	}
+
+	// Scheduled orders are taxed and numbered like any other.
+	options, err := order.TaxOptionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	zone, err := operatinghours.StoreTimezoneFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	options = append(options, order.WithStoreTimezone(zone))
+	orders := order.NewService(order.NewPostgresRepository(db),
+		append(options, order.WithPromotions(promo.NewService(promo.NewPostgresRepository(db))))...)
+	recurring := order.NewRecurringService(
+		order.NewRecurringPostgresRepository(db),
+		orders,
+		notification.NewService(notification.NewPostgresRepository(db)),
+	)
+
+	summary, err := recurring.RunDue(context.Background(), time.Now().UTC())
+	if err != nil {
+		log.Fatal("run due recurring orders:", err)
+	}
+	log.Printf("recurring orders: %d placed, %d skipped, %d paused, %d failed",
+		summary.Placed, summary.Skipped, summary.Paused, summary.Failed)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/delivery"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatinghours"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatingstatus"
+	"github.com/georgemunganga/printa-backend/internal/modules/routing"
+	_ "github.com/lib/pq"
+)
+
+// routing-reassign fails routing decisions that their store declined or left unanswered past the response SLA and
+// routes those orders to the next best store. Run it on a short schedule while ROUTING_RESPONSE_SLA is set.
+func main() {
+	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		log.Fatal("open database:", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatal("database connection failed:", err)
+	}
+
+	sla, err := routing.ResponseSLAFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	loc, err := operatinghours.StoreTimezoneFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	routingService := routing.NewService(routing.NewPostgresRepository(db),
+		routing.WithOperatingHours(operatinghours.NewService(operatinghours.NewPostgresRepository(db)), loc),
+		routing.WithOperatingStatus(operatingstatus.NewService(operatingstatus.NewPostgresRepository(db))),
+		routing.WithDeliveryZones(delivery.NewZoneService(delivery.NewZonePostgresRepository(db))),
+		routing.WithResponseSLA(sla),
+	)
+
+	summary, err := routingService.ReassignStale(context.Background(), time.Now().UTC())
+	if err != nil {
+		log.Fatal("reassign stale routing decisions:", err)
+	}
+	log.Printf("routing reassign: %d re-routed, %d unroutable, %d skipped, %d failed",
+		summary.Rerouted, summary.Unroutable, summary.Skipped, summary.Failed)
+}
@@ -16,6 +16,10 @@ import (
 
 	"github.com/georgemunganga/printa-backend/internal/modules/comms"
 	"github.com/georgemunganga/printa-backend/internal/modules/notification"
+	"github.com/georgemunganga/printa-backend/internal/modules/order"
+	"github.com/georgemunganga/printa-backend/internal/modules/payment"
+	"github.com/georgemunganga/printa-backend/internal/modules/production"
+	"github.com/georgemunganga/printa-backend/internal/modules/routing"
 	"github.com/georgemunganga/printa-backend/internal/outbox"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -45,10 +49,17 @@ func main() {
 		comms.NewPushAdapter(),
 		comms.NewWhatsAppAdapter(),
 	)
+	productionService := production.NewService(production.NewPostgresRepository(db))
+	orderService := order.NewService(order.NewPostgresRepository(db), order.WithPickupCodes(commsService))
+	paymentService := payment.NewService(payment.NewPostgresRepository(db), payment.GatewaysFromEnv())
 	worker := &outbox.Worker{
 		Repository: outbox.NewRepository(db),
 		Handlers: map[string]outbox.Handler{
-			"notification.dispatch.v1": notificationDispatchHandler(commsService),
+			"notification.dispatch.v1":       notificationDispatchHandler(commsService),
+			order.EventOrderConfirmed:        productionJobsHandler(productionService),
+			order.EventOrderCancelled:        inOrder(productionJobsHandler(productionService), checkoutRefundHandler(orderService, paymentService)),
+			routing.EventDecisionCreated:     productionJobsHandler(productionService),
+			production.EventJobStatusChanged: orderProductionHandler(productionService, orderService),
 		},
 		PollEvery:   durationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second),
 		LeaseFor:    durationEnv("OUTBOX_LEASE_DURATION", 5*time.Minute),
@@ -76,6 +87,78 @@ func notificationDispatchHandler(commsService comms.Service) outbox.Handler {
 	}
 }
 
+// productionJobsHandler syncs the production jobs of an order that was confirmed, routed or cancelled. Each event
+// carries the order_id.
+func productionJobsHandler(productionService production.Service) outbox.Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		var payload struct {
+			OrderID string `json:"order_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("decode %s event: %w", event.EventType, err)
+		}
+		if payload.OrderID == "" {
+			return fmt.Errorf("%s event requires order_id", event.EventType)
+		}
+		_, err := productionService.SyncOrderJobs(ctx, payload.OrderID)
+		return err
+	}
+}
+
+// inOrder runs handlers one after another and stops at the first failure. The event is retried as a whole, so each
+// handler must be safe to repeat.
+func inOrder(handlers ...outbox.Handler) outbox.Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		for _, handle := range handlers {
+			if err := handle(ctx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// orderProductionHandler moves an order along as its production jobs start and complete. It reads the current
+// state of all the order's jobs rather than trusting the event, so redelivered and out-of-order events converge.
+func orderProductionHandler(productionService production.Service, orderService order.Service) outbox.Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		var jobEvent production.JobEvent
+		if err := json.Unmarshal(event.Payload, &jobEvent); err != nil {
+			return fmt.Errorf("decode production job event: %w", err)
+		}
+		orderID := jobEvent.OrderID.String()
+		progress, err := productionService.OrderProgress(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("load production progress: %w", err)
+		}
+		_, err = orderService.SyncProduction(ctx, orderID, order.ProductionProgress{Started: progress.Started, Finished: progress.Finished})
+		return err
+	}
+}
+
+// checkoutRefundHandler refunds a cancelled checkout order's share of the checkout payment. Orders placed on their
+// own, and checkouts not yet paid, have nothing to refund; a payment still in flight is retried.
+func checkoutRefundHandler(orderService order.Service, paymentService payment.Service) outbox.Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		var orderEvent order.OrderEvent
+		if err := json.Unmarshal(event.Payload, &orderEvent); err != nil {
+			return fmt.Errorf("decode order cancelled event: %w", err)
+		}
+		o, err := orderService.GetOrder(ctx, orderEvent.OrderID.String())
+		if err != nil {
+			return fmt.Errorf("load cancelled order: %w", err)
+		}
+		if o.CheckoutID == nil || o.Total <= 0 {
+			return nil
+		}
+		_, err = paymentService.RefundCheckoutShare(ctx, o.CheckoutID.String(), o.ID.String(), o.Total)
+		if errors.Is(err, payment.ErrNoCompletedPayment) {
+			return nil
+		}
+		return err
+	}
+}
+
 func durationEnv(key string, fallback time.Duration) time.Duration {
 	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
@@ -33,6 +33,9 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Get("/users/{id}", h.getUser)
 		r.Patch("/users/{id}", h.updateUser)
 		r.Delete("/users/{id}", h.deactivateUser)
+		r.Get("/users/{id}/tax-exemption", h.getTaxExemption)
+		r.Put("/users/{id}/tax-exemption", h.setTaxExemption)
+		r.Delete("/users/{id}/tax-exemption", h.revokeTaxExemption)
 
 		// Vendor management
 		r.Get("/vendors", h.listVendors)
@@ -197,6 +200,52 @@ func (h *Handler) deactivateUser(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, map[string]string{"message": "user deactivated"})
 }
 
+func (h *Handler) getTaxExemption(w http.ResponseWriter, r *http.Request) {
+	exemption, err := h.service.GetTaxExemption(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			code = http.StatusNotFound
+		}
+		respond(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, exemption)
+}
+
+func (h *Handler) setTaxExemption(w http.ResponseWriter, r *http.Request) {
+	var req SetTaxExemptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	exemption, err := h.service.SetTaxExemption(r.Context(), appMiddleware.GetUserID(r), chi.URLParam(r, "id"), req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			code = http.StatusNotFound
+		} else if strings.Contains(msg, "required") || strings.Contains(msg, "must") || strings.Contains(msg, "only be granted") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": msg})
+		return
+	}
+	respond(w, http.StatusOK, exemption)
+}
+
+func (h *Handler) revokeTaxExemption(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RevokeTaxExemption(r.Context(), appMiddleware.GetUserID(r), chi.URLParam(r, "id")); err != nil {
+		code := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			code = http.StatusNotFound
+		}
+		respond(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, map[string]string{"message": "tax exemption revoked"})
+}
+
 // ── Vendors ───────────────────────────────────────────────────────────────────
 
 func (h *Handler) listVendors(w http.ResponseWriter, r *http.Request) {
@@ -1,19 +1,23 @@
 package admin
 
-import "time"
+import (
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+)
 
 // ── Platform Stats ────────────────────────────────────────────────────────────
 
 // PlatformStats is a snapshot of key platform metrics for the admin dashboard.
 type PlatformStats struct {
-	TotalUsers          int     `json:"total_users"`
-	TotalVendors        int     `json:"total_vendors"`
-	TotalStores         int     `json:"total_stores"`
-	TotalOrders         int     `json:"total_orders"`
-	TotalRevenue        float64 `json:"total_revenue"`
-	ActiveSubscriptions int     `json:"active_subscriptions"`
-	PendingOrders       int     `json:"pending_orders"`
-	ProductionJobs      int     `json:"active_production_jobs"`
+	TotalUsers          int          `json:"total_users"`
+	TotalVendors        int          `json:"total_vendors"`
+	TotalStores         int          `json:"total_stores"`
+	TotalOrders         int          `json:"total_orders"`
+	TotalRevenue        money.Amount `json:"total_revenue"`
+	ActiveSubscriptions int          `json:"active_subscriptions"`
+	PendingOrders       int          `json:"pending_orders"`
+	ProductionJobs      int          `json:"active_production_jobs"`
 }
 
 // ── User Management ───────────────────────────────────────────────────────────
@@ -46,6 +50,21 @@ type UpdateAdministratorStatusRequest struct {
 	IsActive bool `json:"is_active"`
 }
 
+// CustomerTaxExemption records a ZRA exemption certificate that removes VAT from a customer's orders.
+type CustomerTaxExemption struct {
+	CustomerID        string     `json:"customer_id"`
+	CertificateNumber string     `json:"certificate_number"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	GrantedBy         string     `json:"granted_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type SetTaxExemptionRequest struct {
+	CertificateNumber string     `json:"certificate_number"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
+
 // ── Vendor Management ─────────────────────────────────────────────────────────
 
 type AdminVendor struct {
@@ -65,14 +84,14 @@ type UpdateVendorStatusRequest struct {
 // ── Order Management ─────────────────────────────────────────────────────────
 
 type AdminOrder struct {
-	ID          string    `json:"id"`
-	OrderNumber string    `json:"order_number"`
-	StoreID     string    `json:"store_id"`
-	StoreName   string    `json:"store_name,omitempty"`
-	CustomerID  string    `json:"customer_id,omitempty"`
-	Status      string    `json:"status"`
-	TotalAmount float64   `json:"total_amount"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string       `json:"id"`
+	OrderNumber string       `json:"order_number"`
+	StoreID     string       `json:"store_id"`
+	StoreName   string       `json:"store_name,omitempty"`
+	CustomerID  string       `json:"customer_id,omitempty"`
+	Status      string       `json:"status"`
+	TotalAmount money.Amount `json:"total_amount"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
 // ── Subscription Management ───────────────────────────────────────────────────
@@ -28,7 +28,7 @@ func (r *postgresRepository) GetPlatformStats(ctx context.Context) (*PlatformSta
 		{&stats.TotalVendors, "SELECT COUNT(*) FROM vendors"},
 		{&stats.TotalStores, "SELECT COUNT(*) FROM stores"},
 		{&stats.TotalOrders, "SELECT COUNT(*) FROM orders"},
-		{&stats.TotalRevenue, "SELECT COALESCE(SUM(total_amount),0) FROM orders WHERE status NOT IN ('CANCELLED')"},
+		{&stats.TotalRevenue, "SELECT COALESCE(SUM(total_minor),0)::BIGINT FROM orders WHERE status NOT IN ('CANCELLED')"},
 		{&stats.ActiveSubscriptions, "SELECT COUNT(*) FROM vendor_subscriptions WHERE status IN ('TRIAL','ACTIVE')"},
 		{&stats.PendingOrders, "SELECT COUNT(*) FROM orders WHERE status = 'PENDING'"},
 		{&stats.ProductionJobs, "SELECT COUNT(*) FROM production_jobs WHERE status IN ('QUEUED','IN_PROGRESS')"},
@@ -149,6 +149,54 @@ func (r *postgresRepository) CountActiveAdministrators(ctx context.Context) (int
 	return total, err
 }
 
+func (r *postgresRepository) GetTaxExemption(ctx context.Context, customerID string) (*CustomerTaxExemption, error) {
+	var e CustomerTaxExemption
+	var grantedBy sql.NullString
+	var expiresAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT customer_id, certificate_number, expires_at, granted_by, created_at, updated_at
+		FROM customer_tax_exemptions WHERE customer_id = $1`, customerID).
+		Scan(&e.CustomerID, &e.CertificateNumber, &expiresAt, &grantedBy, &e.CreatedAt, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tax exemption not found for customer: %s", customerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		e.ExpiresAt = &expiresAt.Time
+	}
+	e.GrantedBy = grantedBy.String
+	return &e, nil
+}
+
+func (r *postgresRepository) UpsertTaxExemption(ctx context.Context, exemption CustomerTaxExemption) (*CustomerTaxExemption, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO customer_tax_exemptions (customer_id, certificate_number, expires_at, granted_by)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET certificate_number = EXCLUDED.certificate_number,
+		    expires_at = EXCLUDED.expires_at,
+		    granted_by = EXCLUDED.granted_by,
+		    updated_at = NOW()`,
+		exemption.CustomerID, exemption.CertificateNumber, exemption.ExpiresAt, exemption.GrantedBy)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetTaxExemption(ctx, exemption.CustomerID)
+}
+
+func (r *postgresRepository) DeleteTaxExemption(ctx context.Context, customerID string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM customer_tax_exemptions WHERE customer_id = $1", customerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("tax exemption not found for customer: %s", customerID)
+	}
+	return nil
+}
+
 // ── Vendor Management ─────────────────────────────────────────────────────────
 
 func (r *postgresRepository) ListVendors(ctx context.Context, status, search string, limit, offset int) ([]AdminVendor, int, error) {
@@ -245,7 +293,7 @@ func (r *postgresRepository) ListOrders(ctx context.Context, status string, limi
 	args = append(args, limit, offset)
 	query := fmt.Sprintf(`
 		SELECT o.id, o.order_number, o.store_id, COALESCE(s.name,''), COALESCE(o.customer_id::text,''),
-		       o.status, o.total_amount, o.created_at
+		       o.status, o.total_minor, o.created_at
 		FROM orders o
 		LEFT JOIN stores s ON o.store_id = s.id
 		WHERE %s
@@ -273,7 +321,7 @@ func (r *postgresRepository) GetOrder(ctx context.Context, id string) (*AdminOrd
 	var o AdminOrder
 	err := r.db.QueryRowContext(ctx, `
 		SELECT o.id, o.order_number, o.store_id, COALESCE(s.name,''), COALESCE(o.customer_id::text,''),
-		       o.status, o.total_amount, o.created_at
+		       o.status, o.total_minor, o.created_at
 		FROM orders o
 		LEFT JOIN stores s ON o.store_id = s.id
 		WHERE o.id = $1`, id).
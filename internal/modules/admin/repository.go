@@ -14,6 +14,9 @@ type Repository interface {
 	DeactivateUser(ctx context.Context, id string) error
 	CreateAdministrator(ctx context.Context, request CreateAdministratorRequest, passwordHash string) (*AdminUser, error)
 	CountActiveAdministrators(ctx context.Context) (int, error)
+	GetTaxExemption(ctx context.Context, customerID string) (*CustomerTaxExemption, error)
+	UpsertTaxExemption(ctx context.Context, exemption CustomerTaxExemption) (*CustomerTaxExemption, error)
+	DeleteTaxExemption(ctx context.Context, customerID string) error
 
 	// Vendor management
 	ListVendors(ctx context.Context, status, search string, limit, offset int) ([]AdminVendor, int, error)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/mail"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -22,6 +23,9 @@ type Service interface {
 	DeactivateUser(ctx context.Context, adminID, targetID string) error
 	CreateAdministrator(ctx context.Context, adminID string, request CreateAdministratorRequest) (*AdminUser, error)
 	UpdateAdministratorStatus(ctx context.Context, adminID, targetID string, request UpdateAdministratorStatusRequest) (*AdminUser, error)
+	GetTaxExemption(ctx context.Context, customerID string) (*CustomerTaxExemption, error)
+	SetTaxExemption(ctx context.Context, adminID, customerID string, req SetTaxExemptionRequest) (*CustomerTaxExemption, error)
+	RevokeTaxExemption(ctx context.Context, adminID, customerID string) error
 
 	// Vendors
 	ListVendors(ctx context.Context, status, search string, page, pageSize int) ([]AdminVendor, int, error)
@@ -184,6 +188,63 @@ func (s *service) UpdateAdministratorStatus(ctx context.Context, adminID, target
 	return administrator, nil
 }
 
+func (s *service) GetTaxExemption(ctx context.Context, customerID string) (*CustomerTaxExemption, error) {
+	return s.repo.GetTaxExemption(ctx, customerID)
+}
+
+// SetTaxExemption grants or replaces a customer's exemption. Only customers can hold one; vendor and staff
+// purchases are always taxed.
+func (s *service) SetTaxExemption(ctx context.Context, adminID, customerID string, req SetTaxExemptionRequest) (*CustomerTaxExemption, error) {
+	certificate := strings.TrimSpace(req.CertificateNumber)
+	if certificate == "" {
+		return nil, fmt.Errorf("certificate_number is required")
+	}
+	if len(certificate) > 64 {
+		return nil, fmt.Errorf("certificate_number must not exceed 64 characters")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+	target, err := s.repo.GetUser(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Role != "CUSTOMER" {
+		return nil, fmt.Errorf("tax exemptions can only be granted to customers")
+	}
+	exemption, err := s.repo.UpsertTaxExemption(ctx, CustomerTaxExemption{
+		CustomerID:        customerID,
+		CertificateNumber: certificate,
+		ExpiresAt:         req.ExpiresAt,
+		GrantedBy:         adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.CreateAuditLog(ctx, AuditLog{
+		AdminID:    adminID,
+		Action:     "SET_TAX_EXEMPTION",
+		TargetType: "user",
+		TargetID:   customerID,
+		Details:    fmt.Sprintf("certificate_number=%s", certificate),
+	})
+	return exemption, nil
+}
+
+func (s *service) RevokeTaxExemption(ctx context.Context, adminID, customerID string) error {
+	if err := s.repo.DeleteTaxExemption(ctx, customerID); err != nil {
+		return err
+	}
+	_ = s.repo.CreateAuditLog(ctx, AuditLog{
+		AdminID:    adminID,
+		Action:     "REVOKE_TAX_EXEMPTION",
+		TargetType: "user",
+		TargetID:   customerID,
+		Details:    "tax exemption revoked by admin",
+	})
+	return nil
+}
+
 // ── Vendors ───────────────────────────────────────────────────────────────────
 
 func (s *service) ListVendors(ctx context.Context, status, search string, page, pageSize int) ([]AdminVendor, int, error) {
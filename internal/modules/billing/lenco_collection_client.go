@@ -6,12 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
 )
 
 // LencoCollectionClient initiates and verifies subscription collections using the
@@ -44,12 +44,12 @@ func (c *LencoCollectionClient) InitiateMobileMoneyCollection(ctx context.Contex
 	}
 
 	payload := struct {
-		Amount    float64 `json:"amount"`
-		Reference string  `json:"reference"`
-		Phone     string  `json:"phone"`
-		Operator  string  `json:"operator"`
-		Country   string  `json:"country"`
-		Bearer    string  `json:"bearer"`
+		Amount    money.Amount `json:"amount"`
+		Reference string       `json:"reference"`
+		Phone     string       `json:"phone"`
+		Operator  string       `json:"operator"`
+		Country   string       `json:"country"`
+		Bearer    string       `json:"bearer"`
 	}{
 		Amount:    collection.Amount,
 		Reference: collection.Reference,
@@ -137,8 +137,8 @@ func (c *LencoCollectionClient) doCollectionRequest(req *http.Request, operation
 		}
 		return nil, fmt.Errorf("%s: %s", operation, message)
 	}
-	amount, err := strconv.ParseFloat(body.Data.Amount, 64)
-	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0 {
+	amount, err := money.Parse(body.Data.Amount)
+	if err != nil || amount <= 0 {
 		return nil, fmt.Errorf("%s returned an invalid amount", operation)
 	}
 	return &ProviderCollection{
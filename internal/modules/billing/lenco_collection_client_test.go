@@ -33,12 +33,12 @@ func TestLencoCollectionClientInitiatesMobileMoneyWithServerRequest(t *testing.T
 
 	client := NewLencoCollectionClient(server.URL, "secret-test")
 	collection, err := client.InitiateMobileMoneyCollection(context.Background(), MobileMoneyCollectionRequest{
-		Amount: 500, Currency: "ZMW", Reference: "SUB-locked-reference", Phone: "0977433571", Operator: "mtn", Country: "zm", Bearer: "merchant",
+		Amount: 50000, Currency: "ZMW", Reference: "SUB-locked-reference", Phone: "0977433571", Operator: "mtn", Country: "zm", Bearer: "merchant",
 	})
 	if err != nil {
 		t.Fatalf("InitiateMobileMoneyCollection() error = %v", err)
 	}
-	if collection.ID != "collection-1" || collection.Status != "pay-offline" || collection.Amount != 500 || collection.Reference != "SUB-locked-reference" {
+	if collection.ID != "collection-1" || collection.Status != "pay-offline" || collection.Amount != 50000 || collection.Reference != "SUB-locked-reference" {
 		t.Fatalf("collection = %#v", collection)
 	}
 }
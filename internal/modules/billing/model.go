@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -19,7 +20,7 @@ type TierFeature struct {
 type VendorTier struct {
 	ID           uuid.UUID     `json:"id"`
 	Name         string        `json:"name"`
-	MonthlyPrice float64       `json:"monthly_price"`
+	MonthlyPrice money.Amount  `json:"monthly_price"`
 	Description  string        `json:"description"`
 	DisplayOrder int           `json:"display_order"`
 	IsAvailable  bool          `json:"is_available"`
@@ -48,7 +49,7 @@ type SubscriptionCheckout struct {
 	VendorID             uuid.UUID      `json:"vendor_id"`
 	TierID               uuid.UUID      `json:"tier_id"`
 	TierName             string         `json:"tier_name"`
-	Amount               float64        `json:"amount"`
+	Amount               money.Amount   `json:"amount"`
 	Currency             string         `json:"currency"`
 	Reference            string         `json:"reference"`
 	Status               CheckoutStatus `json:"status"`
@@ -86,7 +87,7 @@ type InitiateMobileMoneyCollectionRequest struct {
 // MobileMoneyCollectionRequest is the normalized request issued by the backend
 // to the payment provider after it has loaded the checkout record.
 type MobileMoneyCollectionRequest struct {
-	Amount    float64
+	Amount    money.Amount
 	Currency  string
 	Reference string
 	Phone     string
@@ -100,7 +101,7 @@ type MobileMoneyCollectionRequest struct {
 type ProviderCollection struct {
 	ID        string
 	Reference string
-	Amount    float64
+	Amount    money.Amount
 	Currency  string
 	Status    string
 	Reason    string
@@ -166,7 +167,7 @@ type VendorSubscription struct {
 	VendorID           uuid.UUID          `json:"vendor_id"`
 	TierID             uuid.UUID          `json:"tier_id"`
 	TierName           string             `json:"tier_name,omitempty"`
-	TierPrice          float64            `json:"tier_price,omitempty"`
+	TierPrice          money.Amount       `json:"tier_price,omitempty"`
 	Status             SubscriptionStatus `json:"status"`
 	BillingCycle       BillingCycle       `json:"billing_cycle"`
 	CurrentPeriodStart time.Time          `json:"current_period_start"`
@@ -219,10 +220,10 @@ const (
 
 // LineItem represents a single line on an invoice.
 type LineItem struct {
-	Description string  `json:"description"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	Amount      float64 `json:"amount"`
+	Description string       `json:"description"`
+	Quantity    int          `json:"quantity"`
+	UnitPrice   money.Amount `json:"unit_price"`
+	Amount      money.Amount `json:"amount"`
 }
 
 // BillingInvoice represents a billing invoice for a vendor subscription cycle.
@@ -231,7 +232,7 @@ type BillingInvoice struct {
 	SubscriptionID   uuid.UUID     `json:"subscription_id"`
 	VendorID         uuid.UUID     `json:"vendor_id"`
 	InvoiceNumber    string        `json:"invoice_number"`
-	Amount           float64       `json:"amount"`
+	Amount           money.Amount  `json:"amount"`
 	Currency         string        `json:"currency"`
 	Status           InvoiceStatus `json:"status"`
 	PeriodStart      time.Time     `json:"period_start"`
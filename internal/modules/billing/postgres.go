@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -110,7 +111,7 @@ func (r *postgresRepo) CreateInvoice(ctx context.Context, inv *BillingInvoice) e
 	}
 	_, err = r.db.ExecContext(ctx, `
 		INSERT INTO billing_invoices
-		  (id, subscription_id, vendor_id, invoice_number, amount, currency,
+		  (id, subscription_id, vendor_id, invoice_number, amount_minor, currency,
 		   status, period_start, period_end, due_date, line_items, notes, idempotency_key)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`,
 		inv.ID, inv.SubscriptionID, inv.VendorID, inv.InvoiceNumber, inv.Amount,
@@ -121,7 +122,7 @@ func (r *postgresRepo) CreateInvoice(ctx context.Context, inv *BillingInvoice) e
 
 func (r *postgresRepo) GetInvoiceByID(ctx context.Context, id string) (*BillingInvoice, error) {
 	return r.scanInv(r.db.QueryRowContext(ctx, `
-		SELECT id,subscription_id,vendor_id,invoice_number,amount,currency,status,
+		SELECT id,subscription_id,vendor_id,invoice_number,amount_minor,currency,status,
 		       period_start,period_end,due_date,paid_at,payment_reference,line_items,
 		       notes,idempotency_key,created_at,updated_at
 		FROM billing_invoices WHERE id=$1`, id))
@@ -129,7 +130,7 @@ func (r *postgresRepo) GetInvoiceByID(ctx context.Context, id string) (*BillingI
 
 func (r *postgresRepo) GetInvoiceByNumber(ctx context.Context, number string) (*BillingInvoice, error) {
 	return r.scanInv(r.db.QueryRowContext(ctx, `
-		SELECT id,subscription_id,vendor_id,invoice_number,amount,currency,status,
+		SELECT id,subscription_id,vendor_id,invoice_number,amount_minor,currency,status,
 		       period_start,period_end,due_date,paid_at,payment_reference,line_items,
 		       notes,idempotency_key,created_at,updated_at
 		FROM billing_invoices WHERE invoice_number=$1`, number))
@@ -137,7 +138,7 @@ func (r *postgresRepo) GetInvoiceByNumber(ctx context.Context, number string) (*
 
 func (r *postgresRepo) GetInvoiceByIdempotencyKey(ctx context.Context, key string) (*BillingInvoice, error) {
 	return r.scanInv(r.db.QueryRowContext(ctx, `
-		SELECT id,subscription_id,vendor_id,invoice_number,amount,currency,status,
+		SELECT id,subscription_id,vendor_id,invoice_number,amount_minor,currency,status,
 		       period_start,period_end,due_date,paid_at,payment_reference,line_items,
 		       notes,idempotency_key,created_at,updated_at
 		FROM billing_invoices WHERE idempotency_key=$1`, key))
@@ -145,7 +146,7 @@ func (r *postgresRepo) GetInvoiceByIdempotencyKey(ctx context.Context, key strin
 
 func (r *postgresRepo) ListInvoicesByVendor(ctx context.Context, vendorID string) ([]*BillingInvoice, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id,subscription_id,vendor_id,invoice_number,amount,currency,status,
+		SELECT id,subscription_id,vendor_id,invoice_number,amount_minor,currency,status,
 		       period_start,period_end,due_date,paid_at,payment_reference,line_items,
 		       notes,idempotency_key,created_at,updated_at
 		FROM billing_invoices WHERE vendor_id=$1 ORDER BY created_at DESC`, vendorID)
@@ -166,7 +167,7 @@ func (r *postgresRepo) ListInvoicesByVendor(ctx context.Context, vendorID string
 
 func (r *postgresRepo) ListInvoicesBySubscription(ctx context.Context, subscriptionID string) ([]*BillingInvoice, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id,subscription_id,vendor_id,invoice_number,amount,currency,status,
+		SELECT id,subscription_id,vendor_id,invoice_number,amount_minor,currency,status,
 		       period_start,period_end,due_date,paid_at,payment_reference,line_items,
 		       notes,idempotency_key,created_at,updated_at
 		FROM billing_invoices WHERE subscription_id=$1 ORDER BY created_at DESC`, subscriptionID)
@@ -248,9 +249,9 @@ func (r *postgresRepo) ListTiers(ctx context.Context) ([]*VendorTier, error) {
 	return tiers, nil
 }
 
-func (r *postgresRepo) GetTierByID(ctx context.Context, tierID string) (string, float64, error) {
+func (r *postgresRepo) GetTierByID(ctx context.Context, tierID string) (string, money.Amount, error) {
 	var name string
-	var price float64
+	var price money.Amount
 	err := r.db.QueryRowContext(ctx, `SELECT name, monthly_price FROM vendor_tiers WHERE id=$1`, tierID).
 		Scan(&name, &price)
 	return name, price, err
@@ -268,7 +269,7 @@ func (r *postgresRepo) GetTierCatalogueEntry(ctx context.Context, tierID string)
 func (r *postgresRepo) CreateCheckout(ctx context.Context, checkout *SubscriptionCheckout) error {
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO subscription_checkouts
-		  (id, vendor_id, tier_id, amount, currency, reference, status, expires_at)
+		  (id, vendor_id, tier_id, amount_minor, currency, reference, status, expires_at)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
 		checkout.ID, checkout.VendorID, checkout.TierID, checkout.Amount, checkout.Currency,
 		checkout.Reference, checkout.Status, checkout.ExpiresAt)
@@ -363,7 +364,7 @@ func (r *postgresRepo) ActivateCheckout(ctx context.Context, checkoutID, provide
 		invoiceNumber := fmt.Sprintf("INV-%s-%s", periodStart.Format("200601"), checkout.ID.String()[:8])
 		_, err = tx.ExecContext(ctx, `
 			INSERT INTO billing_invoices
-			  (id, subscription_id, vendor_id, invoice_number, amount, currency, status,
+			  (id, subscription_id, vendor_id, invoice_number, amount_minor, currency, status,
 			   period_start, period_end, due_date, paid_at, payment_reference, line_items, notes, idempotency_key)
 			VALUES ($1,$2,$3,$4,$5,$6,'PAID',$7,$8,$9,$10,$11,$12,$13,$14)`,
 			invoiceID, subscriptionID, checkout.VendorID, invoiceNumber, checkout.Amount, checkout.Currency,
@@ -391,7 +392,7 @@ func (r *postgresRepo) ActivateCheckout(ctx context.Context, checkoutID, provide
 }
 
 const checkoutSelect = `
-	SELECT sc.id, sc.vendor_id, sc.tier_id, vt.name, sc.amount, sc.currency, sc.reference,
+	SELECT sc.id, sc.vendor_id, sc.tier_id, vt.name, sc.amount_minor, sc.currency, sc.reference,
 	       sc.status, COALESCE(sc.provider_collection_id,''), COALESCE(sc.provider_status,''),
 	       sc.subscription_id, sc.invoice_id, sc.expires_at, sc.completed_at,
 	       COALESCE(sc.failure_reason,''), sc.created_at, sc.updated_at
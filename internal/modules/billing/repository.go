@@ -3,6 +3,8 @@ package billing
 import (
 	"context"
 	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
 )
 
 // Repository defines data access for subscriptions and invoices.
@@ -40,5 +42,5 @@ type Repository interface {
 	ActivateCheckout(ctx context.Context, checkoutID, providerCollectionID, providerStatus string, completedAt time.Time) (*SubscriptionCheckout, error)
 
 	// Tier lookup (needed for invoice generation)
-	GetTierByID(ctx context.Context, tierID string) (name string, price float64, err error)
+	GetTierByID(ctx context.Context, tierID string) (name string, price money.Amount, err error)
 }
@@ -4,11 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"math"
 	"math/rand"
 	"strings"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -106,7 +106,7 @@ func (s *service) CreateSubscriptionCheckout(ctx context.Context, vendorID strin
 	checkoutID := uuid.New()
 	checkout := &SubscriptionCheckout{
 		ID: checkoutID, VendorID: vendorUUID, TierID: tier.ID, TierName: tier.Name,
-		Amount: tier.MonthlyPrice, Currency: "ZMW", Reference: "SUB-" + checkoutID.String(),
+		Amount: tier.MonthlyPrice, Currency: money.DefaultCurrency, Reference: "SUB-" + checkoutID.String(),
 		Status: CheckoutPending, ExpiresAt: now.Add(30 * time.Minute),
 	}
 	if err := s.repo.CreateCheckout(ctx, checkout); err != nil {
@@ -168,7 +168,7 @@ func (s *service) InitiateSubscriptionMobileMoneyCollection(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	if collection == nil || collection.ID == "" || collection.Reference != checkout.Reference || math.Abs(collection.Amount-checkout.Amount) > 0.005 || !strings.EqualFold(collection.Currency, checkout.Currency) {
+	if collection == nil || collection.ID == "" || collection.Reference != checkout.Reference || !money.New(collection.Amount, collection.Currency).Equal(money.New(checkout.Amount, checkout.Currency)) {
 		return nil, fmt.Errorf("payment collection did not match checkout")
 	}
 	if err := s.repo.RecordCheckoutCollection(ctx, checkout.ID.String(), collection.ID, collection.Status); err != nil {
@@ -212,7 +212,7 @@ func (s *service) VerifySubscriptionCheckout(ctx context.Context, vendorID, chec
 	if collection == nil || collection.Reference != checkout.Reference || collection.ID == "" {
 		return nil, fmt.Errorf("payment verification did not match checkout reference")
 	}
-	if math.Abs(collection.Amount-checkout.Amount) > 0.005 || !strings.EqualFold(collection.Currency, checkout.Currency) {
+	if !money.New(collection.Amount, collection.Currency).Equal(money.New(checkout.Amount, checkout.Currency)) {
 		return nil, fmt.Errorf("payment verification amount or currency did not match checkout")
 	}
 	switch strings.ToLower(collection.Status) {
@@ -403,7 +403,7 @@ func (s *service) GenerateInvoice(ctx context.Context, vendorID string, idempote
 	// Free tier (CORE) = ZMW 0 — still generate invoice for audit trail
 	amount := tierPrice
 	if sub.BillingCycle == CycleAnnual {
-		amount = tierPrice * 12 * 0.9 // 10% annual discount
+		amount = tierPrice.Times(12).MulRate(0.9) // 10% annual discount
 	}
 
 	now := time.Now()
@@ -110,14 +110,14 @@ func configuredCheckoutService(repo Repository, verifier CollectionVerifier, ini
 func TestCreateSubscriptionCheckoutLocksDatabaseTierAmount(t *testing.T) {
 	vendorID := uuid.New()
 	tierID := uuid.New()
-	repo := &checkoutRepositoryStub{tier: &VendorTier{ID: tierID, Name: "Core", MonthlyPrice: 250, IsAvailable: true}}
+	repo := &checkoutRepositoryStub{tier: &VendorTier{ID: tierID, Name: "Core", MonthlyPrice: 25000, IsAvailable: true}}
 	svc := configuredCheckoutService(repo, &collectionVerifierStub{}, &collectionInitiatorStub{})
 
 	session, err := svc.CreateSubscriptionCheckout(context.Background(), vendorID.String(), CreateCheckoutRequest{TierID: tierID.String()})
 	if err != nil {
 		t.Fatalf("CreateSubscriptionCheckout() error = %v", err)
 	}
-	if session.Checkout.Amount != 250 || session.Checkout.Currency != "ZMW" || session.Checkout.TierID != tierID {
+	if session.Checkout.Amount != 25000 || session.Checkout.Currency != "ZMW" || session.Checkout.TierID != tierID {
 		t.Fatalf("checkout = %#v, want database-backed Core amount K250", session.Checkout)
 	}
 	if !strings.HasPrefix(session.Checkout.Reference, "SUB-") {
@@ -126,7 +126,7 @@ func TestCreateSubscriptionCheckoutLocksDatabaseTierAmount(t *testing.T) {
 }
 
 func TestCreateSubscriptionCheckoutRejectsUnavailableTier(t *testing.T) {
-	repo := &checkoutRepositoryStub{tier: &VendorTier{ID: uuid.New(), Name: "Enterprise", MonthlyPrice: 1500, IsAvailable: false}}
+	repo := &checkoutRepositoryStub{tier: &VendorTier{ID: uuid.New(), Name: "Enterprise", MonthlyPrice: 150000, IsAvailable: false}}
 	svc := configuredCheckoutService(repo, &collectionVerifierStub{}, &collectionInitiatorStub{})
 	_, err := svc.CreateSubscriptionCheckout(context.Background(), uuid.New().String(), CreateCheckoutRequest{TierID: repo.tier.ID.String()})
 	if err == nil || !strings.Contains(err.Error(), "not available") {
@@ -138,11 +138,11 @@ func TestInitiateMobileMoneyCollectionUsesLockedCheckoutValues(t *testing.T) {
 	vendorID := uuid.New()
 	checkoutID := uuid.New()
 	repo := &checkoutRepositoryStub{checkout: &SubscriptionCheckout{
-		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 500, Currency: "ZMW",
+		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 50000, Currency: "ZMW",
 		Reference: "SUB-" + checkoutID.String(), Status: CheckoutPending, ExpiresAt: time.Now().Add(10 * time.Minute),
 	}}
 	initiator := &collectionInitiatorStub{collection: &ProviderCollection{
-		ID: "collection-1", Reference: repo.checkout.Reference, Amount: 500, Currency: "ZMW", Status: "pay-offline",
+		ID: "collection-1", Reference: repo.checkout.Reference, Amount: 50000, Currency: "ZMW", Status: "pay-offline",
 	}}
 	svc := configuredCheckoutService(repo, &collectionVerifierStub{}, initiator)
 
@@ -150,7 +150,7 @@ func TestInitiateMobileMoneyCollectionUsesLockedCheckoutValues(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InitiateSubscriptionMobileMoneyCollection() error = %v", err)
 	}
-	if initiator.request.Amount != 500 || initiator.request.Currency != "ZMW" || initiator.request.Reference != repo.checkout.Reference || initiator.request.Phone != "0977433571" || initiator.request.Operator != "mtn" {
+	if initiator.request.Amount != 50000 || initiator.request.Currency != "ZMW" || initiator.request.Reference != repo.checkout.Reference || initiator.request.Phone != "0977433571" || initiator.request.Operator != "mtn" {
 		t.Fatalf("provider request = %#v, want database-locked checkout values plus normalized payer details", initiator.request)
 	}
 	if !repo.recordCollectionCalled || checkout.ProviderCollectionID != "collection-1" || checkout.Status != CheckoutPending {
@@ -162,11 +162,11 @@ func TestVerifySubscriptionCheckoutActivatesOnlyExactSuccessfulCollection(t *tes
 	vendorID := uuid.New()
 	checkoutID := uuid.New()
 	repo := &checkoutRepositoryStub{checkout: &SubscriptionCheckout{
-		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), TierName: "Core", Amount: 250, Currency: "ZMW",
+		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), TierName: "Core", Amount: 25000, Currency: "ZMW",
 		Reference: "SUB-" + checkoutID.String(), Status: CheckoutPending, ExpiresAt: time.Now().Add(10 * time.Minute),
 	}}
 	verifier := &collectionVerifierStub{collection: &ProviderCollection{
-		ID: "collection-1", Reference: repo.checkout.Reference, Amount: 250, Currency: "ZMW", Status: "successful",
+		ID: "collection-1", Reference: repo.checkout.Reference, Amount: 25000, Currency: "ZMW", Status: "successful",
 	}}
 	svc := configuredCheckoutService(repo, verifier, &collectionInitiatorStub{})
 
@@ -183,7 +183,7 @@ func TestVerifySubscriptionCheckoutRejectsAmountMismatchWithoutActivation(t *tes
 	vendorID := uuid.New()
 	checkoutID := uuid.New()
 	repo := &checkoutRepositoryStub{checkout: &SubscriptionCheckout{
-		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 250, Currency: "ZMW",
+		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 25000, Currency: "ZMW",
 		Reference: "SUB-" + checkoutID.String(), Status: CheckoutPending, ExpiresAt: time.Now().Add(10 * time.Minute),
 	}}
 	verifier := &collectionVerifierStub{collection: &ProviderCollection{
@@ -204,7 +204,7 @@ func TestVerifySubscriptionCheckoutProviderFailureDoesNotActivate(t *testing.T)
 	vendorID := uuid.New()
 	checkoutID := uuid.New()
 	repo := &checkoutRepositoryStub{checkout: &SubscriptionCheckout{
-		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 250, Currency: "ZMW",
+		ID: checkoutID, VendorID: vendorID, TierID: uuid.New(), Amount: 25000, Currency: "ZMW",
 		Reference: "SUB-" + checkoutID.String(), Status: CheckoutPending, ExpiresAt: time.Now().Add(10 * time.Minute),
 	}}
 	verifier := &collectionVerifierStub{err: errors.New("provider unavailable")}
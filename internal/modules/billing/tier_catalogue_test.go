@@ -35,7 +35,7 @@ func TestListTiersReturnsRepositoryCatalogue(t *testing.T) {
 	expected := []*VendorTier{{
 		ID:           uuid.New(),
 		Name:         "CORE",
-		MonthlyPrice: 250,
+		MonthlyPrice: 25000,
 		Description:  "For getting started",
 		IsAvailable:  true,
 		Features:     []TierFeature{{Text: "20 jobs/day", Included: true}},
@@ -46,7 +46,7 @@ func TestListTiersReturnsRepositoryCatalogue(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ListTiers() error = %v", err)
 	}
-	if len(actual) != 1 || actual[0].Name != "CORE" || actual[0].MonthlyPrice != 250 {
+	if len(actual) != 1 || actual[0].Name != "CORE" || actual[0].MonthlyPrice != 25000 {
 		t.Fatalf("ListTiers() = %#v, want CORE at K250", actual)
 	}
 }
@@ -55,7 +55,7 @@ func TestTierCatalogueHandlerReturnsDatabaseBackedTiers(t *testing.T) {
 	h := NewHandler(tierCatalogueServiceStub{tiers: []*VendorTier{{
 		ID:           uuid.New(),
 		Name:         "PRO",
-		MonthlyPrice: 500,
+		MonthlyPrice: 50000,
 		IsPopular:    true,
 	}}}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/billing/tiers", nil)
@@ -70,7 +70,7 @@ func TestTierCatalogueHandlerReturnsDatabaseBackedTiers(t *testing.T) {
 	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
 		t.Fatalf("decode response: %v", err)
 	}
-	if len(body) != 1 || body[0].Name != "PRO" || body[0].MonthlyPrice != 500 || !body[0].IsPopular {
+	if len(body) != 1 || body[0].Name != "PRO" || body[0].MonthlyPrice != 50000 || !body[0].IsPopular {
 		t.Fatalf("response = %#v, want PRO at K500", body)
 	}
 }
@@ -2,6 +2,7 @@ package catalog
 
 import (
 "encoding/json"
+"errors"
 "net/http"
 
 "github.com/go-chi/chi/v5"
@@ -39,6 +40,10 @@ http.Error(w, err.Error(), http.StatusBadRequest)
 return
 }
 p, err := h.service.CreateProduct(r.Context(), req)
+if errors.Is(err, ErrInvalidTaxClass) || errors.Is(err, ErrInvalidOptionSchema) || errors.Is(err, ErrInvalidProductionEstimate) {
+http.Error(w, err.Error(), http.StatusBadRequest)
+return
+}
 if err != nil {
 http.Error(w, err.Error(), http.StatusInternalServerError)
 return
@@ -64,6 +69,10 @@ http.Error(w, err.Error(), http.StatusBadRequest)
 return
 }
 p, err := h.service.UpdateProduct(r.Context(), id, req)
+if errors.Is(err, ErrInvalidTaxClass) || errors.Is(err, ErrInvalidOptionSchema) || errors.Is(err, ErrInvalidProductionEstimate) {
+http.Error(w, err.Error(), http.StatusBadRequest)
+return
+}
 if err != nil {
 http.Error(w, err.Error(), http.StatusInternalServerError)
 return
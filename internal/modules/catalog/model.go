@@ -2,11 +2,40 @@ package catalog
 
 import (
 "encoding/json"
+"errors"
+"strings"
 "time"
 
 "github.com/google/uuid"
 )
 
+// TaxClass determines how VAT is charged on a product.
+type TaxClass string
+
+const (
+TaxStandard  TaxClass = "STANDARD"
+TaxZeroRated TaxClass = "ZERO_RATED"
+TaxExempt    TaxClass = "EXEMPT"
+)
+
+// ErrInvalidTaxClass is returned when a product is saved with an unknown tax class.
+var ErrInvalidTaxClass = errors.New("tax_class must be STANDARD, ZERO_RATED, or EXEMPT")
+
+// ErrInvalidProductionEstimate is returned when a product's production time estimate is negative.
+var ErrInvalidProductionEstimate = errors.New("setup_minutes and minutes_per_unit must not be negative")
+
+// ParseTaxClass normalises a client-supplied tax class. An empty value means STANDARD.
+func ParseTaxClass(v string) (TaxClass, error) {
+switch c := TaxClass(strings.ToUpper(strings.TrimSpace(v))); c {
+case "":
+return TaxStandard, nil
+case TaxStandard, TaxZeroRated, TaxExempt:
+return c, nil
+default:
+return "", ErrInvalidTaxClass
+}
+}
+
 // PlatformProduct is a product in the master catalog managed by the Printa platform.
 type PlatformProduct struct {
 ID          uuid.UUID       `json:"id"`
@@ -15,10 +44,16 @@ Description string          `json:"description,omitempty"`
 Category    string          `json:"category"`
 BasePrice   float64         `json:"base_price"`
 Currency    string          `json:"currency"`
+TaxClass    TaxClass        `json:"tax_class"`
 SKU         string          `json:"sku,omitempty"`
 ImageURL    string          `json:"image_url,omitempty"`
 IsActive    bool            `json:"is_active"`
 Attributes  json.RawMessage `json:"attributes,omitempty"`
+// OptionSchema declares the customisation an order line must carry; nil leaves customisation free-form.
+OptionSchema *OptionSchema `json:"option_schema,omitempty"`
+// SetupMinutes and MinutesPerUnit estimate production time: a line of n units takes SetupMinutes + n*MinutesPerUnit.
+SetupMinutes   float64 `json:"setup_minutes"`
+MinutesPerUnit float64 `json:"minutes_per_unit"`
 CreatedAt   time.Time       `json:"created_at"`
 UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// OptionType is the kind of value a product option accepts in an order line's customisation.
+type OptionType string
+
+const (
+	OptionEnum    OptionType = "ENUM"    // one of Values, e.g. size A4 or A3
+	OptionInteger OptionType = "INTEGER" // a whole number within Min and Max, e.g. paper weight in gsm
+	OptionBoolean OptionType = "BOOLEAN" // e.g. rounded corners
+	OptionText    OptionType = "TEXT"    // free text up to MaxLength, e.g. the name printed on a card
+	OptionAsset   OptionType = "ASSET"   // the ID of an uploaded design asset, e.g. the artwork
+)
+
+// ProductOption declares one field a customer fills in when ordering the product.
+type ProductOption struct {
+	Key       string     `json:"key"`
+	Label     string     `json:"label,omitempty"`
+	Type      OptionType `json:"type"`
+	Required  bool       `json:"required,omitempty"`
+	Values    []string   `json:"values,omitempty"`
+	Min       *int64     `json:"min,omitempty"`
+	Max       *int64     `json:"max,omitempty"`
+	MaxLength int        `json:"max_length,omitempty"`
+}
+
+// OptionSchema is the typed set of options a product accepts. A customisation may only use the declared keys.
+type OptionSchema struct {
+	Options []ProductOption `json:"options"`
+}
+
+// FieldError explains why one customisation field was rejected, in words the storefront can show next to it.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrInvalidOptionSchema is wrapped by every error describing a malformed option schema.
+var ErrInvalidOptionSchema = errors.New("invalid option_schema")
+
+var optionKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// defaultTextLength bounds TEXT options that do not set MaxLength.
+const defaultTextLength = 500
+
+// Validate checks that the schema itself is well formed before it is saved on a product.
+func (s *OptionSchema) Validate() error {
+	seen := make(map[string]bool, len(s.Options))
+	for i := range s.Options {
+		o := &s.Options[i]
+		o.Type = OptionType(strings.ToUpper(strings.TrimSpace(string(o.Type))))
+		if !optionKeyPattern.MatchString(o.Key) {
+			return fmt.Errorf("%w: option key %q must be lower snake_case", ErrInvalidOptionSchema, o.Key)
+		}
+		if seen[o.Key] {
+			return fmt.Errorf("%w: option %q is declared more than once", ErrInvalidOptionSchema, o.Key)
+		}
+		seen[o.Key] = true
+		switch o.Type {
+		case OptionEnum:
+			if len(o.Values) == 0 {
+				return fmt.Errorf("%w: option %q must list its values", ErrInvalidOptionSchema, o.Key)
+			}
+			values := make(map[string]bool, len(o.Values))
+			for _, v := range o.Values {
+				if strings.TrimSpace(v) == "" || values[v] {
+					return fmt.Errorf("%w: option %q has a blank or repeated value", ErrInvalidOptionSchema, o.Key)
+				}
+				values[v] = true
+			}
+		case OptionInteger:
+			if o.Min != nil && o.Max != nil && *o.Min > *o.Max {
+				return fmt.Errorf("%w: option %q has min greater than max", ErrInvalidOptionSchema, o.Key)
+			}
+		case OptionText:
+			if o.MaxLength < 0 {
+				return fmt.Errorf("%w: option %q has a negative max_length", ErrInvalidOptionSchema, o.Key)
+			}
+		case OptionBoolean, OptionAsset:
+		default:
+			return fmt.Errorf("%w: option %q has unknown type %q", ErrInvalidOptionSchema, o.Key, o.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateCustomisation checks an order line's customisation against the schema and returns one FieldError per
+// problem, in schema order followed by undeclared fields. A nil schema accepts anything, so products that have not
+// declared options keep taking free-form customisation.
+func (s *OptionSchema) ValidateCustomisation(customisation json.RawMessage) []FieldError {
+	if s == nil {
+		return nil
+	}
+	values := make(map[string]json.RawMessage)
+	if len(customisation) > 0 && string(customisation) != "null" {
+		if err := json.Unmarshal(customisation, &values); err != nil {
+			return []FieldError{{Field: "customisation", Message: "must be an object of option values"}}
+		}
+	}
+
+	var errs []FieldError
+	declared := make(map[string]bool, len(s.Options))
+	for _, o := range s.Options {
+		declared[o.Key] = true
+		raw, ok := values[o.Key]
+		if !ok || string(raw) == "null" {
+			if o.Required {
+				errs = append(errs, FieldError{Field: o.Key, Message: fmt.Sprintf("%s is required", o.label())})
+			}
+			continue
+		}
+		if msg := o.check(raw); msg != "" {
+			errs = append(errs, FieldError{Field: o.Key, Message: msg})
+		}
+	}
+
+	var extra []string
+	for key := range values {
+		if !declared[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		errs = append(errs, FieldError{Field: key, Message: fmt.Sprintf("%s is not an option of this product", key)})
+	}
+	return errs
+}
+
+// AssetRef is a design asset referenced by an ASSET option of a customisation.
+type AssetRef struct {
+	Field   string
+	AssetID string
+}
+
+// AssetRefs returns the design assets a valid customisation references through ASSET options, in schema order.
+func (s *OptionSchema) AssetRefs(customisation json.RawMessage) []AssetRef {
+	if s == nil || len(customisation) == 0 {
+		return nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(customisation, &values); err != nil {
+		return nil
+	}
+	var refs []AssetRef
+	for _, o := range s.Options {
+		var id string
+		if o.Type == OptionAsset && json.Unmarshal(values[o.Key], &id) == nil && id != "" {
+			refs = append(refs, AssetRef{Field: o.Key, AssetID: id})
+		}
+	}
+	return refs
+}
+
+// check returns why raw is not an acceptable value for the option, or "" when it is.
+func (o ProductOption) check(raw json.RawMessage) string {
+	switch o.Type {
+	case OptionEnum:
+		var v string
+		if json.Unmarshal(raw, &v) == nil {
+			for _, allowed := range o.Values {
+				if v == allowed {
+					return ""
+				}
+			}
+		}
+		return fmt.Sprintf("%s must be one of %s", o.label(), strings.Join(o.Values, ", "))
+	case OptionInteger:
+		var f float64
+		if json.Unmarshal(raw, &f) != nil || f != math.Trunc(f) {
+			return fmt.Sprintf("%s must be a whole number", o.label())
+		}
+		v := int64(f)
+		switch {
+		case o.Min != nil && o.Max != nil && (v < *o.Min || v > *o.Max):
+			return fmt.Sprintf("%s must be between %d and %d", o.label(), *o.Min, *o.Max)
+		case o.Min != nil && v < *o.Min:
+			return fmt.Sprintf("%s must be at least %d", o.label(), *o.Min)
+		case o.Max != nil && v > *o.Max:
+			return fmt.Sprintf("%s must be at most %d", o.label(), *o.Max)
+		}
+	case OptionBoolean:
+		var v bool
+		if json.Unmarshal(raw, &v) != nil {
+			return fmt.Sprintf("%s must be true or false", o.label())
+		}
+	case OptionText:
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			return fmt.Sprintf("%s must be text", o.label())
+		}
+		limit := o.MaxLength
+		if limit == 0 {
+			limit = defaultTextLength
+		}
+		if len([]rune(v)) > limit {
+			return fmt.Sprintf("%s must not exceed %d characters", o.label(), limit)
+		}
+		if o.Required && strings.TrimSpace(v) == "" {
+			return fmt.Sprintf("%s is required", o.label())
+		}
+	case OptionAsset:
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			return fmt.Sprintf("%s must be an uploaded design asset", o.label())
+		}
+		if _, err := uuid.Parse(v); err != nil {
+			return fmt.Sprintf("%s must be an uploaded design asset", o.label())
+		}
+	}
+	return ""
+}
+
+func (o ProductOption) label() string {
+	if o.Label != "" {
+		return o.Label
+	}
+	return o.Key
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func businessCardSchema() *OptionSchema {
+	minWeight, maxWeight := int64(250), int64(400)
+	return &OptionSchema{Options: []ProductOption{
+		{Key: "size", Label: "Size", Type: OptionEnum, Required: true, Values: []string{"85x55", "90x50"}},
+		{Key: "paper_weight", Label: "Paper weight", Type: OptionInteger, Min: &minWeight, Max: &maxWeight},
+		{Key: "double_sided", Type: OptionBoolean},
+		{Key: "finish", Type: OptionEnum, Values: []string{"matte", "gloss"}},
+		{Key: "artwork", Label: "Artwork", Type: OptionAsset, Required: true},
+	}}
+}
+
+func TestValidateCustomisationAcceptsConformingValues(t *testing.T) {
+	errs := businessCardSchema().ValidateCustomisation(json.RawMessage(`{
+		"size": "85x55", "paper_weight": 350, "double_sided": true,
+		"artwork": "7c6b1a5e-8f1d-4b59-9d0e-2f3a4b5c6d7e"}`))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected field errors: %#v", errs)
+	}
+}
+
+func TestValidateCustomisationReportsEveryFieldInSchemaOrder(t *testing.T) {
+	errs := businessCardSchema().ValidateCustomisation(json.RawMessage(`{
+		"paper_weight": 120.5, "double_sided": "yes", "finish": "satin", "artwork": "logo.png", "colour": "red"}`))
+	want := []FieldError{
+		{Field: "size", Message: "Size is required"},
+		{Field: "paper_weight", Message: "Paper weight must be a whole number"},
+		{Field: "double_sided", Message: "double_sided must be true or false"},
+		{Field: "finish", Message: "finish must be one of matte, gloss"},
+		{Field: "artwork", Message: "Artwork must be an uploaded design asset"},
+		{Field: "colour", Message: "colour is not an option of this product"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("field errors = %#v, want %#v", errs, want)
+	}
+
+	errs = businessCardSchema().ValidateCustomisation(json.RawMessage(`{"size": "85x55", "paper_weight": 500}`))
+	if len(errs) != 2 || errs[0].Message != "Paper weight must be between 250 and 400" || errs[1].Field != "artwork" {
+		t.Fatalf("field errors = %#v", errs)
+	}
+}
+
+func TestValidateCustomisationWithoutSchemaAcceptsAnything(t *testing.T) {
+	var schema *OptionSchema
+	if errs := schema.ValidateCustomisation(json.RawMessage(`{"anything": [1, 2]}`)); errs != nil {
+		t.Fatalf("nil schema rejected customisation: %#v", errs)
+	}
+	if errs := businessCardSchema().ValidateCustomisation(json.RawMessage(`[1]`)); len(errs) != 1 || errs[0].Field != "customisation" {
+		t.Fatalf("non-object customisation errors = %#v", errs)
+	}
+}
+
+func TestOptionSchemaValidateRejectsMalformedSchemas(t *testing.T) {
+	min, max := int64(10), int64(5)
+	for name, schema := range map[string]*OptionSchema{
+		"bad key":         {Options: []ProductOption{{Key: "Paper Size", Type: OptionText}}},
+		"duplicate key":   {Options: []ProductOption{{Key: "size", Type: OptionText}, {Key: "size", Type: OptionText}}},
+		"enum no values":  {Options: []ProductOption{{Key: "size", Type: OptionEnum}}},
+		"repeated value":  {Options: []ProductOption{{Key: "size", Type: OptionEnum, Values: []string{"A4", "A4"}}}},
+		"inverted range":  {Options: []ProductOption{{Key: "copies", Type: OptionInteger, Min: &min, Max: &max}}},
+		"unknown type":    {Options: []ProductOption{{Key: "size", Type: "COLOUR"}}},
+		"negative length": {Options: []ProductOption{{Key: "name", Type: OptionText, MaxLength: -1}}},
+	} {
+		if err := schema.Validate(); !errors.Is(err, ErrInvalidOptionSchema) {
+			t.Errorf("%s: Validate() = %v, want ErrInvalidOptionSchema", name, err)
+		}
+	}
+
+	schema := &OptionSchema{Options: []ProductOption{{Key: "sides", Type: "enum", Values: []string{"single", "double"}}}}
+	if err := schema.Validate(); err != nil || schema.Options[0].Type != OptionEnum {
+		t.Fatalf("Validate() = %v, type %q; want a valid ENUM", err, schema.Options[0].Type)
+	}
+}
@@ -18,27 +18,56 @@ func (r *postgresRepo) Create(ctx context.Context, p *PlatformProduct) error {
 	if p.Attributes != nil {
 		attrs = p.Attributes
 	}
-	_, err := r.db.ExecContext(ctx, `
+	schema, err := encodeOptionSchema(p.OptionSchema)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
 		INSERT INTO platform_products
-		  (id, name, description, category, base_price, currency, sku, image_url, is_active, attributes)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
+		  (id, name, description, category, base_price, currency, sku, image_url, is_active, attributes, tax_class, option_schema,
+		   setup_minutes, minutes_per_unit)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`,
 		p.ID, p.Name, p.Description, p.Category, p.BasePrice,
-		p.Currency, p.SKU, p.ImageURL, p.IsActive, attrs)
+		p.Currency, p.SKU, p.ImageURL, p.IsActive, attrs, p.TaxClass, schema,
+		p.SetupMinutes, p.MinutesPerUnit)
 	return err
 }
 
+// encodeOptionSchema stores a nil schema as SQL NULL.
+func encodeOptionSchema(schema *OptionSchema) (interface{}, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	return json.Marshal(schema)
+}
+
+// DecodeOptionSchema reads an option_schema column; NULL decodes to a nil schema.
+func DecodeOptionSchema(data []byte) (*OptionSchema, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	schema := &OptionSchema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("decode option_schema: %w", err)
+	}
+	return schema, nil
+}
+
 func scanProduct(scan func(...interface{}) error) (*PlatformProduct, error) {
 	p := &PlatformProduct{}
-	var attrs []byte
+	var attrs, schema []byte
 	err := scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.BasePrice,
 		&p.Currency, &p.SKU, &p.ImageURL, &p.IsActive, &attrs,
-		&p.CreatedAt, &p.UpdatedAt)
+		&p.TaxClass, &schema, &p.SetupMinutes, &p.MinutesPerUnit, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	if attrs != nil {
 		p.Attributes = json.RawMessage(attrs)
 	}
+	if p.OptionSchema, err = DecodeOptionSchema(schema); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
@@ -48,13 +77,13 @@ func (r *postgresRepo) GetByID(ctx context.Context, id string) (*PlatformProduct
 		return nil, err
 	}
 	row := r.db.QueryRowContext(ctx, `
-		SELECT id,name,description,category,base_price,currency,sku,image_url,is_active,attributes,created_at,updated_at
+		SELECT id,name,description,category,base_price,currency,sku,image_url,is_active,attributes,tax_class,option_schema,setup_minutes,minutes_per_unit,created_at,updated_at
 		FROM platform_products WHERE id=$1`, uid)
 	return scanProduct(row.Scan)
 }
 
 func (r *postgresRepo) List(ctx context.Context, category string, activeOnly bool) ([]*PlatformProduct, error) {
-	query := `SELECT id,name,description,category,base_price,currency,sku,image_url,is_active,attributes,created_at,updated_at
+	query := `SELECT id,name,description,category,base_price,currency,sku,image_url,is_active,attributes,tax_class,option_schema,setup_minutes,minutes_per_unit,created_at,updated_at
 	          FROM platform_products WHERE 1=1`
 	args := []interface{}{}
 	n := 1
@@ -90,12 +119,18 @@ func (r *postgresRepo) Update(ctx context.Context, p *PlatformProduct) error {
 	if p.Attributes != nil {
 		attrs = p.Attributes
 	}
-	_, err := r.db.ExecContext(ctx, `
+	schema, err := encodeOptionSchema(p.OptionSchema)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
 		UPDATE platform_products
 		SET name=$1, description=$2, category=$3, base_price=$4, currency=$5,
-		    sku=$6, image_url=$7, is_active=$8, attributes=$9, updated_at=NOW()
-		WHERE id=$10`,
+		    sku=$6, image_url=$7, is_active=$8, attributes=$9, tax_class=$10, option_schema=$11,
+		    setup_minutes=$12, minutes_per_unit=$13, updated_at=NOW()
+		WHERE id=$14`,
 		p.Name, p.Description, p.Category, p.BasePrice, p.Currency,
-		p.SKU, p.ImageURL, p.IsActive, attrs, p.ID)
+		p.SKU, p.ImageURL, p.IsActive, attrs, p.TaxClass, schema,
+		p.SetupMinutes, p.MinutesPerUnit, p.ID)
 	return err
 }
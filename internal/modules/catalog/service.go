@@ -21,8 +21,21 @@ Description string  `json:"description"`
 Category    string  `json:"category"`
 BasePrice   float64 `json:"base_price"`
 Currency    string  `json:"currency"`
+TaxClass    string  `json:"tax_class"`
 SKU         string  `json:"sku"`
 ImageURL    string  `json:"image_url"`
+OptionSchema *OptionSchema `json:"option_schema,omitempty"`
+// SetupMinutes and MinutesPerUnit are left unchanged on update when omitted.
+SetupMinutes   *float64 `json:"setup_minutes,omitempty"`
+MinutesPerUnit *float64 `json:"minutes_per_unit,omitempty"`
+}
+
+// validateEstimate rejects negative production time estimates.
+func (req CreateProductRequest) validateEstimate() error {
+if (req.SetupMinutes != nil && *req.SetupMinutes < 0) || (req.MinutesPerUnit != nil && *req.MinutesPerUnit < 0) {
+return ErrInvalidProductionEstimate
+}
+return nil
 }
 
 type service struct{ repo Repository }
@@ -34,6 +47,18 @@ currency := req.Currency
 if currency == "" {
 currency = "ZMW"
 }
+taxClass, err := ParseTaxClass(req.TaxClass)
+if err != nil {
+return nil, err
+}
+if req.OptionSchema != nil {
+if err := req.OptionSchema.Validate(); err != nil {
+return nil, err
+}
+}
+if err := req.validateEstimate(); err != nil {
+return nil, err
+}
 p := &PlatformProduct{
 ID:          uuid.New(),
 Name:        req.Name,
@@ -41,9 +66,17 @@ Description: req.Description,
 Category:    req.Category,
 BasePrice:   req.BasePrice,
 Currency:    currency,
+TaxClass:    taxClass,
 SKU:         req.SKU,
 ImageURL:    req.ImageURL,
 IsActive:    true,
+OptionSchema: req.OptionSchema,
+}
+if req.SetupMinutes != nil {
+p.SetupMinutes = *req.SetupMinutes
+}
+if req.MinutesPerUnit != nil {
+p.MinutesPerUnit = *req.MinutesPerUnit
 }
 if err := s.repo.Create(ctx, p); err != nil {
 return nil, err
@@ -60,6 +93,18 @@ return s.repo.List(ctx, category, activeOnly)
 }
 
 func (s *service) UpdateProduct(ctx context.Context, id string, req CreateProductRequest) (*PlatformProduct, error) {
+taxClass, err := ParseTaxClass(req.TaxClass)
+if err != nil {
+return nil, err
+}
+if req.OptionSchema != nil {
+if err := req.OptionSchema.Validate(); err != nil {
+return nil, err
+}
+}
+if err := req.validateEstimate(); err != nil {
+return nil, err
+}
 p, err := s.repo.GetByID(ctx, id)
 if err != nil {
 return nil, err
@@ -71,8 +116,16 @@ p.BasePrice = req.BasePrice
 if req.Currency != "" {
 p.Currency = req.Currency
 }
+p.TaxClass = taxClass
 p.SKU = req.SKU
 p.ImageURL = req.ImageURL
+p.OptionSchema = req.OptionSchema
+if req.SetupMinutes != nil {
+p.SetupMinutes = *req.SetupMinutes
+}
+if req.MinutesPerUnit != nil {
+p.MinutesPerUnit = *req.MinutesPerUnit
+}
 if err := s.repo.Update(ctx, p); err != nil {
 return nil, err
 }
@@ -0,0 +1,279 @@
+package claim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	assetstore "github.com/georgemunganga/printa-backend/internal/assets"
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/order"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler exposes reprint and refund claims: customers open them on delivered orders, store owners decide them.
+type Handler struct {
+	service          Service
+	orderService     order.Service
+	inventoryService inventory.Service
+	vendorService    vendor.Service
+	storage          assetstore.Storage
+}
+
+func NewHandler(service Service, orderService order.Service, inventoryService inventory.Service, vendorService vendor.Service, storage assetstore.Storage) *Handler {
+	return &Handler{
+		service:          service,
+		orderService:     orderService,
+		inventoryService: inventoryService,
+		vendorService:    vendorService,
+		storage:          storage,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/claims", func(r chi.Router) {
+		r.Post("/", h.openClaim)                      // POST /api/v1/claims
+		r.Get("/{id}", h.getClaim)                    // GET  /api/v1/claims/{id}
+		r.Post("/{id}/accept", h.acceptClaim)         // POST /api/v1/claims/{id}/accept
+		r.Post("/{id}/reject", h.rejectClaim)         // POST /api/v1/claims/{id}/reject
+		r.Get("/{id}/photos/{asset_id}", h.getPhoto)  // GET  /api/v1/claims/{id}/photos/{asset_id}
+		r.Get("/order/{order_id}", h.listOrderClaims) // GET  /api/v1/claims/order/{order_id}
+		r.Get("/store/{store_id}", h.listStoreClaims) // GET  /api/v1/claims/store/{store_id}?status=OPEN
+	})
+}
+
+// openClaim is for customers only: the claim is always raised by the customer who received the order.
+func (h *Handler) openClaim(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) != middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only the customer can open a claim"})
+		return
+	}
+	var req OpenClaimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	req.CustomerID = middleware.GetUserID(r)
+	c, err := h.service.Open(r.Context(), req)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	respond(w, http.StatusCreated, withPhotoURLs(c))
+}
+
+func (h *Handler) getClaim(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.requireClaimAccess(w, r, true)
+	if !ok {
+		return
+	}
+	respond(w, http.StatusOK, withPhotoURLs(c))
+}
+
+func (h *Handler) acceptClaim(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.service.Accept)
+}
+
+func (h *Handler) rejectClaim(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.service.Reject)
+}
+
+// decide runs an accept or reject for the store owner or an administrator.
+func (h *Handler) decide(w http.ResponseWriter, r *http.Request, decision func(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error)) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only the store can decide a claim"})
+		return
+	}
+	c, ok := h.requireClaimAccess(w, r, false)
+	if !ok {
+		return
+	}
+	var req DecideClaimRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+	req.ActorID, req.ActorRole = middleware.GetUserID(r), string(middleware.GetRole(r))
+	decided, err := decision(r.Context(), c.ID.String(), req)
+	if err != nil {
+		var oos *order.OutOfStockError
+		if errors.As(err, &oos) {
+			respond(w, http.StatusConflict, map[string]interface{}{
+				"error":                   oos.Error(),
+				"vendor_store_product_id": oos.VendorStoreProductID,
+				"requested":               oos.Requested,
+				"available":               oos.Available,
+			})
+			return
+		}
+		respondError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, withPhotoURLs(decided))
+}
+
+func (h *Handler) getPhoto(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.requireClaimAccess(w, r, true)
+	if !ok {
+		return
+	}
+	photo, err := h.service.GetPhoto(r.Context(), c.ID.String(), chi.URLParam(r, "asset_id"))
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "photo not found"})
+		return
+	}
+	asset, err := h.storage.Open(r.Context(), photo.AssetID.String(), photo.OwnerID.String())
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "photo not found"})
+		return
+	}
+	w.Header().Set("Content-Type", asset.ContentType)
+	w.Header().Set("Content-Disposition", "inline; filename=\""+strings.ReplaceAll(asset.Name, "\"", "")+"\"")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write(asset.Content)
+}
+
+func (h *Handler) listOrderClaims(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "order_id")
+	purchase, err := h.orderService.GetOrder(r.Context(), orderID)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "order not found"})
+		return
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		if purchase.CustomerID == nil || purchase.CustomerID.String() != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "order does not belong to authenticated customer"})
+			return
+		}
+	} else if !h.requireStoreAccess(w, r, purchase.StoreID.String(), true) {
+		return
+	}
+	claims, err := h.service.ListByOrder(r.Context(), orderID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if claims == nil {
+		claims = make([]*Claim, 0)
+	}
+	for _, c := range claims {
+		withPhotoURLs(c)
+	}
+	respond(w, http.StatusOK, claims)
+}
+
+func (h *Handler) listStoreClaims(w http.ResponseWriter, r *http.Request) {
+	storeID := chi.URLParam(r, "store_id")
+	if !h.requireStoreAccess(w, r, storeID, true) {
+		return
+	}
+	claims, err := h.service.ListByStore(r.Context(), storeID, r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if claims == nil {
+		claims = make([]*Claim, 0)
+	}
+	for _, c := range claims {
+		withPhotoURLs(c)
+	}
+	respond(w, http.StatusOK, claims)
+}
+
+// withPhotoURLs fills in where each photo can be downloaded.
+func withPhotoURLs(c *Claim) *Claim {
+	for _, photo := range c.Photos {
+		photo.URL = "/api/v1/claims/" + c.ID.String() + "/photos/" + photo.AssetID.String()
+	}
+	return c
+}
+
+// requireClaimAccess loads the claim and lets through the customer who opened it and the store's owner, plus its
+// staff when allowStaff is set.
+func (h *Handler) requireClaimAccess(w http.ResponseWriter, r *http.Request, allowStaff bool) (*Claim, bool) {
+	c, err := h.service.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err)
+		return nil, false
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		if c.CustomerID.String() != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "claim does not belong to authenticated customer"})
+			return nil, false
+		}
+		return c, true
+	}
+	if !h.requireStoreAccess(w, r, c.StoreID.String(), allowStaff) {
+		return nil, false
+	}
+	return c, true
+}
+
+func (h *Handler) requireStoreAccess(w http.ResponseWriter, r *http.Request, storeID string, allowStaff bool) bool {
+	store, err := h.inventoryService.GetStore(r.Context(), storeID)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "store not found"})
+		return false
+	}
+
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		return true
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err == nil && v.ID == store.VendorID {
+			return true
+		}
+	case middleware.RoleStaff, middleware.RoleCashier:
+		if allowStaff {
+			staff, err := h.inventoryService.ListStaff(r.Context(), storeID)
+			if err == nil {
+				for _, member := range staff {
+					if member.UserID.String() == middleware.GetUserID(r) && member.IsActive {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+	return false
+}
+
+func respondError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrClaimOpen) || errors.Is(err, ErrClaimNotOpen):
+		code = http.StatusConflict
+	case errors.Is(err, ErrNotClaimable) || errors.Is(err, ErrPartialRefund) || errors.Is(err, order.ErrNotReprintable) ||
+		strings.Contains(msg, "no completed payment"):
+		code = http.StatusUnprocessableEntity
+	case strings.Contains(msg, "does not belong"):
+		code = http.StatusForbidden
+	case strings.Contains(msg, "invalid") || strings.Contains(msg, "not found on this order"):
+		code = http.StatusBadRequest
+	case strings.Contains(msg, "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(msg, "required") || strings.Contains(msg, "must") || strings.Contains(msg, "at least one") ||
+		strings.Contains(msg, "at most") || strings.Contains(msg, "more than once"):
+		code = http.StatusBadRequest
+	}
+	respond(w, code, map[string]string{"error": msg})
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,105 @@
+package claim
+
+import (
+	"errors"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// ClaimType is what the customer asks the store to do about a defective order.
+type ClaimType string
+
+const (
+	TypeReprint ClaimType = "REPRINT"
+	TypeRefund  ClaimType = "REFUND"
+)
+
+// ClaimStatus is the decision state of a claim. OPEN claims await the store; a PROCESSING claim is being accepted
+// and its reprint or refund issued; ACCEPTED and REJECTED are final.
+type ClaimStatus string
+
+const (
+	StatusOpen       ClaimStatus = "OPEN"
+	StatusProcessing ClaimStatus = "PROCESSING"
+	StatusAccepted   ClaimStatus = "ACCEPTED"
+	StatusRejected   ClaimStatus = "REJECTED"
+)
+
+// Claim is a customer's reprint or refund request against a delivered order.
+type Claim struct {
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	StoreID    uuid.UUID    `json:"store_id"`
+	CustomerID uuid.UUID    `json:"customer_id"`
+	Type       ClaimType    `json:"type"`
+	Status     ClaimStatus  `json:"status"`
+	Reason     string       `json:"reason"`
+	Items      []*ClaimItem `json:"items"`
+	Photos     []*Photo     `json:"photos,omitempty"`
+	VendorNote string       `json:"vendor_note,omitempty"`
+	DecidedBy  *uuid.UUID   `json:"decided_by,omitempty"`
+	DecidedAt  *time.Time   `json:"decided_at,omitempty"`
+	// ReprintOrderID and ProductionJobID are set when a REPRINT claim is accepted; RefundAmount and
+	// RefundTransactionIDs when a REFUND claim is.
+	ReprintOrderID       *uuid.UUID   `json:"reprint_order_id,omitempty"`
+	ProductionJobID      *uuid.UUID   `json:"production_job_id,omitempty"`
+	RefundAmount         money.Amount `json:"refund_amount"`
+	RefundTransactionIDs []uuid.UUID  `json:"refund_transaction_ids,omitempty"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// ClaimItem is a delivered order line, and how many of its units, the claim covers.
+type ClaimItem struct {
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+// Photo is a customer-owned design asset attached to a claim as evidence of the defect.
+type Photo struct {
+	AssetID     uuid.UUID `json:"asset_id"`
+	OwnerID     uuid.UUID `json:"-"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	URL         string    `json:"url,omitempty"`
+}
+
+// ClaimLine selects an order line for a new claim.
+type ClaimLine struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// OpenClaimRequest is the payload for opening a claim. Asset IDs must refer to undeleted assets owned by the
+// customer.
+type OpenClaimRequest struct {
+	OrderID    string      `json:"order_id"`
+	Type       string      `json:"type"`
+	Reason     string      `json:"reason"`
+	Items      []ClaimLine `json:"items"`
+	AssetIDs   []string    `json:"asset_ids,omitempty"`
+	CustomerID string      `json:"-"`
+}
+
+// DecideClaimRequest carries the store's note on an accepted or rejected claim and who made the decision.
+type DecideClaimRequest struct {
+	Note      string `json:"note,omitempty"`
+	ActorID   string `json:"-"`
+	ActorRole string `json:"-"`
+}
+
+// ErrClaimOpen is returned when the order already has a claim awaiting a decision.
+var ErrClaimOpen = errors.New("order already has an open claim")
+
+// ErrClaimNotOpen is returned when a claim has already been accepted or rejected, or is being accepted.
+var ErrClaimNotOpen = errors.New("claim has already been decided")
+
+// ErrNotClaimable is returned when a claim is opened against an order that has not been delivered.
+var ErrNotClaimable = errors.New("only DELIVERED orders can be claimed")
+
+// ErrPartialRefund is returned when a REFUND claim does not cover every delivered item in full. Payments are
+// refunded as whole transactions, so part of an order can only be put right with a reprint.
+var ErrPartialRefund = errors.New("a refund claim must cover every delivered item in full")
@@ -0,0 +1,267 @@
+package claim
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type postgresRepo struct{ db *sql.DB }
+
+func NewPostgresRepository(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const claimColumns = `id, order_id, store_id, customer_id, claim_type, status, reason, COALESCE(vendor_note,''),
+	       decided_by, decided_at, reprint_order_id, production_job_id, refund_minor, refund_transaction_ids,
+	       created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *postgresRepo) Create(ctx context.Context, c *Claim, assetIDs []uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if len(assetIDs) > 0 {
+		var ownedCount int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM design_assets
+			WHERE id = ANY($1) AND owner_id = $2 AND deleted_at IS NULL`,
+			pq.Array(assetIDs), c.CustomerID,
+		).Scan(&ownedCount); err != nil {
+			return err
+		}
+		if ownedCount != len(assetIDs) {
+			return fmt.Errorf("every photo must be an available asset owned by the customer")
+		}
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO order_claims (id, order_id, store_id, customer_id, claim_type, status, reason)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING created_at, updated_at`,
+		c.ID, c.OrderID, c.StoreID, c.CustomerID, c.Type, c.Status, c.Reason,
+	).Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "uq_order_claims_open_order" {
+			return ErrClaimOpen
+		}
+		return fmt.Errorf("insert claim: %w", err)
+	}
+	for _, item := range c.Items {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_claim_items (claim_id, order_item_id, quantity) VALUES ($1,$2,$3)`,
+			c.ID, item.OrderItemID, item.Quantity); err != nil {
+			return fmt.Errorf("insert claim item: %w", err)
+		}
+	}
+	for _, assetID := range assetIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_claim_photos (claim_id, asset_id) VALUES ($1,$2)`, c.ID, assetID); err != nil {
+			return fmt.Errorf("insert claim photo: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) GetByID(ctx context.Context, id string) (*Claim, error) {
+	c, err := scanClaim(r.db.QueryRowContext(ctx, `SELECT `+claimColumns+` FROM order_claims WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadDetails(ctx, []*Claim{c}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *postgresRepo) ListByOrder(ctx context.Context, orderID string) ([]*Claim, error) {
+	return r.queryClaims(ctx, `SELECT `+claimColumns+` FROM order_claims WHERE order_id=$1
+		ORDER BY created_at DESC, id DESC`, orderID)
+}
+
+func (r *postgresRepo) ListByStore(ctx context.Context, storeID, status string) ([]*Claim, error) {
+	query := `SELECT ` + claimColumns + ` FROM order_claims WHERE store_id=$1`
+	args := []interface{}{storeID}
+	if status != "" {
+		query += ` AND status=$2`
+		args = append(args, status)
+	}
+	return r.queryClaims(ctx, query+` ORDER BY created_at DESC, id DESC`, args...)
+}
+
+// claimProcessingLease is how long an acceptance may take to issue a claim's reprint or refund before another may
+// take the claim over. Both are idempotent, so a takeover finishes the work rather than repeating it.
+const claimProcessingLease = 10 * time.Minute
+
+func (r *postgresRepo) StartProcessing(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE order_claims SET status='PROCESSING', updated_at=NOW()
+		WHERE id=$1 AND (status='OPEN' OR (status='PROCESSING' AND updated_at < NOW() - $2 * INTERVAL '1 second'))`,
+		id, claimProcessingLease.Seconds())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrClaimNotOpen
+	}
+	return nil
+}
+
+func (r *postgresRepo) Reopen(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE order_claims SET status='OPEN', updated_at=NOW() WHERE id=$1 AND status='PROCESSING'`, id)
+	return err
+}
+
+func (r *postgresRepo) Decide(ctx context.Context, c *Claim, from ClaimStatus) error {
+	transactionIDs := c.RefundTransactionIDs
+	if transactionIDs == nil {
+		transactionIDs = []uuid.UUID{}
+	}
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE order_claims
+		SET status=$2, vendor_note=NULLIF($3,''), decided_by=$4, decided_at=NOW(), reprint_order_id=$5,
+		    production_job_id=$6, refund_minor=$7, refund_transaction_ids=$8, updated_at=NOW()
+		WHERE id=$1 AND status=$9
+		RETURNING decided_at, updated_at`,
+		c.ID, c.Status, c.VendorNote, c.DecidedBy, c.ReprintOrderID, c.ProductionJobID, c.RefundAmount,
+		pq.Array(transactionIDs), from,
+	).Scan(&c.DecidedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrClaimNotOpen
+	}
+	return err
+}
+
+func (r *postgresRepo) GetPhoto(ctx context.Context, claimID, assetID string) (*Photo, error) {
+	photo := &Photo{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT da.id, da.owner_id, da.original_name, da.content_type, da.size_bytes
+		FROM order_claim_photos cp
+		JOIN design_assets da ON da.id = cp.asset_id AND da.deleted_at IS NULL
+		WHERE cp.claim_id = $1 AND cp.asset_id = $2`, claimID, assetID,
+	).Scan(&photo.AssetID, &photo.OwnerID, &photo.Name, &photo.ContentType, &photo.SizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (r *postgresRepo) queryClaims(ctx context.Context, query string, args ...interface{}) ([]*Claim, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var claims []*Claim
+	for rows.Next() {
+		c, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadDetails(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// loadDetails attaches items and photos to the claims with one query each.
+func (r *postgresRepo) loadDetails(ctx context.Context, claims []*Claim) error {
+	if len(claims) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(claims))
+	byID := make(map[uuid.UUID]*Claim, len(claims))
+	for _, c := range claims {
+		ids = append(ids, c.ID)
+		byID[c.ID] = c
+	}
+
+	items, err := r.db.QueryContext(ctx, `
+		SELECT ci.claim_id, ci.order_item_id, ci.quantity
+		FROM order_claim_items ci
+		JOIN order_items oi ON oi.id = ci.order_item_id
+		WHERE ci.claim_id = ANY($1)
+		ORDER BY oi.created_at ASC, oi.id ASC`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer items.Close()
+	for items.Next() {
+		var claimID uuid.UUID
+		item := &ClaimItem{}
+		if err := items.Scan(&claimID, &item.OrderItemID, &item.Quantity); err != nil {
+			return err
+		}
+		byID[claimID].Items = append(byID[claimID].Items, item)
+	}
+	if err := items.Err(); err != nil {
+		return err
+	}
+
+	photos, err := r.db.QueryContext(ctx, `
+		SELECT cp.claim_id, da.id, da.owner_id, da.original_name, da.content_type, da.size_bytes
+		FROM order_claim_photos cp
+		JOIN design_assets da ON da.id = cp.asset_id AND da.deleted_at IS NULL
+		WHERE cp.claim_id = ANY($1)
+		ORDER BY cp.created_at ASC`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer photos.Close()
+	for photos.Next() {
+		var claimID uuid.UUID
+		photo := &Photo{}
+		if err := photos.Scan(&claimID, &photo.AssetID, &photo.OwnerID, &photo.Name, &photo.ContentType, &photo.SizeBytes); err != nil {
+			return err
+		}
+		byID[claimID].Photos = append(byID[claimID].Photos, photo)
+	}
+	return photos.Err()
+}
+
+func scanClaim(row rowScanner) (*Claim, error) {
+	c := &Claim{}
+	var decidedBy, reprintOrderID, productionJobID uuid.NullUUID
+	var decidedAt sql.NullTime
+	var transactionIDs []uuid.UUID
+	if err := row.Scan(&c.ID, &c.OrderID, &c.StoreID, &c.CustomerID, &c.Type, &c.Status, &c.Reason, &c.VendorNote,
+		&decidedBy, &decidedAt, &reprintOrderID, &productionJobID, &c.RefundAmount, pq.Array(&transactionIDs),
+		&c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if decidedBy.Valid {
+		c.DecidedBy = &decidedBy.UUID
+	}
+	if decidedAt.Valid {
+		c.DecidedAt = &decidedAt.Time
+	}
+	if reprintOrderID.Valid {
+		c.ReprintOrderID = &reprintOrderID.UUID
+	}
+	if productionJobID.Valid {
+		c.ProductionJobID = &productionJobID.UUID
+	}
+	if len(transactionIDs) > 0 {
+		c.RefundTransactionIDs = transactionIDs
+	}
+	return c, nil
+}
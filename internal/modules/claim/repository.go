@@ -0,0 +1,38 @@
+package claim
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines data access for order claims.
+type Repository interface {
+	// Create persists a new OPEN claim with its items and photos in one transaction. Every photo must be an
+	// undeleted asset owned by the claim's customer. It returns ErrClaimOpen when the order already has an open claim.
+	Create(ctx context.Context, c *Claim, assetIDs []uuid.UUID) error
+
+	// GetByID retrieves a claim with its items and photos.
+	GetByID(ctx context.Context, id string) (*Claim, error)
+
+	// ListByOrder returns an order's claims, newest first.
+	ListByOrder(ctx context.Context, orderID string) ([]*Claim, error)
+
+	// ListByStore returns a store's claims, newest first, optionally filtered by status.
+	ListByStore(ctx context.Context, storeID, status string) ([]*Claim, error)
+
+	// StartProcessing moves an OPEN claim to PROCESSING so that only one acceptance issues its reprint or refund. A
+	// claim left PROCESSING by an acceptance that died part way is taken over once claimProcessingLease has passed.
+	// It returns ErrClaimNotOpen when the claim was decided, or is being accepted, by someone else.
+	StartProcessing(ctx context.Context, id string) error
+
+	// Reopen returns a PROCESSING claim to OPEN after its reprint or refund failed, so it can be accepted again.
+	Reopen(ctx context.Context, id string) error
+
+	// Decide records c's decision and its outcome, provided the claim is still in status from. It returns
+	// ErrClaimNotOpen when another decision got there first.
+	Decide(ctx context.Context, c *Claim, from ClaimStatus) error
+
+	// GetPhoto returns the metadata of a photo attached to a claim.
+	GetPhoto(ctx context.Context, claimID, assetID string) (*Photo, error)
+}
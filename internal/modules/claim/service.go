@@ -0,0 +1,390 @@
+package claim
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/order"
+	"github.com/georgemunganga/printa-backend/internal/modules/payment"
+	"github.com/georgemunganga/printa-backend/internal/modules/pos"
+	"github.com/georgemunganga/printa-backend/internal/modules/production"
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// Service defines the reprint and refund claim workflow for delivered orders.
+type Service interface {
+	// Open records a customer's OPEN claim against one of their DELIVERED orders. It returns ErrNotClaimable when
+	// the order has not been delivered and ErrClaimOpen when it already has a claim awaiting a decision.
+	Open(ctx context.Context, req OpenClaimRequest) (*Claim, error)
+
+	// Get retrieves a claim with its items and photos.
+	Get(ctx context.Context, id string) (*Claim, error)
+
+	// ListByOrder returns an order's claims, newest first.
+	ListByOrder(ctx context.Context, orderID string) ([]*Claim, error)
+
+	// ListByStore returns a store's claims, newest first, optionally filtered by status.
+	ListByStore(ctx context.Context, storeID, status string) ([]*Claim, error)
+
+	// Accept fulfils an OPEN claim and records the outcome. A REPRINT claim places a zero-charge reprint of the
+	// claimed lines and queues its production job; a REFUND claim, which always covers the whole order, refunds the
+	// order's completed payments. The claim is moved to PROCESSING before anything is issued, so a concurrent
+	// acceptance or rejection cannot also act on it. If fulfilment fails the claim returns to OPEN, and accepting it
+	// again picks up what was already done.
+	Accept(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error)
+
+	// Reject closes an OPEN claim with the store's reason.
+	Reject(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error)
+
+	// GetPhoto returns the metadata of a photo attached to a claim.
+	GetPhoto(ctx context.Context, claimID, assetID string) (*Photo, error)
+}
+
+// Orders loads claimed orders and places reprints. It is satisfied by order.Service.
+type Orders interface {
+	GetOrder(ctx context.Context, id string) (*order.Order, error)
+	PlaceReprint(ctx context.Context, id string, req order.ReprintRequest) (*order.Order, error)
+}
+
+// Jobs queues production for reprints. It is satisfied by production.Service.
+type Jobs interface {
+	SyncOrderJobs(ctx context.Context, orderID string) ([]*production.ProductionJob, error)
+	GetJobByOrder(ctx context.Context, orderID string) (*production.ProductionJob, error)
+}
+
+// Payments refunds online payments. It is satisfied by payment.Service.
+type Payments interface {
+	ListByReference(ctx context.Context, refType payment.ReferenceType, refID string) ([]*payment.PaymentTransaction, error)
+	Refund(ctx context.Context, id string) (*payment.PaymentTransaction, error)
+	RefundCheckoutShare(ctx context.Context, checkoutID, orderID string, amount money.Amount) (*payment.Refund, error)
+}
+
+// POSPayments refunds in-store payments. It is satisfied by pos.Service.
+type POSPayments interface {
+	GetTransactionByOrder(ctx context.Context, orderID string) (*pos.POSTransaction, error)
+	RefundTransaction(ctx context.Context, id string, req pos.RefundRequest) (*pos.POSTransaction, error)
+}
+
+// maxReasonLength bounds the customer's description of the defect and the store's note.
+const maxReasonLength = 2000
+
+// maxPhotos bounds how many photos a claim may carry.
+const maxPhotos = 8
+
+type service struct {
+	repo        Repository
+	orders      Orders
+	jobs        Jobs
+	payments    Payments
+	posPayments POSPayments
+}
+
+// NewService creates a new claim service.
+func NewService(repo Repository, orders Orders, jobs Jobs, payments Payments, posPayments POSPayments) Service {
+	return &service{repo: repo, orders: orders, jobs: jobs, payments: payments, posPayments: posPayments}
+}
+
+func (s *service) Open(ctx context.Context, req OpenClaimRequest) (*Claim, error) {
+	claimType := ClaimType(strings.ToUpper(strings.TrimSpace(req.Type)))
+	if claimType != TypeReprint && claimType != TypeRefund {
+		return nil, fmt.Errorf("type must be REPRINT or REFUND")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if len(reason) > maxReasonLength {
+		return nil, fmt.Errorf("reason must not exceed %d characters", maxReasonLength)
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("claim must cover at least one item")
+	}
+	if len(req.AssetIDs) > maxPhotos {
+		return nil, fmt.Errorf("a claim may include at most %d photos", maxPhotos)
+	}
+	assetIDs := make([]uuid.UUID, 0, len(req.AssetIDs))
+	seenAssets := make(map[uuid.UUID]bool, len(req.AssetIDs))
+	for _, rawID := range req.AssetIDs {
+		assetID, err := uuid.Parse(strings.TrimSpace(rawID))
+		if err != nil {
+			return nil, fmt.Errorf("invalid photo asset ID")
+		}
+		if seenAssets[assetID] {
+			return nil, fmt.Errorf("photo %s appears more than once", assetID)
+		}
+		seenAssets[assetID] = true
+		assetIDs = append(assetIDs, assetID)
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer_id: %w", err)
+	}
+
+	o, err := s.orders.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if o.CustomerID == nil || *o.CustomerID != customerID {
+		return nil, fmt.Errorf("order does not belong to the authenticated customer")
+	}
+	if o.Status != order.StatusDelivered {
+		return nil, ErrNotClaimable
+	}
+
+	delivered := make(map[uuid.UUID]int, len(o.Items))
+	for _, item := range o.Items {
+		delivered[item.ID] = item.Quantity
+	}
+	items := make([]*ClaimItem, 0, len(req.Items))
+	seenItems := make(map[uuid.UUID]bool, len(req.Items))
+	for _, line := range req.Items {
+		itemID, err := uuid.Parse(line.OrderItemID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order_item_id: %w", err)
+		}
+		quantity, ok := delivered[itemID]
+		if !ok {
+			return nil, fmt.Errorf("item %s not found on this order", line.OrderItemID)
+		}
+		if seenItems[itemID] {
+			return nil, fmt.Errorf("item %s appears more than once", line.OrderItemID)
+		}
+		seenItems[itemID] = true
+		if line.Quantity <= 0 || line.Quantity > quantity {
+			return nil, fmt.Errorf("quantity for item %s must be between 1 and %d", line.OrderItemID, quantity)
+		}
+		items = append(items, &ClaimItem{OrderItemID: itemID, Quantity: line.Quantity})
+	}
+	if claimType == TypeRefund && !coversOrder(o, items) {
+		return nil, ErrPartialRefund
+	}
+
+	c := &Claim{
+		ID:         uuid.New(),
+		OrderID:    o.ID,
+		StoreID:    o.StoreID,
+		CustomerID: customerID,
+		Type:       claimType,
+		Status:     StatusOpen,
+		Reason:     reason,
+		Items:      items,
+	}
+	if err := s.repo.Create(ctx, c, assetIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, c.ID.String())
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Claim, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid claim ID")
+	}
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("claim not found: %w", err)
+	}
+	return c, nil
+}
+
+func (s *service) ListByOrder(ctx context.Context, orderID string) ([]*Claim, error) {
+	return s.repo.ListByOrder(ctx, orderID)
+}
+
+func (s *service) ListByStore(ctx context.Context, storeID, status string) ([]*Claim, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch ClaimStatus(status) {
+	case "", StatusOpen, StatusProcessing, StatusAccepted, StatusRejected:
+	default:
+		return nil, fmt.Errorf("invalid status %q", status)
+	}
+	return s.repo.ListByStore(ctx, storeID, status)
+}
+
+func (s *service) Accept(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error) {
+	c, err := s.openClaim(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.StartProcessing(ctx, id); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case TypeReprint:
+		err = s.reprint(ctx, c, req)
+	case TypeRefund:
+		err = s.refund(ctx, c)
+	}
+	if err != nil {
+		if reopenErr := s.repo.Reopen(ctx, id); reopenErr != nil {
+			log.Printf("claim %s: reopen after failed acceptance: %v", id, reopenErr)
+		}
+		return nil, err
+	}
+	c.Status = StatusAccepted
+	if err := s.repo.Decide(ctx, c, StatusProcessing); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) Reject(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error) {
+	c, err := s.openClaim(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if c.VendorNote == "" {
+		return nil, fmt.Errorf("note is required when rejecting a claim")
+	}
+	c.Status = StatusRejected
+	if err := s.repo.Decide(ctx, c, StatusOpen); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetPhoto(ctx context.Context, claimID, assetID string) (*Photo, error) {
+	if _, err := uuid.Parse(claimID); err != nil {
+		return nil, fmt.Errorf("invalid claim ID")
+	}
+	if _, err := uuid.Parse(assetID); err != nil {
+		return nil, fmt.Errorf("invalid asset ID")
+	}
+	return s.repo.GetPhoto(ctx, claimID, assetID)
+}
+
+// openClaim loads a claim that is still awaiting a decision and stamps it with the decider and their note.
+func (s *service) openClaim(ctx context.Context, id string, req DecideClaimRequest) (*Claim, error) {
+	c, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// A PROCESSING claim may have been left by an acceptance that died; StartProcessing and Decide settle who acts.
+	if c.Status != StatusOpen && c.Status != StatusProcessing {
+		return nil, ErrClaimNotOpen
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxReasonLength {
+		return nil, fmt.Errorf("note must not exceed %d characters", maxReasonLength)
+	}
+	c.VendorNote = note
+	if req.ActorID != "" {
+		actorID, err := uuid.Parse(req.ActorID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid actor id: %w", err)
+		}
+		c.DecidedBy = &actorID
+	}
+	return c, nil
+}
+
+// reprint places the zero-charge reprint order and queues its production the way every confirmed order is queued.
+// The idempotency key and the sync make a retried acceptance reuse the reprint and job a failed attempt already
+// created.
+func (s *service) reprint(ctx context.Context, c *Claim, req DecideClaimRequest) error {
+	lines := make([]order.ReprintLine, 0, len(c.Items))
+	for _, item := range c.Items {
+		lines = append(lines, order.ReprintLine{OrderItemID: item.OrderItemID.String(), Quantity: item.Quantity})
+	}
+	reprint, err := s.orders.PlaceReprint(ctx, c.OrderID.String(), order.ReprintRequest{
+		Items:          lines,
+		IdempotencyKey: "claim-" + c.ID.String(),
+		Actor:          order.Actor{ID: req.ActorID, Role: req.ActorRole},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := s.jobs.SyncOrderJobs(ctx, reprint.ID.String()); err != nil {
+		return fmt.Errorf("queue reprint production job: %w", err)
+	}
+	job, err := s.jobs.GetJobByOrder(ctx, reprint.ID.String())
+	if err != nil {
+		return fmt.Errorf("load reprint production job: %w", err)
+	}
+	c.ReprintOrderID, c.ProductionJobID = &reprint.ID, &job.ID
+	return nil
+}
+
+// refund refunds every completed online and POS payment on the order. Both payment services refund whole
+// transactions only, so the claim must cover the whole order; a claim for part of it is refused rather than
+// refunding more than was claimed. An order placed in a multi-store checkout was paid by the checkout payment, and
+// gets its own total back from it. Transactions already refunded by an earlier failed attempt are recorded rather
+// than refunded twice.
+func (s *service) refund(ctx context.Context, c *Claim) error {
+	o, err := s.orders.GetOrder(ctx, c.OrderID.String())
+	if err != nil {
+		return fmt.Errorf("order not found: %w", err)
+	}
+	if !coversOrder(o, c.Items) {
+		return ErrPartialRefund
+	}
+
+	var refunded money.Amount
+	var transactionIDs []uuid.UUID
+
+	var transactions []*payment.PaymentTransaction
+	if o.CheckoutID != nil {
+		share, err := s.payments.RefundCheckoutShare(ctx, o.CheckoutID.String(), c.OrderID.String(), o.Total)
+		switch {
+		case err == nil:
+			refunded += share.Amount
+			transactionIDs = append(transactionIDs, share.TransactionID)
+		case !errors.Is(err, payment.ErrNoCompletedPayment):
+			return fmt.Errorf("refund checkout payment: %w", err)
+		}
+	} else if transactions, err = s.payments.ListByReference(ctx, payment.RefOrder, c.OrderID.String()); err != nil {
+		return fmt.Errorf("list order payments: %w", err)
+	}
+	for _, tx := range transactions {
+		switch tx.Status {
+		case payment.TxCompleted:
+			if _, err := s.payments.Refund(ctx, tx.ID.String()); err != nil {
+				return fmt.Errorf("refund payment %s: %w", tx.ID, err)
+			}
+		case payment.TxRefunded:
+		default:
+			continue
+		}
+		refunded += tx.Amount
+		transactionIDs = append(transactionIDs, tx.ID)
+	}
+
+	posTx, err := s.posPayments.GetTransactionByOrder(ctx, c.OrderID.String())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("load order POS transaction: %w", err)
+	}
+	if posTx != nil && (posTx.Status == pos.TxCompleted || posTx.Status == pos.TxRefunded) {
+		if posTx.Status == pos.TxCompleted {
+			reason := "Refund for claim " + c.ID.String()
+			if _, err := s.posPayments.RefundTransaction(ctx, posTx.ID.String(), pos.RefundRequest{Reason: reason}); err != nil {
+				return fmt.Errorf("refund POS transaction %s: %w", posTx.ID, err)
+			}
+		}
+		refunded += posTx.Amount
+		transactionIDs = append(transactionIDs, posTx.ID)
+	}
+
+	if len(transactionIDs) == 0 {
+		return errors.New("order has no completed payment to refund")
+	}
+	c.RefundAmount, c.RefundTransactionIDs = refunded, transactionIDs
+	return nil
+}
+
+// coversOrder reports whether the claimed items are every item of the order at its full delivered quantity.
+func coversOrder(o *order.Order, items []*ClaimItem) bool {
+	claimed := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		claimed[item.OrderItemID] += item.Quantity
+	}
+	for _, item := range o.Items {
+		if claimed[item.ID] != item.Quantity {
+			return false
+		}
+	}
+	return true
+}
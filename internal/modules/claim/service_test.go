@@ -0,0 +1,439 @@
+package claim
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/order"
+	"github.com/georgemunganga/printa-backend/internal/modules/payment"
+	"github.com/georgemunganga/printa-backend/internal/modules/pos"
+	"github.com/georgemunganga/printa-backend/internal/modules/production"
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+type fakeRepository struct {
+	claims map[uuid.UUID]*Claim
+	// concurrent is a status another request records just before this one's next guarded write.
+	concurrent ClaimStatus
+}
+
+// guard applies a pending concurrent change and reports whether the claim is still in status from.
+func (f *fakeRepository) guard(id uuid.UUID, from ClaimStatus) bool {
+	if f.concurrent != "" {
+		f.claims[id].Status, f.concurrent = f.concurrent, ""
+	}
+	return f.claims[id].Status == from
+}
+
+func (f *fakeRepository) StartProcessing(_ context.Context, id string) error {
+	uid := uuid.MustParse(id)
+	if !f.guard(uid, StatusOpen) {
+		return ErrClaimNotOpen
+	}
+	f.claims[uid].Status = StatusProcessing
+	return nil
+}
+
+func (f *fakeRepository) Reopen(_ context.Context, id string) error {
+	if c := f.claims[uuid.MustParse(id)]; c.Status == StatusProcessing {
+		c.Status = StatusOpen
+	}
+	return nil
+}
+
+func (f *fakeRepository) Create(_ context.Context, c *Claim, _ []uuid.UUID) error {
+	for _, existing := range f.claims {
+		if existing.OrderID == c.OrderID && (existing.Status == StatusOpen || existing.Status == StatusProcessing) {
+			return ErrClaimOpen
+		}
+	}
+	f.claims[c.ID] = c
+	return nil
+}
+
+func (f *fakeRepository) GetByID(_ context.Context, id string) (*Claim, error) {
+	c, ok := f.claims[uuid.MustParse(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (f *fakeRepository) ListByOrder(context.Context, string) ([]*Claim, error) { return nil, nil }
+
+func (f *fakeRepository) ListByStore(context.Context, string, string) ([]*Claim, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) Decide(_ context.Context, c *Claim, from ClaimStatus) error {
+	if !f.guard(c.ID, from) {
+		return ErrClaimNotOpen
+	}
+	decided := *c
+	f.claims[c.ID] = &decided
+	return nil
+}
+
+func (f *fakeRepository) GetPhoto(context.Context, string, string) (*Photo, error) {
+	return nil, sql.ErrNoRows
+}
+
+type fakeOrders struct {
+	orders   map[string]*order.Order
+	reprints map[string]*order.Order // by idempotency key
+}
+
+func (f *fakeOrders) GetOrder(_ context.Context, id string) (*order.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return o, nil
+}
+
+func (f *fakeOrders) PlaceReprint(_ context.Context, id string, req order.ReprintRequest) (*order.Order, error) {
+	if existing, ok := f.reprints[req.IdempotencyKey]; ok {
+		return existing, nil
+	}
+	original := f.orders[id]
+	reprint := &order.Order{ID: uuid.New(), StoreID: original.StoreID, Status: order.StatusConfirmed, ReprintOf: &original.ID}
+	for _, line := range req.Items {
+		reprint.Items = append(reprint.Items, &order.OrderItem{ID: uuid.MustParse(line.OrderItemID), Quantity: line.Quantity})
+	}
+	f.reprints[req.IdempotencyKey] = reprint
+	return reprint, nil
+}
+
+type fakeJobs struct {
+	jobs    map[string]*production.ProductionJob
+	created []string // orders a job was queued for
+	fail    error
+}
+
+func (f *fakeJobs) GetJobByOrder(_ context.Context, orderID string) (*production.ProductionJob, error) {
+	job, ok := f.jobs[orderID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+func (f *fakeJobs) SyncOrderJobs(_ context.Context, orderID string) ([]*production.ProductionJob, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	if _, ok := f.jobs[orderID]; ok {
+		return nil, nil
+	}
+	f.created = append(f.created, orderID)
+	job := &production.ProductionJob{ID: uuid.New(), OrderID: uuid.MustParse(orderID)}
+	f.jobs[orderID] = job
+	return []*production.ProductionJob{job}, nil
+}
+
+type fakePayments struct {
+	transactions []*payment.PaymentTransaction
+	refunded     []uuid.UUID
+	shares       map[string]*payment.Refund // checkout shares refunded, by order
+}
+
+func (f *fakePayments) ListByReference(context.Context, payment.ReferenceType, string) ([]*payment.PaymentTransaction, error) {
+	return f.transactions, nil
+}
+
+func (f *fakePayments) Refund(_ context.Context, id string) (*payment.PaymentTransaction, error) {
+	for _, tx := range f.transactions {
+		if tx.ID.String() == id {
+			tx.Status = payment.TxRefunded
+			f.refunded = append(f.refunded, tx.ID)
+			return tx, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePayments) RefundCheckoutShare(_ context.Context, checkoutID, orderID string, amount money.Amount) (*payment.Refund, error) {
+	if refund, ok := f.shares[orderID]; ok {
+		return refund, nil
+	}
+	for _, tx := range f.transactions {
+		if tx.ReferenceType == payment.RefCheckout && tx.ReferenceID.String() == checkoutID && tx.Status == payment.TxCompleted {
+			refund := &payment.Refund{ID: uuid.New(), TransactionID: tx.ID, Amount: amount, Status: payment.RefundCompleted}
+			f.shares[orderID] = refund
+			return refund, nil
+		}
+	}
+	return nil, payment.ErrNoCompletedPayment
+}
+
+func TestAcceptIssuesNothingWhenAnotherDecisionGetsThereFirst(t *testing.T) {
+	for _, other := range []ClaimStatus{StatusRejected, StatusProcessing} {
+		f := newClaimFixture()
+		c := f.open(t, TypeRefund)
+		f.payments.transactions = []*payment.PaymentTransaction{{ID: uuid.New(), Status: payment.TxCompleted, Amount: 15000}}
+		f.pos.transaction = &pos.POSTransaction{ID: uuid.New(), Status: pos.TxCompleted, Amount: 2500}
+
+		// The claim is read as OPEN, then rejected or taken by a concurrent acceptance before it is claimed.
+		f.repo.concurrent = other
+		if _, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{}); !errors.Is(err, ErrClaimNotOpen) {
+			t.Fatalf("%s first: Accept = %v, want ErrClaimNotOpen", other, err)
+		}
+		if len(f.payments.refunded) != 0 || f.pos.transaction.Status != pos.TxCompleted {
+			t.Fatalf("%s first: refunded %v, POS %s; want nothing refunded", other, f.payments.refunded, f.pos.transaction.Status)
+		}
+		if f.repo.claims[c.ID].Status != other {
+			t.Fatalf("%s first: claim left %s", other, f.repo.claims[c.ID].Status)
+		}
+	}
+}
+
+type fakePOS struct {
+	transaction *pos.POSTransaction
+	fail        error
+}
+
+func (f *fakePOS) GetTransactionByOrder(context.Context, string) (*pos.POSTransaction, error) {
+	if f.transaction == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.transaction, nil
+}
+
+func (f *fakePOS) RefundTransaction(context.Context, string, pos.RefundRequest) (*pos.POSTransaction, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	f.transaction.Status = pos.TxRefunded
+	return f.transaction, nil
+}
+
+type claimFixture struct {
+	svc      Service
+	repo     *fakeRepository
+	orders   *fakeOrders
+	jobs     *fakeJobs
+	payments *fakePayments
+	pos      *fakePOS
+	order    *order.Order
+	customer uuid.UUID
+}
+
+func newClaimFixture() *claimFixture {
+	customer := uuid.New()
+	delivered := &order.Order{
+		ID: uuid.New(), StoreID: uuid.New(), CustomerID: &customer, Status: order.StatusDelivered,
+		Items: []*order.OrderItem{{ID: uuid.New(), Quantity: 3}},
+	}
+	f := &claimFixture{
+		repo:     &fakeRepository{claims: make(map[uuid.UUID]*Claim)},
+		orders:   &fakeOrders{orders: map[string]*order.Order{delivered.ID.String(): delivered}, reprints: make(map[string]*order.Order)},
+		jobs:     &fakeJobs{jobs: make(map[string]*production.ProductionJob)},
+		payments: &fakePayments{shares: make(map[string]*payment.Refund)},
+		pos:      &fakePOS{},
+		order:    delivered,
+		customer: customer,
+	}
+	f.svc = NewService(f.repo, f.orders, f.jobs, f.payments, f.pos)
+	return f
+}
+
+func (f *claimFixture) open(t *testing.T, claimType ClaimType) *Claim {
+	t.Helper()
+	// Reprints cover part of the delivered line; refunds must cover all of it.
+	quantity := 2
+	if claimType == TypeRefund {
+		quantity = f.order.Items[0].Quantity
+	}
+	c, err := f.svc.Open(context.Background(), OpenClaimRequest{
+		OrderID:    f.order.ID.String(),
+		Type:       string(claimType),
+		Reason:     "colours came out washed",
+		Items:      []ClaimLine{{OrderItemID: f.order.Items[0].ID.String(), Quantity: quantity}},
+		CustomerID: f.customer.String(),
+	})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	return c
+}
+
+func TestOpenClaimRequiresDeliveredOrderOfTheCustomer(t *testing.T) {
+	f := newClaimFixture()
+	req := OpenClaimRequest{
+		OrderID:    f.order.ID.String(),
+		Type:       "reprint",
+		Reason:     "smudged",
+		Items:      []ClaimLine{{OrderItemID: f.order.Items[0].ID.String(), Quantity: 1}},
+		CustomerID: uuid.NewString(),
+	}
+	if _, err := f.svc.Open(context.Background(), req); err == nil {
+		t.Fatal("expected a claim by another customer to be rejected")
+	}
+
+	req.CustomerID = f.customer.String()
+	req.Items[0].Quantity = 4
+	if _, err := f.svc.Open(context.Background(), req); err == nil {
+		t.Fatal("expected a claim for more units than were delivered to be rejected")
+	}
+
+	req.Items[0].Quantity = 1
+	f.order.Status = order.StatusReady
+	if _, err := f.svc.Open(context.Background(), req); !errors.Is(err, ErrNotClaimable) {
+		t.Fatalf("claim on an undelivered order = %v, want ErrNotClaimable", err)
+	}
+
+	f.order.Status = order.StatusDelivered
+	c, err := f.svc.Open(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if c.Type != TypeReprint || c.Status != StatusOpen || c.StoreID != f.order.StoreID {
+		t.Fatalf("opened claim = %+v", c)
+	}
+	if _, err := f.svc.Open(context.Background(), req); !errors.Is(err, ErrClaimOpen) {
+		t.Fatalf("second open claim = %v, want ErrClaimOpen", err)
+	}
+}
+
+func TestAcceptReprintClaimPlacesReprintAndJobOnceAcrossRetries(t *testing.T) {
+	f := newClaimFixture()
+	c := f.open(t, TypeReprint)
+	vendorUser := uuid.NewString()
+
+	f.jobs.fail = errors.New("production queue unavailable")
+	if _, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{ActorID: vendorUser}); err == nil {
+		t.Fatal("expected the job failure to surface")
+	}
+	if f.repo.claims[c.ID].Status != StatusOpen {
+		t.Fatal("a failed acceptance must leave the claim OPEN")
+	}
+
+	f.jobs.fail = nil
+	accepted, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{ActorID: vendorUser, Note: "reprinting on gloss"})
+	if err != nil {
+		t.Fatalf("Accept returned error: %v", err)
+	}
+	if len(f.orders.reprints) != 1 || len(f.jobs.created) != 1 {
+		t.Fatalf("reprints %d jobs %d; want exactly one of each", len(f.orders.reprints), len(f.jobs.created))
+	}
+	reprint := f.orders.reprints["claim-"+c.ID.String()]
+	if accepted.Status != StatusAccepted || *accepted.ReprintOrderID != reprint.ID || *accepted.ProductionJobID != f.jobs.jobs[reprint.ID.String()].ID {
+		t.Fatalf("accepted claim = %+v", accepted)
+	}
+	if reprint.Items[0].Quantity != 2 || f.jobs.created[0] != reprint.ID.String() {
+		t.Fatalf("reprint quantity %d, job queued for %s", reprint.Items[0].Quantity, f.jobs.created[0])
+	}
+	if accepted.DecidedBy == nil || accepted.DecidedBy.String() != vendorUser || accepted.VendorNote != "reprinting on gloss" {
+		t.Fatalf("decision not recorded: %+v", accepted)
+	}
+
+	if _, err := f.svc.Reject(context.Background(), c.ID.String(), DecideClaimRequest{Note: "changed mind"}); !errors.Is(err, ErrClaimNotOpen) {
+		t.Fatalf("rejecting an accepted claim = %v, want ErrClaimNotOpen", err)
+	}
+}
+
+func TestAcceptRefundClaimRefundsCompletedPaymentsWithoutRepeating(t *testing.T) {
+	f := newClaimFixture()
+	c := f.open(t, TypeRefund)
+	online := &payment.PaymentTransaction{ID: uuid.New(), Status: payment.TxCompleted, Amount: 15000}
+	failed := &payment.PaymentTransaction{ID: uuid.New(), Status: payment.TxFailed, Amount: 15000}
+	f.payments.transactions = []*payment.PaymentTransaction{failed, online}
+	f.pos.transaction = &pos.POSTransaction{ID: uuid.New(), Status: pos.TxCompleted, Amount: 2500}
+
+	f.pos.fail = errors.New("till offline")
+	if _, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{}); err == nil {
+		t.Fatal("expected the POS refund failure to surface")
+	}
+	f.pos.fail = nil
+	accepted, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{})
+	if err != nil {
+		t.Fatalf("Accept returned error: %v", err)
+	}
+	if len(f.payments.refunded) != 1 || f.pos.transaction.Status != pos.TxRefunded {
+		t.Fatalf("online refunds %v POS status %s; want each payment refunded once", f.payments.refunded, f.pos.transaction.Status)
+	}
+	if accepted.RefundAmount != money.Amount(17500) || len(accepted.RefundTransactionIDs) != 2 {
+		t.Fatalf("refund recorded as %v over %v", accepted.RefundAmount, accepted.RefundTransactionIDs)
+	}
+}
+
+func TestRefundClaimWithoutPaymentsStaysOpenAndRejectNeedsNote(t *testing.T) {
+	f := newClaimFixture()
+	c := f.open(t, TypeRefund)
+
+	if _, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{}); err == nil {
+		t.Fatal("expected a refund with nothing to refund to be rejected")
+	}
+	if _, err := f.svc.Reject(context.Background(), c.ID.String(), DecideClaimRequest{Note: "  "}); err == nil {
+		t.Fatal("expected a rejection without a note to be refused")
+	}
+	rejected, err := f.svc.Reject(context.Background(), c.ID.String(), DecideClaimRequest{Note: "print matches the approved proof"})
+	if err != nil || rejected.Status != StatusRejected {
+		t.Fatalf("Reject = %v, %v", rejected, err)
+	}
+	if reopened := f.open(t, TypeReprint); reopened.Status != StatusOpen {
+		t.Fatalf("a new claim after a rejection = %+v", reopened)
+	}
+}
+
+func TestRefundClaimMustCoverTheWholeOrder(t *testing.T) {
+	f := newClaimFixture()
+	f.order.Items = append(f.order.Items, &order.OrderItem{ID: uuid.New(), Quantity: 1})
+	f.payments.transactions = []*payment.PaymentTransaction{{ID: uuid.New(), Status: payment.TxCompleted, Amount: 15000}}
+	req := OpenClaimRequest{
+		OrderID:    f.order.ID.String(),
+		Type:       "refund",
+		Reason:     "colours came out washed",
+		Items:      []ClaimLine{{OrderItemID: f.order.Items[0].ID.String(), Quantity: 3}},
+		CustomerID: f.customer.String(),
+	}
+	if _, err := f.svc.Open(context.Background(), req); !errors.Is(err, ErrPartialRefund) {
+		t.Fatalf("refund claim for one of two lines = %v, want ErrPartialRefund", err)
+	}
+
+	// A partial refund claim recorded before the rule existed is refused on acceptance, not refunded in full.
+	partial := &Claim{ID: uuid.New(), OrderID: f.order.ID, Type: TypeRefund, Status: StatusOpen,
+		Items: []*ClaimItem{{OrderItemID: f.order.Items[0].ID, Quantity: 3}}}
+	f.repo.claims[partial.ID] = partial
+	if _, err := f.svc.Accept(context.Background(), partial.ID.String(), DecideClaimRequest{}); !errors.Is(err, ErrPartialRefund) {
+		t.Fatalf("accepting a partial refund claim = %v, want ErrPartialRefund", err)
+	}
+	if len(f.payments.refunded) != 0 {
+		t.Fatalf("a partial refund claim refunded %v", f.payments.refunded)
+	}
+
+	delete(f.repo.claims, partial.ID)
+	req.Items = append(req.Items, ClaimLine{OrderItemID: f.order.Items[1].ID.String(), Quantity: 1})
+	c, err := f.svc.Open(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if accepted, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{}); err != nil || accepted.RefundAmount != 15000 {
+		t.Fatalf("Accept = %v, %v; want the whole payment refunded", accepted, err)
+	}
+}
+
+func TestRefundClaimOnACheckoutOrderRefundsItsShareOfTheCheckoutPayment(t *testing.T) {
+	f := newClaimFixture()
+	checkoutID := uuid.New()
+	f.order.CheckoutID, f.order.Total = &checkoutID, 4000
+	checkoutPayment := &payment.PaymentTransaction{ID: uuid.New(), ReferenceType: payment.RefCheckout, ReferenceID: checkoutID,
+		Status: payment.TxCompleted, Amount: 10000}
+	f.payments.transactions = []*payment.PaymentTransaction{checkoutPayment}
+	c := f.open(t, TypeRefund)
+
+	accepted, err := f.svc.Accept(context.Background(), c.ID.String(), DecideClaimRequest{})
+	if err != nil {
+		t.Fatalf("Accept returned error: %v", err)
+	}
+	if accepted.RefundAmount != 4000 || len(accepted.RefundTransactionIDs) != 1 || accepted.RefundTransactionIDs[0] != checkoutPayment.ID {
+		t.Fatalf("refund recorded as %v over %v; want the order's 40.00 from the checkout payment", accepted.RefundAmount, accepted.RefundTransactionIDs)
+	}
+	if len(f.payments.refunded) != 0 || checkoutPayment.Status != payment.TxCompleted {
+		t.Fatal("the whole checkout payment must not be refunded for one of its orders")
+	}
+}
@@ -3,6 +3,7 @@ package inventory
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/georgemunganga/printa-backend/internal/middleware"
@@ -27,6 +28,7 @@ func (h *Handler) RegisterStorefrontRoutes(r chi.Router) {
 	r.Route("/api/v1/storefront", func(r chi.Router) {
 		r.Get("/stores", h.listStorefrontStores)
 		r.Get("/stores/{store_id}/products", h.listStorefrontProducts)
+		r.Post("/stores/{store_id}/products/{id}/price-preview", h.previewPrice)
 	})
 }
 
@@ -52,6 +54,8 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Patch("/products/{id}/stock", h.updateStock)
 		r.Patch("/products/{id}/price", h.updateVendorPrice)
 		r.Patch("/products/{id}/availability", h.setAvailability)
+		r.Put("/products/{id}/price-rules", h.updatePriceRules)
+		r.Get("/products/{id}/stock-movements", h.listStockMovements)
 	})
 }
 
@@ -459,6 +463,77 @@ func (h *Handler) setAvailability(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, map[string]string{"status": "availability updated"})
 }
 
+// updatePriceRules replaces the quantity breaks and option modifiers of a listing. Only the store owner may price.
+func (h *Handler) updatePriceRules(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	product, err := h.service.GetProduct(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "product not found"})
+		return
+	}
+	if _, ok := h.requireStoreAccess(w, r, product.StoreID.String(), false); !ok {
+		return
+	}
+	var rules PriceRules
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	updated, err := h.service.UpdatePriceRules(r.Context(), id, rules)
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, updated)
+}
+
+// previewPrice shows a storefront customer the unit price and line total for a quantity and set of options.
+func (h *Handler) previewPrice(w http.ResponseWriter, r *http.Request) {
+	var req PricePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	breakdown, err := h.service.PreviewPrice(r.Context(), chi.URLParam(r, "store_id"), chi.URLParam(r, "id"), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		respond(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, breakdown)
+}
+
+// listStockMovements shows the reservation ledger, including rejected oversell attempts, for a store product.
+func (h *Handler) listStockMovements(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	product, err := h.service.GetProduct(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "product not found"})
+		return
+	}
+	if _, ok := h.requireStoreAccess(w, r, product.StoreID.String(), true); !ok {
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	movements, err := h.service.ListStockMovements(r.Context(), id, limit)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if movements == nil {
+		movements = make([]*StockMovement, 0)
+	}
+	respond(w, http.StatusOK, movements)
+}
+
 func (h *Handler) currentVendorID(w http.ResponseWriter, r *http.Request) (string, bool) {
 	v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
 	if err != nil {
@@ -20,8 +20,12 @@ type Store struct {
 	Latitude    *float64  `json:"latitude,omitempty"`
 	Longitude   *float64  `json:"longitude,omitempty"`
 	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// PricesIncludeTax marks stores that quote gross shelf prices; VAT is extracted rather than added at checkout.
+	PricesIncludeTax bool `json:"prices_include_tax"`
+	// DailyCapacityMinutes is how many production minutes the store has per day; nil when the store has not said.
+	DailyCapacityMinutes *int      `json:"daily_capacity_minutes,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 // StoreStaff links a user to a store with a role.
@@ -48,8 +52,10 @@ type VendorStoreProduct struct {
 	Currency          string    `json:"currency"`
 	StockQuantity     int       `json:"stock_quantity"`
 	IsAvailable       bool      `json:"is_available"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	// PriceRules are the quantity breaks and option modifiers applied on top of VendorPrice when an order is priced.
+	PriceRules PriceRules `json:"price_rules"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 // StorefrontProduct is the customer-safe, joined store-product representation.
@@ -64,3 +70,25 @@ type StorefrontProduct struct {
 	ImageURL    string    `json:"image_url,omitempty"`
 	InStock     bool      `json:"in_stock"`
 }
+
+// StockMovementType classifies an entry in the inventory stock ledger.
+type StockMovementType string
+
+const (
+	StockMovementReserved         StockMovementType = "RESERVED"
+	StockMovementReleased         StockMovementType = "RELEASED"
+	StockMovementOversellRejected StockMovementType = "OVERSELL_REJECTED"
+)
+
+// StockMovement records a stock change made by order placement or cancellation.
+// OVERSELL_REJECTED entries have no order because the checkout that triggered them was rolled back.
+type StockMovement struct {
+	ID                   uuid.UUID         `json:"id"`
+	VendorStoreProductID uuid.UUID         `json:"vendor_store_product_id"`
+	StoreID              uuid.UUID         `json:"store_id"`
+	OrderID              *uuid.UUID        `json:"order_id,omitempty"`
+	MovementType         StockMovementType `json:"movement_type"`
+	Quantity             int               `json:"quantity"`
+	StockAfter           int               `json:"stock_after"`
+	CreatedAt            time.Time         `json:"created_at"`
+}
@@ -3,6 +3,7 @@ package inventory
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -16,10 +17,10 @@ func NewStorePostgresRepository(db *sql.DB) StoreRepository { return &storePostg
 
 func (r *storePostgres) CreateStore(ctx context.Context, s *Store) error {
 	_, err := r.db.ExecContext(ctx, `
-INSERT INTO stores (id,vendor_id,name,description,address,city,country,phone,email,latitude,longitude,is_active)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
+INSERT INTO stores (id,vendor_id,name,description,address,city,country,phone,email,latitude,longitude,is_active,prices_include_tax,daily_capacity_minutes)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`,
 		s.ID, s.VendorID, s.Name, s.Description, s.Address,
-		s.City, s.Country, s.Phone, s.Email, s.Latitude, s.Longitude, s.IsActive)
+		s.City, s.Country, s.Phone, s.Email, s.Latitude, s.Longitude, s.IsActive, s.PricesIncludeTax, s.DailyCapacityMinutes)
 	return err
 }
 
@@ -30,10 +31,10 @@ func (r *storePostgres) GetStoreByID(ctx context.Context, id string) (*Store, er
 	}
 	s := &Store{}
 	err = r.db.QueryRowContext(ctx, `
-	SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,created_at,updated_at
+	SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,prices_include_tax,daily_capacity_minutes,created_at,updated_at
 		FROM stores WHERE id=$1`, uid).
 		Scan(&s.ID, &s.VendorID, &s.Name, &s.Description, &s.Address,
-			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive,
+			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive, &s.PricesIncludeTax, &s.DailyCapacityMinutes,
 			&s.CreatedAt, &s.UpdatedAt)
 	return s, err
 }
@@ -44,7 +45,7 @@ func (r *storePostgres) ListStoresByVendor(ctx context.Context, vendorID string)
 		return nil, err
 	}
 	rows, err := r.db.QueryContext(ctx, `
-			SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,created_at,updated_at
+			SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,prices_include_tax,daily_capacity_minutes,created_at,updated_at
 			FROM stores WHERE vendor_id=$1 AND is_active=true ORDER BY created_at DESC`, uid)
 
 	if err != nil {
@@ -55,7 +56,7 @@ func (r *storePostgres) ListStoresByVendor(ctx context.Context, vendorID string)
 	for rows.Next() {
 		s := &Store{}
 		if err := rows.Scan(&s.ID, &s.VendorID, &s.Name, &s.Description, &s.Address,
-			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive,
+			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive, &s.PricesIncludeTax, &s.DailyCapacityMinutes,
 			&s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
@@ -69,7 +70,7 @@ func (r *storePostgres) ListStoresByVendor(ctx context.Context, vendorID string)
 
 func (r *storePostgres) ListActiveStores(ctx context.Context) ([]*Store, error) {
 	rows, err := r.db.QueryContext(ctx, `
-					SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,created_at,updated_at
+					SELECT id,vendor_id,name,COALESCE(description, ''),COALESCE(address, ''),COALESCE(city, ''),country,COALESCE(phone, ''),COALESCE(email, ''),latitude,longitude,is_active,prices_include_tax,daily_capacity_minutes,created_at,updated_at
 				FROM stores WHERE is_active=true ORDER BY created_at DESC`)
 
 	if err != nil {
@@ -81,7 +82,7 @@ func (r *storePostgres) ListActiveStores(ctx context.Context) ([]*Store, error)
 	for rows.Next() {
 		s := &Store{}
 		if err := rows.Scan(&s.ID, &s.VendorID, &s.Name, &s.Description, &s.Address,
-			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			&s.City, &s.Country, &s.Phone, &s.Email, &s.Latitude, &s.Longitude, &s.IsActive, &s.PricesIncludeTax, &s.DailyCapacityMinutes, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		stores = append(stores, s)
@@ -92,9 +93,10 @@ func (r *storePostgres) ListActiveStores(ctx context.Context) ([]*Store, error)
 func (r *storePostgres) UpdateStore(ctx context.Context, s *Store) error {
 	result, err := r.db.ExecContext(ctx, `
 	UPDATE stores
-SET name=$2, description=$3, address=$4, city=$5, country=$6, phone=$7, email=$8, latitude=$9, longitude=$10, updated_at=NOW()
+SET name=$2, description=$3, address=$4, city=$5, country=$6, phone=$7, email=$8, latitude=$9, longitude=$10, prices_include_tax=$11, daily_capacity_minutes=$12, updated_at=NOW()
 		WHERE id=$1 AND is_active=true`,
-		s.ID, s.Name, s.Description, s.Address, s.City, s.Country, s.Phone, s.Email, s.Latitude, s.Longitude)
+		s.ID, s.Name, s.Description, s.Address, s.City, s.Country, s.Phone, s.Email, s.Latitude, s.Longitude, s.PricesIncludeTax,
+		s.DailyCapacityMinutes)
 	if err != nil {
 		return err
 	}
@@ -230,14 +232,20 @@ func (r *productPostgres) GetProductByID(ctx context.Context, id string) (*Vendo
 	if err != nil {
 		return nil, err
 	}
+	return scanProduct(r.db.QueryRowContext(ctx, `
+	SELECT id,store_id,platform_product_id,vendor_price,currency,stock_quantity,is_available,price_rules,created_at,updated_at
+	FROM vendor_store_products WHERE id=$1`, uid).Scan)
+}
+
+func scanProduct(scan func(...interface{}) error) (*VendorStoreProduct, error) {
 	p := &VendorStoreProduct{}
-	err = r.db.QueryRowContext(ctx, `
-	SELECT id,store_id,platform_product_id,vendor_price,currency,stock_quantity,is_available,created_at,updated_at
-	FROM vendor_store_products WHERE id=$1`, uid).Scan(
-		&p.ID, &p.StoreID, &p.PlatformProductID, &p.VendorPrice,
-		&p.Currency, &p.StockQuantity, &p.IsAvailable, &p.CreatedAt, &p.UpdatedAt,
-	)
-	if err != nil {
+	var rules []byte
+	if err := scan(&p.ID, &p.StoreID, &p.PlatformProductID, &p.VendorPrice,
+		&p.Currency, &p.StockQuantity, &p.IsAvailable, &rules, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.PriceRules, err = DecodePriceRules(rules); err != nil {
 		return nil, err
 	}
 	return p, nil
@@ -249,7 +257,7 @@ func (r *productPostgres) ListProducts(ctx context.Context, storeID string) ([]*
 		return nil, err
 	}
 	rows, err := r.db.QueryContext(ctx, `
-SELECT id,store_id,platform_product_id,vendor_price,currency,stock_quantity,is_available,created_at,updated_at
+SELECT id,store_id,platform_product_id,vendor_price,currency,stock_quantity,is_available,price_rules,created_at,updated_at
 FROM vendor_store_products WHERE store_id=$1 ORDER BY created_at DESC`, uid)
 	if err != nil {
 		return nil, err
@@ -257,14 +265,13 @@ FROM vendor_store_products WHERE store_id=$1 ORDER BY created_at DESC`, uid)
 	defer rows.Close()
 	var products []*VendorStoreProduct
 	for rows.Next() {
-		p := &VendorStoreProduct{}
-		if err := rows.Scan(&p.ID, &p.StoreID, &p.PlatformProductID, &p.VendorPrice,
-			&p.Currency, &p.StockQuantity, &p.IsAvailable, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		p, err := scanProduct(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
 	}
-	return products, nil
+	return products, rows.Err()
 }
 
 func (r *productPostgres) ListAvailableStorefrontProducts(ctx context.Context, storeID string) ([]*StorefrontProduct, error) {
@@ -344,3 +351,66 @@ func (r *productPostgres) UpdateAvailability(ctx context.Context, id string, ava
 		`UPDATE vendor_store_products SET is_available=$1, updated_at=NOW() WHERE id=$2`, available, uid)
 	return err
 }
+
+// UpdatePriceRules replaces a listing's price rules; empty rules are stored as NULL.
+func (r *productPostgres) UpdatePriceRules(ctx context.Context, id string, rules PriceRules) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	var encoded interface{}
+	if len(rules.QuantityBreaks) > 0 || len(rules.Modifiers) > 0 {
+		data, err := json.Marshal(rules)
+		if err != nil {
+			return err
+		}
+		encoded = data
+	}
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE vendor_store_products SET price_rules=$2, updated_at=NOW() WHERE id=$1`, uid, encoded)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListStockMovements returns the newest ledger entries for a store product first.
+func (r *productPostgres) ListStockMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error) {
+	uid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, vendor_store_product_id, store_id, order_id, movement_type, quantity, stock_after, created_at
+		FROM inventory_stock_movements
+		WHERE vendor_store_product_id=$1
+		ORDER BY created_at DESC
+		LIMIT $2`, uid, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []*StockMovement
+	for rows.Next() {
+		m := &StockMovement{}
+		var orderID sql.NullString
+		if err := rows.Scan(&m.ID, &m.VendorStoreProductID, &m.StoreID, &orderID,
+			&m.MovementType, &m.Quantity, &m.StockAfter, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if orderID.Valid {
+			oid, _ := uuid.Parse(orderID.String)
+			m.OrderID = &oid
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
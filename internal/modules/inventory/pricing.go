@@ -0,0 +1,146 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+)
+
+// PriceRules vary a listing's unit price by quantity tier and by the options chosen in an order line's
+// customisation. Empty rules sell every unit at the flat vendor price.
+type PriceRules struct {
+	QuantityBreaks []QuantityBreak `json:"quantity_breaks,omitempty"`
+	Modifiers      []PriceModifier `json:"modifiers,omitempty"`
+}
+
+// QuantityBreak replaces the vendor price as the base unit price for lines of MinQuantity units or more.
+type QuantityBreak struct {
+	MinQuantity int          `json:"min_quantity"`
+	UnitPrice   money.Amount `json:"unit_price"`
+}
+
+// PriceModifier adds Amount to the unit price when the customisation sets Option to Value, e.g. +1.50 for
+// size A3 or -0.40 for colour mono. Integer and boolean options are matched on their JSON text ("300", "true").
+type PriceModifier struct {
+	Option string       `json:"option"`
+	Value  string       `json:"value"`
+	Amount money.Amount `json:"amount"`
+}
+
+// PriceBreakdown explains how a line's unit price was reached, so the storefront can show it before checkout.
+type PriceBreakdown struct {
+	Quantity      int             `json:"quantity"`
+	Currency      string          `json:"currency,omitempty"`
+	BaseUnitPrice money.Amount    `json:"base_unit_price"`
+	QuantityBreak *QuantityBreak  `json:"quantity_break,omitempty"`
+	Adjustments   []PriceModifier `json:"adjustments"`
+	UnitPrice     money.Amount    `json:"unit_price"`
+	LineTotal     money.Amount    `json:"line_total"`
+}
+
+// ErrInvalidPriceRules is wrapped by every error describing malformed price rules.
+var ErrInvalidPriceRules = errors.New("invalid price_rules")
+
+// Validate checks the rules before they are saved and sorts quantity breaks by MinQuantity.
+func (r *PriceRules) Validate() error {
+	sort.Slice(r.QuantityBreaks, func(i, j int) bool {
+		return r.QuantityBreaks[i].MinQuantity < r.QuantityBreaks[j].MinQuantity
+	})
+	for i, b := range r.QuantityBreaks {
+		if b.MinQuantity < 2 {
+			return fmt.Errorf("%w: quantity break min_quantity must be at least 2", ErrInvalidPriceRules)
+		}
+		if i > 0 && r.QuantityBreaks[i-1].MinQuantity == b.MinQuantity {
+			return fmt.Errorf("%w: more than one quantity break starts at %d", ErrInvalidPriceRules, b.MinQuantity)
+		}
+		if b.UnitPrice <= 0 {
+			return fmt.Errorf("%w: quantity break at %d must have a unit_price greater than zero", ErrInvalidPriceRules, b.MinQuantity)
+		}
+	}
+	seen := make(map[string]bool, len(r.Modifiers))
+	for i := range r.Modifiers {
+		m := &r.Modifiers[i]
+		m.Option, m.Value = strings.TrimSpace(m.Option), strings.TrimSpace(m.Value)
+		if m.Option == "" || m.Value == "" {
+			return fmt.Errorf("%w: every modifier requires an option and a value", ErrInvalidPriceRules)
+		}
+		key := m.Option + "=" + m.Value
+		if seen[key] {
+			return fmt.Errorf("%w: modifier for %s is declared more than once", ErrInvalidPriceRules, key)
+		}
+		seen[key] = true
+		if m.Amount == 0 {
+			return fmt.Errorf("%w: modifier for %s must have a non-zero amount", ErrInvalidPriceRules, key)
+		}
+	}
+	return nil
+}
+
+// Price computes the unit price of quantity units with the given customisation. base is the listing's vendor
+// price; the highest quantity break the quantity reaches replaces it, then every matching modifier is added.
+func (r PriceRules) Price(base money.Amount, quantity int, customisation json.RawMessage) (*PriceBreakdown, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be > 0")
+	}
+	b := &PriceBreakdown{Quantity: quantity, BaseUnitPrice: base, Adjustments: []PriceModifier{}}
+	for i := range r.QuantityBreaks {
+		if quantity >= r.QuantityBreaks[i].MinQuantity {
+			applied := r.QuantityBreaks[i]
+			b.QuantityBreak = &applied
+			b.BaseUnitPrice = applied.UnitPrice
+		}
+	}
+	b.UnitPrice = b.BaseUnitPrice
+
+	if len(r.Modifiers) > 0 {
+		chosen := optionValues(customisation)
+		for _, m := range r.Modifiers {
+			if v, ok := chosen[m.Option]; ok && v == m.Value {
+				b.Adjustments = append(b.Adjustments, m)
+				b.UnitPrice += m.Amount
+			}
+		}
+	}
+	if b.UnitPrice <= 0 {
+		return nil, fmt.Errorf("the chosen options bring the unit price to %s; it must be greater than zero", b.UnitPrice)
+	}
+	b.LineTotal = b.UnitPrice.Times(quantity)
+	return b, nil
+}
+
+// optionValues flattens a customisation object to the text each scalar option value is matched on.
+func optionValues(customisation json.RawMessage) map[string]string {
+	var raw map[string]interface{}
+	if len(customisation) == 0 || json.Unmarshal(customisation, &raw) != nil {
+		return nil
+	}
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return values
+}
+
+// DecodePriceRules reads a price_rules column; NULL decodes to empty rules.
+func DecodePriceRules(data []byte) (PriceRules, error) {
+	var rules PriceRules
+	if len(data) == 0 {
+		return rules, nil
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("decode price_rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,43 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPriceRulesValidateSortsBreaksAndRejectsAmbiguousRules(t *testing.T) {
+	rules := PriceRules{QuantityBreaks: []QuantityBreak{{MinQuantity: 1000, UnitPrice: 25}, {MinQuantity: 500, UnitPrice: 35}}}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if rules.QuantityBreaks[0].MinQuantity != 500 {
+		t.Fatalf("quantity breaks were not sorted: %#v", rules.QuantityBreaks)
+	}
+
+	for name, bad := range map[string]PriceRules{
+		"break at one":       {QuantityBreaks: []QuantityBreak{{MinQuantity: 1, UnitPrice: 25}}},
+		"repeated break":     {QuantityBreaks: []QuantityBreak{{MinQuantity: 100, UnitPrice: 25}, {MinQuantity: 100, UnitPrice: 20}}},
+		"free break":         {QuantityBreaks: []QuantityBreak{{MinQuantity: 100}}},
+		"modifier no value":  {Modifiers: []PriceModifier{{Option: "size", Amount: 150}}},
+		"repeated modifier":  {Modifiers: []PriceModifier{{Option: "size", Value: "A3", Amount: 150}, {Option: "size", Value: "A3", Amount: 100}}},
+		"zero-amount change": {Modifiers: []PriceModifier{{Option: "size", Value: "A3"}}},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidPriceRules) {
+			t.Errorf("%s: expected ErrInvalidPriceRules, got %v", name, err)
+		}
+	}
+}
+
+func TestPriceRulesPriceRejectsOptionsThatMakeTheLineFree(t *testing.T) {
+	rules := PriceRules{Modifiers: []PriceModifier{{Option: "colour", Value: "mono", Amount: -50}}}
+	if _, err := rules.Price(50, 10, []byte(`{"colour":"mono"}`)); err == nil {
+		t.Fatal("expected a non-positive unit price to be rejected")
+	}
+	b, err := rules.Price(50, 10, []byte(`{"colour":"full"}`))
+	if err != nil {
+		t.Fatalf("Price returned error: %v", err)
+	}
+	if b.UnitPrice != 50 || b.LineTotal != 500 || len(b.Adjustments) != 0 {
+		t.Fatalf("unexpected breakdown: %#v", b)
+	}
+}
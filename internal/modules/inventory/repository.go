@@ -29,4 +29,6 @@ type ProductRepository interface {
 	UpdateStock(ctx context.Context, id string, qty int) error
 	UpdateVendorPrice(ctx context.Context, id string, price float64) error
 	UpdateAvailability(ctx context.Context, id string, available bool) error
+	UpdatePriceRules(ctx context.Context, id string, rules PriceRules) error
+	ListStockMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error)
 }
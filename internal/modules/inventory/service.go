@@ -2,8 +2,10 @@ package inventory
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -31,20 +33,27 @@ type Service interface {
 	UpdateStock(ctx context.Context, productID string, qty int) error
 	UpdateVendorPrice(ctx context.Context, productID string, price float64) error
 	SetAvailability(ctx context.Context, productID string, available bool) error
+	UpdatePriceRules(ctx context.Context, productID string, rules PriceRules) (*VendorStoreProduct, error)
+	// PreviewPrice prices a storefront listing for a quantity and customisation exactly as order placement will.
+	PreviewPrice(ctx context.Context, storeID, productID string, req PricePreviewRequest) (*PriceBreakdown, error)
+	ListStockMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error)
 }
 
 // CreateStoreRequest holds data for creating a store.
 type CreateStoreRequest struct {
-	VendorID    string   `json:"vendor_id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Address     string   `json:"address"`
-	City        string   `json:"city"`
-	Country     string   `json:"country"`
-	Phone       string   `json:"phone"`
-	Email       string   `json:"email"`
-	Latitude    *float64 `json:"latitude"`
-	Longitude   *float64 `json:"longitude"`
+	VendorID         string   `json:"vendor_id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Address          string   `json:"address"`
+	City             string   `json:"city"`
+	Country          string   `json:"country"`
+	Phone            string   `json:"phone"`
+	Email            string   `json:"email"`
+	Latitude         *float64 `json:"latitude"`
+	Longitude        *float64 `json:"longitude"`
+	PricesIncludeTax bool     `json:"prices_include_tax"`
+	// DailyCapacityMinutes, when set, lets routing project how long the store's queue takes to clear.
+	DailyCapacityMinutes *int `json:"daily_capacity_minutes"`
 }
 
 // UpdateStoreRequest holds the mutable attributes of a vendor store.
@@ -59,6 +68,10 @@ type UpdateStoreRequest struct {
 	Email       string   `json:"email"`
 	Latitude    *float64 `json:"latitude"`
 	Longitude   *float64 `json:"longitude"`
+	// PricesIncludeTax is left unchanged when omitted.
+	PricesIncludeTax *bool `json:"prices_include_tax"`
+	// DailyCapacityMinutes is left unchanged when omitted.
+	DailyCapacityMinutes *int `json:"daily_capacity_minutes"`
 }
 
 // AddProductRequest holds data for listing a product in a store.
@@ -70,6 +83,12 @@ type AddProductRequest struct {
 	StockQuantity     int     `json:"stock_quantity"`
 }
 
+// PricePreviewRequest is the quantity and customisation a storefront customer is considering.
+type PricePreviewRequest struct {
+	Quantity      int             `json:"quantity"`
+	Customisation json.RawMessage `json:"customisation,omitempty"`
+}
+
 type service struct {
 	storeRepo   StoreRepository
 	staffRepo   StoreStaffRepository
@@ -89,6 +108,9 @@ func (s *service) CreateStore(ctx context.Context, req CreateStoreRequest) (*Sto
 	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
 		return nil, err
 	}
+	if err := validateDailyCapacity(req.DailyCapacityMinutes); err != nil {
+		return nil, err
+	}
 	vendorID, err := uuid.Parse(req.VendorID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid vendor_id: %w", err)
@@ -98,18 +120,20 @@ func (s *service) CreateStore(ctx context.Context, req CreateStoreRequest) (*Sto
 		country = "Zambia"
 	}
 	store := &Store{
-		ID:          uuid.New(),
-		VendorID:    vendorID,
-		Name:        req.Name,
-		Description: req.Description,
-		Address:     req.Address,
-		City:        req.City,
-		Country:     country,
-		Phone:       req.Phone,
-		Email:       req.Email,
-		Latitude:    req.Latitude,
-		Longitude:   req.Longitude,
-		IsActive:    true,
+		ID:                   uuid.New(),
+		VendorID:             vendorID,
+		Name:                 req.Name,
+		Description:          req.Description,
+		Address:              req.Address,
+		City:                 req.City,
+		Country:              country,
+		Phone:                req.Phone,
+		Email:                req.Email,
+		Latitude:             req.Latitude,
+		Longitude:            req.Longitude,
+		IsActive:             true,
+		PricesIncludeTax:     req.PricesIncludeTax,
+		DailyCapacityMinutes: req.DailyCapacityMinutes,
 	}
 	if err := s.storeRepo.CreateStore(ctx, store); err != nil {
 		return nil, err
@@ -137,6 +161,9 @@ func (s *service) UpdateStore(ctx context.Context, id string, req UpdateStoreReq
 	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
 		return nil, err
 	}
+	if err := validateDailyCapacity(req.DailyCapacityMinutes); err != nil {
+		return nil, err
+	}
 	store, err := s.storeRepo.GetStoreByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -151,6 +178,12 @@ func (s *service) UpdateStore(ctx context.Context, id string, req UpdateStoreReq
 	store.Email = req.Email
 	store.Latitude = req.Latitude
 	store.Longitude = req.Longitude
+	if req.PricesIncludeTax != nil {
+		store.PricesIncludeTax = *req.PricesIncludeTax
+	}
+	if req.DailyCapacityMinutes != nil {
+		store.DailyCapacityMinutes = req.DailyCapacityMinutes
+	}
 	if store.Country == "" {
 		store.Country = "Zambia"
 	}
@@ -174,6 +207,13 @@ func validateCoordinates(latitude, longitude *float64) error {
 	return nil
 }
 
+func validateDailyCapacity(minutes *int) error {
+	if minutes != nil && (*minutes <= 0 || *minutes > 24*60) {
+		return fmt.Errorf("daily_capacity_minutes must be between 1 and 1440")
+	}
+	return nil
+}
+
 func (s *service) DeactivateStore(ctx context.Context, id string) error {
 	return s.storeRepo.DeactivateStore(ctx, id)
 }
@@ -270,3 +310,37 @@ func (s *service) UpdateVendorPrice(ctx context.Context, productID string, price
 func (s *service) SetAvailability(ctx context.Context, productID string, available bool) error {
 	return s.productRepo.UpdateAvailability(ctx, productID, available)
 }
+
+func (s *service) UpdatePriceRules(ctx context.Context, productID string, rules PriceRules) (*VendorStoreProduct, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.productRepo.UpdatePriceRules(ctx, productID, rules); err != nil {
+		return nil, err
+	}
+	return s.productRepo.GetProductByID(ctx, productID)
+}
+
+func (s *service) PreviewPrice(ctx context.Context, storeID, productID string, req PricePreviewRequest) (*PriceBreakdown, error) {
+	store, err := s.storeRepo.GetStoreByID(ctx, storeID)
+	if err != nil || !store.IsActive {
+		return nil, fmt.Errorf("store not found")
+	}
+	p, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil || p.StoreID != store.ID || !p.IsAvailable {
+		return nil, fmt.Errorf("product not found")
+	}
+	breakdown, err := p.PriceRules.Price(money.FromMajor(p.VendorPrice), req.Quantity, req.Customisation)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.Currency = p.Currency
+	return breakdown, nil
+}
+
+func (s *service) ListStockMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.productRepo.ListStockMovements(ctx, productID, limit)
+}
@@ -9,6 +9,8 @@ const (
 	TypeOrderPlaced        Type = "ORDER_PLACED"
 	TypeOrderStatusChanged Type = "ORDER_STATUS_CHANGED"
 	TypeOrderCancelled     Type = "ORDER_CANCELLED"
+	TypeRecurringOrderSkipped Type = "RECURRING_ORDER_SKIPPED"
+	TypeRecurringOrderPaused  Type = "RECURRING_ORDER_PAUSED"
 	TypePaymentReceived    Type = "PAYMENT_RECEIVED"
 	TypePaymentFailed      Type = "PAYMENT_FAILED"
 	TypePaymentRefunded    Type = "PAYMENT_REFUNDED"
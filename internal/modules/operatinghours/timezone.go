@@ -0,0 +1,26 @@
+package operatinghours
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // STORE_TIMEZONE must resolve in images without a system zoneinfo database
+)
+
+// DefaultStoreTimezone is the zone stores trade in when STORE_TIMEZONE is unset.
+const DefaultStoreTimezone = "Africa/Lusaka"
+
+// StoreTimezoneFromEnv loads STORE_TIMEZONE, the zone store operating hours are published in and store calendars,
+// such as daily order numbering, follow. Every process that reads store hours or numbers orders uses it.
+func StoreTimezoneFromEnv() (*time.Location, error) {
+	name := strings.TrimSpace(os.Getenv("STORE_TIMEZONE"))
+	if name == "" {
+		name = DefaultStoreTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("STORE_TIMEZONE %q is not a valid IANA time zone: %w", name, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,168 @@
+package order
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/go-chi/chi/v5"
+)
+
+// CheckoutHandler exposes multi-store checkout. Each store sees and manages only its own child order through the
+// order endpoints; the checkout itself belongs to the customer.
+type CheckoutHandler struct {
+	service          CheckoutService
+	inventoryService inventory.Service
+	db               *sql.DB
+}
+
+func NewCheckoutHandler(service CheckoutService, inventoryService inventory.Service, db *sql.DB) *CheckoutHandler {
+	return &CheckoutHandler{service: service, inventoryService: inventoryService, db: db}
+}
+
+func (h *CheckoutHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/checkouts", func(r chi.Router) {
+		r.Post("/", h.placeCheckout)                              // POST /api/v1/checkouts
+		r.Get("/{id}", h.getCheckout)                             // GET  /api/v1/checkouts/{id}
+		r.Get("/customer/{customer_id}", h.listCustomerCheckouts) // GET  /api/v1/checkouts/customer/{customer_id}
+	})
+}
+
+// placeCheckout is for customers only: every child order is placed on the authenticated customer's behalf.
+func (h *CheckoutHandler) placeCheckout(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) != middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only customers can check out"})
+		return
+	}
+	var req PlaceCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	req.Actor = requestActor(r)
+	req.CustomerID = middleware.GetUserID(r)
+	if len(req.IdempotencyKey) > 128 {
+		respond(w, http.StatusBadRequest, map[string]string{"error": "Idempotency-Key must not exceed 128 characters"})
+		return
+	}
+	for i := range req.Stores {
+		cart := &req.Stores[i]
+		if _, err := h.inventoryService.GetStore(r.Context(), cart.StoreID); err != nil {
+			respond(w, http.StatusNotFound, map[string]string{"error": "store " + cart.StoreID + " not found"})
+			return
+		}
+		if err := validateCustomerAssets(r, h.db, cart.Items); err != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		delivery := PlaceOrderRequest{
+			StoreID: cart.StoreID, CustomerID: req.CustomerID, FulfilmentMethod: cart.FulfilmentMethod, DeliveryAddress: cart.DeliveryAddress,
+		}
+		if err := validateCustomerDelivery(r, h.db, &delivery); err != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": "store " + cart.StoreID + ": " + err.Error()})
+			return
+		}
+		cart.DeliveryAddress = delivery.DeliveryAddress
+	}
+
+	c, err := h.service.PlaceCheckout(r.Context(), req)
+	if err != nil {
+		var oos *OutOfStockError
+		if errors.As(err, &oos) {
+			respond(w, http.StatusConflict, map[string]interface{}{
+				"error":                   oos.Error(),
+				"vendor_store_product_id": oos.VendorStoreProductID,
+				"requested":               oos.Requested,
+				"available":               oos.Available,
+			})
+			return
+		}
+		var invalid *CustomisationError
+		if errors.As(err, &invalid) {
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": invalid.Error(), "lines": invalid.Lines})
+			return
+		}
+		var rejected *promo.RejectionError
+		if errors.As(err, &rejected) {
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":      rejected.Error(),
+				"promo_code": rejected.Code,
+				"reason":     rejected.Reason,
+				"detail":     rejected.Detail,
+			})
+			return
+		}
+		respondCheckoutError(w, err)
+		return
+	}
+	respond(w, http.StatusCreated, c)
+}
+
+func (h *CheckoutHandler) getCheckout(w http.ResponseWriter, r *http.Request) {
+	c, err := h.service.GetCheckout(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondCheckoutError(w, err)
+		return
+	}
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+	case middleware.RoleCustomer:
+		if c.CustomerID.String() != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "checkout does not belong to authenticated customer"})
+			return
+		}
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	respond(w, http.StatusOK, c)
+}
+
+func (h *CheckoutHandler) listCustomerCheckouts(w http.ResponseWriter, r *http.Request) {
+	customerID := chi.URLParam(r, "customer_id")
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+	case middleware.RoleCustomer:
+		if customerID != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "customer scope does not match authenticated user"})
+			return
+		}
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	checkouts, err := h.service.ListCustomerCheckouts(r.Context(), customerID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if checkouts == nil {
+		checkouts = make([]*Checkout, 0)
+	}
+	respond(w, http.StatusOK, checkouts)
+}
+
+func respondCheckoutError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "unavailable") || errors.Is(err, ErrProductNotInStore) || strings.Contains(msg, "not enabled"):
+		code = http.StatusUnprocessableEntity
+	case strings.Contains(msg, "invalid"):
+		code = http.StatusBadRequest
+	case strings.Contains(msg, "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(msg, "already associated"):
+		code = http.StatusConflict
+	case strings.Contains(msg, "required") || strings.Contains(msg, "must") || strings.Contains(msg, "at least one") ||
+		strings.Contains(msg, "more than once"):
+		code = http.StatusBadRequest
+	}
+	respond(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,92 @@
+package order
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// CancellationPolicy decides what happens to the rest of a multi-store checkout when one of its orders is
+// cancelled, whether the store rejects it or the customer withdraws it.
+type CancellationPolicy string
+
+const (
+	// PolicyIndependent lets the other stores' orders go ahead; the cancelled order's share of the checkout
+	// payment is refunded.
+	PolicyIndependent CancellationPolicy = "INDEPENDENT"
+	// PolicyCancelAll cancels every sibling order that is still PENDING or CONFIRMED. Siblings already in
+	// production cannot be cancelled and go ahead.
+	PolicyCancelAll CancellationPolicy = "CANCEL_ALL"
+)
+
+// CheckoutStatus summarises the child orders of a checkout. It is derived, never stored.
+type CheckoutStatus string
+
+const (
+	CheckoutActive             CheckoutStatus = "ACTIVE"
+	CheckoutPartiallyCancelled CheckoutStatus = "PARTIALLY_CANCELLED"
+	CheckoutCancelled          CheckoutStatus = "CANCELLED"
+)
+
+// Checkout is one customer checkout spanning several stores. Each store gets its own child Order, and the customer
+// pays for all of them with a single payment referencing the checkout (reference_type CHECKOUT).
+type Checkout struct {
+	ID                 uuid.UUID          `json:"id"`
+	CheckoutNumber     string             `json:"checkout_number"`
+	CustomerID         uuid.UUID          `json:"customer_id"`
+	CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+	Status             CheckoutStatus     `json:"status"`
+	Total              money.Amount       `json:"total"`           // every child order as placed
+	PayableTotal       money.Amount       `json:"payable_total"`   // child orders not cancelled; what the payment collects
+	CancelledTotal     money.Amount       `json:"cancelled_total"` // cancelled child orders; refunded from the checkout payment if already paid
+	Currency           string             `json:"currency"`
+	Orders             []*Order           `json:"orders"`
+	IdempotencyKey     string             `json:"-"`
+	CreatedAt          time.Time          `json:"created_at"`
+}
+
+// StoreCart is one store's share of a multi-store checkout.
+type StoreCart struct {
+	StoreID string     `json:"store_id"`
+	Items   []CartItem `json:"items"`
+	Notes   string     `json:"notes,omitempty"`
+	// FulfilmentMethod defaults to the method in DeliveryAddress, as for a single-store order.
+	FulfilmentMethod FulfilmentMethod `json:"fulfilment_method,omitempty"`
+	DeliveryAddress  json.RawMessage  `json:"delivery_address,omitempty"`
+	PromoCode        string           `json:"promo_code,omitempty"`
+	DueAt            *time.Time       `json:"due_at,omitempty"`
+}
+
+// PlaceCheckoutRequest is the payload for a multi-store checkout. CancellationPolicy defaults to INDEPENDENT.
+type PlaceCheckoutRequest struct {
+	CustomerID         string      `json:"-"`
+	Stores             []StoreCart `json:"stores"`
+	CancellationPolicy string      `json:"cancellation_policy,omitempty"`
+	IdempotencyKey     string      `json:"-"`
+	Actor              Actor       `json:"-"`
+}
+
+// summarise derives the status and totals from the child orders.
+func (c *Checkout) summarise() {
+	c.Total, c.PayableTotal, c.CancelledTotal = 0, 0, 0
+	cancelled := 0
+	for _, o := range c.Orders {
+		c.Total += o.Total
+		if o.Status == StatusCancelled {
+			c.CancelledTotal += o.Total
+			cancelled++
+		} else {
+			c.PayableTotal += o.Total
+		}
+	}
+	switch {
+	case cancelled == 0:
+		c.Status = CheckoutActive
+	case cancelled == len(c.Orders):
+		c.Status = CheckoutCancelled
+	default:
+		c.Status = CheckoutPartiallyCancelled
+	}
+}
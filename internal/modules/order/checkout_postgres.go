@@ -0,0 +1,127 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+type checkoutPostgresRepository struct {
+	db     *sql.DB
+	orders *postgresRepo
+}
+
+func NewCheckoutPostgresRepository(db *sql.DB) CheckoutRepository {
+	return &checkoutPostgresRepository{db: db, orders: &postgresRepo{db: db}}
+}
+
+const checkoutColumns = `id, checkout_number, customer_id, cancellation_policy, currency, created_at`
+
+// CreateCheckout inserts the child orders in store order, so two checkouts touching the same stores lock their
+// product rows in the same sequence.
+func (r *checkoutPostgresRepository) NextCheckoutSequence(ctx context.Context, series string) (int64, error) {
+	return nextDocumentSequence(ctx, r.db, "order_checkouts", "checkout_number", series)
+}
+
+func (r *checkoutPostgresRepository) CreateCheckout(ctx context.Context, c *Checkout, change StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sort.Slice(c.Orders, func(i, j int) bool { return c.Orders[i].StoreID.String() < c.Orders[j].StoreID.String() })
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO order_checkouts (id, checkout_number, customer_id, cancellation_policy, currency, idempotency_key)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6,''))
+		RETURNING created_at`,
+		c.ID, c.CheckoutNumber, c.CustomerID, c.CancellationPolicy, c.Currency, c.IdempotencyKey,
+	).Scan(&c.CreatedAt); err != nil {
+		return fmt.Errorf("insert checkout: %w", err)
+	}
+	for _, o := range c.Orders {
+		if err := insertOrder(ctx, tx, o, change); err != nil {
+			var oos *OutOfStockError
+			if errors.As(err, &oos) {
+				_ = tx.Rollback()
+				r.orders.recordOversell(ctx, o.StoreID, oos)
+			}
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *checkoutPostgresRepository) GetCheckout(ctx context.Context, id string) (*Checkout, error) {
+	return r.getCheckout(ctx, `SELECT `+checkoutColumns+` FROM order_checkouts WHERE id=$1`, id)
+}
+
+func (r *checkoutPostgresRepository) GetCheckoutByIdempotencyKey(ctx context.Context, key string) (*Checkout, error) {
+	return r.getCheckout(ctx, `SELECT `+checkoutColumns+` FROM order_checkouts WHERE idempotency_key=$1`, key)
+}
+
+func (r *checkoutPostgresRepository) getCheckout(ctx context.Context, query string, arg interface{}) (*Checkout, error) {
+	c := &Checkout{}
+	if err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&c.ID, &c.CheckoutNumber, &c.CustomerID, &c.CancellationPolicy, &c.Currency, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	orders, err := r.orders.queryOrders(ctx, `SELECT `+orderColumns+` FROM orders WHERE checkout_id=$1
+		ORDER BY store_id ASC`, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		if o.Items, err = r.orders.listItems(ctx, o.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+	c.Orders = orders
+	c.summarise()
+	return c, nil
+}
+
+func (r *checkoutPostgresRepository) ListCheckoutsByCustomer(ctx context.Context, customerID string) ([]*Checkout, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+checkoutColumns+` FROM order_checkouts WHERE customer_id=$1
+		ORDER BY created_at DESC`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var checkouts []*Checkout
+	byID := make(map[string]*Checkout)
+	var ids []string
+	for rows.Next() {
+		c := &Checkout{}
+		if err := rows.Scan(&c.ID, &c.CheckoutNumber, &c.CustomerID, &c.CancellationPolicy, &c.Currency, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		checkouts = append(checkouts, c)
+		byID[c.ID.String()] = c
+		ids = append(ids, c.ID.String())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return checkouts, nil
+	}
+
+	orders, err := r.orders.queryOrders(ctx, `SELECT `+orderColumns+` FROM orders WHERE checkout_id = ANY($1::uuid[])
+		ORDER BY store_id ASC`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		c := byID[o.CheckoutID.String()]
+		c.Orders = append(c.Orders, o)
+	}
+	for _, c := range checkouts {
+		c.summarise()
+	}
+	return checkouts, nil
+}
@@ -0,0 +1,22 @@
+package order
+
+import "context"
+
+// CheckoutRepository persists multi-store checkouts. Child orders are written through the same stock reservation,
+// promo redemption and status history as Repository.CreateOrder.
+type CheckoutRepository interface {
+	// CreateCheckout writes the checkout and every child order in c.Orders in one transaction, so either every
+	// store's stock is reserved or none is. It returns *OutOfStockError when a line cannot be covered.
+	CreateCheckout(ctx context.Context, c *Checkout, change StatusChange) error
+
+	// NextCheckoutSequence increments the counter of one checkout numbering series, e.g. "CHK-20261016", and returns
+	// the new value. It commits on its own, so concurrent callers never receive the same value.
+	NextCheckoutSequence(ctx context.Context, series string) (int64, error)
+
+	// GetCheckout retrieves a checkout with its child orders and their items.
+	GetCheckout(ctx context.Context, id string) (*Checkout, error)
+	GetCheckoutByIdempotencyKey(ctx context.Context, key string) (*Checkout, error)
+
+	// ListCheckoutsByCustomer returns a customer's checkouts, newest first, with child orders but not their items.
+	ListCheckoutsByCustomer(ctx context.Context, customerID string) ([]*Checkout, error)
+}
@@ -0,0 +1,155 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// CheckoutService places one customer checkout across several stores as linked child orders, one per store.
+type CheckoutService interface {
+	// PlaceCheckout prices every store's cart exactly as PlaceOrder would and writes all child orders atomically:
+	// if any store cannot cover a line, no order is placed. A repeated IdempotencyKey returns the checkout already
+	// placed with it. It returns an error wrapping *OutOfStockError or *promo.RejectionError like PlaceOrder.
+	PlaceCheckout(ctx context.Context, req PlaceCheckoutRequest) (*Checkout, error)
+	GetCheckout(ctx context.Context, id string) (*Checkout, error)
+	ListCustomerCheckouts(ctx context.Context, customerID string) ([]*Checkout, error)
+}
+
+// maxCheckoutStores bounds how many stores one checkout may span.
+const maxCheckoutStores = 10
+
+type checkoutService struct {
+	repo   CheckoutRepository
+	orders *service
+}
+
+// NewCheckoutService creates a checkout service. Child orders are priced and taxed with the same options as
+// NewService.
+func NewCheckoutService(repo CheckoutRepository, orders Repository, options ...ServiceOption) CheckoutService {
+	return &checkoutService{repo: repo, orders: NewService(orders, options...).(*service)}
+}
+
+func (s *checkoutService) PlaceCheckout(ctx context.Context, req PlaceCheckoutRequest) (*Checkout, error) {
+	if req.IdempotencyKey != "" {
+		existing, err := s.repo.GetCheckoutByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err == nil {
+			if existing.CustomerID.String() != req.CustomerID {
+				return nil, fmt.Errorf("idempotency key is already associated with another customer")
+			}
+			return existing, nil
+		}
+		if !strings.Contains(err.Error(), "no rows") && !strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("lookup idempotent checkout: %w", err)
+		}
+	}
+
+	if req.CustomerID == "" {
+		return nil, fmt.Errorf("customer_id is required")
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer_id: %w", err)
+	}
+	if len(req.Stores) == 0 {
+		return nil, fmt.Errorf("checkout must contain at least one store")
+	}
+	if len(req.Stores) > maxCheckoutStores {
+		return nil, fmt.Errorf("checkout must not span more than %d stores", maxCheckoutStores)
+	}
+	policy := CancellationPolicy(strings.ToUpper(strings.TrimSpace(req.CancellationPolicy)))
+	if policy == "" {
+		policy = PolicyIndependent
+	}
+	if policy != PolicyIndependent && policy != PolicyCancelAll {
+		return nil, fmt.Errorf("cancellation_policy must be INDEPENDENT or CANCEL_ALL")
+	}
+	change, err := newStatusChange(req.Actor, "")
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := s.nextCheckoutNumber(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkout{
+		ID:                 uuid.New(),
+		CheckoutNumber:     number,
+		CustomerID:         customerID,
+		CancellationPolicy: policy,
+		Currency:           money.DefaultCurrency,
+		IdempotencyKey:     req.IdempotencyKey,
+	}
+	seen := make(map[string]bool, len(req.Stores))
+	for _, cart := range req.Stores {
+		if cart.StoreID == "" {
+			return nil, fmt.Errorf("store_id is required for every store")
+		}
+		if seen[cart.StoreID] {
+			return nil, fmt.Errorf("store %s appears more than once", cart.StoreID)
+		}
+		seen[cart.StoreID] = true
+		if len(cart.Items) == 0 {
+			return nil, fmt.Errorf("store %s: order must contain at least one item", cart.StoreID)
+		}
+		o, err := s.orders.prepareOrder(ctx, PlaceOrderRequest{
+			StoreID:          cart.StoreID,
+			CustomerID:       req.CustomerID,
+			Channel:          string(ChannelOnline),
+			Items:            cart.Items,
+			Notes:            cart.Notes,
+			FulfilmentMethod: cart.FulfilmentMethod,
+			DeliveryAddress:  cart.DeliveryAddress,
+			PromoCode:        cart.PromoCode,
+			DueAt:            cart.DueAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("store %s: %w", cart.StoreID, err)
+		}
+		o.CheckoutID = &c.ID
+		c.Orders = append(c.Orders, o)
+	}
+
+	if err := s.repo.CreateCheckout(ctx, c, change); err != nil {
+		if req.IdempotencyKey != "" && strings.Contains(err.Error(), "duplicate key") {
+			if existing, lookupErr := s.repo.GetCheckoutByIdempotencyKey(ctx, req.IdempotencyKey); lookupErr == nil &&
+				existing.CustomerID == customerID {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to persist checkout: %w", err)
+	}
+	c.summarise()
+	return c, nil
+}
+
+func (s *checkoutService) GetCheckout(ctx context.Context, id string) (*Checkout, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid checkout id: %w", err)
+	}
+	c, err := s.repo.GetCheckout(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("checkout not found: %w", err)
+	}
+	return c, nil
+}
+
+func (s *checkoutService) ListCustomerCheckouts(ctx context.Context, customerID string) ([]*Checkout, error) {
+	return s.repo.ListCheckoutsByCustomer(ctx, customerID)
+}
+
+// nextCheckoutNumber issues the next platform-wide checkout number of the day, CHK-YYYYMMDD-0042, dated in the
+// store timezone like order numbers.
+func (s *checkoutService) nextCheckoutNumber(ctx context.Context, now time.Time) (string, error) {
+	series := "CHK-" + now.In(s.orders.zone).Format("20060102")
+	value, err := s.repo.NextCheckoutSequence(ctx, series)
+	if err != nil {
+		return "", fmt.Errorf("allocate checkout number: %w", err)
+	}
+	return fmt.Sprintf("%s-%04d", series, value), nil
+}
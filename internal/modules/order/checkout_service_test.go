@@ -0,0 +1,207 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// fakeCheckoutRepository keeps checkouts over the fake order repository, so child orders reserve and release the
+// same fake stock as single-store orders.
+type fakeCheckoutRepository struct {
+	orders    *fakeRepository
+	checkouts map[uuid.UUID]*Checkout
+	sequences map[string]int64
+}
+
+func newFakeCheckoutRepository(orders *fakeRepository) *fakeCheckoutRepository {
+	return &fakeCheckoutRepository{orders: orders, checkouts: make(map[uuid.UUID]*Checkout), sequences: make(map[string]int64)}
+}
+
+func (f *fakeCheckoutRepository) NextCheckoutSequence(_ context.Context, series string) (int64, error) {
+	f.sequences[series]++
+	return f.sequences[series], nil
+}
+
+func (f *fakeCheckoutRepository) CreateCheckout(ctx context.Context, c *Checkout, change StatusChange) error {
+	// Check every store before touching stock, as the single Postgres transaction would.
+	wanted := make(map[string]int)
+	for _, o := range c.Orders {
+		for _, item := range o.Items {
+			wanted[item.VendorStoreProductID.String()] += item.Quantity
+		}
+	}
+	for id, quantity := range wanted {
+		if p := f.orders.products[id]; p.stock < quantity {
+			return &OutOfStockError{VendorStoreProductID: uuid.MustParse(id), Requested: quantity, Available: p.stock}
+		}
+	}
+	for _, o := range c.Orders {
+		if err := f.orders.CreateOrder(ctx, o, change); err != nil {
+			return err
+		}
+	}
+	stored := *c
+	f.checkouts[c.ID] = &stored
+	return nil
+}
+
+func (f *fakeCheckoutRepository) GetCheckout(_ context.Context, id string) (*Checkout, error) {
+	c, ok := f.checkouts[uuid.MustParse(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *c
+	copied.Orders = nil
+	for _, o := range c.Orders {
+		current := *f.orders.orders[o.ID.String()]
+		copied.Orders = append(copied.Orders, &current)
+	}
+	copied.summarise()
+	return &copied, nil
+}
+
+func (f *fakeCheckoutRepository) GetCheckoutByIdempotencyKey(ctx context.Context, key string) (*Checkout, error) {
+	for _, c := range f.checkouts {
+		if c.IdempotencyKey == key {
+			return f.GetCheckout(ctx, c.ID.String())
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCheckoutRepository) ListCheckoutsByCustomer(context.Context, string) ([]*Checkout, error) {
+	return nil, nil
+}
+
+type checkoutFixture struct {
+	repo      *fakeRepository
+	checkouts *fakeCheckoutRepository
+	orders    Service
+	service   CheckoutService
+	stores    [2]string
+	products  [2]string
+}
+
+func newCheckoutFixture(stock int) *checkoutFixture {
+	f := &checkoutFixture{repo: newFakeRepository()}
+	f.checkouts = newFakeCheckoutRepository(f.repo)
+	f.orders = NewService(f.repo, WithCheckouts(f.checkouts))
+	f.service = NewCheckoutService(f.checkouts, f.repo)
+	for i := range f.stores {
+		f.stores[i], f.products[i] = uuid.NewString(), uuid.NewString()
+		f.repo.products[f.products[i]] = &fakeProduct{price: money.Amount(1000 * (i + 1)), available: true, stock: stock}
+	}
+	return f
+}
+
+func (f *checkoutFixture) request(policy string, quantity int) PlaceCheckoutRequest {
+	return PlaceCheckoutRequest{
+		CustomerID:         uuid.NewString(),
+		CancellationPolicy: policy,
+		Stores: []StoreCart{
+			{StoreID: f.stores[0], Items: []CartItem{{VendorStoreProductID: f.products[0], Quantity: 1}}},
+			{StoreID: f.stores[1], Items: []CartItem{{VendorStoreProductID: f.products[1], Quantity: quantity}}},
+		},
+	}
+}
+
+func TestPlaceCheckoutCreatesOneLinkedOrderPerStore(t *testing.T) {
+	f := newCheckoutFixture(5)
+	req := f.request("", 2)
+	req.IdempotencyKey = "basket-1"
+
+	c, err := f.service.PlaceCheckout(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PlaceCheckout returned error: %v", err)
+	}
+	if len(c.Orders) != 2 || c.CancellationPolicy != PolicyIndependent || c.Status != CheckoutActive {
+		t.Fatalf("checkout = %+v", c)
+	}
+	if want := "CHK-" + time.Now().UTC().Format("20060102") + "-0001"; c.CheckoutNumber != want {
+		t.Fatalf("checkout number = %q, want %q", c.CheckoutNumber, want)
+	}
+	var sum int64
+	for _, o := range c.Orders {
+		if o.CheckoutID == nil || *o.CheckoutID != c.ID || o.CustomerID == nil || o.CustomerID.String() != req.CustomerID {
+			t.Fatalf("child order %+v is not linked to checkout %s", o, c.ID)
+		}
+		sum += int64(o.Total)
+	}
+	if int64(c.Total) != sum || c.PayableTotal != c.Total || c.CancelledTotal != 0 {
+		t.Fatalf("totals = %d/%d/%d, want %d payable", c.Total, c.PayableTotal, c.CancelledTotal, sum)
+	}
+
+	again, err := f.service.PlaceCheckout(context.Background(), req)
+	if err != nil || again.ID != c.ID || len(f.repo.orders) != 2 {
+		t.Fatalf("repeated checkout = %v, %v with %d orders; want the original", again, err, len(f.repo.orders))
+	}
+}
+
+func TestPlaceCheckoutOutOfStockAtOneStorePlacesNothing(t *testing.T) {
+	f := newCheckoutFixture(3)
+
+	_, err := f.service.PlaceCheckout(context.Background(), f.request("", 4))
+	var oos *OutOfStockError
+	if !errors.As(err, &oos) {
+		t.Fatalf("expected OutOfStockError, got %v", err)
+	}
+	if len(f.repo.orders) != 0 || f.repo.products[f.products[0]].stock != 3 {
+		t.Fatalf("expected no orders and untouched stock, got %d orders and stock %d",
+			len(f.repo.orders), f.repo.products[f.products[0]].stock)
+	}
+}
+
+func TestCancellationPolicyDecidesWhetherSiblingsAreCancelled(t *testing.T) {
+	for _, tc := range []struct {
+		policy  string
+		sibling OrderStatus
+		status  CheckoutStatus
+	}{
+		{"INDEPENDENT", StatusPending, CheckoutPartiallyCancelled},
+		{"CANCEL_ALL", StatusCancelled, CheckoutCancelled},
+	} {
+		f := newCheckoutFixture(5)
+		c, err := f.service.PlaceCheckout(context.Background(), f.request(tc.policy, 1))
+		if err != nil {
+			t.Fatalf("%s: PlaceCheckout returned error: %v", tc.policy, err)
+		}
+		rejected, sibling := c.Orders[0], c.Orders[1]
+		if _, err := f.orders.UpdateStatus(context.Background(), rejected.ID.String(), UpdateStatusRequest{
+			Status: "CANCELLED", Reason: "Store cannot print this",
+		}); err != nil {
+			t.Fatalf("%s: reject child order: %v", tc.policy, err)
+		}
+
+		reloaded, err := f.service.GetCheckout(context.Background(), c.ID.String())
+		if err != nil {
+			t.Fatalf("%s: GetCheckout returned error: %v", tc.policy, err)
+		}
+		if got := f.repo.orders[sibling.ID.String()].Status; got != tc.sibling {
+			t.Fatalf("%s: sibling status = %s, want %s", tc.policy, got, tc.sibling)
+		}
+		if reloaded.Status != tc.status || reloaded.CancelledTotal < rejected.Total {
+			t.Fatalf("%s: checkout = %+v", tc.policy, reloaded)
+		}
+		if tc.sibling == StatusCancelled && f.repo.products[sibling.Items[0].VendorStoreProductID.String()].stock != 5 {
+			t.Fatalf("%s: sibling stock was not released", tc.policy)
+		}
+	}
+}
+
+func TestPlaceCheckoutRejectsRepeatedStoreAndUnknownPolicy(t *testing.T) {
+	f := newCheckoutFixture(5)
+	repeated := f.request("", 1)
+	repeated.Stores[1].StoreID = repeated.Stores[0].StoreID
+	if _, err := f.service.PlaceCheckout(context.Background(), repeated); err == nil {
+		t.Fatal("expected a store listed twice to be rejected")
+	}
+	if _, err := f.service.PlaceCheckout(context.Background(), f.request("FIRST_WINS", 1)); err == nil {
+		t.Fatal("expected an unknown cancellation policy to be rejected")
+	}
+}
@@ -0,0 +1,17 @@
+package order
+
+import "github.com/google/uuid"
+
+// Order outbox events are recorded in the same transaction as the status change they announce.
+const (
+	// EventOrderConfirmed is recorded whenever an order becomes CONFIRMED; production queues its jobs.
+	EventOrderConfirmed = "order.confirmed.v1"
+	// EventOrderCancelled is recorded whenever an order is cancelled; a checkout child's share of the checkout
+	// payment is refunded.
+	EventOrderCancelled = "order.cancelled.v1"
+)
+
+// OrderEvent is the payload of order outbox events.
+type OrderEvent struct {
+	OrderID uuid.UUID `json:"order_id"`
+}
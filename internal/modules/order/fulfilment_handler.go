@@ -0,0 +1,80 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// sendPickupCode issues a fresh pickup code for a READY pickup order, e.g. when the customer lost the first one or
+// it was locked by wrong attempts. Only store-side users may issue codes.
+func (h *Handler) sendPickupCode(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	if err := h.service.SendPickupCode(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondHandoverError(w, err)
+		return
+	}
+	respond(w, http.StatusAccepted, map[string]string{"status": "pickup code sent"})
+}
+
+// handOver checks the code the customer shows at the counter and marks the order DELIVERED when it matches.
+func (h *Handler) handOver(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	var req HandoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Actor = requestActor(r)
+	o, err := h.service.HandOver(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondHandoverError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, o)
+}
+
+// listHandoverAttempts shows every pickup code check made for an order and who made it.
+func (h *Handler) listHandoverAttempts(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	attempts, err := h.service.ListHandoverAttempts(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if attempts == nil {
+		attempts = make([]*HandoverAttempt, 0)
+	}
+	respond(w, http.StatusOK, attempts)
+}
+
+func respondHandoverError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrPickupCodeLocked):
+		code = http.StatusLocked
+	case errors.Is(err, ErrPickupCodeMismatch), errors.Is(err, ErrNoPickupCode), errors.Is(err, ErrNotPickup),
+		errors.Is(err, ErrNotReadyForPickup), strings.Contains(err.Error(), "not enabled"):
+		code = http.StatusUnprocessableEntity
+	case strings.Contains(err.Error(), "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		code = http.StatusBadRequest
+	case strings.Contains(err.Error(), "send the pickup code") || strings.Contains(err.Error(), "send pickup code"):
+		code = http.StatusBadGateway
+	}
+	respond(w, code, map[string]string{"error": err.Error()})
+}
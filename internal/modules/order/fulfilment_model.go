@@ -0,0 +1,72 @@
+package order
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FulfilmentMethod is how an order reaches the customer.
+type FulfilmentMethod string
+
+const (
+	// FulfilmentPickup orders are collected at the store. Once READY, a customer's pickup order is handed over only
+	// against its single-use pickup code.
+	FulfilmentPickup FulfilmentMethod = "PICKUP"
+	// FulfilmentDelivery orders are taken to the address snapshotted in DeliveryAddress.
+	FulfilmentDelivery FulfilmentMethod = "DELIVERY"
+)
+
+// HandoverOutcome is the result of one pickup code entered at the counter.
+type HandoverOutcome string
+
+const (
+	HandoverVerified HandoverOutcome = "VERIFIED" // the code matched; the order was marked DELIVERED
+	HandoverRejected HandoverOutcome = "REJECTED" // the code did not match
+	HandoverLocked   HandoverOutcome = "LOCKED"   // too many wrong codes; a new code must be issued first
+)
+
+// maxPickupCodeAttempts is how many wrong codes lock a pickup code until staff issue a new one.
+const maxPickupCodeAttempts = 5
+
+// HandoverAttempt records one pickup code check and the staff member who made it.
+type HandoverAttempt struct {
+	ID        uuid.UUID       `json:"id"`
+	OrderID   uuid.UUID       `json:"order_id"`
+	StaffID   *uuid.UUID      `json:"staff_id,omitempty"`
+	StaffRole string          `json:"staff_role,omitempty"`
+	Outcome   HandoverOutcome `json:"outcome"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// HandoverRequest is the pickup code the customer shows at the counter.
+type HandoverRequest struct {
+	Code  string `json:"code"`
+	Actor Actor  `json:"-"`
+}
+
+// CustomerContact is where a customer's pickup code is sent.
+type CustomerContact struct {
+	Email string
+	Phone string
+}
+
+// ErrHandoverRequired is returned when a READY pickup order is moved to DELIVERED without checking its pickup code.
+var ErrHandoverRequired = errors.New("pickup orders are handed over by verifying the customer's pickup code")
+
+// ErrNotPickup is returned when a pickup code is requested or checked for an order that is not collected in store.
+var ErrNotPickup = errors.New("only pickup orders have a pickup code")
+
+// ErrNotReadyForPickup is returned when a pickup code is issued or checked before the order is READY or after it
+// has been handed over.
+var ErrNotReadyForPickup = errors.New("only READY orders can be handed over")
+
+// ErrNoPickupCode is returned when a READY pickup order has no code to check, e.g. because delivery failed.
+var ErrNoPickupCode = errors.New("no pickup code has been issued for this order; issue a new one")
+
+// ErrPickupCodeMismatch is returned when the code entered at the counter is wrong. The attempt is recorded.
+var ErrPickupCodeMismatch = errors.New("pickup code does not match")
+
+// ErrPickupCodeLocked is returned once a pickup code has been entered wrongly too many times.
+var ErrPickupCodeLocked = errors.New("pickup code is locked after too many wrong attempts; issue a new one")
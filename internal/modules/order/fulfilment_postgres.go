@@ -0,0 +1,157 @@
+package order
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r *postgresRepo) GetCustomerContact(ctx context.Context, customerID string) (*CustomerContact, error) {
+	c := &CustomerContact{}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(email,''), COALESCE(phone,'') FROM users WHERE id=$1`, customerID).Scan(&c.Email, &c.Phone)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// lockForHandover locks the order row and checks it is a READY pickup order.
+func lockForHandover(ctx context.Context, tx *sql.Tx, orderID uuid.UUID) error {
+	var status OrderStatus
+	var method FulfilmentMethod
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status, fulfilment_method FROM orders WHERE id=$1 FOR UPDATE`, orderID).Scan(&status, &method); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("order not found: %w", err)
+		}
+		return err
+	}
+	if method != FulfilmentPickup {
+		return ErrNotPickup
+	}
+	if status != StatusReady {
+		return fmt.Errorf("%w (current: %s)", ErrNotReadyForPickup, status)
+	}
+	return nil
+}
+
+func (r *postgresRepo) IssuePickupCode(ctx context.Context, orderID, codeHash string) error {
+	uid, err := uuid.Parse(orderID)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockForHandover(ctx, tx, uid); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_pickup_codes (order_id, code_hash, issued_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (order_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, failed_attempts = 0, issued_at = NOW(), used_at = NULL, verified_by = NULL`,
+		uid, codeHash); err != nil {
+		return fmt.Errorf("store pickup code: %w", err)
+	}
+	return tx.Commit()
+}
+
+// VerifyPickupCode commits the attempt record even when the code is wrong, so failed attempts count towards the
+// lock and remain in the audit trail.
+func (r *postgresRepo) VerifyPickupCode(ctx context.Context, orderID, codeHash string, change StatusChange) error {
+	uid, err := uuid.Parse(orderID)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockForHandover(ctx, tx, uid); err != nil {
+		return err
+	}
+	var stored string
+	var failed int
+	err = tx.QueryRowContext(ctx, `
+		SELECT code_hash, failed_attempts FROM order_pickup_codes
+		WHERE order_id=$1 AND used_at IS NULL FOR UPDATE`, uid).Scan(&stored, &failed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoPickupCode
+	}
+	if err != nil {
+		return err
+	}
+
+	outcome, result := HandoverVerified, error(nil)
+	switch {
+	case failed >= maxPickupCodeAttempts:
+		outcome, result = HandoverLocked, ErrPickupCodeLocked
+	case subtle.ConstantTimeCompare([]byte(stored), []byte(codeHash)) != 1:
+		outcome, result = HandoverRejected, ErrPickupCodeMismatch
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE order_pickup_codes SET failed_attempts = failed_attempts + 1 WHERE order_id=$1`, uid); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_handover_attempts (order_id, staff_id, staff_role, outcome)
+		VALUES ($1, NULLIF($2,'')::uuid, NULLIF($3,''), $4)`,
+		uid, change.Actor.ID, change.Actor.Role, outcome); err != nil {
+		return fmt.Errorf("record handover attempt: %w", err)
+	}
+
+	if outcome == HandoverVerified {
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE order_pickup_codes SET used_at=$2, verified_by=NULLIF($3,'')::uuid WHERE order_id=$1`,
+			uid, now, change.Actor.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE orders SET status=$1, updated_at=$2 WHERE id=$3`, StatusDelivered, now, uid); err != nil {
+			return err
+		}
+		if err := insertStatusEvent(ctx, tx, uid, StatusReady, StatusDelivered, change); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return result
+}
+
+func (r *postgresRepo) ListHandoverAttempts(ctx context.Context, orderID string) ([]*HandoverAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, order_id, staff_id, COALESCE(staff_role,''), outcome, created_at
+		FROM order_handover_attempts WHERE order_id=$1 ORDER BY created_at ASC, id ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var attempts []*HandoverAttempt
+	for rows.Next() {
+		a := &HandoverAttempt{}
+		var staffID uuid.NullUUID
+		if err := rows.Scan(&a.ID, &a.OrderID, &staffID, &a.StaffRole, &a.Outcome, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if staffID.Valid {
+			a.StaffID = &staffID.UUID
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
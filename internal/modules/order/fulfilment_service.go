@@ -0,0 +1,166 @@
+package order
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/comms"
+	"github.com/google/uuid"
+)
+
+// PickupCodeSender delivers pickup codes to customers. It is deliberately narrow and satisfied by comms.Service,
+// so every code sent keeps the communications delivery audit trail.
+type PickupCodeSender interface {
+	Send(ctx context.Context, req comms.SendRequest) (*comms.SendResult, error)
+}
+
+// WithPickupCodes issues a single-use pickup code to the customer when their pickup order becomes READY, and
+// requires that code to hand the order over. Without it, pickup orders move to DELIVERED through UpdateStatus.
+func WithPickupCodes(sender PickupCodeSender) ServiceOption {
+	return func(s *service) {
+		s.pickupCodes = sender
+	}
+}
+
+// fulfilmentFor resolves an order's fulfilment method from the explicit request field and the method recorded in
+// the delivery address snapshot. Orders naming neither are collected in store.
+func fulfilmentFor(method FulfilmentMethod, address json.RawMessage) (FulfilmentMethod, error) {
+	method = FulfilmentMethod(strings.ToUpper(strings.TrimSpace(string(method))))
+	hasAddress := len(address) > 0 && string(address) != "null"
+	var recorded struct {
+		Method string `json:"method"`
+	}
+	if hasAddress {
+		_ = json.Unmarshal(address, &recorded)
+	}
+	fromAddress := FulfilmentMethod(strings.ToUpper(strings.TrimSpace(recorded.Method)))
+	switch {
+	case method == "" && fromAddress == "":
+		method = FulfilmentPickup
+	case method == "":
+		method = fromAddress
+	case fromAddress != "" && fromAddress != method:
+		return "", fmt.Errorf("fulfilment_method %s does not match delivery_address method %s", method, fromAddress)
+	}
+	switch method {
+	case FulfilmentPickup:
+	case FulfilmentDelivery:
+		if !hasAddress {
+			return "", fmt.Errorf("delivery orders require a delivery_address")
+		}
+	default:
+		return "", fmt.Errorf("invalid fulfilment_method %q: use PICKUP or DELIVERY", method)
+	}
+	return method, nil
+}
+
+// requiresPickupCode reports whether o is handed over against a pickup code. Walk-in orders have nobody to send a
+// code to and are handed over directly.
+func (s *service) requiresPickupCode(o *Order) bool {
+	return s.pickupCodes != nil && o.FulfilmentMethod == FulfilmentPickup && o.CustomerID != nil
+}
+
+func (s *service) SendPickupCode(ctx context.Context, id string) error {
+	o, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("order not found: %w", err)
+	}
+	if o.FulfilmentMethod != FulfilmentPickup {
+		return ErrNotPickup
+	}
+	if !s.requiresPickupCode(o) {
+		return fmt.Errorf("pickup codes are not enabled for this order")
+	}
+	return s.issuePickupCode(ctx, o)
+}
+
+// issuePickupCode replaces the order's pickup code and sends the new one to the customer, by SMS when they have a
+// phone number and by email otherwise.
+func (s *service) issuePickupCode(ctx context.Context, o *Order) error {
+	contact, err := s.repo.GetCustomerContact(ctx, o.CustomerID.String())
+	if err != nil {
+		return fmt.Errorf("load customer contact: %w", err)
+	}
+	req := comms.SendRequest{
+		Channel:     comms.ChannelSMS,
+		Recipient:   contact.Phone,
+		RecipientID: o.CustomerID.String(),
+	}
+	if strings.TrimSpace(contact.Phone) == "" {
+		if strings.TrimSpace(contact.Email) == "" {
+			return fmt.Errorf("customer has no phone number or email address to send the pickup code to")
+		}
+		req.Channel, req.Recipient = comms.ChannelEmail, contact.Email
+		req.Subject = fmt.Sprintf("Order %s is ready for pickup", o.OrderNumber)
+	}
+
+	code, err := newPickupCode()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.IssuePickupCode(ctx, o.ID.String(), hashPickupCode(o.ID, code)); err != nil {
+		return err
+	}
+	req.Body = fmt.Sprintf("Your Printa order %s is ready for pickup. Show code %s at the counter to collect it. "+
+		"The code works once; do not share it with anyone who is not collecting the order for you.", o.OrderNumber, code)
+	req.IdempotencyKey = "order-pickup-code:" + o.ID.String() + ":" + uuid.NewString()
+	result, err := s.pickupCodes.Send(ctx, req)
+	if err != nil {
+		return fmt.Errorf("send pickup code: %w", err)
+	}
+	if result == nil || result.Status == comms.DeliveryFailed {
+		return errors.New("unable to send the pickup code to the customer")
+	}
+	return nil
+}
+
+func (s *service) HandOver(ctx context.Context, id string, req HandoverRequest) (*Order, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	o, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if !s.requiresPickupCode(o) {
+		if o.FulfilmentMethod != FulfilmentPickup {
+			return nil, ErrNotPickup
+		}
+		return nil, fmt.Errorf("pickup codes are not enabled for this order")
+	}
+	change, err := newStatusChange(req.Actor, "Pickup code verified at the counter")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.VerifyPickupCode(ctx, id, hashPickupCode(o.ID, code), change); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderByID(ctx, id)
+}
+
+func (s *service) ListHandoverAttempts(ctx context.Context, id string) ([]*HandoverAttempt, error) {
+	return s.repo.ListHandoverAttempts(ctx, id)
+}
+
+// newPickupCode returns a random six-digit code. Wrong guesses lock it after maxPickupCodeAttempts.
+func newPickupCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("generate pickup code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPickupCode salts the code with the order ID so the same code on two orders never shares a hash.
+func hashPickupCode(orderID uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(orderID.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
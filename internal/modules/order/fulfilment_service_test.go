@@ -0,0 +1,221 @@
+package order
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/comms"
+	"github.com/google/uuid"
+)
+
+type fakePickupCode struct {
+	hash       string
+	failed     int
+	used       bool
+	verifiedBy string
+}
+
+func (f *fakeRepository) GetCustomerContact(_ context.Context, customerID string) (*CustomerContact, error) {
+	if c, ok := f.contacts[customerID]; ok {
+		return c, nil
+	}
+	return &CustomerContact{}, nil
+}
+
+func (f *fakeRepository) readyPickupOrder(orderID string) (*Order, error) {
+	o, ok := f.orders[orderID]
+	if !ok {
+		return nil, errors.New("order not found")
+	}
+	if o.FulfilmentMethod != FulfilmentPickup {
+		return nil, ErrNotPickup
+	}
+	if o.Status != StatusReady {
+		return nil, ErrNotReadyForPickup
+	}
+	return o, nil
+}
+
+func (f *fakeRepository) IssuePickupCode(_ context.Context, orderID, codeHash string) error {
+	if _, err := f.readyPickupOrder(orderID); err != nil {
+		return err
+	}
+	if f.pickup == nil {
+		f.pickup = make(map[string]*fakePickupCode)
+	}
+	f.pickup[orderID] = &fakePickupCode{hash: codeHash}
+	return nil
+}
+
+func (f *fakeRepository) VerifyPickupCode(_ context.Context, orderID, codeHash string, change StatusChange) error {
+	o, err := f.readyPickupOrder(orderID)
+	if err != nil {
+		return err
+	}
+	code, ok := f.pickup[orderID]
+	if !ok || code.used {
+		return ErrNoPickupCode
+	}
+	outcome, result := HandoverVerified, error(nil)
+	switch {
+	case code.failed >= maxPickupCodeAttempts:
+		outcome, result = HandoverLocked, ErrPickupCodeLocked
+	case subtle.ConstantTimeCompare([]byte(code.hash), []byte(codeHash)) != 1:
+		outcome, result = HandoverRejected, ErrPickupCodeMismatch
+		code.failed++
+	}
+	attempt := &HandoverAttempt{ID: uuid.New(), OrderID: o.ID, StaffRole: change.Actor.Role, Outcome: outcome}
+	if staffID, err := uuid.Parse(change.Actor.ID); err == nil {
+		attempt.StaffID = &staffID
+	}
+	f.handovers = append(f.handovers, attempt)
+	if outcome == HandoverVerified {
+		code.used, code.verifiedBy = true, change.Actor.ID
+		f.appendEvent(o.ID, o.Status, StatusDelivered, change)
+		o.Status = StatusDelivered
+	}
+	return result
+}
+
+func (f *fakeRepository) ListHandoverAttempts(_ context.Context, orderID string) ([]*HandoverAttempt, error) {
+	var attempts []*HandoverAttempt
+	for _, a := range f.handovers {
+		if a.OrderID.String() == orderID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+// fakeSender records every message instead of delivering it.
+type fakeSender struct {
+	sent []comms.SendRequest
+}
+
+func (f *fakeSender) Send(_ context.Context, req comms.SendRequest) (*comms.SendResult, error) {
+	f.sent = append(f.sent, req)
+	return &comms.SendResult{Channel: req.Channel, Status: comms.DeliverySent}, nil
+}
+
+var sentPickupCode = regexp.MustCompile(`code (\d{6})`)
+
+// readyForPickup places a customer's pickup order and moves it to READY, returning the code sent to the customer.
+func readyForPickup(t *testing.T, svc Service, repo *fakeRepository, sender *fakeSender) (*Order, string) {
+	t.Helper()
+	ctx := context.Background()
+	productID, customerID := uuid.NewString(), uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 5}
+	repo.contacts = map[string]*CustomerContact{customerID: {Email: "jane@example.com", Phone: "+260977000000"}}
+	o, err := svc.PlaceOrder(ctx, PlaceOrderRequest{
+		StoreID: uuid.NewString(), CustomerID: customerID,
+		Items: []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	for _, status := range []OrderStatus{StatusConfirmed, StatusInProduction, StatusReady} {
+		if _, err := svc.UpdateStatus(ctx, o.ID.String(), UpdateStatusRequest{Status: string(status)}); err != nil {
+			t.Fatalf("move to %s: %v", status, err)
+		}
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected one pickup code message, got %d", len(sender.sent))
+	}
+	match := sentPickupCode.FindStringSubmatch(sender.sent[0].Body)
+	if match == nil {
+		t.Fatalf("pickup code missing from message %q", sender.sent[0].Body)
+	}
+	return o, match[1]
+}
+
+func TestFulfilmentForResolvesMethodFromRequestAndAddress(t *testing.T) {
+	delivery := []byte(`{"method":"delivery","city":"Lusaka"}`)
+	for _, tc := range []struct {
+		name    string
+		method  FulfilmentMethod
+		address []byte
+		want    FulfilmentMethod
+		wantErr bool
+	}{
+		{name: "nothing given", want: FulfilmentPickup},
+		{name: "from address", address: delivery, want: FulfilmentDelivery},
+		{name: "explicit pickup", method: "pickup", want: FulfilmentPickup},
+		{name: "explicit and matching", method: FulfilmentDelivery, address: delivery, want: FulfilmentDelivery},
+		{name: "conflicting", method: FulfilmentPickup, address: delivery, wantErr: true},
+		{name: "delivery without address", method: FulfilmentDelivery, wantErr: true},
+		{name: "unknown method", method: "courier", wantErr: true},
+	} {
+		got, err := fulfilmentFor(tc.method, tc.address)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q (error %v)", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestReadyPickupOrderIsHandedOverOnlyAgainstItsCode(t *testing.T) {
+	ctx := context.Background()
+	repo, sender := newFakeRepository(), &fakeSender{}
+	svc := NewService(repo, WithPickupCodes(sender))
+	o, code := readyForPickup(t, svc, repo, sender)
+	if sender.sent[0].Channel != comms.ChannelSMS || sender.sent[0].Recipient != "+260977000000" {
+		t.Fatalf("pickup code sent as %s to %q, want SMS to the customer's phone", sender.sent[0].Channel, sender.sent[0].Recipient)
+	}
+
+	if _, err := svc.UpdateStatus(ctx, o.ID.String(), UpdateStatusRequest{Status: string(StatusDelivered)}); !errors.Is(err, ErrHandoverRequired) {
+		t.Fatalf("expected ErrHandoverRequired when skipping the code, got %v", err)
+	}
+	staff := Actor{ID: uuid.NewString(), Role: "STAFF"}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	if _, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: wrong, Actor: staff}); !errors.Is(err, ErrPickupCodeMismatch) {
+		t.Fatalf("expected ErrPickupCodeMismatch, got %v", err)
+	}
+	delivered, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: code, Actor: staff})
+	if err != nil {
+		t.Fatalf("HandOver returned error: %v", err)
+	}
+	if delivered.Status != StatusDelivered || repo.pickup[o.ID.String()].verifiedBy != staff.ID {
+		t.Fatalf("order status %s verified by %q, want DELIVERED by %s", delivered.Status, repo.pickup[o.ID.String()].verifiedBy, staff.ID)
+	}
+	if _, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: code, Actor: staff}); !errors.Is(err, ErrNotReadyForPickup) {
+		t.Fatalf("expected a used code to be refused, got %v", err)
+	}
+
+	attempts, _ := svc.ListHandoverAttempts(ctx, o.ID.String())
+	if len(attempts) != 2 || attempts[0].Outcome != HandoverRejected || attempts[1].Outcome != HandoverVerified ||
+		attempts[1].StaffID == nil || attempts[1].StaffID.String() != staff.ID {
+		t.Fatalf("unexpected handover attempts: %#v", attempts)
+	}
+}
+
+func TestPickupCodeLocksAfterRepeatedWrongAttemptsUntilReissued(t *testing.T) {
+	ctx := context.Background()
+	repo, sender := newFakeRepository(), &fakeSender{}
+	svc := NewService(repo, WithPickupCodes(sender))
+	o, code := readyForPickup(t, svc, repo, sender)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < maxPickupCodeAttempts; i++ {
+		if _, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: wrong}); !errors.Is(err, ErrPickupCodeMismatch) {
+			t.Fatalf("attempt %d: expected ErrPickupCodeMismatch, got %v", i+1, err)
+		}
+	}
+	if _, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: code}); !errors.Is(err, ErrPickupCodeLocked) {
+		t.Fatalf("expected the right code to be refused once locked, got %v", err)
+	}
+
+	if err := svc.SendPickupCode(ctx, o.ID.String()); err != nil {
+		t.Fatalf("SendPickupCode returned error: %v", err)
+	}
+	fresh := sentPickupCode.FindStringSubmatch(sender.sent[len(sender.sent)-1].Body)[1]
+	if _, err := svc.HandOver(ctx, o.ID.String(), HandoverRequest{Code: fresh}); err != nil {
+		t.Fatalf("HandOver with the reissued code returned error: %v", err)
+	}
+}
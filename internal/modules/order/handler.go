@@ -3,11 +3,16 @@ package order
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -21,13 +26,21 @@ func NewHandler(service Service, db *sql.DB) *Handler { return &Handler{service:
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/orders", func(r chi.Router) {
-		r.Post("/", h.placeOrder)                              // POST   /api/v1/orders
-		r.Get("/{id}", h.getOrder)                             // GET    /api/v1/orders/{id}
-		r.Get("/number/{number}", h.getOrderByNumber)          // GET    /api/v1/orders/number/{number}
-		r.Patch("/{id}/status", h.updateStatus)                // PATCH  /api/v1/orders/{id}/status
-		r.Delete("/{id}", h.cancelOrder)                       // DELETE /api/v1/orders/{id}
-		r.Get("/store/{store_id}", h.listStoreOrders)          // GET    /api/v1/orders/store/{store_id}?status=PENDING
-		r.Get("/customer/{customer_id}", h.listCustomerOrders) // GET /api/v1/orders/customer/{customer_id}
+		r.Post("/", h.placeOrder)                                       // POST   /api/v1/orders
+		r.Get("/{id}", h.getOrder)                                      // GET    /api/v1/orders/{id}
+		r.Get("/number/{number}", h.getOrderByNumber)                   // GET    /api/v1/orders/number/{number}
+		r.Patch("/{id}/status", h.updateStatus)                         // PATCH  /api/v1/orders/{id}/status
+		r.Delete("/{id}", h.cancelOrder)                                // DELETE /api/v1/orders/{id}?reason=...
+		r.Post("/{id}/amendments", h.amendOrder)                        // POST   /api/v1/orders/{id}/amendments
+		r.Get("/{id}/versions", h.listVersions)                         // GET    /api/v1/orders/{id}/versions
+		r.Get("/{id}/timeline", h.getTimeline)                          // GET    /api/v1/orders/{id}/timeline
+		r.Post("/{id}/pickup-code", h.sendPickupCode)                   // POST   /api/v1/orders/{id}/pickup-code
+		r.Post("/{id}/handover", h.handOver)                            // POST   /api/v1/orders/{id}/handover
+		r.Get("/{id}/handover-attempts", h.listHandoverAttempts)        // GET    /api/v1/orders/{id}/handover-attempts
+		r.Get("/store/{store_id}", h.listStoreOrders)                   // GET    /api/v1/orders/store/{store_id}?status=PENDING
+		r.Get("/customer/{customer_id}", h.listCustomerOrders)          // GET /api/v1/orders/customer/{customer_id}
+		r.Get("/store/{store_id}/search", h.searchStoreOrders)          // GET    /api/v1/orders/store/{store_id}/search?status=PENDING,CONFIRMED&cursor=...
+		r.Get("/customer/{customer_id}/search", h.searchCustomerOrders) // GET    /api/v1/orders/customer/{customer_id}/search
 	})
 }
 
@@ -38,26 +51,52 @@ func (h *Handler) placeOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	req.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	req.Actor = requestActor(r)
 	if len(req.IdempotencyKey) > 128 {
 		respond(w, http.StatusBadRequest, map[string]string{"error": "Idempotency-Key must not exceed 128 characters"})
 		return
 	}
 	if middleware.GetRole(r) == middleware.RoleCustomer {
 		req.CustomerID = middleware.GetUserID(r)
-		if err := h.validateCustomerAssets(r, req); err != nil {
+		if err := validateCustomerAssets(r, h.db, req.Items); err != nil {
 			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
 			return
 		}
-		if err := h.validateCustomerDelivery(r, &req); err != nil {
+		if err := validateCustomerDelivery(r, h.db, &req); err != nil {
 			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
 			return
 		}
 	}
 	o, err := h.service.PlaceOrder(r.Context(), req)
 	if err != nil {
+		var oos *OutOfStockError
+		if errors.As(err, &oos) {
+			respond(w, http.StatusConflict, map[string]interface{}{
+				"error":                   oos.Error(),
+				"vendor_store_product_id": oos.VendorStoreProductID,
+				"requested":               oos.Requested,
+				"available":               oos.Available,
+			})
+			return
+		}
+		var invalid *CustomisationError
+		if errors.As(err, &invalid) {
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": invalid.Error(), "lines": invalid.Lines})
+			return
+		}
+		var rejected *promo.RejectionError
+		if errors.As(err, &rejected) {
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":      rejected.Error(),
+				"promo_code": rejected.Code,
+				"reason":     rejected.Reason,
+				"detail":     rejected.Detail,
+			})
+			return
+		}
 		code := http.StatusInternalServerError
 		msg := err.Error()
-		if strings.Contains(msg, "unavailable") || strings.Contains(msg, "not found in this store") {
+		if strings.Contains(msg, "unavailable") || errors.Is(err, ErrProductNotInStore) || strings.Contains(msg, "not enabled") {
 			code = http.StatusUnprocessableEntity
 		} else if strings.Contains(msg, "required") || strings.Contains(msg, "invalid") || strings.Contains(msg, "at least one") {
 			code = http.StatusBadRequest
@@ -68,8 +107,9 @@ func (h *Handler) placeOrder(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusCreated, o)
 }
 
-func (h *Handler) validateCustomerAssets(r *http.Request, req PlaceOrderRequest) error {
-	for _, item := range req.Items {
+// validateCustomerAssets rejects customisation that references a design asset the customer does not own.
+func validateCustomerAssets(r *http.Request, db *sql.DB, items []CartItem) error {
+	for _, item := range items {
 		if len(item.Customisation) == 0 {
 			continue
 		}
@@ -83,7 +123,7 @@ func (h *Handler) validateCustomerAssets(r *http.Request, req PlaceOrderRequest)
 			continue
 		}
 		var exists bool
-		if err := h.db.QueryRowContext(r.Context(), `SELECT EXISTS(SELECT 1 FROM design_assets WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)`, customisation.AssetID, middleware.GetUserID(r)).Scan(&exists); err != nil {
+		if err := db.QueryRowContext(r.Context(), `SELECT EXISTS(SELECT 1 FROM design_assets WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)`, customisation.AssetID, middleware.GetUserID(r)).Scan(&exists); err != nil {
 			return err
 		}
 		if !exists {
@@ -116,7 +156,7 @@ type canonicalCustomerDelivery struct {
 // validateCustomerDelivery accepts a customer delivery order only when its referenced saved location belongs to the
 // authenticated customer and the requested store has an active matching city-level zone. Client address text and
 // coordinates are never persisted; the order receives a canonical server snapshot instead.
-func (h *Handler) validateCustomerDelivery(r *http.Request, req *PlaceOrderRequest) error {
+func validateCustomerDelivery(r *http.Request, db *sql.DB, req *PlaceOrderRequest) error {
 	if len(req.DeliveryAddress) == 0 || string(req.DeliveryAddress) == "null" {
 		return nil
 	}
@@ -124,7 +164,11 @@ func (h *Handler) validateCustomerDelivery(r *http.Request, req *PlaceOrderReque
 	if err := json.Unmarshal(req.DeliveryAddress, &input); err != nil {
 		return fmt.Errorf("delivery_address must be valid JSON")
 	}
-	switch strings.ToLower(strings.TrimSpace(input.Method)) {
+	method := input.Method
+	if method == "" {
+		method = string(req.FulfilmentMethod)
+	}
+	switch strings.ToLower(strings.TrimSpace(method)) {
 	case "", "pickup":
 		canonical, err := json.Marshal(map[string]string{"method": "pickup", "store_id": req.StoreID})
 		if err != nil {
@@ -142,7 +186,7 @@ func (h *Handler) validateCustomerDelivery(r *http.Request, req *PlaceOrderReque
 
 	var snapshot canonicalCustomerDelivery
 	var latitude, longitude sql.NullFloat64
-	err := h.db.QueryRowContext(r.Context(), `
+	err := db.QueryRowContext(r.Context(), `
 		SELECT id, label, recipient_name, recipient_phone, address_line1, COALESCE(address_line2, ''), city, country, latitude, longitude
 		FROM customer_delivery_locations
 		WHERE id=$1 AND customer_id=$2`, input.LocationID, req.CustomerID).
@@ -154,7 +198,7 @@ func (h *Handler) validateCustomerDelivery(r *http.Request, req *PlaceOrderReque
 		return err
 	}
 	var covered bool
-	if err := h.db.QueryRowContext(r.Context(), `
+	if err := db.QueryRowContext(r.Context(), `
 		SELECT EXISTS(
 			SELECT 1 FROM store_delivery_zones
 			WHERE store_id=$1 AND is_active=true AND LOWER(city)=LOWER($2) AND LOWER(country)=LOWER($3)
@@ -195,9 +239,15 @@ func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) getOrderByNumber(w http.ResponseWriter, r *http.Request) {
 	number := chi.URLParam(r, "number")
-	o, err := h.service.GetOrderByNumber(r.Context(), number)
+	o, err := h.service.GetOrderByNumber(r.Context(), r.URL.Query().Get("store_id"), number)
 	if err != nil {
-		respond(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		code := http.StatusNotFound
+		if errors.Is(err, ErrAmbiguousOrderNumber) {
+			code = http.StatusConflict
+		} else if strings.Contains(err.Error(), "invalid store_id") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": err.Error()})
 		return
 	}
 	if !h.requireCustomerOrderAccess(w, r, o) {
@@ -213,13 +263,19 @@ func (h *Handler) updateStatus(w http.ResponseWriter, r *http.Request) {
 		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	req.Actor = requestActor(r)
 	o, err := h.service.UpdateStatus(r.Context(), id, req)
 	if err != nil {
 		code := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "cannot transition") {
+		if errors.Is(err, ErrStatusConflict) {
+			code = http.StatusConflict
+		} else if strings.Contains(err.Error(), "cannot transition") || errors.Is(err, ErrNotCancellable) ||
+			errors.Is(err, ErrHandoverRequired) {
 			code = http.StatusUnprocessableEntity
 		} else if strings.Contains(err.Error(), "not found") {
 			code = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "must not exceed") || strings.Contains(err.Error(), "invalid") {
+			code = http.StatusBadRequest
 		}
 		respond(w, code, map[string]string{"error": err.Error()})
 		return
@@ -237,12 +293,15 @@ func (h *Handler) cancelOrder(w http.ResponseWriter, r *http.Request) {
 	if !h.requireCustomerOrderAccess(w, r, o) {
 		return
 	}
-	if err := h.service.CancelOrder(r.Context(), id); err != nil {
+	req := CancelOrderRequest{Reason: r.URL.Query().Get("reason"), Actor: requestActor(r)}
+	if err := h.service.CancelOrder(r.Context(), id, req); err != nil {
 		code := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "only PENDING") {
+		if errors.Is(err, ErrNotCancellable) {
 			code = http.StatusUnprocessableEntity
 		} else if strings.Contains(err.Error(), "not found") {
 			code = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "must not exceed") || strings.Contains(err.Error(), "invalid") {
+			code = http.StatusBadRequest
 		}
 		respond(w, code, map[string]string{"error": err.Error()})
 		return
@@ -250,6 +309,116 @@ func (h *Handler) cancelOrder(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, map[string]string{"status": "order cancelled"})
 }
 
+// amendOrder replaces the lines of a PENDING or CONFIRMED order. Customers may only amend their own orders.
+func (h *Handler) amendOrder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	o, err := h.service.GetOrder(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.requireCustomerOrderAccess(w, r, o) {
+		return
+	}
+	var req AmendOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		lines := make([]CartItem, len(req.Items))
+		for i, line := range req.Items {
+			lines[i] = CartItem{VendorStoreProductID: line.VendorStoreProductID, Quantity: line.Quantity, Customisation: line.Customisation}
+		}
+		if err := validateCustomerAssets(r, h.db, lines); err != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	req.Actor = requestActor(r)
+	amended, err := h.service.AmendOrder(r.Context(), id, req)
+	if err != nil {
+		var oos *OutOfStockError
+		var rejection *promo.RejectionError
+		var invalid *CustomisationError
+		switch {
+		case errors.As(err, &oos):
+			respond(w, http.StatusConflict, map[string]interface{}{
+				"error":                   oos.Error(),
+				"vendor_store_product_id": oos.VendorStoreProductID,
+				"requested":               oos.Requested,
+				"available":               oos.Available,
+			})
+		case errors.As(err, &invalid):
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": invalid.Error(), "lines": invalid.Lines})
+		case errors.As(err, &rejection):
+			respond(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":      rejection.Error(),
+				"promo_code": rejection.Code,
+				"reason":     rejection.Reason,
+				"detail":     rejection.Detail,
+			})
+		case errors.Is(err, ErrVersionConflict):
+			respond(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, ErrNotAmendable) || errors.Is(err, ErrProductNotInStore) ||
+			strings.Contains(err.Error(), "unavailable") || strings.Contains(err.Error(), "not enabled"):
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		case strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "must") ||
+			strings.Contains(err.Error(), "requires") || strings.Contains(err.Error(), "not found on this order") ||
+			strings.Contains(err.Error(), "more than once"):
+			respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+	respond(w, http.StatusOK, amended)
+}
+
+// listVersions returns every version of the order, from placement onwards.
+func (h *Handler) listVersions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	o, err := h.service.GetOrder(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.requireCustomerOrderAccess(w, r, o) {
+		return
+	}
+	versions, err := h.service.ListVersions(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if versions == nil {
+		versions = make([]*OrderVersion, 0)
+	}
+	respond(w, http.StatusOK, versions)
+}
+
+// getTimeline returns the order's status history. Customers may only read the timeline of their own orders.
+func (h *Handler) getTimeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	o, err := h.service.GetOrder(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.requireCustomerOrderAccess(w, r, o) {
+		return
+	}
+	events, err := h.service.GetTimeline(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if events == nil {
+		events = make([]*StatusEvent, 0)
+	}
+	respond(w, http.StatusOK, map[string]interface{}{"order_id": o.ID, "status": o.Status, "events": events})
+}
+
 func (h *Handler) listStoreOrders(w http.ResponseWriter, r *http.Request) {
 	storeID := chi.URLParam(r, "store_id")
 	status := r.URL.Query().Get("status")
@@ -281,6 +450,115 @@ func (h *Handler) listCustomerOrders(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, orders)
 }
 
+// searchStoreOrders pages through a store's orders. Customers must use their own customer search instead.
+func (h *Handler) searchStoreOrders(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	search, err := parseOrderSearch(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	search.StoreID = chi.URLParam(r, "store_id")
+	search.CustomerID = r.URL.Query().Get("customer_id")
+	h.respondSearch(w, r, search)
+}
+
+func (h *Handler) searchCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	customerID := chi.URLParam(r, "customer_id")
+	if middleware.GetRole(r) == middleware.RoleCustomer && customerID != middleware.GetUserID(r) {
+		respond(w, http.StatusForbidden, map[string]string{"error": "customer scope does not match authenticated user"})
+		return
+	}
+	search, err := parseOrderSearch(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	search.CustomerID = customerID
+	search.StoreID = r.URL.Query().Get("store_id")
+	h.respondSearch(w, r, search)
+}
+
+func (h *Handler) respondSearch(w http.ResponseWriter, r *http.Request, search OrderSearch) {
+	page, err := h.service.SearchOrders(r.Context(), search)
+	if err != nil {
+		msg := err.Error()
+		code := http.StatusInternalServerError
+		if strings.Contains(msg, "invalid") || strings.Contains(msg, "required") ||
+			strings.Contains(msg, "must") || strings.Contains(msg, "cannot") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": msg})
+		return
+	}
+	respond(w, http.StatusOK, page)
+}
+
+// parseOrderSearch reads the shared search filters from the query string. status may be repeated or comma
+// separated. from and to take RFC 3339 timestamps or dates; a date in to includes that whole day.
+func parseOrderSearch(r *http.Request) (OrderSearch, error) {
+	q := r.URL.Query()
+	search := OrderSearch{
+		Channel:      OrderChannel(q.Get("channel")),
+		NumberPrefix: q.Get("number"),
+		Cursor:       q.Get("cursor"),
+	}
+	for _, value := range q["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				search.Statuses = append(search.Statuses, OrderStatus(status))
+			}
+		}
+	}
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+		endDay bool
+	}{{"from", &search.From, false}, {"to", &search.To, true}} {
+		value := q.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			day, dayErr := time.Parse("2006-01-02", value)
+			if dayErr != nil {
+				return search, fmt.Errorf("invalid %s: use an RFC 3339 timestamp or YYYY-MM-DD", bound.name)
+			}
+			if bound.endDay {
+				day = day.AddDate(0, 0, 1)
+			}
+			t = day
+		}
+		*bound.target = &t
+	}
+	for _, bound := range []struct {
+		name   string
+		target **money.Amount
+	}{{"min_total", &search.MinTotal}, {"max_total", &search.MaxTotal}} {
+		value := q.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		amount, err := money.Parse(value)
+		if err != nil {
+			return search, fmt.Errorf("invalid %s: %w", bound.name, err)
+		}
+		*bound.target = &amount
+	}
+	if value := q.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return search, fmt.Errorf("invalid limit: %w", err)
+		}
+		search.Limit = limit
+	}
+	return search, nil
+}
+
 func (h *Handler) requireCustomerOrderAccess(w http.ResponseWriter, r *http.Request, o *Order) bool {
 	if middleware.GetRole(r) != middleware.RoleCustomer {
 		return true
@@ -292,6 +570,11 @@ func (h *Handler) requireCustomerOrderAccess(w http.ResponseWriter, r *http.Requ
 	return true
 }
 
+// requestActor identifies the authenticated caller for the order's status history.
+func requestActor(r *http.Request) Actor {
+	return Actor{ID: middleware.GetUserID(r), Role: string(middleware.GetRole(r))}
+}
+
 func respond(w http.ResponseWriter, status int, body interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -2,8 +2,14 @@ package order
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -30,37 +36,82 @@ const (
 
 // Order represents a customer's print order at a store.
 type Order struct {
-	ID              uuid.UUID       `json:"id"`
-	StoreID         uuid.UUID       `json:"store_id"`
-	CustomerID      *uuid.UUID      `json:"customer_id,omitempty"` // nil for walk-in POS orders
-	OrderNumber     string          `json:"order_number"`
-	Status          OrderStatus     `json:"status"`
-	Channel         OrderChannel    `json:"channel"`
-	Subtotal        float64         `json:"subtotal"`
-	Discount        float64         `json:"discount"`
-	Tax             float64         `json:"tax"`
-	Total           float64         `json:"total"`
-	Currency        string          `json:"currency"`
-	Notes           string          `json:"notes,omitempty"`
-	DeliveryAddress json.RawMessage `json:"delivery_address,omitempty"`
-	Metadata        json.RawMessage `json:"metadata,omitempty"`
-	IdempotencyKey  string          `json:"-"`
-	Items           []*OrderItem    `json:"items,omitempty"`
-	CreatedAt       time.Time       `json:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at"`
+	ID          uuid.UUID    `json:"id"`
+	StoreID     uuid.UUID    `json:"store_id"`
+	CustomerID  *uuid.UUID   `json:"customer_id,omitempty"` // nil for walk-in POS orders
+	OrderNumber string       `json:"order_number"`
+	Status      OrderStatus  `json:"status"`
+	Channel     OrderChannel `json:"channel"`
+	Subtotal    money.Amount `json:"subtotal"`
+	Discount    money.Amount `json:"discount"`
+	PromoCodeID *uuid.UUID   `json:"promo_code_id,omitempty"`
+	PromoCode   string       `json:"promo_code,omitempty"`
+	QuoteID     *uuid.UUID   `json:"quote_id,omitempty"`    // set when the order was placed by accepting a quote
+	ReprintOf   *uuid.UUID   `json:"reprint_of,omitempty"`  // set on the zero-charge reprint of a delivered order
+	CheckoutID  *uuid.UUID   `json:"checkout_id,omitempty"` // set when the order is one store's share of a multi-store checkout
+	Tax         money.Amount `json:"tax"`
+	Total       money.Amount `json:"total"`
+	Currency    string       `json:"currency"`
+	Notes       string       `json:"notes,omitempty"`
+	// FulfilmentMethod decides whether the order is collected against a pickup code or delivered to DeliveryAddress.
+	FulfilmentMethod FulfilmentMethod `json:"fulfilment_method"`
+	DeliveryAddress  json.RawMessage  `json:"delivery_address,omitempty"`
+	Metadata         json.RawMessage  `json:"metadata,omitempty"`
+	DueAt            *time.Time       `json:"due_at,omitempty"` // when the customer needs the order; routing plans production against it
+	Version          int              `json:"version"`          // 1 as placed, incremented by every amendment
+	// PricesIncludeTax, TaxExempt and TaxExemptionCertificate snapshot the tax treatment applied at checkout.
+	PricesIncludeTax        bool         `json:"prices_include_tax"`
+	TaxExempt               bool         `json:"tax_exempt"`
+	TaxExemptionCertificate string       `json:"tax_exemption_certificate,omitempty"`
+	IdempotencyKey          string       `json:"-"`
+	quotedAt                time.Time    // pricing version of the accepted quote, checked when it is claimed
+	Items                   []*OrderItem `json:"items,omitempty"`
+	CreatedAt               time.Time    `json:"created_at"`
+	UpdatedAt               time.Time    `json:"updated_at"`
 }
 
 // OrderItem is a single line item within an order.
 type OrderItem struct {
-	ID                   uuid.UUID       `json:"id"`
-	OrderID              uuid.UUID       `json:"order_id"`
-	VendorStoreProductID uuid.UUID       `json:"vendor_store_product_id"`
-	Quantity             int             `json:"quantity"`
-	UnitPrice            float64         `json:"unit_price"`
-	LineTotal            float64         `json:"line_total"`
-	Customisation        json.RawMessage `json:"customisation,omitempty"`
-	CreatedAt            time.Time       `json:"created_at"`
-	UpdatedAt            time.Time       `json:"updated_at"`
+	ID                   uuid.UUID    `json:"id"`
+	OrderID              uuid.UUID    `json:"order_id"`
+	VendorStoreProductID uuid.UUID    `json:"vendor_store_product_id"`
+	Quantity             int          `json:"quantity"`
+	UnitPrice            money.Amount `json:"unit_price"`
+	LineTotal            money.Amount `json:"line_total"`
+	// TaxableAmount is the line's share of the order after discount, net of VAT; TaxAmount is the VAT on it.
+	TaxClass      catalog.TaxClass `json:"tax_class"`
+	TaxRate       float64          `json:"tax_rate"`
+	TaxableAmount money.Amount     `json:"taxable_amount"`
+	TaxAmount     money.Amount     `json:"tax_amount"`
+	Customisation json.RawMessage  `json:"customisation,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// ProductPricing is the current price, availability, tax class, catalog category and option schema of a store
+// product at checkout. UnitPrice is the flat vendor price that PriceRules start from.
+type ProductPricing struct {
+	UnitPrice    money.Amount
+	PriceRules   inventory.PriceRules
+	Available    bool
+	TaxClass     catalog.TaxClass
+	Category     string
+	OptionSchema *catalog.OptionSchema
+}
+
+// LinePrice applies the vendor's quantity breaks and option modifiers to a line of quantity units.
+func (p *ProductPricing) LinePrice(quantity int, customisation json.RawMessage) (money.Amount, error) {
+	breakdown, err := p.PriceRules.Price(p.UnitPrice, quantity, customisation)
+	if err != nil {
+		return 0, err
+	}
+	return breakdown.UnitPrice, nil
+}
+
+// OrderNumbering is how the vendor owning a store numbers its orders. Prefix is never empty.
+type OrderNumbering struct {
+	Prefix string
+	Reset  vendor.OrderNumberReset
 }
 
 // CartItem is a transient struct used during checkout to describe what a customer wants.
@@ -72,17 +123,194 @@ type CartItem struct {
 
 // PlaceOrderRequest is the payload for creating a new order.
 type PlaceOrderRequest struct {
-	StoreID         string          `json:"store_id"`
-	CustomerID      string          `json:"customer_id,omitempty"` // optional for POS
-	Channel         string          `json:"channel"`
-	Items           []CartItem      `json:"items"`
-	Notes           string          `json:"notes,omitempty"`
-	DeliveryAddress json.RawMessage `json:"delivery_address,omitempty"`
-	Discount        float64         `json:"discount,omitempty"`
-	IdempotencyKey  string          `json:"-"`
+	StoreID    string     `json:"store_id"`
+	CustomerID string     `json:"customer_id,omitempty"` // optional for POS
+	Channel    string     `json:"channel"`
+	Items      []CartItem `json:"items"`
+	Notes      string     `json:"notes,omitempty"`
+	// FulfilmentMethod defaults to the method recorded in DeliveryAddress, and to PICKUP without one.
+	FulfilmentMethod FulfilmentMethod `json:"fulfilment_method,omitempty"`
+	DeliveryAddress  json.RawMessage  `json:"delivery_address,omitempty"`
+	PromoCode        string           `json:"promo_code,omitempty"`
+	DueAt            *time.Time       `json:"due_at,omitempty"`
+	IdempotencyKey   string           `json:"-"`
+	Actor            Actor            `json:"-"`
+}
+
+// AmendLine is one line of an amended order. A line with ItemID keeps its tax class and may change quantity or
+// customisation; omitting Customisation keeps the current one. An unchanged line keeps the price it was sold at; a
+// changed one is repriced at the product's current price for its new quantity and customisation, and a new
+// customisation is validated against the product's options. A line with only VendorStoreProductID is added at the
+// product's current price. Existing lines left out are removed.
+type AmendLine struct {
+	ItemID               string          `json:"item_id,omitempty"`
+	VendorStoreProductID string          `json:"vendor_store_product_id,omitempty"`
+	Quantity             int             `json:"quantity"`
+	Customisation        json.RawMessage `json:"customisation,omitempty"`
+}
+
+// AmendOrderRequest replaces the lines of a PENDING or CONFIRMED order. Version must be the order version the
+// caller is amending, so two concurrent edits cannot silently overwrite each other.
+type AmendOrderRequest struct {
+	Version int         `json:"version"`
+	Items   []AmendLine `json:"items"`
+	Reason  string      `json:"reason,omitempty"`
+	Actor   Actor       `json:"-"`
+}
+
+// ReprintLine selects a line of a delivered order, and how many of it, to print again.
+type ReprintLine struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ReprintRequest places a zero-charge copy of some lines of a DELIVERED order. A repeated IdempotencyKey returns
+// the reprint already placed with it.
+type ReprintRequest struct {
+	Items          []ReprintLine
+	IdempotencyKey string
+	Actor          Actor
+}
+
+// OrderVersion is a snapshot of an order's lines and totals as of one version. Version 1 is the order as placed.
+type OrderVersion struct {
+	ID        uuid.UUID    `json:"id"`
+	OrderID   uuid.UUID    `json:"order_id"`
+	Version   int          `json:"version"`
+	Subtotal  money.Amount `json:"subtotal"`
+	Discount  money.Amount `json:"discount"`
+	Tax       money.Amount `json:"tax"`
+	Total     money.Amount `json:"total"`
+	Items     []*OrderItem `json:"items"`
+	ActorID   *uuid.UUID   `json:"actor_id,omitempty"`
+	ActorRole string       `json:"actor_role,omitempty"`
+	Reason    string       `json:"reason,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// OrderSearch filters a store's or a customer's orders; at least one of StoreID and CustomerID is required. Zero
+// values leave a filter off. Results are newest first and paged with the cursor returned in OrderPage.NextCursor.
+type OrderSearch struct {
+	StoreID      string
+	CustomerID   string
+	Statuses     []OrderStatus
+	Channel      OrderChannel
+	From         *time.Time // inclusive
+	To           *time.Time // exclusive
+	NumberPrefix string
+	MinTotal     *money.Amount
+	MaxTotal     *money.Amount
+	Cursor       string
+	Limit        int
+}
+
+// OrderPage is one page of search results. Total counts every matching order, not only this page.
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	Total      int      `json:"total"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// OrderCursor is the keyset position of the last order on a page; the next page starts strictly after it.
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
 }
 
 // UpdateStatusRequest is the payload for advancing an order's status.
 type UpdateStatusRequest struct {
 	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Actor  Actor  `json:"-"`
+}
+
+// CancelOrderRequest carries the optional reason for a cancellation and who requested it.
+type CancelOrderRequest struct {
+	Reason string `json:"reason,omitempty"`
+	Actor  Actor  `json:"-"`
+}
+
+// Actor identifies the authenticated user behind an order change. Both fields are empty for system changes.
+type Actor struct {
+	ID   string
+	Role string
+}
+
+// StatusChange is what the repository records alongside a status transition.
+type StatusChange struct {
+	Actor  Actor
+	Reason string
+}
+
+// StatusEvent is one entry in an order's append-only status timeline. FromStatus is nil for placement.
+type StatusEvent struct {
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	FromStatus *OrderStatus `json:"from_status,omitempty"`
+	ToStatus   OrderStatus  `json:"to_status"`
+	ActorID    *uuid.UUID   `json:"actor_id,omitempty"`
+	ActorRole  string       `json:"actor_role,omitempty"`
+	Reason     string       `json:"reason,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// ErrNotCancellable is returned when an order has left the PENDING/CONFIRMED window by the time its row is locked.
+var ErrNotCancellable = errors.New("only PENDING or CONFIRMED orders can be cancelled")
+
+// ErrStatusConflict is returned when an order's status changed between reading it and locking its row, and the
+// requested transition is no longer allowed from the status it has now.
+var ErrStatusConflict = errors.New("order status changed concurrently; reload it and try again")
+
+// ErrNotAmendable is returned when an order has left the PENDING/CONFIRMED window by the time its row is locked.
+var ErrNotAmendable = errors.New("only PENDING or CONFIRMED orders can be amended")
+
+// ErrVersionConflict is returned when an amendment was prepared against an order version that is no longer current.
+var ErrVersionConflict = errors.New("order was amended concurrently; reload it and try again")
+
+// ErrAmbiguousOrderNumber is returned when an order number is looked up without a store and several stores have
+// issued it.
+var ErrAmbiguousOrderNumber = errors.New("several stores have issued this order number; pass store_id")
+
+// ErrNotReprintable is returned when a reprint is requested for an order that has not been delivered.
+var ErrNotReprintable = errors.New("only DELIVERED orders can be reprinted")
+
+// ErrProductNotInStore is wrapped by the error returned when a line's product is not sold by the order's store.
+var ErrProductNotInStore = errors.New("not found in this store")
+
+// ErrProductUnavailable is wrapped by the error returned when a line's product has been switched off by its store.
+var ErrProductUnavailable = errors.New("is currently unavailable")
+
+// OutOfStockError reports a line whose requested quantity exceeds the store's remaining stock.
+// It is returned by CreateOrder after the reservation transaction has been rolled back.
+type OutOfStockError struct {
+	VendorStoreProductID uuid.UUID `json:"vendor_store_product_id"`
+	Requested            int       `json:"requested"`
+	Available            int       `json:"available"`
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("product %s is out of stock (requested %d, available %d)",
+		e.VendorStoreProductID, e.Requested, e.Available)
+}
+
+// CustomisationError reports every line whose customisation does not satisfy its product's option schema, so the
+// storefront can flag all of them at once.
+type CustomisationError struct {
+	Lines []LineCustomisationError `json:"lines"`
+}
+
+// LineCustomisationError lists the rejected fields of one line. Line is the line's zero-based position in the request.
+type LineCustomisationError struct {
+	Line                 int                  `json:"line"`
+	VendorStoreProductID string               `json:"vendor_store_product_id"`
+	Fields               []catalog.FieldError `json:"fields"`
+}
+
+func (e *CustomisationError) Error() string {
+	if len(e.Lines) == 1 && len(e.Lines[0].Fields) == 1 {
+		field := e.Lines[0].Fields[0]
+		return fmt.Sprintf("invalid customisation for product %s: %s %s",
+			e.Lines[0].VendorStoreProductID, field.Field, field.Message)
+	}
+	return fmt.Sprintf("customisation is invalid on %d line(s)", len(e.Lines))
 }
@@ -3,56 +3,244 @@ package order
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/georgemunganga/printa-backend/internal/outbox"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type postgresRepo struct{ db *sql.DB }
 
+// orderColumns is the select list shared by scanOrder and queryOrders.
+const orderColumns = `id,store_id,customer_id,order_number,status,channel,
+		       subtotal_minor,discount_minor,tax_minor,total_minor,currency,notes,delivery_address,metadata,
+		       prices_include_tax,tax_exempt,COALESCE(tax_exemption_certificate,''),promo_code_id,COALESCE(promo_code,''),
+		       quote_id,reprint_of_order_id,checkout_id,fulfilment_method,due_at,version,created_at,updated_at`
+
 func NewPostgresRepository(db *sql.DB) Repository { return &postgresRepo{db: db} }
 
-// CreateOrder inserts the order and all its items inside a single transaction.
-func (r *postgresRepo) CreateOrder(ctx context.Context, o *Order) error {
+// CreateOrder inserts the order and all its items inside a single transaction. Every product row is locked with
+// SELECT ... FOR UPDATE before the order is written, so two checkouts can never both claim the last unit.
+func (r *postgresRepo) CreateOrder(ctx context.Context, o *Order, change StatusChange) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := insertOrder(ctx, tx, o, change); err != nil {
+		var oos *OutOfStockError
+		if errors.As(err, &oos) {
+			// Release the row locks before recording the rejection on a separate connection.
+			_ = tx.Rollback()
+			r.recordOversell(ctx, o.StoreID, oos)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertOrder reserves stock for o and writes the order, its items, any quote acceptance and promo redemption,
+// and its first status event and version inside tx.
+func insertOrder(ctx context.Context, tx *sql.Tx, o *Order, change StatusChange) error {
+	reservations, err := reserveStock(ctx, tx, o)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO orders
 		  (id, store_id, customer_id, order_number, status, channel,
-		   subtotal, discount, tax, total, currency, notes, delivery_address, metadata, idempotency_key)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,NULLIF($15,''))`,
+		   subtotal_minor, discount_minor, tax_minor, total_minor, currency, notes, delivery_address, metadata, idempotency_key,
+		   prices_include_tax, tax_exempt, tax_exemption_certificate, promo_code_id, promo_code, quote_id,
+		   reprint_of_order_id, checkout_id, fulfilment_method, due_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,NULLIF($15,''),$16,$17,NULLIF($18,''),$19,NULLIF($20,''),$21,$22,$23,$24,$25)`,
 		o.ID, o.StoreID, o.CustomerID, o.OrderNumber, o.Status, o.Channel,
 		o.Subtotal, o.Discount, o.Tax, o.Total, o.Currency, o.Notes,
-		nullableJSON(o.DeliveryAddress), nullableJSON(o.Metadata), o.IdempotencyKey)
+		nullableJSON(o.DeliveryAddress), nullableJSON(o.Metadata), o.IdempotencyKey,
+		o.PricesIncludeTax, o.TaxExempt, o.TaxExemptionCertificate, o.PromoCodeID, o.PromoCode, o.QuoteID,
+		o.ReprintOf, o.CheckoutID, o.FulfilmentMethod, o.DueAt)
 	if err != nil {
 		return fmt.Errorf("insert order: %w", err)
 	}
 
-	for _, item := range o.Items {
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO order_items
-			  (id, order_id, vendor_store_product_id, quantity, unit_price, line_total, customisation)
-			VALUES ($1,$2,$3,$4,$5,$6,$7)`,
-			item.ID, o.ID, item.VendorStoreProductID,
-			item.Quantity, item.UnitPrice, item.LineTotal,
-			nullableJSON(item.Customisation))
-		if err != nil {
-			return fmt.Errorf("insert order_item: %w", err)
+	if err := writeItems(ctx, tx, o); err != nil {
+		return err
+	}
+
+	if err := insertStockMovements(ctx, tx, o.StoreID, o.ID, inventory.StockMovementReserved, reservations); err != nil {
+		return err
+	}
+	if o.QuoteID != nil {
+		if err := acceptQuote(ctx, tx, *o.QuoteID, o.ID, o.quotedAt); err != nil {
+			return err
+		}
+	}
+	if o.PromoCodeID != nil {
+		// The quote was advisory; the redemption re-checks limits with the promo row locked.
+		if err := promo.Redeem(ctx, tx, &promo.Redemption{
+			PromoCodeID: *o.PromoCodeID, OrderID: o.ID, CustomerID: o.CustomerID, Discount: o.Discount,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := insertStatusEvent(ctx, tx, o.ID, "", o.Status, change); err != nil {
+		return err
+	}
+	return insertVersion(ctx, tx, o.ID, change)
+}
+
+// AmendOrder locks the order row before anything else, so it serialises with CancelOrder and UpdateStatus. Stock
+// moves by the difference between what the amended lines need and what the ledger says the order already holds.
+func (r *postgresRepo) AmendOrder(ctx context.Context, o *Order, expectedVersion int, change StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status OrderStatus
+	var version int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status, version FROM orders WHERE id=$1 FOR UPDATE`, o.ID).Scan(&status, &version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("order not found: %w", err)
 		}
+		return err
+	}
+	if status != StatusPending && status != StatusConfirmed {
+		return fmt.Errorf("%w (current: %s)", ErrNotAmendable, status)
+	}
+	if version != expectedVersion {
+		return fmt.Errorf("%w (amending version %d, current is %d)", ErrVersionConflict, expectedVersion, version)
+	}
+
+	held, err := heldStock(ctx, tx, o.ID)
+	if err != nil {
+		return err
+	}
+	heldQuantities := make(map[uuid.UUID]int, len(held))
+	for _, h := range held {
+		heldQuantities[h.productID] = h.quantity
+	}
+	reserved, released, err := adjustStock(ctx, tx, o.StoreID, orderQuantities(o), heldQuantities)
+	if err != nil {
+		var oos *OutOfStockError
+		if errors.As(err, &oos) {
+			_ = tx.Rollback()
+			r.recordOversell(ctx, o.StoreID, oos)
+		}
+		return err
+	}
+	if err := insertStockMovements(ctx, tx, o.StoreID, o.ID, inventory.StockMovementReserved, reserved); err != nil {
+		return err
+	}
+	if err := insertStockMovements(ctx, tx, o.StoreID, o.ID, inventory.StockMovementReleased, released); err != nil {
+		return err
 	}
 
+	keep := make([]string, len(o.Items))
+	for i, item := range o.Items {
+		keep[i] = item.ID.String()
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM order_items WHERE order_id=$1 AND id <> ALL($2::uuid[])`, o.ID, pq.Array(keep)); err != nil {
+		return fmt.Errorf("remove order items: %w", err)
+	}
+	if err := writeItems(ctx, tx, o); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE orders
+		SET subtotal_minor=$1, discount_minor=$2, tax_minor=$3, total_minor=$4, version=$5, updated_at=NOW()
+		WHERE id=$6`,
+		o.Subtotal, o.Discount, o.Tax, o.Total, o.Version, o.ID); err != nil {
+		return err
+	}
+	if o.PromoCodeID != nil {
+		if err := promo.UpdateDiscount(ctx, tx, o.ID, o.Discount); err != nil {
+			return err
+		}
+	}
+	if err := insertVersion(ctx, tx, o.ID, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelOrder locks the order row, re-checks that it is still cancellable, and returns whatever stock the order
+// still holds. Releases are derived from the stock ledger rather than order_items so orders placed before
+// reservations existed, or already released, never inflate stock.
+func (r *postgresRepo) CancelOrder(ctx context.Context, id string, change StatusChange) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var storeID uuid.UUID
+	var status OrderStatus
+	if err := tx.QueryRowContext(ctx,
+		`SELECT store_id, status FROM orders WHERE id=$1 FOR UPDATE`, uid).Scan(&storeID, &status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("order not found: %w", err)
+		}
+		return err
+	}
+	if status != StatusPending && status != StatusConfirmed {
+		return fmt.Errorf("%w (current: %s)", ErrNotCancellable, status)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status=$1, updated_at=NOW() WHERE id=$2`, StatusCancelled, uid); err != nil {
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, uid, status, StatusCancelled, change); err != nil {
+		return err
+	}
+	if err := promo.Release(ctx, tx, uid); err != nil {
+		return err
+	}
+
+	held, err := heldStock(ctx, tx, uid)
+	if err != nil {
+		return err
+	}
+	releases := make([]stockReservation, 0, len(held))
+	for _, h := range held {
+		var stockAfter int
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE vendor_store_products SET stock_quantity = stock_quantity + $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING stock_quantity`, h.quantity, h.productID).Scan(&stockAfter); err != nil {
+			return fmt.Errorf("release stock for product %s: %w", h.productID, err)
+		}
+		h.stockAfter = stockAfter
+		releases = append(releases, h)
+	}
+	if err := insertStockMovements(ctx, tx, storeID, uid, inventory.StockMovementReleased, releases); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
 func (r *postgresRepo) GetByIdempotencyKey(ctx context.Context, key string) (*Order, error) {
 	o, err := r.scanOrder(r.db.QueryRowContext(ctx, `
-		SELECT id,store_id,customer_id,order_number,status,channel,
-		       subtotal,discount,tax,total,currency,notes,delivery_address,metadata,created_at,updated_at
+		SELECT `+orderColumns+`
 		FROM orders WHERE idempotency_key=$1`, key))
 	if err != nil {
 		return nil, err
@@ -67,8 +255,7 @@ func (r *postgresRepo) GetOrderByID(ctx context.Context, id string) (*Order, err
 		return nil, err
 	}
 	o, err := r.scanOrder(r.db.QueryRowContext(ctx, `
-		SELECT id,store_id,customer_id,order_number,status,channel,
-		       subtotal,discount,tax,total,currency,notes,delivery_address,metadata,created_at,updated_at
+		SELECT `+orderColumns+`
 		FROM orders WHERE id=$1`, uid))
 	if err != nil {
 		return nil, err
@@ -77,11 +264,19 @@ func (r *postgresRepo) GetOrderByID(ctx context.Context, id string) (*Order, err
 	return o, err
 }
 
-func (r *postgresRepo) GetOrderByNumber(ctx context.Context, orderNumber string) (*Order, error) {
+func (r *postgresRepo) GetOrderByNumber(ctx context.Context, storeID, orderNumber string) (*Order, error) {
+	var matches int
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM orders WHERE order_number=$1 AND ($2 = '' OR store_id::text=$2)`,
+		orderNumber, storeID).Scan(&matches); err != nil {
+		return nil, err
+	}
+	if matches > 1 {
+		return nil, ErrAmbiguousOrderNumber
+	}
 	o, err := r.scanOrder(r.db.QueryRowContext(ctx, `
-		SELECT id,store_id,customer_id,order_number,status,channel,
-		       subtotal,discount,tax,total,currency,notes,delivery_address,metadata,created_at,updated_at
-		FROM orders WHERE order_number=$1`, orderNumber))
+		SELECT `+orderColumns+`
+		FROM orders WHERE order_number=$1 AND ($2 = '' OR store_id::text=$2)`, orderNumber, storeID))
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +285,7 @@ func (r *postgresRepo) GetOrderByNumber(ctx context.Context, orderNumber string)
 }
 
 func (r *postgresRepo) ListOrdersByStore(ctx context.Context, storeID string, status string) ([]*Order, error) {
-	query := `SELECT id,store_id,customer_id,order_number,status,channel,
-	                 subtotal,discount,tax,total,currency,notes,delivery_address,metadata,created_at,updated_at
+	query := `SELECT ` + orderColumns + `
 	          FROM orders WHERE store_id=$1`
 	args := []interface{}{storeID}
 	if status != "" {
@@ -104,37 +298,537 @@ func (r *postgresRepo) ListOrdersByStore(ctx context.Context, storeID string, st
 
 func (r *postgresRepo) ListOrdersByCustomer(ctx context.Context, customerID string) ([]*Order, error) {
 	return r.queryOrders(ctx, `
-		SELECT id,store_id,customer_id,order_number,status,channel,
-		       subtotal,discount,tax,total,currency,notes,delivery_address,metadata,created_at,updated_at
+		SELECT `+orderColumns+`
 		FROM orders WHERE customer_id=$1 ORDER BY created_at DESC`, customerID)
 }
 
-func (r *postgresRepo) UpdateStatus(ctx context.Context, id string, status OrderStatus) error {
-	_, err := r.db.ExecContext(ctx,
+// SearchOrders builds the filter once and uses it for both the count and the page. The page is read by keyset on
+// (created_at, id), so deep pages cost the same as the first one.
+func (r *postgresRepo) SearchOrders(ctx context.Context, search OrderSearch, after *OrderCursor) ([]*Order, int, error) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if search.StoreID != "" {
+		where = append(where, "store_id="+arg(search.StoreID))
+	}
+	if search.CustomerID != "" {
+		where = append(where, "customer_id="+arg(search.CustomerID))
+	}
+	if len(search.Statuses) > 0 {
+		statuses := make([]string, len(search.Statuses))
+		for i, status := range search.Statuses {
+			statuses[i] = string(status)
+		}
+		where = append(where, "status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	if search.Channel != "" {
+		where = append(where, "channel="+arg(search.Channel))
+	}
+	if search.From != nil {
+		where = append(where, "created_at >= "+arg(*search.From))
+	}
+	if search.To != nil {
+		where = append(where, "created_at < "+arg(*search.To))
+	}
+	if search.NumberPrefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(search.NumberPrefix)
+		where = append(where, "order_number LIKE "+arg(escaped+"%"))
+	}
+	if search.MinTotal != nil {
+		where = append(where, "total_minor >= "+arg(*search.MinTotal))
+	}
+	if search.MaxTotal != nil {
+		where = append(where, "total_minor <= "+arg(*search.MaxTotal))
+	}
+	filter := strings.Join(where, " AND ")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders WHERE `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if after != nil {
+		filter += " AND (created_at, id) < (" + arg(after.CreatedAt) + ", " + arg(after.ID) + ")"
+	}
+	orders, err := r.queryOrders(ctx, `
+		SELECT `+orderColumns+`
+		FROM orders WHERE `+filter+`
+		ORDER BY created_at DESC, id DESC LIMIT `+arg(search.Limit), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// UpdateStatus locks the order row so the recorded from_status is the one actually replaced, even when two
+// transitions race.
+func (r *postgresRepo) UpdateStatus(ctx context.Context, id string, status OrderStatus, change StatusChange) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current OrderStatus
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status FROM orders WHERE id=$1 FOR UPDATE`, uid).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("order not found: %w", err)
+		}
+		return err
+	}
+	// The service checked the transition against the status it read; another request may have moved the order since.
+	if !canTransition(current, status) {
+		return fmt.Errorf("%w: the order is now %s", ErrStatusConflict, current)
+	}
+	if _, err := tx.ExecContext(ctx,
 		`UPDATE orders SET status=$1, updated_at=$2 WHERE id=$3`,
-		status, time.Now(), id)
-	return err
+		status, time.Now(), uid); err != nil {
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, uid, current, status, change); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *postgresRepo) GetProductPrice(ctx context.Context, storeID, vendorStoreProductID string) (float64, bool, error) {
-	var price float64
-	var available bool
-	err := r.db.QueryRowContext(ctx,
-		`SELECT vendor_price, is_available FROM vendor_store_products WHERE id=$1 AND store_id=$2`,
-		vendorStoreProductID, storeID).Scan(&price, &available)
-	return price, available, err
+// AdvanceStatus is UpdateStatus guarded by the expected current status, checked with the order row locked.
+func (r *postgresRepo) AdvanceStatus(ctx context.Context, id string, from, to OrderStatus, change StatusChange) (bool, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return false, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current OrderStatus
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status FROM orders WHERE id=$1 FOR UPDATE`, uid).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("order not found: %w", err)
+		}
+		return false, err
+	}
+	if current != from {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status=$1, updated_at=$2 WHERE id=$3`,
+		to, time.Now(), uid); err != nil {
+		return false, err
+	}
+	if err := insertStatusEvent(ctx, tx, uid, from, to, change); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *postgresRepo) ListStatusEvents(ctx context.Context, orderID string) ([]*StatusEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, order_id, from_status, to_status, actor_id, COALESCE(actor_role,''), COALESCE(reason,''), created_at
+		FROM order_status_events WHERE order_id=$1 ORDER BY created_at ASC, id ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*StatusEvent
+	for rows.Next() {
+		e := &StatusEvent{}
+		var fromStatus, actorID sql.NullString
+		if err := rows.Scan(&e.ID, &e.OrderID, &fromStatus, &e.ToStatus, &actorID, &e.ActorRole, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if fromStatus.Valid {
+			from := OrderStatus(fromStatus.String)
+			e.FromStatus = &from
+		}
+		if actorID.Valid {
+			uid, _ := uuid.Parse(actorID.String)
+			e.ActorID = &uid
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *postgresRepo) ListVersions(ctx context.Context, orderID string) ([]*OrderVersion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, order_id, version, subtotal_minor, discount_minor, tax_minor, total_minor, items,
+		       actor_id, COALESCE(actor_role,''), COALESCE(reason,''), created_at
+		FROM order_versions WHERE order_id=$1 ORDER BY version ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var versions []*OrderVersion
+	for rows.Next() {
+		v := &OrderVersion{}
+		var items []byte
+		var actorID uuid.NullUUID
+		if err := rows.Scan(&v.ID, &v.OrderID, &v.Version, &v.Subtotal, &v.Discount, &v.Tax, &v.Total, &items,
+			&actorID, &v.ActorRole, &v.Reason, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			v.ActorID = &actorID.UUID
+		}
+		if v.Items, err = decodeVersionItems(items); err != nil {
+			return nil, fmt.Errorf("decode order version %d: %w", v.Version, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *postgresRepo) GetProductPricing(ctx context.Context, storeID, vendorStoreProductID string) (*ProductPricing, error) {
+	p := &ProductPricing{}
+	var schema, rules []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT vsp.vendor_price, vsp.price_rules, vsp.is_available, pp.tax_class, pp.category, pp.option_schema
+		FROM vendor_store_products vsp
+		JOIN platform_products pp ON pp.id = vsp.platform_product_id
+		WHERE vsp.id=$1 AND vsp.store_id=$2`,
+		vendorStoreProductID, storeID).Scan(&p.UnitPrice, &rules, &p.Available, &p.TaxClass, &p.Category, &schema)
+	if err != nil {
+		return nil, err
+	}
+	if p.PriceRules, err = inventory.DecodePriceRules(rules); err != nil {
+		return nil, err
+	}
+	if p.OptionSchema, err = catalog.DecodeOptionSchema(schema); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *postgresRepo) DesignAssetOwned(ctx context.Context, assetID, ownerID string) (bool, error) {
+	var owned bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM design_assets WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)`,
+		assetID, ownerID).Scan(&owned)
+	return owned, err
+}
+
+func (r *postgresRepo) GetTaxProfile(ctx context.Context, storeID, customerID string) (*TaxProfile, error) {
+	profile := &TaxProfile{}
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT prices_include_tax FROM stores WHERE id=$1`, storeID).Scan(&profile.PricesIncludeTax); err != nil {
+		return nil, err
+	}
+	if customerID == "" {
+		return profile, nil
+	}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT certificate_number FROM customer_tax_exemptions
+		WHERE customer_id=$1 AND (expires_at IS NULL OR expires_at > NOW())`,
+		customerID).Scan(&profile.ExemptionCertificate)
+	if err == sql.ErrNoRows {
+		return profile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.CustomerExempt = true
+	return profile, nil
+}
+
+func (r *postgresRepo) GetOrderNumbering(ctx context.Context, storeID string) (*OrderNumbering, error) {
+	n := &OrderNumbering{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(v.order_number_prefix, $2), v.order_number_reset
+		FROM stores s JOIN vendors v ON v.id = s.vendor_id
+		WHERE s.id=$1`, storeID, vendor.DefaultOrderNumberPrefix).Scan(&n.Prefix, &n.Reset)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// NextOrderSequence runs outside any order transaction: the counter row is locked only for this one statement, so
+// checkouts at a busy store do not queue behind each other. An order that later fails leaves a gap in its series.
+// The scan of existing numbers only runs when the period's counter is created; everything issued after that comes
+// from the counter. Legacy numbers ended in four hex characters, so only all-digit suffixes can collide.
+func (r *postgresRepo) NextOrderSequence(ctx context.Context, storeID, period, series string) (int64, error) {
+	var value int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO order_number_sequences (store_id, period, last_value)
+		VALUES ($1, $2, 1 + COALESCE((
+			SELECT MAX(substring(order_number FROM length($3) + 2)::BIGINT)
+			FROM orders
+			WHERE store_id = $1 AND order_number LIKE $3 || '-%'
+			  AND substring(order_number FROM length($3) + 2) ~ '^[0-9]{1,18}$'), 0))
+		ON CONFLICT (store_id, period) DO UPDATE
+		SET last_value = order_number_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value`, storeID, period, series).Scan(&value)
+	return value, err
 }
 
 // ── helpers ──────────────────────────────────────────────────────────────────
 
+// nextDocumentSequence bumps the platform-wide counter of a quote or checkout series, e.g. "QTE-20261016", in its
+// own statement. Like NextOrderSequence, a new counter starts above the highest all-digit number column of table
+// already carries in the series, so numbers issued before the counter existed are not issued again.
+func nextDocumentSequence(ctx context.Context, db *sql.DB, table, column, series string) (int64, error) {
+	var value int64
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO document_number_sequences (series, last_value)
+		VALUES ($1, 1 + COALESCE((
+			SELECT MAX(substring(`+column+` FROM length($1) + 2)::BIGINT)
+			FROM `+table+`
+			WHERE `+column+` LIKE $1 || '-%'
+			  AND substring(`+column+` FROM length($1) + 2) ~ '^[0-9]{1,18}$'), 0))
+		ON CONFLICT (series) DO UPDATE
+		SET last_value = document_number_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value`, series).Scan(&value)
+	return value, err
+}
+
+// stockReservation is the net quantity of one product moved by a single order operation.
+type stockReservation struct {
+	productID  uuid.UUID
+	quantity   int
+	stockAfter int
+}
+
+// reserveStock reserves the full quantity of every product in a new order.
+func reserveStock(ctx context.Context, tx *sql.Tx, o *Order) ([]stockReservation, error) {
+	reserved, _, err := adjustStock(ctx, tx, o.StoreID, orderQuantities(o), nil)
+	return reserved, err
+}
+
+// orderQuantities totals the order's lines per product, so repeated lines for one product are reserved together.
+func orderQuantities(o *Order) map[uuid.UUID]int {
+	quantities := make(map[uuid.UUID]int)
+	for _, item := range o.Items {
+		quantities[item.VendorStoreProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// adjustStock moves each product from the quantity the order holds to the quantity it wants. Products are locked in
+// a stable ID order, so carts that overlap cannot deadlock, and stock is only decremented once an increase is known
+// to fit. A shortfall is reported against everything the order would hold, counting what it already has.
+func adjustStock(ctx context.Context, tx *sql.Tx, storeID uuid.UUID, wanted, held map[uuid.UUID]int) (reserved, released []stockReservation, err error) {
+	ids := make([]uuid.UUID, 0, len(wanted)+len(held))
+	for id := range wanted {
+		ids = append(ids, id)
+	}
+	for id := range held {
+		if _, ok := wanted[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	for _, id := range ids {
+		delta := wanted[id] - held[id]
+		if delta == 0 {
+			continue
+		}
+		var stock int
+		err := tx.QueryRowContext(ctx, `
+			SELECT stock_quantity FROM vendor_store_products
+			WHERE id=$1 AND store_id=$2
+			FOR UPDATE`, id, storeID).Scan(&stock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lock stock for product %s: %w", id, err)
+		}
+		if stock < delta {
+			return nil, nil, &OutOfStockError{VendorStoreProductID: id, Requested: wanted[id], Available: stock + held[id]}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE vendor_store_products SET stock_quantity = stock_quantity - $1, updated_at = NOW()
+			WHERE id = $2`, delta, id); err != nil {
+			return nil, nil, fmt.Errorf("reserve stock for product %s: %w", id, err)
+		}
+		if delta > 0 {
+			reserved = append(reserved, stockReservation{productID: id, quantity: delta, stockAfter: stock - delta})
+		} else {
+			released = append(released, stockReservation{productID: id, quantity: -delta, stockAfter: stock - delta})
+		}
+	}
+	return reserved, released, nil
+}
+
+// writeItems inserts the order's lines, updating in place any line that already exists on the same order.
+func writeItems(ctx context.Context, tx *sql.Tx, o *Order) error {
+	for _, item := range o.Items {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_items
+			  (id, order_id, vendor_store_product_id, quantity, unit_price_minor, line_total_minor,
+			   tax_class, tax_rate, taxable_amount_minor, tax_amount_minor, customisation)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			ON CONFLICT (id) DO UPDATE
+			SET quantity=EXCLUDED.quantity, line_total_minor=EXCLUDED.line_total_minor,
+			    tax_rate=EXCLUDED.tax_rate, taxable_amount_minor=EXCLUDED.taxable_amount_minor,
+			    tax_amount_minor=EXCLUDED.tax_amount_minor, customisation=EXCLUDED.customisation, updated_at=NOW()
+			WHERE order_items.order_id = EXCLUDED.order_id`,
+			item.ID, o.ID, item.VendorStoreProductID,
+			item.Quantity, item.UnitPrice, item.LineTotal,
+			item.TaxClass, item.TaxRate, item.TaxableAmount, item.TaxAmount,
+			nullableJSON(item.Customisation)); err != nil {
+			return fmt.Errorf("write order_item: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertVersion snapshots the order's totals and its order_items rows, as just written in tx, under the order's
+// current version.
+func insertVersion(ctx context.Context, tx *sql.Tx, orderID uuid.UUID, change StatusChange) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_versions
+		  (order_id, version, subtotal_minor, discount_minor, tax_minor, total_minor, items, actor_id, actor_role, reason)
+		SELECT o.id, o.version, o.subtotal_minor, o.discount_minor, o.tax_minor, o.total_minor,
+		       COALESCE((SELECT jsonb_agg(to_jsonb(oi) ORDER BY oi.created_at, oi.id)
+		                 FROM order_items oi WHERE oi.order_id = o.id), '[]'::jsonb),
+		       NULLIF($2,'')::uuid, NULLIF($3,''), NULLIF($4,'')
+		FROM orders o WHERE o.id=$1`,
+		orderID, change.Actor.ID, change.Actor.Role, change.Reason); err != nil {
+		return fmt.Errorf("record order version: %w", err)
+	}
+	return nil
+}
+
+// versionItem is the shape of an order_items row inside an order_versions snapshot.
+type versionItem struct {
+	ID                   uuid.UUID        `json:"id"`
+	OrderID              uuid.UUID        `json:"order_id"`
+	VendorStoreProductID uuid.UUID        `json:"vendor_store_product_id"`
+	Quantity             int              `json:"quantity"`
+	UnitPrice            int64            `json:"unit_price_minor"`
+	LineTotal            int64            `json:"line_total_minor"`
+	TaxClass             catalog.TaxClass `json:"tax_class"`
+	TaxRate              float64          `json:"tax_rate"`
+	TaxableAmount        int64            `json:"taxable_amount_minor"`
+	TaxAmount            int64            `json:"tax_amount_minor"`
+	Customisation        json.RawMessage  `json:"customisation"`
+	CreatedAt            time.Time        `json:"created_at"`
+	UpdatedAt            time.Time        `json:"updated_at"`
+}
+
+func decodeVersionItems(data []byte) ([]*OrderItem, error) {
+	var rows []versionItem
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	items := make([]*OrderItem, len(rows))
+	for i, row := range rows {
+		items[i] = &OrderItem{
+			ID:                   row.ID,
+			OrderID:              row.OrderID,
+			VendorStoreProductID: row.VendorStoreProductID,
+			Quantity:             row.Quantity,
+			UnitPrice:            money.Amount(row.UnitPrice),
+			LineTotal:            money.Amount(row.LineTotal),
+			TaxClass:             row.TaxClass,
+			TaxRate:              row.TaxRate,
+			TaxableAmount:        money.Amount(row.TaxableAmount),
+			TaxAmount:            money.Amount(row.TaxAmount),
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+		}
+		if string(row.Customisation) != "null" {
+			items[i].Customisation = row.Customisation
+		}
+	}
+	return items, nil
+}
+
+// heldStock returns the quantity of each product an order has reserved and not yet released, in ID order.
+func heldStock(ctx context.Context, tx *sql.Tx, orderID uuid.UUID) ([]stockReservation, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT vendor_store_product_id,
+		       SUM(CASE WHEN movement_type = $2 THEN quantity ELSE -quantity END) AS held
+		FROM inventory_stock_movements
+		WHERE order_id = $1 AND movement_type IN ($2, $3)
+		GROUP BY vendor_store_product_id
+		HAVING SUM(CASE WHEN movement_type = $2 THEN quantity ELSE -quantity END) > 0
+		ORDER BY vendor_store_product_id`,
+		orderID, inventory.StockMovementReserved, inventory.StockMovementReleased)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var held []stockReservation
+	for rows.Next() {
+		var h stockReservation
+		if err := rows.Scan(&h.productID, &h.quantity); err != nil {
+			return nil, err
+		}
+		held = append(held, h)
+	}
+	return held, rows.Err()
+}
+
+func insertStockMovements(ctx context.Context, tx *sql.Tx, storeID, orderID uuid.UUID, movementType inventory.StockMovementType, moves []stockReservation) error {
+	for _, m := range moves {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO inventory_stock_movements
+			  (vendor_store_product_id, store_id, order_id, movement_type, quantity, stock_after)
+			VALUES ($1,$2,$3,$4,$5,$6)`,
+			m.productID, storeID, orderID, movementType, m.quantity, m.stockAfter); err != nil {
+			return fmt.Errorf("record stock movement: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertStatusEvent appends one transition to the order's status history. An empty from status marks placement.
+func insertStatusEvent(ctx context.Context, tx *sql.Tx, orderID uuid.UUID, from, to OrderStatus, change StatusChange) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_status_events (order_id, from_status, to_status, actor_id, actor_role, reason)
+		VALUES ($1, NULLIF($2,''), $3, NULLIF($4,'')::uuid, NULLIF($5,''), NULLIF($6,''))`,
+		orderID, from, to, change.Actor.ID, change.Actor.Role, change.Reason); err != nil {
+		return fmt.Errorf("record status event: %w", err)
+	}
+	// Confirmations and cancellations are picked up from the outbox, so the event commits or rolls back with the
+	// status.
+	switch to {
+	case StatusConfirmed:
+		if err := outbox.EnqueueTx(ctx, tx, "order", orderID, EventOrderConfirmed, OrderEvent{OrderID: orderID}); err != nil {
+			return fmt.Errorf("record order confirmed event: %w", err)
+		}
+	case StatusCancelled:
+		if err := outbox.EnqueueTx(ctx, tx, "order", orderID, EventOrderCancelled, OrderEvent{OrderID: orderID}); err != nil {
+			return fmt.Errorf("record order cancelled event: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordOversell logs a rejected reservation for the inventory ledger. It is best-effort: the customer-facing
+// out-of-stock error is what matters, and a failed audit write must not mask it.
+func (r *postgresRepo) recordOversell(ctx context.Context, storeID uuid.UUID, oos *OutOfStockError) {
+	_, _ = r.db.ExecContext(ctx, `
+		INSERT INTO inventory_stock_movements
+		  (vendor_store_product_id, store_id, movement_type, quantity, stock_after)
+		VALUES ($1,$2,$3,$4,$5)`,
+		oos.VendorStoreProductID, storeID, inventory.StockMovementOversellRejected, oos.Requested, oos.Available)
+}
+
 func (r *postgresRepo) scanOrder(row *sql.Row) (*Order, error) {
 	o := &Order{}
 	var customerID sql.NullString
+	var promoCodeID, quoteID, reprintOf, checkoutID uuid.NullUUID
 	var deliveryAddr, metadata []byte
+	var dueAt sql.NullTime
 	err := row.Scan(
 		&o.ID, &o.StoreID, &customerID, &o.OrderNumber, &o.Status, &o.Channel,
 		&o.Subtotal, &o.Discount, &o.Tax, &o.Total, &o.Currency, &o.Notes,
-		&deliveryAddr, &metadata, &o.CreatedAt, &o.UpdatedAt)
+		&deliveryAddr, &metadata, &o.PricesIncludeTax, &o.TaxExempt, &o.TaxExemptionCertificate,
+		&promoCodeID, &o.PromoCode, &quoteID, &reprintOf, &checkoutID, &o.FulfilmentMethod, &dueAt, &o.Version, &o.CreatedAt, &o.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +836,23 @@ func (r *postgresRepo) scanOrder(row *sql.Row) (*Order, error) {
 		uid, _ := uuid.Parse(customerID.String)
 		o.CustomerID = &uid
 	}
+	if promoCodeID.Valid {
+		o.PromoCodeID = &promoCodeID.UUID
+	}
+	if quoteID.Valid {
+		o.QuoteID = &quoteID.UUID
+	}
+	if reprintOf.Valid {
+		o.ReprintOf = &reprintOf.UUID
+	}
+	if checkoutID.Valid {
+		o.CheckoutID = &checkoutID.UUID
+	}
 	o.DeliveryAddress = deliveryAddr
 	o.Metadata = metadata
+	if dueAt.Valid {
+		o.DueAt = &dueAt.Time
+	}
 	return o, nil
 }
 
@@ -157,19 +866,37 @@ func (r *postgresRepo) queryOrders(ctx context.Context, query string, args ...in
 	for rows.Next() {
 		o := &Order{}
 		var customerID sql.NullString
+		var promoCodeID, quoteID, reprintOf, checkoutID uuid.NullUUID
 		var deliveryAddr, metadata []byte
+		var dueAt sql.NullTime
 		if err := rows.Scan(
 			&o.ID, &o.StoreID, &customerID, &o.OrderNumber, &o.Status, &o.Channel,
 			&o.Subtotal, &o.Discount, &o.Tax, &o.Total, &o.Currency, &o.Notes,
-			&deliveryAddr, &metadata, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			&deliveryAddr, &metadata, &o.PricesIncludeTax, &o.TaxExempt, &o.TaxExemptionCertificate,
+			&promoCodeID, &o.PromoCode, &quoteID, &reprintOf, &checkoutID, &o.FulfilmentMethod, &dueAt, &o.Version, &o.CreatedAt, &o.UpdatedAt); err != nil {
 			return nil, err
 		}
 		if customerID.Valid {
 			uid, _ := uuid.Parse(customerID.String)
 			o.CustomerID = &uid
 		}
+		if promoCodeID.Valid {
+			o.PromoCodeID = &promoCodeID.UUID
+		}
+		if quoteID.Valid {
+			o.QuoteID = &quoteID.UUID
+		}
+		if reprintOf.Valid {
+			o.ReprintOf = &reprintOf.UUID
+		}
+		if checkoutID.Valid {
+			o.CheckoutID = &checkoutID.UUID
+		}
 		o.DeliveryAddress = deliveryAddr
 		o.Metadata = metadata
+		if dueAt.Valid {
+			o.DueAt = &dueAt.Time
+		}
 		orders = append(orders, o)
 	}
 	return orders, nil
@@ -177,7 +904,8 @@ func (r *postgresRepo) queryOrders(ctx context.Context, query string, args ...in
 
 func (r *postgresRepo) listItems(ctx context.Context, orderID string) ([]*OrderItem, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, order_id, vendor_store_product_id, quantity, unit_price, line_total, customisation, created_at, updated_at
+		SELECT id, order_id, vendor_store_product_id, quantity, unit_price_minor, line_total_minor,
+		       tax_class, tax_rate, taxable_amount_minor, tax_amount_minor, customisation, created_at, updated_at
 		FROM order_items WHERE order_id=$1 ORDER BY created_at ASC`, orderID)
 	if err != nil {
 		return nil, err
@@ -189,7 +917,7 @@ func (r *postgresRepo) listItems(ctx context.Context, orderID string) ([]*OrderI
 		var customisation []byte
 		if err := rows.Scan(&item.ID, &item.OrderID, &item.VendorStoreProductID,
 			&item.Quantity, &item.UnitPrice, &item.LineTotal,
-			&customisation, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			&item.TaxClass, &item.TaxRate, &item.TaxableAmount, &item.TaxAmount, &customisation, &item.CreatedAt, &item.UpdatedAt); err != nil {
 			return nil, err
 		}
 		item.Customisation = customisation
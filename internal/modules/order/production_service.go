@@ -0,0 +1,58 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// ProductionProgress summarises the production jobs of an order.
+type ProductionProgress struct {
+	Started  bool // a job has been started
+	Finished bool // the order has jobs and every one not cancelled is completed
+}
+
+// SyncProduction moves a CONFIRMED order to IN_PRODUCTION once production has started on it, and an
+// IN_PRODUCTION order to READY once all of its production is finished. It only ever moves an order forward from
+// the status it was read in, so repeated or out-of-order calls cannot advance an order twice.
+func (s *service) SyncProduction(ctx context.Context, id string, progress ProductionProgress) (*Order, error) {
+	o, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	for {
+		next, reason := nextProductionStatus(o.Status, progress)
+		if next == "" {
+			return o, nil
+		}
+		advanced, err := s.repo.AdvanceStatus(ctx, id, o.Status, next, StatusChange{Reason: reason})
+		if err != nil {
+			return nil, err
+		}
+		if !advanced {
+			// Someone else moved the order first; carry on from wherever it is now. Statuses only move forward, so
+			// this ends.
+			if o, err = s.repo.GetOrderByID(ctx, id); err != nil {
+				return nil, fmt.Errorf("order not found: %w", err)
+			}
+			continue
+		}
+		o.Status = next
+		if next == StatusReady && s.requiresPickupCode(o) {
+			if err := s.issuePickupCode(ctx, o); err != nil {
+				log.Printf("order %s: pickup code not sent: %v", o.ID, err)
+			}
+		}
+	}
+}
+
+// nextProductionStatus returns the status production progress moves an order to next, or "" when it stays put.
+func nextProductionStatus(status OrderStatus, progress ProductionProgress) (OrderStatus, string) {
+	switch {
+	case status == StatusConfirmed && (progress.Started || progress.Finished):
+		return StatusInProduction, "Production started"
+	case status == StatusInProduction && progress.Finished:
+		return StatusReady, "Production completed"
+	}
+	return "", ""
+}
@@ -0,0 +1,62 @@
+package order
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSyncProductionAdvancesOnceHoweverOftenItIsDelivered(t *testing.T) {
+	repo := newFakeRepository()
+	o := &Order{ID: uuid.New(), Status: StatusConfirmed, FulfilmentMethod: FulfilmentDelivery}
+	repo.orders[o.ID.String()] = o
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := svc.SyncProduction(ctx, o.ID.String(), ProductionProgress{Started: true})
+		if err != nil {
+			t.Fatalf("SyncProduction returned error: %v", err)
+		}
+		if got.Status != StatusInProduction {
+			t.Fatalf("status = %s, want IN_PRODUCTION", got.Status)
+		}
+	}
+	if events := repo.events[o.ID.String()]; len(events) != 1 || events[0].Reason != "Production started" {
+		t.Fatalf("expected one production start in the timeline, got %d events", len(events))
+	}
+
+	// A stale start delivered after completion must not move the order anywhere.
+	if _, err := svc.SyncProduction(ctx, o.ID.String(), ProductionProgress{Started: true, Finished: true}); err != nil {
+		t.Fatalf("SyncProduction returned error: %v", err)
+	}
+	if _, err := svc.SyncProduction(ctx, o.ID.String(), ProductionProgress{Started: true}); err != nil {
+		t.Fatalf("SyncProduction returned error: %v", err)
+	}
+	if o.Status != StatusReady || len(repo.events[o.ID.String()]) != 2 {
+		t.Fatalf("expected the order READY after two transitions, got %s with %d", o.Status, len(repo.events[o.ID.String()]))
+	}
+}
+
+func TestSyncProductionCatchesUpWhenCompletionArrivesFirst(t *testing.T) {
+	repo := newFakeRepository()
+	o := &Order{ID: uuid.New(), Status: StatusConfirmed, FulfilmentMethod: FulfilmentDelivery}
+	repo.orders[o.ID.String()] = o
+	pending := &Order{ID: uuid.New(), Status: StatusPending}
+	repo.orders[pending.ID.String()] = pending
+	svc := NewService(repo)
+
+	got, err := svc.SyncProduction(context.Background(), o.ID.String(), ProductionProgress{Finished: true})
+	if err != nil {
+		t.Fatalf("SyncProduction returned error: %v", err)
+	}
+	events := repo.events[o.ID.String()]
+	if got.Status != StatusReady || len(events) != 2 || *events[1].FromStatus != StatusInProduction {
+		t.Fatalf("expected CONFIRMED -> IN_PRODUCTION -> READY, got %s with %d events", got.Status, len(events))
+	}
+
+	if got, err := svc.SyncProduction(context.Background(), pending.ID.String(), ProductionProgress{Started: true}); err != nil || got.Status != StatusPending {
+		t.Fatalf("an unconfirmed order must not enter production, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,278 @@
+package order
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/go-chi/chi/v5"
+)
+
+// QuoteHandler exposes the quote-first workflow: customers request and accept quotes, store owners price them.
+type QuoteHandler struct {
+	service          QuoteService
+	inventoryService inventory.Service
+	vendorService    vendor.Service
+	db               *sql.DB
+}
+
+func NewQuoteHandler(service QuoteService, inventoryService inventory.Service, vendorService vendor.Service, db *sql.DB) *QuoteHandler {
+	return &QuoteHandler{service: service, inventoryService: inventoryService, vendorService: vendorService, db: db}
+}
+
+func (h *QuoteHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/quotes", func(r chi.Router) {
+		r.Post("/", h.requestQuote)                            // POST /api/v1/quotes
+		r.Get("/{id}", h.getQuote)                             // GET  /api/v1/quotes/{id}
+		r.Post("/{id}/pricing", h.priceQuote)                  // POST /api/v1/quotes/{id}/pricing
+		r.Post("/{id}/accept", h.acceptQuote)                  // POST /api/v1/quotes/{id}/accept
+		r.Post("/{id}/decline", h.declineQuote)                // POST /api/v1/quotes/{id}/decline
+		r.Get("/store/{store_id}", h.listStoreQuotes)          // GET  /api/v1/quotes/store/{store_id}?status=QUOTED
+		r.Get("/customer/{customer_id}", h.listCustomerQuotes) // GET  /api/v1/quotes/customer/{customer_id}
+	})
+}
+
+func (h *QuoteHandler) requestQuote(w http.ResponseWriter, r *http.Request) {
+	var req RequestQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		req.CustomerID = middleware.GetUserID(r)
+		if err := validateCustomerAssets(r, h.db, req.Items); err != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+	} else if !h.requireStoreAccess(w, r, req.StoreID, true) {
+		return
+	}
+	q, err := h.service.RequestQuote(r.Context(), req)
+	if err != nil {
+		respondQuoteError(w, err)
+		return
+	}
+	respond(w, http.StatusCreated, q)
+}
+
+func (h *QuoteHandler) getQuote(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.requireQuoteAccess(w, r, true)
+	if !ok {
+		return
+	}
+	respond(w, http.StatusOK, q)
+}
+
+func (h *QuoteHandler) priceQuote(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only the store can price a quote"})
+		return
+	}
+	q, ok := h.requireQuoteAccess(w, r, false)
+	if !ok {
+		return
+	}
+	var req PriceQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Actor = requestActor(r)
+	priced, err := h.service.PriceQuote(r.Context(), q.ID.String(), req)
+	if err != nil {
+		respondQuoteError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, priced)
+}
+
+// acceptQuote places the order. Only the customer who requested the quote, or an administrator, may accept it.
+func (h *QuoteHandler) acceptQuote(w http.ResponseWriter, r *http.Request) {
+	role := middleware.GetRole(r)
+	if role != middleware.RoleCustomer && role != middleware.RoleAdmin {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only the customer can accept a quote"})
+		return
+	}
+	q, ok := h.requireQuoteAccess(w, r, false)
+	if !ok {
+		return
+	}
+	var req AcceptQuoteRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if role == middleware.RoleCustomer {
+		delivery := PlaceOrderRequest{
+			StoreID: q.StoreID.String(), CustomerID: q.CustomerID.String(),
+			FulfilmentMethod: req.FulfilmentMethod, DeliveryAddress: req.DeliveryAddress,
+		}
+		if err := validateCustomerDelivery(r, h.db, &delivery); err != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		req.DeliveryAddress = delivery.DeliveryAddress
+	}
+	req.Actor = requestActor(r)
+	o, err := h.service.AcceptQuote(r.Context(), q.ID.String(), req)
+	if err != nil {
+		var oos *OutOfStockError
+		if errors.As(err, &oos) {
+			respond(w, http.StatusConflict, map[string]interface{}{
+				"error":                   oos.Error(),
+				"vendor_store_product_id": oos.VendorStoreProductID,
+				"requested":               oos.Requested,
+				"available":               oos.Available,
+			})
+			return
+		}
+		respondQuoteError(w, err)
+		return
+	}
+	respond(w, http.StatusCreated, o)
+}
+
+func (h *QuoteHandler) declineQuote(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.requireQuoteAccess(w, r, false)
+	if !ok {
+		return
+	}
+	var req DeclineQuoteRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	req.Actor = requestActor(r)
+	declined, err := h.service.DeclineQuote(r.Context(), q.ID.String(), req)
+	if err != nil {
+		respondQuoteError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, declined)
+}
+
+func (h *QuoteHandler) listStoreQuotes(w http.ResponseWriter, r *http.Request) {
+	storeID := chi.URLParam(r, "store_id")
+	if !h.requireStoreAccess(w, r, storeID, true) {
+		return
+	}
+	quotes, err := h.service.ListStoreQuotes(r.Context(), storeID, r.URL.Query().Get("status"))
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if quotes == nil {
+		quotes = make([]*Quote, 0)
+	}
+	respond(w, http.StatusOK, quotes)
+}
+
+func (h *QuoteHandler) listCustomerQuotes(w http.ResponseWriter, r *http.Request) {
+	customerID := chi.URLParam(r, "customer_id")
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+	case middleware.RoleCustomer:
+		if customerID != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "customer scope does not match authenticated user"})
+			return
+		}
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	quotes, err := h.service.ListCustomerQuotes(r.Context(), customerID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if quotes == nil {
+		quotes = make([]*Quote, 0)
+	}
+	respond(w, http.StatusOK, quotes)
+}
+
+// requireQuoteAccess loads the quote and lets through the customer who requested it and the store's owner,
+// plus its staff when allowStaff is set.
+func (h *QuoteHandler) requireQuoteAccess(w http.ResponseWriter, r *http.Request, allowStaff bool) (*Quote, bool) {
+	q, err := h.service.GetQuote(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondQuoteError(w, err)
+		return nil, false
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		if q.CustomerID.String() != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "quote does not belong to authenticated customer"})
+			return nil, false
+		}
+		return q, true
+	}
+	if !h.requireStoreAccess(w, r, q.StoreID.String(), allowStaff) {
+		return nil, false
+	}
+	return q, true
+}
+
+func (h *QuoteHandler) requireStoreAccess(w http.ResponseWriter, r *http.Request, storeID string, allowStaff bool) bool {
+	store, err := h.inventoryService.GetStore(r.Context(), storeID)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "store not found"})
+		return false
+	}
+
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		return true
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err == nil && v.ID == store.VendorID {
+			return true
+		}
+	case middleware.RoleStaff, middleware.RoleCashier:
+		if allowStaff {
+			staff, err := h.inventoryService.ListStaff(r.Context(), storeID)
+			if err == nil {
+				for _, member := range staff {
+					if member.UserID.String() == middleware.GetUserID(r) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+	return false
+}
+
+func respondQuoteError(w http.ResponseWriter, err error) {
+	var invalid *CustomisationError
+	if errors.As(err, &invalid) {
+		respond(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": invalid.Error(), "lines": invalid.Lines})
+		return
+	}
+	code := http.StatusInternalServerError
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrQuoteNotAcceptable) || errors.Is(err, ErrQuoteNotOpen):
+		code = http.StatusConflict
+	case strings.Contains(msg, "invalid") || strings.Contains(msg, "on this quote"):
+		code = http.StatusBadRequest
+	case errors.Is(err, ErrProductNotInStore) || strings.Contains(msg, "unavailable"):
+		code = http.StatusUnprocessableEntity
+	case strings.Contains(msg, "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(msg, "required") || strings.Contains(msg, "must") || strings.Contains(msg, "cannot") ||
+		strings.Contains(msg, "at least one") || strings.Contains(msg, "more than once"):
+		code = http.StatusBadRequest
+	}
+	respond(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,117 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// QuoteStatus is the lifecycle state of a quote. Accepting a quote places an order in StatusPending.
+type QuoteStatus string
+
+const (
+	QuoteStatusRequested QuoteStatus = "QUOTE_REQUESTED"
+	QuoteStatusQuoted    QuoteStatus = "QUOTED"
+	QuoteStatusAccepted  QuoteStatus = "ACCEPTED"
+	QuoteStatusDeclined  QuoteStatus = "DECLINED"
+	// QuoteStatusExpired is reported for a QUOTED quote past its expiry. It is never stored; the vendor may
+	// re-price an expired quote.
+	QuoteStatusExpired QuoteStatus = "EXPIRED"
+)
+
+// ErrQuoteNotAcceptable is returned when a quote is accepted after it expired, was declined or re-priced, or
+// was already accepted by a concurrent request.
+var ErrQuoteNotAcceptable = errors.New("quote is not open for acceptance")
+
+// ErrQuoteNotOpen is returned when pricing or declining a quote that was already accepted or declined.
+var ErrQuoteNotOpen = errors.New("quote has already been accepted or declined")
+
+// Quote is a customer's request for a vendor-priced job, and the vendor's answer.
+type Quote struct {
+	ID            uuid.UUID    `json:"id"`
+	QuoteNumber   string       `json:"quote_number"`
+	StoreID       uuid.UUID    `json:"store_id"`
+	CustomerID    uuid.UUID    `json:"customer_id"`
+	Status        QuoteStatus  `json:"status"`
+	Notes         string       `json:"notes,omitempty"`
+	VendorNotes   string       `json:"vendor_notes,omitempty"`
+	DeclineReason string       `json:"decline_reason,omitempty"`
+	Subtotal      money.Amount `json:"subtotal"`
+	Currency      string       `json:"currency"`
+	ExpiresAt     *time.Time   `json:"expires_at,omitempty"`
+	QuotedBy      *uuid.UUID   `json:"quoted_by,omitempty"`
+	QuotedAt      *time.Time   `json:"quoted_at,omitempty"`
+	OrderID       *uuid.UUID   `json:"order_id,omitempty"`
+	AcceptedAt    *time.Time   `json:"accepted_at,omitempty"`
+	Items         []*QuoteItem `json:"items,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// QuoteItem is a requested line. UnitPrice and LineTotal are nil until the vendor prices the quote.
+type QuoteItem struct {
+	ID                   uuid.UUID       `json:"id"`
+	QuoteID              uuid.UUID       `json:"quote_id"`
+	VendorStoreProductID uuid.UUID       `json:"vendor_store_product_id"`
+	Quantity             int             `json:"quantity"`
+	UnitPrice            *money.Amount   `json:"unit_price,omitempty"`
+	LineTotal            *money.Amount   `json:"line_total,omitempty"`
+	Customisation        json.RawMessage `json:"customisation,omitempty"`
+}
+
+// priceLines fills each line total and, once every line is priced, the quote subtotal.
+func (q *Quote) priceLines() {
+	q.Subtotal = 0
+	priced := len(q.Items) > 0
+	for _, item := range q.Items {
+		if item.UnitPrice == nil {
+			item.LineTotal = nil
+			priced = false
+			continue
+		}
+		total := item.UnitPrice.Times(item.Quantity)
+		item.LineTotal = &total
+		q.Subtotal += total
+	}
+	if !priced {
+		q.Subtotal = 0
+	}
+}
+
+// RequestQuoteRequest is the customer's payload for asking a store to price a job.
+type RequestQuoteRequest struct {
+	StoreID    string     `json:"store_id"`
+	CustomerID string     `json:"customer_id,omitempty"`
+	Items      []CartItem `json:"items"`
+	Notes      string     `json:"notes,omitempty"`
+}
+
+// QuoteLinePrice prices one requested line.
+type QuoteLinePrice struct {
+	ItemID    string       `json:"item_id"`
+	UnitPrice money.Amount `json:"unit_price"`
+}
+
+// PriceQuoteRequest is the vendor's payload for pricing, or re-pricing, a quote. Every line must be priced.
+type PriceQuoteRequest struct {
+	Lines       []QuoteLinePrice `json:"lines"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	VendorNotes string           `json:"vendor_notes,omitempty"`
+	Actor       Actor            `json:"-"`
+}
+
+// AcceptQuoteRequest carries the delivery choice made when the customer accepts a quote.
+type AcceptQuoteRequest struct {
+	FulfilmentMethod FulfilmentMethod `json:"fulfilment_method,omitempty"`
+	DeliveryAddress  json.RawMessage  `json:"delivery_address,omitempty"`
+	Actor            Actor            `json:"-"`
+}
+
+// DeclineQuoteRequest records why the customer or vendor declined a quote.
+type DeclineQuoteRequest struct {
+	Reason string `json:"reason,omitempty"`
+	Actor  Actor  `json:"-"`
+}
@@ -0,0 +1,242 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+type quotePostgresRepository struct{ db *sql.DB }
+
+func NewQuotePostgresRepository(db *sql.DB) QuoteRepository { return &quotePostgresRepository{db: db} }
+
+// quoteStatusExpr reports a lapsed QUOTED quote as EXPIRED without storing the derived state.
+const quoteStatusExpr = `CASE WHEN status='QUOTED' AND expires_at <= NOW() THEN 'EXPIRED' ELSE status END`
+
+const quoteColumns = `id,quote_number,store_id,customer_id,` + quoteStatusExpr + `,COALESCE(notes,''),
+		       COALESCE(vendor_notes,''),COALESCE(decline_reason,''),expires_at,quoted_by,quoted_at,order_id,accepted_at,
+		       created_at,updated_at`
+
+func (r *quotePostgresRepository) NextQuoteSequence(ctx context.Context, series string) (int64, error) {
+	return nextDocumentSequence(ctx, r.db, "order_quotes", "quote_number", series)
+}
+
+func (r *quotePostgresRepository) CreateQuote(ctx context.Context, q *Quote) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_quotes (id, quote_number, store_id, customer_id, status, notes)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6,''))`,
+		q.ID, q.QuoteNumber, q.StoreID, q.CustomerID, q.Status, q.Notes); err != nil {
+		return fmt.Errorf("insert quote: %w", err)
+	}
+	for _, item := range q.Items {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_quote_items (id, quote_id, vendor_store_product_id, quantity, customisation)
+			VALUES ($1,$2,$3,$4,$5)`,
+			item.ID, q.ID, item.VendorStoreProductID, item.Quantity, nullableJSON(item.Customisation)); err != nil {
+			return fmt.Errorf("insert quote item: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *quotePostgresRepository) GetQuote(ctx context.Context, id string) (*Quote, error) {
+	q, err := scanQuote(r.db.QueryRowContext(ctx, `SELECT `+quoteColumns+` FROM order_quotes WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadItems(ctx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (r *quotePostgresRepository) ListQuotesByStore(ctx context.Context, storeID string, status string) ([]*Quote, error) {
+	if status != "" {
+		return r.queryQuotes(ctx, `
+			SELECT `+quoteColumns+` FROM order_quotes
+			WHERE store_id=$1 AND `+quoteStatusExpr+`=$2 ORDER BY created_at DESC`, storeID, status)
+	}
+	return r.queryQuotes(ctx, `
+		SELECT `+quoteColumns+` FROM order_quotes WHERE store_id=$1 ORDER BY created_at DESC`, storeID)
+}
+
+func (r *quotePostgresRepository) ListQuotesByCustomer(ctx context.Context, customerID string) ([]*Quote, error) {
+	return r.queryQuotes(ctx, `
+		SELECT `+quoteColumns+` FROM order_quotes WHERE customer_id=$1 ORDER BY created_at DESC`, customerID)
+}
+
+func (r *quotePostgresRepository) PriceQuote(ctx context.Context, id string, prices map[uuid.UUID]money.Amount, expiresAt time.Time, vendorNotes, quotedBy string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status QuoteStatus
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status FROM order_quotes WHERE id=$1 FOR UPDATE`, id).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("quote not found: %w", err)
+		}
+		return err
+	}
+	if status != QuoteStatusRequested && status != QuoteStatusQuoted {
+		return fmt.Errorf("%w (current: %s)", ErrQuoteNotOpen, status)
+	}
+	for itemID, price := range prices {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE order_quote_items SET unit_price_minor=$1, updated_at=NOW() WHERE id=$2 AND quote_id=$3`,
+			price, itemID, id)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("quote item %s not found on this quote", itemID)
+		}
+	}
+	// quoted_at changes on every re-price, which is what lets acceptance detect prices it never saw.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE order_quotes
+		SET status=$1, expires_at=$2, vendor_notes=NULLIF($3,''), quoted_by=NULLIF($4,'')::uuid,
+		    quoted_at=NOW(), updated_at=NOW()
+		WHERE id=$5`,
+		QuoteStatusQuoted, expiresAt, vendorNotes, quotedBy, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *quotePostgresRepository) DeclineQuote(ctx context.Context, id, reason string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE order_quotes SET status=$1, decline_reason=NULLIF($2,''), updated_at=NOW()
+		WHERE id=$3 AND status IN ($4,$5)`,
+		QuoteStatusDeclined, reason, id, QuoteStatusRequested, QuoteStatusQuoted)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM order_quotes WHERE id=$1)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("quote not found: %w", sql.ErrNoRows)
+		}
+		return ErrQuoteNotOpen
+	}
+	return nil
+}
+
+// acceptQuote claims a quote for the order being written in tx. It only succeeds while the quote is QUOTED,
+// unexpired and still carries the pricing the order was built from.
+func acceptQuote(ctx context.Context, tx *sql.Tx, quoteID, orderID uuid.UUID, quotedAt time.Time) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE order_quotes SET status=$1, order_id=$2, accepted_at=NOW(), updated_at=NOW()
+		WHERE id=$3 AND status=$4 AND expires_at > NOW() AND quoted_at=$5`,
+		QuoteStatusAccepted, orderID, quoteID, QuoteStatusQuoted, quotedAt)
+	if err != nil {
+		return fmt.Errorf("accept quote: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrQuoteNotAcceptable
+	}
+	return nil
+}
+
+func (r *quotePostgresRepository) queryQuotes(ctx context.Context, query string, args ...interface{}) ([]*Quote, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var quotes []*Quote
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, q := range quotes {
+		if err := r.loadItems(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+	return quotes, nil
+}
+
+func (r *quotePostgresRepository) loadItems(ctx context.Context, q *Quote) error {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, quote_id, vendor_store_product_id, quantity, unit_price_minor, customisation
+		FROM order_quote_items WHERE quote_id=$1 ORDER BY created_at ASC, id ASC`, q.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	q.Items = nil
+	for rows.Next() {
+		item := &QuoteItem{}
+		var unitPrice sql.NullInt64
+		var customisation []byte
+		if err := rows.Scan(&item.ID, &item.QuoteID, &item.VendorStoreProductID, &item.Quantity, &unitPrice, &customisation); err != nil {
+			return err
+		}
+		if unitPrice.Valid {
+			price := money.Amount(unitPrice.Int64)
+			item.UnitPrice = &price
+		}
+		item.Customisation = customisation
+		q.Items = append(q.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	q.priceLines()
+	return nil
+}
+
+// quoteScanner is satisfied by both *sql.Row and *sql.Rows.
+type quoteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuote(row quoteScanner) (*Quote, error) {
+	q := &Quote{Currency: money.DefaultCurrency}
+	var expiresAt, quotedAt, acceptedAt sql.NullTime
+	var quotedBy, orderID uuid.NullUUID
+	if err := row.Scan(&q.ID, &q.QuoteNumber, &q.StoreID, &q.CustomerID, &q.Status, &q.Notes,
+		&q.VendorNotes, &q.DeclineReason, &expiresAt, &quotedBy, &quotedAt, &orderID, &acceptedAt,
+		&q.CreatedAt, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		q.ExpiresAt = &expiresAt.Time
+	}
+	if quotedBy.Valid {
+		q.QuotedBy = &quotedBy.UUID
+	}
+	if quotedAt.Valid {
+		q.QuotedAt = &quotedAt.Time
+	}
+	if orderID.Valid {
+		q.OrderID = &orderID.UUID
+	}
+	if acceptedAt.Valid {
+		q.AcceptedAt = &acceptedAt.Time
+	}
+	return q, nil
+}
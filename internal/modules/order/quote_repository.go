@@ -0,0 +1,33 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// QuoteRepository persists quotes and their lines. Acceptance is not here: the order Repository's CreateOrder
+// claims the quote in the same transaction that writes the order.
+type QuoteRepository interface {
+	CreateQuote(ctx context.Context, q *Quote) error
+
+	// NextQuoteSequence increments the counter of one quote numbering series, e.g. "QTE-20261016", and returns the
+	// new value. It commits on its own, so concurrent callers never receive the same value.
+	NextQuoteSequence(ctx context.Context, series string) (int64, error)
+
+	// GetQuote retrieves a quote with its items. A QUOTED quote past its expiry is returned as EXPIRED.
+	GetQuote(ctx context.Context, id string) (*Quote, error)
+
+	// ListQuotesByStore returns a store's quotes, newest first, optionally filtered by reported status.
+	ListQuotesByStore(ctx context.Context, storeID string, status string) ([]*Quote, error)
+	ListQuotesByCustomer(ctx context.Context, customerID string) ([]*Quote, error)
+
+	// PriceQuote sets every line price and the expiry and moves the quote to QUOTED. It returns
+	// ErrQuoteNotOpen unless the quote is QUOTE_REQUESTED or QUOTED.
+	PriceQuote(ctx context.Context, id string, prices map[uuid.UUID]money.Amount, expiresAt time.Time, vendorNotes, quotedBy string) error
+
+	// DeclineQuote moves an open quote to DECLINED. It returns ErrQuoteNotOpen once the quote is accepted or declined.
+	DeclineQuote(ctx context.Context, id, reason string) error
+}
@@ -0,0 +1,270 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// QuoteService runs the quote-first workflow for jobs the vendor prices before the customer commits:
+// QUOTE_REQUESTED -> QUOTED -> ACCEPTED, where acceptance places a normal PENDING order.
+type QuoteService interface {
+	// RequestQuote records the customer's items and customisation for the store to price.
+	RequestQuote(ctx context.Context, req RequestQuoteRequest) (*Quote, error)
+	GetQuote(ctx context.Context, id string) (*Quote, error)
+	ListStoreQuotes(ctx context.Context, storeID string, status string) ([]*Quote, error)
+	ListCustomerQuotes(ctx context.Context, customerID string) ([]*Quote, error)
+
+	// PriceQuote attaches a price to every line and an expiry. An open or expired quote may be re-priced.
+	PriceQuote(ctx context.Context, id string, req PriceQuoteRequest) (*Quote, error)
+
+	// AcceptQuote places an order at the quoted prices through the normal CreateOrder path, reserving stock.
+	// Accepting an already accepted quote returns its order. It returns an error wrapping ErrQuoteNotAcceptable
+	// when the quote is not QUOTED, has expired or was re-priced concurrently.
+	AcceptQuote(ctx context.Context, id string, req AcceptQuoteRequest) (*Order, error)
+	DeclineQuote(ctx context.Context, id string, req DeclineQuoteRequest) (*Quote, error)
+}
+
+type quoteService struct {
+	repo   QuoteRepository
+	orders *service
+	now    func() time.Time
+}
+
+// NewQuoteService creates a quote service. Orders placed from quotes are taxed with the same options as
+// NewService.
+func NewQuoteService(repo QuoteRepository, orders Repository, options ...ServiceOption) QuoteService {
+	return &quoteService{repo: repo, orders: NewService(orders, options...).(*service), now: time.Now}
+}
+
+func (s *quoteService) RequestQuote(ctx context.Context, req RequestQuoteRequest) (*Quote, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("quote must contain at least one item")
+	}
+	storeID, err := uuid.Parse(req.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store_id: %w", err)
+	}
+	if req.CustomerID == "" {
+		return nil, fmt.Errorf("customer_id is required")
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer_id: %w", err)
+	}
+
+	number, err := s.nextQuoteNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := &Quote{
+		ID:          uuid.New(),
+		QuoteNumber: number,
+		StoreID:     storeID,
+		CustomerID:  customerID,
+		Status:      QuoteStatusRequested,
+		Notes:       strings.TrimSpace(req.Notes),
+	}
+	var invalid []LineCustomisationError
+	for i, ci := range req.Items {
+		if ci.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be > 0 for product %s", ci.VendorStoreProductID)
+		}
+		pid, err := uuid.Parse(ci.VendorStoreProductID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vendor_store_product_id: %w", err)
+		}
+		pricing, err := s.orders.repo.GetProductPricing(ctx, req.StoreID, ci.VendorStoreProductID)
+		if err != nil {
+			return nil, productLookupError(ci.VendorStoreProductID, err)
+		}
+		if !pricing.Available {
+			return nil, fmt.Errorf("product %s %w", ci.VendorStoreProductID, ErrProductUnavailable)
+		}
+		fields, err := s.orders.checkCustomisation(ctx, req.CustomerID, pricing.OptionSchema, ci.Customisation)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			invalid = append(invalid, LineCustomisationError{Line: i, VendorStoreProductID: ci.VendorStoreProductID, Fields: fields})
+		}
+		q.Items = append(q.Items, &QuoteItem{
+			ID:                   uuid.New(),
+			QuoteID:              q.ID,
+			VendorStoreProductID: pid,
+			Quantity:             ci.Quantity,
+			Customisation:        ci.Customisation,
+		})
+	}
+
+	if len(invalid) > 0 {
+		return nil, &CustomisationError{Lines: invalid}
+	}
+
+	if err := s.repo.CreateQuote(ctx, q); err != nil {
+		return nil, fmt.Errorf("failed to persist quote: %w", err)
+	}
+	return s.repo.GetQuote(ctx, q.ID.String())
+}
+
+func (s *quoteService) GetQuote(ctx context.Context, id string) (*Quote, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid quote id: %w", err)
+	}
+	q, err := s.repo.GetQuote(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("quote not found: %w", err)
+	}
+	return q, nil
+}
+
+func (s *quoteService) ListStoreQuotes(ctx context.Context, storeID string, status string) ([]*Quote, error) {
+	return s.repo.ListQuotesByStore(ctx, storeID, strings.ToUpper(status))
+}
+
+func (s *quoteService) ListCustomerQuotes(ctx context.Context, customerID string) ([]*Quote, error) {
+	return s.repo.ListQuotesByCustomer(ctx, customerID)
+}
+
+func (s *quoteService) PriceQuote(ctx context.Context, id string, req PriceQuoteRequest) (*Quote, error) {
+	q, err := s.GetQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status == QuoteStatusAccepted || q.Status == QuoteStatusDeclined {
+		return nil, fmt.Errorf("%w (current: %s)", ErrQuoteNotOpen, q.Status)
+	}
+	if !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+	change, err := newStatusChange(req.Actor, "")
+	if err != nil {
+		return nil, err
+	}
+
+	onQuote := make(map[uuid.UUID]bool, len(q.Items))
+	for _, item := range q.Items {
+		onQuote[item.ID] = true
+	}
+	prices := make(map[uuid.UUID]money.Amount, len(req.Lines))
+	for _, line := range req.Lines {
+		itemID, err := uuid.Parse(line.ItemID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item_id: %w", err)
+		}
+		if !onQuote[itemID] {
+			return nil, fmt.Errorf("quote item %s not found on this quote", itemID)
+		}
+		if _, dup := prices[itemID]; dup {
+			return nil, fmt.Errorf("quote item %s is priced more than once", itemID)
+		}
+		if line.UnitPrice < 0 {
+			return nil, fmt.Errorf("unit_price for quote item %s cannot be negative", itemID)
+		}
+		prices[itemID] = line.UnitPrice
+	}
+	if len(prices) != len(q.Items) {
+		return nil, fmt.Errorf("every quote item must be priced (%d of %d priced)", len(prices), len(q.Items))
+	}
+
+	if err := s.repo.PriceQuote(ctx, id, prices, req.ExpiresAt.UTC(), strings.TrimSpace(req.VendorNotes), change.Actor.ID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuote(ctx, id)
+}
+
+func (s *quoteService) AcceptQuote(ctx context.Context, id string, req AcceptQuoteRequest) (*Order, error) {
+	q, err := s.GetQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status == QuoteStatusAccepted && q.OrderID != nil {
+		return s.orders.repo.GetOrderByID(ctx, q.OrderID.String())
+	}
+	if q.Status != QuoteStatusQuoted || q.QuotedAt == nil {
+		return nil, fmt.Errorf("%w (current: %s)", ErrQuoteNotAcceptable, q.Status)
+	}
+	change, err := newStatusChange(req.Actor, "Accepted quote "+q.QuoteNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*OrderItem
+	var subtotal money.Amount
+	for _, qi := range q.Items {
+		if qi.UnitPrice == nil {
+			return nil, fmt.Errorf("%w: quote item %s is unpriced", ErrQuoteNotAcceptable, qi.ID)
+		}
+		// Prices come from the quote; the catalog is only consulted for availability and tax class.
+		pricing, err := s.orders.repo.GetProductPricing(ctx, q.StoreID.String(), qi.VendorStoreProductID.String())
+		if err != nil {
+			return nil, productLookupError(qi.VendorStoreProductID.String(), err)
+		}
+		if !pricing.Available {
+			return nil, fmt.Errorf("product %s %w", qi.VendorStoreProductID, ErrProductUnavailable)
+		}
+		lineTotal := qi.UnitPrice.Times(qi.Quantity)
+		subtotal += lineTotal
+		items = append(items, &OrderItem{
+			ID:                   uuid.New(),
+			VendorStoreProductID: qi.VendorStoreProductID,
+			Quantity:             qi.Quantity,
+			UnitPrice:            *qi.UnitPrice,
+			LineTotal:            lineTotal,
+			TaxClass:             pricing.TaxClass,
+			Customisation:        qi.Customisation,
+		})
+	}
+
+	customerID := q.CustomerID
+	o, err := s.orders.newPendingOrder(ctx, q.StoreID, &customerID, items, subtotal, 0)
+	if err != nil {
+		return nil, err
+	}
+	o.Notes = q.Notes
+	if o.FulfilmentMethod, err = fulfilmentFor(req.FulfilmentMethod, req.DeliveryAddress); err != nil {
+		return nil, err
+	}
+	o.DeliveryAddress = req.DeliveryAddress
+	o.QuoteID = &q.ID
+	o.quotedAt = *q.QuotedAt
+
+	if err := s.orders.repo.CreateOrder(ctx, o, change); err != nil {
+		if errors.Is(err, ErrQuoteNotAcceptable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to persist order: %w", err)
+	}
+	return o, nil
+}
+
+func (s *quoteService) DeclineQuote(ctx context.Context, id string, req DeclineQuoteRequest) (*Quote, error) {
+	if _, err := s.GetQuote(ctx, id); err != nil {
+		return nil, err
+	}
+	change, err := newStatusChange(req.Actor, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeclineQuote(ctx, id, change.Reason); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuote(ctx, id)
+}
+
+// generateQuoteNumber creates a human-readable quote number: QTE-YYYYMMDD-XXXX
+// nextQuoteNumber issues the next platform-wide quote number of the day, QTE-YYYYMMDD-0042, dated in the store
+// timezone like order numbers.
+func (s *quoteService) nextQuoteNumber(ctx context.Context) (string, error) {
+	series := "QTE-" + s.now().In(s.orders.zone).Format("20060102")
+	value, err := s.repo.NextQuoteSequence(ctx, series)
+	if err != nil {
+		return "", fmt.Errorf("allocate quote number: %w", err)
+	}
+	return fmt.Sprintf("%s-%04d", series, value), nil
+}
@@ -0,0 +1,248 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+type fakeQuoteRepository struct {
+	quotes    map[string]*Quote
+	sequences map[string]int64
+	now       time.Time
+}
+
+func newFakeQuoteRepository(now time.Time) *fakeQuoteRepository {
+	return &fakeQuoteRepository{quotes: make(map[string]*Quote), sequences: make(map[string]int64), now: now}
+}
+
+func (f *fakeQuoteRepository) NextQuoteSequence(_ context.Context, series string) (int64, error) {
+	f.sequences[series]++
+	return f.sequences[series], nil
+}
+
+func (f *fakeQuoteRepository) CreateQuote(_ context.Context, q *Quote) error {
+	f.quotes[q.ID.String()] = q
+	return nil
+}
+
+func (f *fakeQuoteRepository) GetQuote(_ context.Context, id string) (*Quote, error) {
+	q, ok := f.quotes[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *q
+	copied.Items = nil
+	for _, item := range q.Items {
+		line := *item
+		copied.Items = append(copied.Items, &line)
+	}
+	if copied.Status == QuoteStatusQuoted && !f.now.Before(*copied.ExpiresAt) {
+		copied.Status = QuoteStatusExpired
+	}
+	copied.priceLines()
+	return &copied, nil
+}
+
+func (f *fakeQuoteRepository) ListQuotesByStore(context.Context, string, string) ([]*Quote, error) {
+	return nil, nil
+}
+
+func (f *fakeQuoteRepository) ListQuotesByCustomer(context.Context, string) ([]*Quote, error) {
+	return nil, nil
+}
+
+func (f *fakeQuoteRepository) PriceQuote(_ context.Context, id string, prices map[uuid.UUID]money.Amount, expiresAt time.Time, vendorNotes, _ string) error {
+	q := f.quotes[id]
+	if q.Status != QuoteStatusRequested && q.Status != QuoteStatusQuoted {
+		return ErrQuoteNotOpen
+	}
+	for _, item := range q.Items {
+		price := prices[item.ID]
+		item.UnitPrice = &price
+	}
+	// Every re-price gets a new pricing version, as quoted_at does in Postgres.
+	quotedAt := f.now
+	if q.QuotedAt != nil && !q.QuotedAt.Before(quotedAt) {
+		quotedAt = q.QuotedAt.Add(time.Microsecond)
+	}
+	q.Status, q.ExpiresAt, q.VendorNotes, q.QuotedAt = QuoteStatusQuoted, &expiresAt, vendorNotes, &quotedAt
+	return nil
+}
+
+func (f *fakeQuoteRepository) DeclineQuote(_ context.Context, id, reason string) error {
+	q := f.quotes[id]
+	if q.Status != QuoteStatusRequested && q.Status != QuoteStatusQuoted {
+		return ErrQuoteNotOpen
+	}
+	q.Status, q.DeclineReason = QuoteStatusDeclined, reason
+	return nil
+}
+
+func (f *fakeQuoteRepository) accept(quoteID, orderID uuid.UUID, quotedAt time.Time) error {
+	q := f.quotes[quoteID.String()]
+	if q.Status != QuoteStatusQuoted || !f.now.Before(*q.ExpiresAt) || !q.QuotedAt.Equal(quotedAt) {
+		return ErrQuoteNotAcceptable
+	}
+	q.Status, q.OrderID = QuoteStatusAccepted, &orderID
+	return nil
+}
+
+type quoteFixture struct {
+	svc      *quoteService
+	orders   *fakeRepository
+	quotes   *fakeQuoteRepository
+	banner   string
+	customer string
+}
+
+func newQuoteFixture(t *testing.T) *quoteFixture {
+	t.Helper()
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	orders := newFakeRepository()
+	quotes := newFakeQuoteRepository(now)
+	orders.quotes = quotes
+	banner := uuid.NewString()
+	orders.products[banner] = &fakeProduct{price: 100, available: true, stock: 10}
+	svc := NewQuoteService(quotes, orders).(*quoteService)
+	svc.now = func() time.Time { return now }
+	return &quoteFixture{svc: svc, orders: orders, quotes: quotes, banner: banner, customer: uuid.NewString()}
+}
+
+func (f *quoteFixture) request(t *testing.T, quantity int) *Quote {
+	t.Helper()
+	q, err := f.svc.RequestQuote(context.Background(), RequestQuoteRequest{
+		StoreID:    uuid.NewString(),
+		CustomerID: f.customer,
+		Items:      []CartItem{{VendorStoreProductID: f.banner, Quantity: quantity, Customisation: []byte(`{"size":"3x1m"}`)}},
+		Notes:      "vinyl, eyelets every 50cm",
+	})
+	if err != nil {
+		t.Fatalf("RequestQuote returned error: %v", err)
+	}
+	return q
+}
+
+func (f *quoteFixture) price(t *testing.T, q *Quote, unitPrice money.Amount, validFor time.Duration) *Quote {
+	t.Helper()
+	priced, err := f.svc.PriceQuote(context.Background(), q.ID.String(), PriceQuoteRequest{
+		Lines:     []QuoteLinePrice{{ItemID: q.Items[0].ID.String(), UnitPrice: unitPrice}},
+		ExpiresAt: f.svc.now().Add(validFor),
+	})
+	if err != nil {
+		t.Fatalf("PriceQuote returned error: %v", err)
+	}
+	return priced
+}
+
+func TestRequestQuoteNumbersQuotesSequentiallyWithinTheDay(t *testing.T) {
+	f := newQuoteFixture(t)
+	for _, want := range []string{"QTE-20260401-0001", "QTE-20260401-0002"} {
+		if q := f.request(t, 1); q.QuoteNumber != want {
+			t.Fatalf("quote number = %q, want %q", q.QuoteNumber, want)
+		}
+	}
+}
+
+func TestAcceptQuotePlacesOrderAtQuotedPricesAndReservesStock(t *testing.T) {
+	f := newQuoteFixture(t)
+	q := f.request(t, 2)
+	if q.Status != QuoteStatusRequested || q.Subtotal != 0 || q.Items[0].UnitPrice != nil {
+		t.Fatalf("new quote = %+v", q)
+	}
+	if _, err := f.svc.AcceptQuote(context.Background(), q.ID.String(), AcceptQuoteRequest{}); !errors.Is(err, ErrQuoteNotAcceptable) {
+		t.Fatalf("accepting an unpriced quote = %v, want ErrQuoteNotAcceptable", err)
+	}
+
+	q = f.price(t, q, 45000, 72*time.Hour)
+	if q.Status != QuoteStatusQuoted || q.Subtotal != 90000 {
+		t.Fatalf("priced quote status %s subtotal %v", q.Status, q.Subtotal)
+	}
+
+	o, err := f.svc.AcceptQuote(context.Background(), q.ID.String(), AcceptQuoteRequest{Actor: Actor{ID: f.customer, Role: "CUSTOMER"}})
+	if err != nil {
+		t.Fatalf("AcceptQuote returned error: %v", err)
+	}
+	if o.Status != StatusPending || o.Subtotal != 90000 || o.Items[0].UnitPrice != 45000 || o.QuoteID == nil || *o.QuoteID != q.ID {
+		t.Fatalf("order from quote = %+v", o)
+	}
+	if o.CustomerID == nil || o.CustomerID.String() != f.customer || string(o.Items[0].Customisation) != `{"size":"3x1m"}` {
+		t.Fatalf("order lost quote details: customer %v customisation %s", o.CustomerID, o.Items[0].Customisation)
+	}
+	if f.orders.products[f.banner].stock != 8 {
+		t.Fatalf("stock = %d, want 8 reserved through CreateOrder", f.orders.products[f.banner].stock)
+	}
+	if events := f.orders.events[o.ID.String()]; len(events) != 1 || events[0].Reason != "Accepted quote "+q.QuoteNumber {
+		t.Fatalf("placement event = %+v", events)
+	}
+
+	again, err := f.svc.AcceptQuote(context.Background(), q.ID.String(), AcceptQuoteRequest{})
+	if err != nil || again.ID != o.ID || len(f.orders.orders) != 1 {
+		t.Fatalf("repeated accept = %v, %v; want the original order", again, err)
+	}
+}
+
+func TestExpiredQuoteCannotBeAcceptedUntilRepriced(t *testing.T) {
+	f := newQuoteFixture(t)
+	q := f.price(t, f.request(t, 1), 5000, time.Hour)
+
+	f.quotes.now = f.quotes.now.Add(2 * time.Hour)
+	f.svc.now = func() time.Time { return f.quotes.now }
+	expired, err := f.svc.GetQuote(context.Background(), q.ID.String())
+	if err != nil || expired.Status != QuoteStatusExpired {
+		t.Fatalf("quote past expiry = %v, %v; want EXPIRED", expired, err)
+	}
+	if _, err := f.svc.AcceptQuote(context.Background(), q.ID.String(), AcceptQuoteRequest{}); !errors.Is(err, ErrQuoteNotAcceptable) {
+		t.Fatalf("accepting an expired quote = %v, want ErrQuoteNotAcceptable", err)
+	}
+	if len(f.orders.orders) != 0 || f.orders.products[f.banner].stock != 10 {
+		t.Fatal("rejected acceptance must not place an order or reserve stock")
+	}
+
+	f.price(t, q, 6000, time.Hour)
+	o, err := f.svc.AcceptQuote(context.Background(), q.ID.String(), AcceptQuoteRequest{})
+	if err != nil || o.Subtotal != 6000 {
+		t.Fatalf("accepting the re-priced quote = %v, %v; want the new price", o, err)
+	}
+}
+
+func TestPriceQuoteRequiresEveryLineAndFutureExpiry(t *testing.T) {
+	f := newQuoteFixture(t)
+	q := f.request(t, 1)
+
+	if _, err := f.svc.PriceQuote(context.Background(), q.ID.String(), PriceQuoteRequest{
+		ExpiresAt: f.svc.now().Add(time.Hour),
+	}); err == nil {
+		t.Fatal("expected unpriced lines to be rejected")
+	}
+	if _, err := f.svc.PriceQuote(context.Background(), q.ID.String(), PriceQuoteRequest{
+		Lines:     []QuoteLinePrice{{ItemID: q.Items[0].ID.String(), UnitPrice: 100}},
+		ExpiresAt: f.svc.now().Add(-time.Minute),
+	}); err == nil {
+		t.Fatal("expected a past expiry to be rejected")
+	}
+	if _, err := f.svc.PriceQuote(context.Background(), q.ID.String(), PriceQuoteRequest{
+		Lines:     []QuoteLinePrice{{ItemID: uuid.NewString(), UnitPrice: 100}},
+		ExpiresAt: f.svc.now().Add(time.Hour),
+	}); err == nil {
+		t.Fatal("expected a line from another quote to be rejected")
+	}
+
+	if _, err := f.svc.DeclineQuote(context.Background(), q.ID.String(), DeclineQuoteRequest{Reason: "  too expensive "}); err != nil {
+		t.Fatalf("DeclineQuote returned error: %v", err)
+	}
+	if f.quotes.quotes[q.ID.String()].DeclineReason != "too expensive" {
+		t.Fatalf("decline reason = %q", f.quotes.quotes[q.ID.String()].DeclineReason)
+	}
+	if _, err := f.svc.PriceQuote(context.Background(), q.ID.String(), PriceQuoteRequest{
+		Lines:     []QuoteLinePrice{{ItemID: q.Items[0].ID.String(), UnitPrice: 100}},
+		ExpiresAt: f.svc.now().Add(time.Hour),
+	}); !errors.Is(err, ErrQuoteNotOpen) {
+		t.Fatalf("pricing a declined quote = %v, want ErrQuoteNotOpen", err)
+	}
+}
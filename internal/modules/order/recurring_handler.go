@@ -0,0 +1,261 @@
+package order
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/go-chi/chi/v5"
+)
+
+// RecurringHandler exposes recurring orders: customers set them up and manage them, store owners see what is
+// scheduled against their store and can pause or end it.
+type RecurringHandler struct {
+	service          RecurringService
+	inventoryService inventory.Service
+	vendorService    vendor.Service
+	db               *sql.DB
+}
+
+func NewRecurringHandler(service RecurringService, inventoryService inventory.Service, vendorService vendor.Service, db *sql.DB) *RecurringHandler {
+	return &RecurringHandler{service: service, inventoryService: inventoryService, vendorService: vendorService, db: db}
+}
+
+func (h *RecurringHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/recurring-orders", func(r chi.Router) {
+		r.Post("/", h.createRecurring)                            // POST   /api/v1/recurring-orders
+		r.Get("/{id}", h.getRecurring)                            // GET    /api/v1/recurring-orders/{id}
+		r.Delete("/{id}", h.endRecurring)                         // DELETE /api/v1/recurring-orders/{id}
+		r.Post("/{id}/pause", h.pauseRecurring)                   // POST   /api/v1/recurring-orders/{id}/pause
+		r.Post("/{id}/resume", h.resumeRecurring)                 // POST   /api/v1/recurring-orders/{id}/resume
+		r.Get("/{id}/runs", h.listRuns)                           // GET    /api/v1/recurring-orders/{id}/runs
+		r.Get("/store/{store_id}", h.listStoreRecurring)          // GET    /api/v1/recurring-orders/store/{store_id}
+		r.Get("/customer/{customer_id}", h.listCustomerRecurring) // GET    /api/v1/recurring-orders/customer/{customer_id}
+	})
+}
+
+// createRecurring is for customers only: every occurrence is placed on their behalf.
+func (h *RecurringHandler) createRecurring(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r) != middleware.RoleCustomer {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only customers can set up recurring orders"})
+		return
+	}
+	var req CreateRecurringOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.CustomerID = middleware.GetUserID(r)
+	if _, err := h.inventoryService.GetStore(r.Context(), req.StoreID); err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "store not found"})
+		return
+	}
+	if err := validateCustomerAssets(r, h.db, req.Items); err != nil {
+		respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	delivery := PlaceOrderRequest{StoreID: req.StoreID, CustomerID: req.CustomerID, DeliveryAddress: req.DeliveryAddress}
+	if err := validateCustomerDelivery(r, h.db, &delivery); err != nil {
+		respond(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	req.DeliveryAddress = delivery.DeliveryAddress
+	ro, err := h.service.CreateRecurring(r.Context(), req)
+	if err != nil {
+		respondRecurringError(w, err)
+		return
+	}
+	respond(w, http.StatusCreated, ro)
+}
+
+func (h *RecurringHandler) getRecurring(w http.ResponseWriter, r *http.Request) {
+	ro, ok := h.requireRecurringAccess(w, r, true)
+	if !ok {
+		return
+	}
+	respond(w, http.StatusOK, ro)
+}
+
+func (h *RecurringHandler) pauseRecurring(w http.ResponseWriter, r *http.Request) {
+	ro, ok := h.requireRecurringAccess(w, r, false)
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	paused, err := h.service.PauseRecurring(r.Context(), ro.ID.String(), req.Reason)
+	if err != nil {
+		respondRecurringError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, paused)
+}
+
+// resumeRecurring is for the customer or an administrator: resuming commits the customer to further orders.
+func (h *RecurringHandler) resumeRecurring(w http.ResponseWriter, r *http.Request) {
+	role := middleware.GetRole(r)
+	if role != middleware.RoleCustomer && role != middleware.RoleAdmin {
+		respond(w, http.StatusForbidden, map[string]string{"error": "only the customer can resume a recurring order"})
+		return
+	}
+	ro, ok := h.requireRecurringAccess(w, r, false)
+	if !ok {
+		return
+	}
+	resumed, err := h.service.ResumeRecurring(r.Context(), ro.ID.String())
+	if err != nil {
+		respondRecurringError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, resumed)
+}
+
+func (h *RecurringHandler) endRecurring(w http.ResponseWriter, r *http.Request) {
+	ro, ok := h.requireRecurringAccess(w, r, false)
+	if !ok {
+		return
+	}
+	ended, err := h.service.EndRecurring(r.Context(), ro.ID.String())
+	if err != nil {
+		respondRecurringError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, ended)
+}
+
+func (h *RecurringHandler) listRuns(w http.ResponseWriter, r *http.Request) {
+	ro, ok := h.requireRecurringAccess(w, r, true)
+	if !ok {
+		return
+	}
+	runs, err := h.service.ListRecurringRuns(r.Context(), ro.ID.String())
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if runs == nil {
+		runs = make([]*RecurringRun, 0)
+	}
+	respond(w, http.StatusOK, runs)
+}
+
+func (h *RecurringHandler) listStoreRecurring(w http.ResponseWriter, r *http.Request) {
+	storeID := chi.URLParam(r, "store_id")
+	if !h.requireStoreAccess(w, r, storeID, true) {
+		return
+	}
+	orders, err := h.service.ListStoreRecurring(r.Context(), storeID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if orders == nil {
+		orders = make([]*RecurringOrder, 0)
+	}
+	respond(w, http.StatusOK, orders)
+}
+
+func (h *RecurringHandler) listCustomerRecurring(w http.ResponseWriter, r *http.Request) {
+	customerID := chi.URLParam(r, "customer_id")
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+	case middleware.RoleCustomer:
+		if customerID != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "customer scope does not match authenticated user"})
+			return
+		}
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		return
+	}
+	orders, err := h.service.ListCustomerRecurring(r.Context(), customerID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if orders == nil {
+		orders = make([]*RecurringOrder, 0)
+	}
+	respond(w, http.StatusOK, orders)
+}
+
+// requireRecurringAccess loads the recurring order and lets through the customer who set it up and the store's
+// owner, plus its staff when allowStaff is set.
+func (h *RecurringHandler) requireRecurringAccess(w http.ResponseWriter, r *http.Request, allowStaff bool) (*RecurringOrder, bool) {
+	ro, err := h.service.GetRecurring(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondRecurringError(w, err)
+		return nil, false
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		if ro.CustomerID.String() != middleware.GetUserID(r) {
+			respond(w, http.StatusForbidden, map[string]string{"error": "recurring order does not belong to authenticated customer"})
+			return nil, false
+		}
+		return ro, true
+	}
+	if !h.requireStoreAccess(w, r, ro.StoreID.String(), allowStaff) {
+		return nil, false
+	}
+	return ro, true
+}
+
+func (h *RecurringHandler) requireStoreAccess(w http.ResponseWriter, r *http.Request, storeID string, allowStaff bool) bool {
+	store, err := h.inventoryService.GetStore(r.Context(), storeID)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "store not found"})
+		return false
+	}
+
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		return true
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err == nil && v.ID == store.VendorID {
+			return true
+		}
+	case middleware.RoleStaff, middleware.RoleCashier:
+		if allowStaff {
+			staff, err := h.inventoryService.ListStaff(r.Context(), storeID)
+			if err == nil {
+				for _, member := range staff {
+					if member.UserID.String() == middleware.GetUserID(r) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+	return false
+}
+
+func respondRecurringError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrRecurringNotActive) || errors.Is(err, ErrRecurringNotPaused) || errors.Is(err, ErrRecurringEnded):
+		code = http.StatusConflict
+	case strings.Contains(msg, "invalid"):
+		code = http.StatusBadRequest
+	case strings.Contains(msg, "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(msg, "required") || strings.Contains(msg, "must") || strings.Contains(msg, "at least one"):
+		code = http.StatusBadRequest
+	}
+	respond(w, code, map[string]string{"error": msg})
+}
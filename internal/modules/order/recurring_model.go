@@ -0,0 +1,143 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurrenceFrequency is the unit a recurring order repeats in.
+type RecurrenceFrequency string
+
+const (
+	FrequencyWeekly  RecurrenceFrequency = "WEEKLY"
+	FrequencyMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+// RecurringStatus is the lifecycle state of a recurring order. Only ACTIVE templates are run.
+type RecurringStatus string
+
+const (
+	RecurringActive RecurringStatus = "ACTIVE"
+	RecurringPaused RecurringStatus = "PAUSED"
+	RecurringEnded  RecurringStatus = "ENDED"
+)
+
+// UnavailablePolicy decides what a run does when a product is unavailable, missing from the store or out of stock,
+// or the template no longer fits it: its customisation breaks the product's options or its promo code was closed.
+type UnavailablePolicy string
+
+const (
+	UnavailableSkip  UnavailablePolicy = "SKIP"  // skip this occurrence and try again at the next one
+	UnavailablePause UnavailablePolicy = "PAUSE" // pause the template until the customer resumes it
+)
+
+// RecurringOrder is a customer's order template placed again on a fixed schedule. Every occurrence is derived from
+// StartsAt, so a monthly order started on the 31st runs on the last day of shorter months without drifting.
+type RecurringOrder struct {
+	ID              uuid.UUID           `json:"id"`
+	CustomerID      uuid.UUID           `json:"customer_id"`
+	StoreID         uuid.UUID           `json:"store_id"`
+	Name            string              `json:"name"`
+	Items           []CartItem          `json:"items"`
+	Notes           string              `json:"notes,omitempty"`
+	DeliveryAddress json.RawMessage     `json:"delivery_address,omitempty"`
+	Frequency       RecurrenceFrequency `json:"frequency"`
+	Interval        int                 `json:"interval"` // every Interval weeks or months
+	StartsAt        time.Time           `json:"starts_at"`
+	EndsAt          *time.Time          `json:"ends_at,omitempty"`
+	OnUnavailable   UnavailablePolicy   `json:"on_unavailable"`
+	Status          RecurringStatus     `json:"status"`
+	PauseReason     string              `json:"pause_reason,omitempty"`
+	NextRunAt       *time.Time          `json:"next_run_at,omitempty"` // nil once ENDED
+	LastOrderID     *uuid.UUID          `json:"last_order_id,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// RecurringOutcome records what the scheduler did with one occurrence.
+type RecurringOutcome string
+
+const (
+	OutcomePlaced  RecurringOutcome = "PLACED"
+	OutcomeSkipped RecurringOutcome = "SKIPPED"
+	OutcomePaused  RecurringOutcome = "PAUSED"
+)
+
+// RecurringRun is the scheduler's record of one occurrence of a recurring order.
+type RecurringRun struct {
+	ID               uuid.UUID        `json:"id"`
+	RecurringOrderID uuid.UUID        `json:"recurring_order_id"`
+	OccurrenceAt     time.Time        `json:"occurrence_at"`
+	Outcome          RecurringOutcome `json:"outcome"`
+	OrderID          *uuid.UUID       `json:"order_id,omitempty"`
+	Detail           string           `json:"detail,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// CreateRecurringOrderRequest is the payload for a new recurring order. StartsAt is the first occurrence and
+// defaults to now; OnUnavailable defaults to SKIP.
+type CreateRecurringOrderRequest struct {
+	StoreID         string          `json:"store_id"`
+	CustomerID      string          `json:"-"`
+	Name            string          `json:"name"`
+	Items           []CartItem      `json:"items"`
+	Notes           string          `json:"notes,omitempty"`
+	DeliveryAddress json.RawMessage `json:"delivery_address,omitempty"`
+	Frequency       string          `json:"frequency"`
+	Interval        int             `json:"interval,omitempty"`
+	StartsAt        *time.Time      `json:"starts_at,omitempty"`
+	EndsAt          *time.Time      `json:"ends_at,omitempty"`
+	OnUnavailable   string          `json:"on_unavailable,omitempty"`
+}
+
+// RecurringCursor is where ListDueRecurring resumes: after the recurring order with this next run and ID.
+type RecurringCursor struct {
+	NextRunAt time.Time
+	ID        uuid.UUID
+}
+
+// RecurringRunSummary counts what one scheduler pass did. Failed occurrences are left due and retried next pass.
+type RecurringRunSummary struct {
+	Placed  int `json:"placed"`
+	Skipped int `json:"skipped"`
+	Paused  int `json:"paused"`
+	Failed  int `json:"failed"`
+}
+
+// ErrRecurringNotActive is returned when pausing a recurring order that is not ACTIVE.
+var ErrRecurringNotActive = errors.New("recurring order is not active")
+
+// ErrRecurringNotPaused is returned when resuming a recurring order that is not PAUSED.
+var ErrRecurringNotPaused = errors.New("recurring order is not paused")
+
+// ErrRecurringEnded is returned when changing a recurring order that has already ended.
+var ErrRecurringEnded = errors.New("recurring order has ended")
+
+// occurrence returns the n-th scheduled time, counting StartsAt as the 0th. Monthly occurrences keep StartsAt's
+// day of month, clamped to the last day of shorter months.
+func (ro *RecurringOrder) occurrence(n int) time.Time {
+	start := ro.StartsAt
+	if ro.Frequency == FrequencyWeekly {
+		return start.AddDate(0, 0, 7*ro.Interval*n)
+	}
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(ro.Interval*n), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(start.Day(), lastDay)-1)
+}
+
+// nextOccurrenceAfter returns the first scheduled time strictly after t, or nil when it would fall after EndsAt.
+func (ro *RecurringOrder) nextOccurrenceAfter(t time.Time) *time.Time {
+	for n := 0; ; n++ {
+		next := ro.occurrence(n)
+		if ro.EndsAt != nil && next.After(*ro.EndsAt) {
+			return nil
+		}
+		if next.After(t) {
+			return &next
+		}
+	}
+}
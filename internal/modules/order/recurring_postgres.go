@@ -0,0 +1,179 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type recurringPostgresRepository struct{ db *sql.DB }
+
+func NewRecurringPostgresRepository(db *sql.DB) RecurringRepository {
+	return &recurringPostgresRepository{db: db}
+}
+
+const recurringColumns = `id,customer_id,store_id,name,items,COALESCE(notes,''),delivery_address,frequency,interval_count,
+		       starts_at,ends_at,on_unavailable,status,COALESCE(pause_reason,''),next_run_at,last_order_id,created_at,updated_at`
+
+func (r *recurringPostgresRepository) CreateRecurring(ctx context.Context, ro *RecurringOrder) error {
+	items, err := json.Marshal(ro.Items)
+	if err != nil {
+		return fmt.Errorf("encode recurring order items: %w", err)
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO recurring_orders
+		  (id, customer_id, store_id, name, items, notes, delivery_address, frequency, interval_count, starts_at, ends_at,
+		   on_unavailable, status, next_run_at)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6,''),$7,$8,$9,$10,$11,$12,$13,$14)
+		RETURNING created_at, updated_at`,
+		ro.ID, ro.CustomerID, ro.StoreID, ro.Name, items, ro.Notes, nullableJSON(ro.DeliveryAddress), ro.Frequency,
+		ro.Interval, ro.StartsAt, ro.EndsAt, ro.OnUnavailable, ro.Status, ro.NextRunAt,
+	).Scan(&ro.CreatedAt, &ro.UpdatedAt)
+}
+
+func (r *recurringPostgresRepository) GetRecurring(ctx context.Context, id string) (*RecurringOrder, error) {
+	return scanRecurring(r.db.QueryRowContext(ctx, `SELECT `+recurringColumns+` FROM recurring_orders WHERE id=$1`, id))
+}
+
+func (r *recurringPostgresRepository) ListRecurringByCustomer(ctx context.Context, customerID string) ([]*RecurringOrder, error) {
+	return r.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM recurring_orders WHERE customer_id=$1
+		ORDER BY created_at DESC`, customerID)
+}
+
+func (r *recurringPostgresRepository) ListRecurringByStore(ctx context.Context, storeID string) ([]*RecurringOrder, error) {
+	return r.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM recurring_orders WHERE store_id=$1
+		ORDER BY created_at DESC`, storeID)
+}
+
+func (r *recurringPostgresRepository) ListDueRecurring(ctx context.Context, now time.Time, after *RecurringCursor, limit int) ([]*RecurringOrder, error) {
+	if after == nil {
+		return r.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM recurring_orders
+			WHERE status='ACTIVE' AND next_run_at <= $1
+			ORDER BY next_run_at ASC, id ASC
+			LIMIT $2`, now, limit)
+	}
+	return r.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM recurring_orders
+		WHERE status='ACTIVE' AND next_run_at <= $1 AND (next_run_at, id) > ($2, $3)
+		ORDER BY next_run_at ASC, id ASC
+		LIMIT $4`, now, after.NextRunAt, after.ID, limit)
+}
+
+func (r *recurringPostgresRepository) RecordRecurringRun(ctx context.Context, ro *RecurringOrder, run *RecurringRun) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE recurring_orders
+		SET status=$2, pause_reason=NULLIF($3,''), next_run_at=$4, last_order_id=COALESCE($5, last_order_id),
+		    updated_at=NOW()
+		WHERE id=$1 AND status='ACTIVE' AND next_run_at=$6`,
+		ro.ID, ro.Status, ro.PauseReason, ro.NextRunAt, run.OrderID, run.OccurrenceAt)
+	if err != nil {
+		return fmt.Errorf("advance recurring order: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO recurring_order_runs (id, recurring_order_id, occurrence_at, outcome, order_id, detail)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6,''))
+		RETURNING created_at`,
+		run.ID, run.RecurringOrderID, run.OccurrenceAt, run.Outcome, run.OrderID, run.Detail,
+	).Scan(&run.CreatedAt); err != nil {
+		return fmt.Errorf("record recurring run: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *recurringPostgresRepository) UpdateRecurringSchedule(ctx context.Context, ro *RecurringOrder) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_orders
+		SET status=$2, pause_reason=NULLIF($3,''), next_run_at=$4, updated_at=NOW()
+		WHERE id=$1 AND status <> 'ENDED'`,
+		ro.ID, ro.Status, ro.PauseReason, ro.NextRunAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRecurringEnded
+	}
+	return nil
+}
+
+func (r *recurringPostgresRepository) ListRecurringRuns(ctx context.Context, id string) ([]*RecurringRun, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, recurring_order_id, occurrence_at, outcome, order_id, COALESCE(detail,''), created_at
+		FROM recurring_order_runs WHERE recurring_order_id=$1
+		ORDER BY occurrence_at DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []*RecurringRun
+	for rows.Next() {
+		run := &RecurringRun{}
+		var orderID uuid.NullUUID
+		if err := rows.Scan(&run.ID, &run.RecurringOrderID, &run.OccurrenceAt, &run.Outcome, &orderID, &run.Detail, &run.CreatedAt); err != nil {
+			return nil, err
+		}
+		if orderID.Valid {
+			run.OrderID = &orderID.UUID
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *recurringPostgresRepository) queryRecurring(ctx context.Context, query string, args ...interface{}) ([]*RecurringOrder, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []*RecurringOrder
+	for rows.Next() {
+		ro, err := scanRecurring(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, ro)
+	}
+	return orders, rows.Err()
+}
+
+type recurringScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecurring(row recurringScanner) (*RecurringOrder, error) {
+	ro := &RecurringOrder{}
+	var items, deliveryAddress []byte
+	var endsAt, nextRunAt sql.NullTime
+	var lastOrderID uuid.NullUUID
+	if err := row.Scan(&ro.ID, &ro.CustomerID, &ro.StoreID, &ro.Name, &items, &ro.Notes, &deliveryAddress,
+		&ro.Frequency, &ro.Interval, &ro.StartsAt, &endsAt, &ro.OnUnavailable, &ro.Status, &ro.PauseReason,
+		&nextRunAt, &lastOrderID, &ro.CreatedAt, &ro.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &ro.Items); err != nil {
+		return nil, fmt.Errorf("decode recurring order items: %w", err)
+	}
+	ro.DeliveryAddress = deliveryAddress
+	if endsAt.Valid {
+		ro.EndsAt = &endsAt.Time
+	}
+	if nextRunAt.Valid {
+		ro.NextRunAt = &nextRunAt.Time
+	}
+	if lastOrderID.Valid {
+		ro.LastOrderID = &lastOrderID.UUID
+	}
+	return ro, nil
+}
@@ -0,0 +1,32 @@
+package order
+
+import (
+	"context"
+	"time"
+)
+
+// RecurringRepository persists recurring orders and the record of each scheduled run. Placing the order itself goes
+// through Service.PlaceOrder, whose idempotency key makes a repeated run return the order already placed.
+type RecurringRepository interface {
+	CreateRecurring(ctx context.Context, ro *RecurringOrder) error
+	GetRecurring(ctx context.Context, id string) (*RecurringOrder, error)
+
+	// ListRecurringByCustomer and ListRecurringByStore return recurring orders, newest first.
+	ListRecurringByCustomer(ctx context.Context, customerID string) ([]*RecurringOrder, error)
+	ListRecurringByStore(ctx context.Context, storeID string) ([]*RecurringOrder, error)
+
+	// ListDueRecurring returns up to limit ACTIVE recurring orders whose next run is at or before now, ordered by
+	// next run and ID. With after set it returns the ones ordered after that position.
+	ListDueRecurring(ctx context.Context, now time.Time, after *RecurringCursor, limit int) ([]*RecurringOrder, error)
+
+	// RecordRecurringRun appends run and saves ro's status, pause reason, next run and last order in one
+	// transaction. It does nothing when the occurrence was already recorded or ro is no longer ACTIVE and due at
+	// run.OccurrenceAt, so two overlapping scheduler passes record each occurrence once.
+	RecordRecurringRun(ctx context.Context, ro *RecurringOrder, run *RecurringRun) error
+
+	// UpdateRecurringSchedule saves a pause, resume or end of ro.
+	UpdateRecurringSchedule(ctx context.Context, ro *RecurringOrder) error
+
+	// ListRecurringRuns returns a recurring order's runs, newest first.
+	ListRecurringRuns(ctx context.Context, id string) ([]*RecurringRun, error)
+}
@@ -0,0 +1,358 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/notification"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/google/uuid"
+)
+
+// RecurringService manages recurring order templates and runs the ones that are due.
+type RecurringService interface {
+	// CreateRecurring validates and stores an ACTIVE recurring order whose first run is at StartsAt.
+	CreateRecurring(ctx context.Context, req CreateRecurringOrderRequest) (*RecurringOrder, error)
+	GetRecurring(ctx context.Context, id string) (*RecurringOrder, error)
+	ListCustomerRecurring(ctx context.Context, customerID string) ([]*RecurringOrder, error)
+	ListStoreRecurring(ctx context.Context, storeID string) ([]*RecurringOrder, error)
+	ListRecurringRuns(ctx context.Context, id string) ([]*RecurringRun, error)
+
+	// PauseRecurring stops an ACTIVE recurring order from running; ResumeRecurring schedules a PAUSED one again from
+	// its next occurrence after now. EndRecurring stops it for good.
+	PauseRecurring(ctx context.Context, id, reason string) (*RecurringOrder, error)
+	ResumeRecurring(ctx context.Context, id string) (*RecurringOrder, error)
+	EndRecurring(ctx context.Context, id string) (*RecurringOrder, error)
+
+	// RunDue places one order for every due ACTIVE recurring order through Service.PlaceOrder, keyed by the
+	// occurrence so a repeated pass cannot order twice. When a product is unavailable or out of stock, or the template
+	// no longer fits it, the occurrence is skipped or the template paused, as its OnUnavailable policy says. Occurrences missed while the scheduler was
+	// down are not caught up: each due template places at most one order per pass. The customer is notified of
+	// every outcome.
+	RunDue(ctx context.Context, now time.Time) (*RecurringRunSummary, error)
+}
+
+// Notifier tells customers what happened to their recurring orders. It is satisfied by notification.Service.
+type Notifier interface {
+	Dispatch(ctx context.Context, event notification.Event) error
+}
+
+// maxRecurringBatch bounds how many due recurring orders one RunDue pass loads at a time.
+const maxRecurringBatch = 100
+
+type recurringService struct {
+	repo     RecurringRepository
+	orders   Service
+	notifier Notifier
+	now      func() time.Time
+}
+
+// NewRecurringService creates a recurring order service that places orders through orders and notifies customers
+// through notifier.
+func NewRecurringService(repo RecurringRepository, orders Service, notifier Notifier) RecurringService {
+	return &recurringService{repo: repo, orders: orders, notifier: notifier, now: time.Now}
+}
+
+func (s *recurringService) CreateRecurring(ctx context.Context, req CreateRecurringOrderRequest) (*RecurringOrder, error) {
+	storeID, err := uuid.Parse(req.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store_id: %w", err)
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer_id: %w", err)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(name) > 120 {
+		return nil, fmt.Errorf("name must not exceed 120 characters")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("recurring order must contain at least one item")
+	}
+	for _, ci := range req.Items {
+		if ci.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be > 0 for product %s", ci.VendorStoreProductID)
+		}
+		if _, err := uuid.Parse(ci.VendorStoreProductID); err != nil {
+			return nil, fmt.Errorf("invalid vendor_store_product_id: %w", err)
+		}
+	}
+
+	frequency := RecurrenceFrequency(strings.ToUpper(strings.TrimSpace(req.Frequency)))
+	if frequency != FrequencyWeekly && frequency != FrequencyMonthly {
+		return nil, fmt.Errorf("frequency must be WEEKLY or MONTHLY")
+	}
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 1 || interval > 12 {
+		return nil, fmt.Errorf("interval must be between 1 and 12")
+	}
+	policy := UnavailablePolicy(strings.ToUpper(strings.TrimSpace(req.OnUnavailable)))
+	if policy == "" {
+		policy = UnavailableSkip
+	}
+	if policy != UnavailableSkip && policy != UnavailablePause {
+		return nil, fmt.Errorf("on_unavailable must be SKIP or PAUSE")
+	}
+
+	now := s.now().UTC()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = req.StartsAt.UTC()
+		if startsAt.Before(now.Add(-time.Minute)) {
+			return nil, fmt.Errorf("starts_at must not be in the past")
+		}
+	}
+	// Whole seconds keep every derived occurrence exactly representable in Postgres.
+	startsAt = startsAt.Truncate(time.Second)
+	var endsAt *time.Time
+	if req.EndsAt != nil {
+		end := req.EndsAt.UTC()
+		if end.Before(startsAt) {
+			return nil, fmt.Errorf("ends_at must not be before starts_at")
+		}
+		endsAt = &end
+	}
+
+	ro := &RecurringOrder{
+		ID:              uuid.New(),
+		CustomerID:      customerID,
+		StoreID:         storeID,
+		Name:            name,
+		Items:           req.Items,
+		Notes:           strings.TrimSpace(req.Notes),
+		DeliveryAddress: req.DeliveryAddress,
+		Frequency:       frequency,
+		Interval:        interval,
+		StartsAt:        startsAt,
+		EndsAt:          endsAt,
+		OnUnavailable:   policy,
+		Status:          RecurringActive,
+		NextRunAt:       &startsAt,
+	}
+	if err := s.repo.CreateRecurring(ctx, ro); err != nil {
+		return nil, fmt.Errorf("failed to persist recurring order: %w", err)
+	}
+	return ro, nil
+}
+
+func (s *recurringService) GetRecurring(ctx context.Context, id string) (*RecurringOrder, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid recurring order id")
+	}
+	ro, err := s.repo.GetRecurring(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("recurring order not found: %w", err)
+	}
+	return ro, nil
+}
+
+func (s *recurringService) ListCustomerRecurring(ctx context.Context, customerID string) ([]*RecurringOrder, error) {
+	return s.repo.ListRecurringByCustomer(ctx, customerID)
+}
+
+func (s *recurringService) ListStoreRecurring(ctx context.Context, storeID string) ([]*RecurringOrder, error) {
+	return s.repo.ListRecurringByStore(ctx, storeID)
+}
+
+func (s *recurringService) ListRecurringRuns(ctx context.Context, id string) ([]*RecurringRun, error) {
+	return s.repo.ListRecurringRuns(ctx, id)
+}
+
+func (s *recurringService) PauseRecurring(ctx context.Context, id, reason string) (*RecurringOrder, error) {
+	ro, err := s.GetRecurring(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ro.Status != RecurringActive {
+		return nil, ErrRecurringNotActive
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxStatusReasonLength {
+		return nil, fmt.Errorf("reason must not exceed %d characters", maxStatusReasonLength)
+	}
+	ro.Status, ro.PauseReason = RecurringPaused, reason
+	return ro, s.saveSchedule(ctx, ro)
+}
+
+func (s *recurringService) ResumeRecurring(ctx context.Context, id string) (*RecurringOrder, error) {
+	ro, err := s.GetRecurring(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ro.Status != RecurringPaused {
+		return nil, ErrRecurringNotPaused
+	}
+	ro.PauseReason = ""
+	now := s.now()
+	if ro.StartsAt.After(now) {
+		ro.Status, ro.NextRunAt = RecurringActive, &ro.StartsAt
+	} else if next := ro.nextOccurrenceAfter(now); next != nil {
+		ro.Status, ro.NextRunAt = RecurringActive, next
+	} else {
+		ro.Status, ro.NextRunAt = RecurringEnded, nil
+	}
+	return ro, s.saveSchedule(ctx, ro)
+}
+
+func (s *recurringService) EndRecurring(ctx context.Context, id string) (*RecurringOrder, error) {
+	ro, err := s.GetRecurring(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ro.Status == RecurringEnded {
+		return nil, ErrRecurringEnded
+	}
+	ro.Status, ro.NextRunAt = RecurringEnded, nil
+	return ro, s.saveSchedule(ctx, ro)
+}
+
+func (s *recurringService) saveSchedule(ctx context.Context, ro *RecurringOrder) error {
+	if err := s.repo.UpdateRecurringSchedule(ctx, ro); err != nil {
+		if errors.Is(err, ErrRecurringEnded) {
+			return err
+		}
+		return fmt.Errorf("failed to update recurring order: %w", err)
+	}
+	return nil
+}
+
+func (s *recurringService) RunDue(ctx context.Context, now time.Time) (*RecurringRunSummary, error) {
+	summary := &RecurringRunSummary{}
+	// Failed occurrences stay due, so the pass pages on past each batch rather than re-reading from the oldest due:
+	// a full batch of templates that keep failing must not hide the ones behind it.
+	var after *RecurringCursor
+	for {
+		due, err := s.repo.ListDueRecurring(ctx, now, after, maxRecurringBatch)
+		if err != nil {
+			return summary, fmt.Errorf("list due recurring orders: %w", err)
+		}
+		if len(due) > 0 {
+			last := due[len(due)-1]
+			after = &RecurringCursor{NextRunAt: *last.NextRunAt, ID: last.ID}
+		}
+		for _, ro := range due {
+			outcome, err := s.runOccurrence(ctx, ro, now)
+			if err != nil {
+				log.Printf("recurring order %s: %v", ro.ID, err)
+				summary.Failed++
+				continue
+			}
+			switch outcome {
+			case OutcomePlaced:
+				summary.Placed++
+			case OutcomeSkipped:
+				summary.Skipped++
+			case OutcomePaused:
+				summary.Paused++
+			}
+		}
+		if len(due) < maxRecurringBatch {
+			return summary, nil
+		}
+	}
+}
+
+// runOccurrence places, skips or pauses ro's due occurrence and records the outcome. It returns an error, leaving
+// the occurrence due, only when the order could not be placed for a reason unavailableReason does not recognise.
+func (s *recurringService) runOccurrence(ctx context.Context, ro *RecurringOrder, now time.Time) (RecurringOutcome, error) {
+	occurrence := *ro.NextRunAt
+	run := &RecurringRun{ID: uuid.New(), RecurringOrderID: ro.ID, OccurrenceAt: occurrence}
+
+	o, err := s.orders.PlaceOrder(ctx, PlaceOrderRequest{
+		StoreID:         ro.StoreID.String(),
+		CustomerID:      ro.CustomerID.String(),
+		Channel:         string(ChannelOnline),
+		Items:           ro.Items,
+		Notes:           ro.Notes,
+		DeliveryAddress: ro.DeliveryAddress,
+		IdempotencyKey:  recurringIdempotencyKey(ro.ID, occurrence),
+	})
+	switch {
+	case err == nil:
+		run.Outcome, run.OrderID = OutcomePlaced, &o.ID
+	case unavailableReason(err) != "":
+		run.Detail = unavailableReason(err)
+		run.Outcome = OutcomeSkipped
+		if ro.OnUnavailable == UnavailablePause {
+			run.Outcome = OutcomePaused
+		}
+	default:
+		return "", err
+	}
+
+	if run.Outcome == OutcomePaused {
+		ro.Status, ro.PauseReason = RecurringPaused, run.Detail
+	} else if ro.NextRunAt = ro.nextOccurrenceAfter(maxTime(occurrence, now)); ro.NextRunAt == nil {
+		ro.Status = RecurringEnded
+	}
+	if err := s.repo.RecordRecurringRun(ctx, ro, run); err != nil {
+		return "", err
+	}
+	s.notify(ctx, ro, run, o)
+	return run.Outcome, nil
+}
+
+// notify tells the customer about a run. Notification failures are logged, never retried through the run.
+func (s *recurringService) notify(ctx context.Context, ro *RecurringOrder, run *RecurringRun, o *Order) {
+	if s.notifier == nil {
+		return
+	}
+	event := notification.Event{
+		RecipientID: ro.CustomerID.String(),
+		Metadata:    map[string]string{"recurring_order_id": ro.ID.String(), "store_id": ro.StoreID.String()},
+	}
+	date := run.OccurrenceAt.Format("2 January 2006")
+	switch run.Outcome {
+	case OutcomePlaced:
+		event.Type, event.Title = notification.TypeOrderPlaced, "Recurring order placed"
+		event.Body = fmt.Sprintf("Your recurring order %q for %s was placed as %s.", ro.Name, date, o.OrderNumber)
+		event.Metadata["order_id"] = o.ID.String()
+	case OutcomeSkipped:
+		event.Type, event.Title = notification.TypeRecurringOrderSkipped, "Recurring order skipped"
+		event.Body = fmt.Sprintf("Your recurring order %q for %s was skipped: %s.", ro.Name, date, run.Detail)
+	case OutcomePaused:
+		event.Type, event.Title = notification.TypeRecurringOrderPaused, "Recurring order paused"
+		event.Body = fmt.Sprintf("Your recurring order %q was paused on %s because %s. Resume it once the product is back.", ro.Name, date, run.Detail)
+		event.Priority = notification.PriorityHigh
+	}
+	if err := s.notifier.Dispatch(ctx, event); err != nil {
+		log.Printf("recurring order %s: notify customer: %v", ro.ID, err)
+	}
+}
+
+// recurringIdempotencyKey identifies the order placed for one occurrence of a recurring order.
+func recurringIdempotencyKey(id uuid.UUID, occurrence time.Time) string {
+	return "recurring-" + id.String() + "-" + occurrence.UTC().Format(time.RFC3339)
+}
+
+// unavailableReason describes why PlaceOrder could not place the template's lines as they stand: a product is
+// missing, switched off or out of stock, a customisation no longer fits the product's options, or a promo code can
+// no longer be applied. It returns "" for any other failure, which may succeed if retried.
+func unavailableReason(err error) string {
+	var oos *OutOfStockError
+	var invalid *CustomisationError
+	var rejected *promo.RejectionError
+	switch {
+	case errors.As(err, &oos), errors.As(err, &invalid), errors.As(err, &rejected),
+		errors.Is(err, ErrProductNotInStore), errors.Is(err, ErrProductUnavailable):
+		return err.Error()
+	}
+	return ""
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
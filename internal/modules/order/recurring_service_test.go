@@ -0,0 +1,325 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/notification"
+	"github.com/google/uuid"
+)
+
+type fakeRecurringRepository struct {
+	orders map[uuid.UUID]*RecurringOrder
+	runs   []*RecurringRun
+}
+
+func newFakeRecurringRepository() *fakeRecurringRepository {
+	return &fakeRecurringRepository{orders: make(map[uuid.UUID]*RecurringOrder)}
+}
+
+func (f *fakeRecurringRepository) CreateRecurring(_ context.Context, ro *RecurringOrder) error {
+	copied := *ro
+	f.orders[ro.ID] = &copied
+	return nil
+}
+
+func (f *fakeRecurringRepository) GetRecurring(_ context.Context, id string) (*RecurringOrder, error) {
+	ro, ok := f.orders[uuid.MustParse(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *ro
+	return &copied, nil
+}
+
+func (f *fakeRecurringRepository) ListRecurringByCustomer(context.Context, string) ([]*RecurringOrder, error) {
+	return nil, nil
+}
+
+func (f *fakeRecurringRepository) ListRecurringByStore(context.Context, string) ([]*RecurringOrder, error) {
+	return nil, nil
+}
+
+func (f *fakeRecurringRepository) ListDueRecurring(_ context.Context, now time.Time, after *RecurringCursor, limit int) ([]*RecurringOrder, error) {
+	var due []*RecurringOrder
+	for _, ro := range f.orders {
+		if ro.Status != RecurringActive || ro.NextRunAt.After(now) {
+			continue
+		}
+		if after != nil && (ro.NextRunAt.Before(after.NextRunAt) ||
+			(ro.NextRunAt.Equal(after.NextRunAt) && ro.ID.String() <= after.ID.String())) {
+			continue
+		}
+		copied := *ro
+		due = append(due, &copied)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextRunAt.Equal(*due[j].NextRunAt) {
+			return due[i].NextRunAt.Before(*due[j].NextRunAt)
+		}
+		return due[i].ID.String() < due[j].ID.String()
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (f *fakeRecurringRepository) RecordRecurringRun(_ context.Context, ro *RecurringOrder, run *RecurringRun) error {
+	stored := f.orders[ro.ID]
+	if stored.Status != RecurringActive || !stored.NextRunAt.Equal(run.OccurrenceAt) {
+		return nil
+	}
+	stored.Status, stored.PauseReason, stored.NextRunAt = ro.Status, ro.PauseReason, ro.NextRunAt
+	if run.OrderID != nil {
+		stored.LastOrderID = run.OrderID
+	}
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakeRecurringRepository) UpdateRecurringSchedule(_ context.Context, ro *RecurringOrder) error {
+	stored := f.orders[ro.ID]
+	if stored.Status == RecurringEnded {
+		return ErrRecurringEnded
+	}
+	stored.Status, stored.PauseReason, stored.NextRunAt = ro.Status, ro.PauseReason, ro.NextRunAt
+	return nil
+}
+
+func (f *fakeRecurringRepository) ListRecurringRuns(context.Context, string) ([]*RecurringRun, error) {
+	return f.runs, nil
+}
+
+type fakeNotifier struct {
+	events []notification.Event
+}
+
+func (f *fakeNotifier) Dispatch(_ context.Context, event notification.Event) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func newRecurringFixture(t *testing.T, stock int, policy string) (*recurringService, *fakeRepository, *fakeRecurringRepository, *fakeNotifier, *RecurringOrder) {
+	t.Helper()
+	orders := newFakeRepository()
+	productID := uuid.NewString()
+	orders.products[productID] = &fakeProduct{price: 1000, available: true, stock: stock}
+	repo := newFakeRecurringRepository()
+	notifier := &fakeNotifier{}
+	svc := NewRecurringService(repo, NewService(orders), notifier).(*recurringService)
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return start }
+
+	ro, err := svc.CreateRecurring(context.Background(), CreateRecurringOrderRequest{
+		StoreID:       uuid.NewString(),
+		CustomerID:    uuid.NewString(),
+		Name:          "Weekly flyers",
+		Items:         []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+		Frequency:     "weekly",
+		OnUnavailable: policy,
+	})
+	if err != nil {
+		t.Fatalf("create recurring order: %v", err)
+	}
+	return svc, orders, repo, notifier, ro
+}
+
+func TestRunDuePlacesEachOccurrenceOnceWithDeterministicKey(t *testing.T) {
+	svc, orders, repo, notifier, ro := newRecurringFixture(t, 10, "")
+	ctx := context.Background()
+	now := ro.StartsAt.Add(time.Hour)
+
+	summary, err := svc.RunDue(ctx, now)
+	if err != nil || summary.Placed != 1 {
+		t.Fatalf("expected one placed order, got %+v, %v", summary, err)
+	}
+	key := recurringIdempotencyKey(ro.ID, ro.StartsAt)
+	placed, err := orders.GetByIdempotencyKey(ctx, key)
+	if err != nil || placed.CustomerID == nil || *placed.CustomerID != ro.CustomerID {
+		t.Fatalf("expected the customer's order under key %s, got %+v, %v", key, placed, err)
+	}
+
+	// A second pass in the same window finds nothing due; a crash between placing and recording would be
+	// absorbed by the idempotency key instead.
+	if summary, _ := svc.RunDue(ctx, now); summary.Placed != 0 {
+		t.Fatalf("expected no further orders, got %+v", summary)
+	}
+	if len(orders.orders) != 1 {
+		t.Fatalf("expected exactly one order, got %d", len(orders.orders))
+	}
+	stored := repo.orders[ro.ID]
+	if want := ro.StartsAt.AddDate(0, 0, 7); !stored.NextRunAt.Equal(want) || *stored.LastOrderID != placed.ID {
+		t.Fatalf("expected next run %s and last order %s, got %+v", want, placed.ID, stored)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notification.TypeOrderPlaced ||
+		notifier.events[0].RecipientID != ro.CustomerID.String() {
+		t.Fatalf("expected one order-placed notification for the customer, got %+v", notifier.events)
+	}
+}
+
+func TestRunDueSkipsUnavailableOccurrenceAndTriesTheNext(t *testing.T) {
+	svc, orders, repo, notifier, ro := newRecurringFixture(t, 0, "SKIP")
+
+	summary, err := svc.RunDue(context.Background(), ro.StartsAt)
+	if err != nil || summary.Skipped != 1 {
+		t.Fatalf("expected one skipped occurrence, got %+v, %v", summary, err)
+	}
+	stored := repo.orders[ro.ID]
+	if stored.Status != RecurringActive || !stored.NextRunAt.Equal(ro.StartsAt.AddDate(0, 0, 7)) {
+		t.Fatalf("expected the template to stay active and move to next week, got %+v", stored)
+	}
+	if len(orders.orders) != 0 || len(repo.runs) != 1 || repo.runs[0].Outcome != OutcomeSkipped {
+		t.Fatalf("expected a recorded skip and no order, got orders=%d runs=%+v", len(orders.orders), repo.runs)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notification.TypeRecurringOrderSkipped {
+		t.Fatalf("expected a skip notification, got %+v", notifier.events)
+	}
+}
+
+func TestRunDuePausesOnUnavailableProductUntilResumed(t *testing.T) {
+	svc, orders, repo, notifier, ro := newRecurringFixture(t, 10, "PAUSE")
+	for _, p := range orders.products {
+		p.available = false
+	}
+	ctx := context.Background()
+
+	summary, err := svc.RunDue(ctx, ro.StartsAt)
+	if err != nil || summary.Paused != 1 {
+		t.Fatalf("expected the template to pause, got %+v, %v", summary, err)
+	}
+	stored := repo.orders[ro.ID]
+	if stored.Status != RecurringPaused || stored.PauseReason == "" {
+		t.Fatalf("expected a paused template with a reason, got %+v", stored)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notification.TypeRecurringOrderPaused {
+		t.Fatalf("expected a pause notification, got %+v", notifier.events)
+	}
+
+	// Resuming three weeks later picks up from the next occurrence instead of replaying the missed ones.
+	svc.now = func() time.Time { return ro.StartsAt.AddDate(0, 0, 20) }
+	resumed, err := svc.ResumeRecurring(ctx, ro.ID.String())
+	if err != nil || resumed.Status != RecurringActive || !resumed.NextRunAt.Equal(ro.StartsAt.AddDate(0, 0, 21)) {
+		t.Fatalf("expected an active template due on day 21, got %+v, %v", resumed, err)
+	}
+	if _, err := svc.ResumeRecurring(ctx, ro.ID.String()); err != ErrRecurringNotPaused {
+		t.Fatalf("expected ErrRecurringNotPaused, got %v", err)
+	}
+}
+
+func TestRunDuePagesPastTemplatesThatKeepFailing(t *testing.T) {
+	svc, orders, repo, _, ro := newRecurringFixture(t, 10, "")
+	// A full batch of templates due before ro whose delivery address no longer validates fails on every pass.
+	for i := 0; i < maxRecurringBatch; i++ {
+		broken := *ro
+		broken.ID = uuid.New()
+		broken.DeliveryAddress = []byte(`{"method":"DRONE"}`)
+		broken.NextRunAt = &broken.StartsAt
+		repo.orders[broken.ID] = &broken
+	}
+	later := ro.StartsAt.Add(time.Minute)
+	repo.orders[ro.ID].NextRunAt = &later
+
+	summary, err := svc.RunDue(context.Background(), later)
+	if err != nil || summary.Failed != maxRecurringBatch || summary.Placed != 1 {
+		t.Fatalf("expected every broken template tried once and the healthy one placed, got %+v, %v", summary, err)
+	}
+	if len(orders.orders) != 1 {
+		t.Fatalf("expected one order, got %d", len(orders.orders))
+	}
+}
+
+func TestRunDueSkipsATemplateWhoseCustomisationNoLongerFits(t *testing.T) {
+	svc, orders, repo, notifier, ro := newRecurringFixture(t, 10, "SKIP")
+	// The store has since made a size option required.
+	for _, p := range orders.products {
+		p.schema = &catalog.OptionSchema{Options: []catalog.ProductOption{
+			{Key: "size", Label: "Size", Type: catalog.OptionEnum, Required: true, Values: []string{"A4", "A3"}},
+		}}
+	}
+
+	summary, err := svc.RunDue(context.Background(), ro.StartsAt)
+	if err != nil || summary.Skipped != 1 || summary.Failed != 0 {
+		t.Fatalf("expected the occurrence skipped rather than retried, got %+v, %v", summary, err)
+	}
+	if stored := repo.orders[ro.ID]; !stored.NextRunAt.Equal(ro.StartsAt.AddDate(0, 0, 7)) {
+		t.Fatalf("expected the template moved to next week, got %+v", stored)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notification.TypeRecurringOrderSkipped {
+		t.Fatalf("expected a skip notification, got %+v", notifier.events)
+	}
+}
+
+func TestRunDueRetriesAnOccurrenceWhenTheCatalogCannotBeRead(t *testing.T) {
+	svc, orders, repo, notifier, ro := newRecurringFixture(t, 10, "SKIP")
+	for _, p := range orders.products {
+		p.err = errors.New("connection reset by peer")
+	}
+
+	summary, err := svc.RunDue(context.Background(), ro.StartsAt)
+	if err != nil || summary.Failed != 1 || summary.Skipped != 0 || summary.Paused != 0 {
+		t.Fatalf("expected the occurrence left for a retry, got %+v, %v", summary, err)
+	}
+	if stored := repo.orders[ro.ID]; !stored.NextRunAt.Equal(ro.StartsAt) || stored.Status != RecurringActive {
+		t.Fatalf("expected the template still due and active, got %+v", stored)
+	}
+	if len(notifier.events) != 0 {
+		t.Fatalf("a transient failure must not notify the customer, got %+v", notifier.events)
+	}
+}
+
+func TestMonthlyOccurrencesClampToMonthEndWithoutDrifting(t *testing.T) {
+	ro := &RecurringOrder{
+		Frequency: FrequencyMonthly,
+		Interval:  1,
+		StartsAt:  time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+	}
+	want := []time.Time{
+		time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 28, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 31, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC),
+	}
+	for n, expected := range want {
+		if got := ro.occurrence(n); !got.Equal(expected) {
+			t.Fatalf("occurrence %d: expected %s, got %s", n, expected, got)
+		}
+	}
+
+	end := time.Date(2026, 3, 31, 8, 0, 0, 0, time.UTC)
+	ro.EndsAt = &end
+	if next := ro.nextOccurrenceAfter(end); next != nil {
+		t.Fatalf("expected no occurrence after ends_at, got %s", next)
+	}
+}
+
+func TestCreateRecurringRejectsBadSchedules(t *testing.T) {
+	svc, _, _, _, _ := newRecurringFixture(t, 1, "")
+	base := CreateRecurringOrderRequest{
+		StoreID:    uuid.NewString(),
+		CustomerID: uuid.NewString(),
+		Name:       "Stickers",
+		Items:      []CartItem{{VendorStoreProductID: uuid.NewString(), Quantity: 1}},
+		Frequency:  "MONTHLY",
+	}
+	past := svc.now().Add(-time.Hour)
+	for name, mutate := range map[string]func(*CreateRecurringOrderRequest){
+		"frequency": func(r *CreateRecurringOrderRequest) { r.Frequency = "DAILY" },
+		"interval":  func(r *CreateRecurringOrderRequest) { r.Interval = 13 },
+		"policy":    func(r *CreateRecurringOrderRequest) { r.OnUnavailable = "RETRY" },
+		"starts_at": func(r *CreateRecurringOrderRequest) { r.StartsAt = &past },
+		"ends_at":   func(r *CreateRecurringOrderRequest) { r.EndsAt = &past },
+		"items":     func(r *CreateRecurringOrderRequest) { r.Items = nil },
+	} {
+		req := base
+		mutate(&req)
+		if _, err := svc.CreateRecurring(context.Background(), req); err == nil {
+			t.Fatalf("%s: expected validation error", name)
+		}
+	}
+}
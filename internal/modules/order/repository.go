@@ -4,14 +4,17 @@ import "context"
 
 // Repository defines data access for orders.
 type Repository interface {
-	// CreateOrder persists a new order and its items atomically in a transaction.
-	CreateOrder(ctx context.Context, o *Order) error
+	// CreateOrder persists a new order and its items atomically in a transaction, reserving stock for every line
+	// under a row lock, and records the placement as the first status event. It returns *OutOfStockError when a
+	// line cannot be covered.
+	CreateOrder(ctx context.Context, o *Order, change StatusChange) error
 
 	// GetOrderByID retrieves an order with its items by UUID.
 	GetOrderByID(ctx context.Context, id string) (*Order, error)
 
-	// GetOrderByNumber retrieves an order by its human-readable order number.
-	GetOrderByNumber(ctx context.Context, orderNumber string) (*Order, error)
+	// GetOrderByNumber retrieves an order by its human-readable order number, within one store when storeID is set.
+	// It returns ErrAmbiguousOrderNumber when no store is given and more than one store has issued the number.
+	GetOrderByNumber(ctx context.Context, storeID, orderNumber string) (*Order, error)
 	GetByIdempotencyKey(ctx context.Context, key string) (*Order, error)
 
 	// ListOrdersByStore returns all orders for a given store, optionally filtered by status.
@@ -20,9 +23,65 @@ type Repository interface {
 	// ListOrdersByCustomer returns all orders placed by a specific customer.
 	ListOrdersByCustomer(ctx context.Context, customerID string) ([]*Order, error)
 
-	// UpdateStatus advances an order to a new status.
-	UpdateStatus(ctx context.Context, id string, status OrderStatus) error
+	// SearchOrders returns up to search.Limit orders matching the filters, newest first and starting after the
+	// cursor when one is given, together with the number of orders matching the filters across all pages.
+	SearchOrders(ctx context.Context, search OrderSearch, after *OrderCursor) ([]*Order, int, error)
 
-	// GetProductPrice fetches the current vendor price and availability for a store product.
-	GetProductPrice(ctx context.Context, storeID, vendorStoreProductID string) (price float64, available bool, err error)
+	// UpdateStatus advances an order to a new status and appends the transition to its status history. It checks the
+	// transition again with the order row locked and returns ErrStatusConflict if it is no longer allowed.
+	UpdateStatus(ctx context.Context, id string, status OrderStatus, change StatusChange) error
+
+	// AdvanceStatus moves the order from status from to status to and appends the transition to its status history.
+	// It reports false, changing nothing, when the order is no longer in status from.
+	AdvanceStatus(ctx context.Context, id string, from, to OrderStatus, change StatusChange) (bool, error)
+
+	// CancelOrder marks a PENDING or CONFIRMED order CANCELLED, returns its reserved stock and records the
+	// transition in one transaction. It returns ErrNotCancellable when the order has already moved on.
+	CancelOrder(ctx context.Context, id string, change StatusChange) error
+
+	// AmendOrder writes o's new lines and totals as version expectedVersion+1. With the order row locked it
+	// re-checks the status and version, returning ErrNotAmendable or ErrVersionConflict, then reserves or releases
+	// the net change in stock per product, reprices any promo redemption and snapshots the new version.
+	AmendOrder(ctx context.Context, o *Order, expectedVersion int, change StatusChange) error
+
+	// ListVersions returns an order's version snapshots, oldest first.
+	ListVersions(ctx context.Context, orderID string) ([]*OrderVersion, error)
+
+	// ListStatusEvents returns an order's status history, oldest first.
+	ListStatusEvents(ctx context.Context, orderID string) ([]*StatusEvent, error)
+
+	// GetProductPricing fetches the current vendor price, availability, catalog tax class and option schema for a
+	// store product.
+	GetProductPricing(ctx context.Context, storeID, vendorStoreProductID string) (*ProductPricing, error)
+
+	// GetTaxProfile reports whether the store quotes gross prices and whether the customer holds a current
+	// tax exemption. customerID may be empty for walk-in orders.
+	GetTaxProfile(ctx context.Context, storeID, customerID string) (*TaxProfile, error)
+
+	// DesignAssetOwned reports whether the design asset exists, is not deleted and belongs to ownerID.
+	DesignAssetOwned(ctx context.Context, assetID, ownerID string) (bool, error)
+
+	// GetOrderNumbering returns the order number prefix and reset period of the vendor that owns the store.
+	GetOrderNumbering(ctx context.Context, storeID string) (*OrderNumbering, error)
+
+	// NextOrderSequence increments a store's counter for one period and returns the new value. A new counter starts
+	// above the highest all-digit number the store's orders already carry in the series, e.g. "ORD-20261016", so
+	// numbers issued before the counter existed are not issued again. It commits on its own, so concurrent callers
+	// never receive the same value.
+	NextOrderSequence(ctx context.Context, storeID, period, series string) (int64, error)
+
+	// GetCustomerContact returns the email address and phone number a customer's pickup code is sent to.
+	GetCustomerContact(ctx context.Context, customerID string) (*CustomerContact, error)
+
+	// IssuePickupCode stores the hash of a new pickup code for a READY pickup order, replacing any earlier code
+	// and its failed attempts. It returns ErrNotPickup or ErrNotReadyForPickup, checked with the order row locked.
+	IssuePickupCode(ctx context.Context, orderID, codeHash string) error
+
+	// VerifyPickupCode checks a pickup code at the counter and records the attempt. A matching code is used up and
+	// the order moves from READY to DELIVERED in the same transaction. It returns ErrNoPickupCode,
+	// ErrPickupCodeMismatch or ErrPickupCodeLocked when the order is not handed over.
+	VerifyPickupCode(ctx context.Context, orderID, codeHash string, change StatusChange) error
+
+	// ListHandoverAttempts returns an order's pickup code checks, oldest first.
+	ListHandoverAttempts(ctx context.Context, orderID string) ([]*HandoverAttempt, error)
 }
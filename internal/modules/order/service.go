@@ -1,24 +1,37 @@
 package order
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
 // Service defines the order management business logic.
 type Service interface {
-	// PlaceOrder validates the cart, calculates totals, and persists the order atomically.
+	// PlaceOrder validates the cart, calculates totals, and persists the order atomically, reserving stock.
+	// It returns an error wrapping *OutOfStockError when the store cannot cover a line, and a
+	// *promo.RejectionError when the order's promo code does not apply.
 	PlaceOrder(ctx context.Context, req PlaceOrderRequest) (*Order, error)
 
 	// GetOrder retrieves a full order with its items by UUID.
 	GetOrder(ctx context.Context, id string) (*Order, error)
 
-	// GetOrderByNumber retrieves an order by its human-readable number.
-	GetOrderByNumber(ctx context.Context, orderNumber string) (*Order, error)
+	// GetOrderByNumber retrieves an order by its human-readable number. Numbers are unique per store, so storeID
+	// narrows the lookup; without it an order number more than one store has issued returns ErrAmbiguousOrderNumber.
+	GetOrderByNumber(ctx context.Context, storeID, orderNumber string) (*Order, error)
 
 	// ListStoreOrders returns all orders for a store, optionally filtered by status.
 	ListStoreOrders(ctx context.Context, storeID string, status string) ([]*Order, error)
@@ -26,20 +39,107 @@ type Service interface {
 	// ListCustomerOrders returns all orders placed by a customer.
 	ListCustomerOrders(ctx context.Context, customerID string) ([]*Order, error)
 
+	// SearchOrders returns one page of a store's or customer's orders matching the filters, with the total number
+	// of matches. Passing the page's NextCursor back returns the following page.
+	SearchOrders(ctx context.Context, search OrderSearch) (*OrderPage, error)
+
 	// UpdateStatus advances an order to a new lifecycle status.
 	UpdateStatus(ctx context.Context, id string, req UpdateStatusRequest) (*Order, error)
 
-	// CancelOrder cancels a PENDING or CONFIRMED order.
-	CancelOrder(ctx context.Context, id string) error
+	// CancelOrder cancels a PENDING or CONFIRMED order and returns its reserved stock.
+	CancelOrder(ctx context.Context, id string, req CancelOrderRequest) error
+
+	// GetTimeline returns the order's status history, oldest first.
+	GetTimeline(ctx context.Context, id string) ([]*StatusEvent, error)
+
+	// AmendOrder replaces the lines of a PENDING or CONFIRMED order, recomputes discount, tax and totals on the
+	// server, moves stock by the net change per product and records the result as a new order version. It returns
+	// ErrVersionConflict when req.Version is stale, ErrNotAmendable once the order has moved on, and an error
+	// wrapping *OutOfStockError when the store cannot cover an increase.
+	AmendOrder(ctx context.Context, id string, req AmendOrderRequest) (*Order, error)
+
+	// ListVersions returns every recorded version of an order, oldest first.
+	ListVersions(ctx context.Context, id string) ([]*OrderVersion, error)
+
+	// PlaceReprint places a CONFIRMED zero-charge order that prints the selected lines of a DELIVERED order again,
+	// linked to it through ReprintOf. Stock is reserved as for any order. It returns ErrNotReprintable when the
+	// order has not been delivered.
+	PlaceReprint(ctx context.Context, id string, req ReprintRequest) (*Order, error)
+
+	// SendPickupCode issues a new pickup code for a READY pickup order and sends it to the customer, replacing the
+	// previous code and unlocking it after too many wrong attempts.
+	SendPickupCode(ctx context.Context, id string) error
+
+	// HandOver checks the customer's pickup code at the counter and, when it matches, marks the READY order
+	// DELIVERED. Every attempt is recorded with the staff member who made it. It returns ErrPickupCodeMismatch or
+	// ErrPickupCodeLocked when the order is not handed over.
+	HandOver(ctx context.Context, id string, req HandoverRequest) (*Order, error)
+
+	// ListHandoverAttempts returns the pickup code checks made for an order, oldest first.
+	ListHandoverAttempts(ctx context.Context, id string) ([]*HandoverAttempt, error)
+
+	// SyncProduction advances the order as its production jobs start and finish: CONFIRMED to IN_PRODUCTION, then
+	// to READY. Calling it again with the same progress changes nothing.
+	SyncProduction(ctx context.Context, id string, progress ProductionProgress) (*Order, error)
 }
 
 type service struct {
-	repo Repository
+	repo        Repository
+	taxRates    TaxRates
+	promotions  Promotions
+	checkouts   CheckoutRepository
+	pickupCodes PickupCodeSender
+	zone        *time.Location // order number periods follow this calendar
+}
+
+// Promotions prices promo codes for checkout and amendments. It is satisfied by promo.Service.
+type Promotions interface {
+	Quote(ctx context.Context, req promo.QuoteRequest) (*promo.Quote, error)
+	Reprice(ctx context.Context, promoCodeID string, lines []promo.QuoteLine) (money.Amount, error)
+}
+
+// ServiceOption configures optional order service behaviour.
+type ServiceOption func(*service)
+
+// WithTaxRates overrides the VAT rate charged for each catalog tax class.
+func WithTaxRates(rates TaxRates) ServiceOption {
+	return func(s *service) {
+		for class, rate := range rates {
+			s.taxRates[class] = rate
+		}
+	}
+}
+
+// WithStoreTimezone dates order number series in the zone stores trade in, so a daily series restarts at the
+// stores' midnight rather than UTC's.
+func WithStoreTimezone(loc *time.Location) ServiceOption {
+	return func(s *service) {
+		s.zone = loc
+	}
+}
+
+// WithPromotions enables promo codes on PlaceOrder. Without it, orders carrying a promo_code are rejected.
+func WithPromotions(p Promotions) ServiceOption {
+	return func(s *service) {
+		s.promotions = p
+	}
+}
+
+// WithCheckouts applies each multi-store checkout's cancellation policy when one of its orders is cancelled.
+// Without it, cancelling a child order never touches its siblings.
+func WithCheckouts(repo CheckoutRepository) ServiceOption {
+	return func(s *service) {
+		s.checkouts = repo
+	}
 }
 
 // NewService creates a new order service.
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, options ...ServiceOption) Service {
+	s := &service{repo: repo, taxRates: DefaultTaxRates(), zone: time.UTC}
+	for _, option := range options {
+		option(s)
+	}
+	return s
 }
 
 // validTransitions defines the allowed status state machine.
@@ -52,6 +152,25 @@ var validTransitions = map[OrderStatus][]OrderStatus{
 	StatusCancelled:    {},
 }
 
+// productLookupError reports a failed product lookup: ErrProductNotInStore when the store does not sell the product,
+// and the underlying error otherwise, so a database failure is not mistaken for a missing product.
+func productLookupError(productID string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product %s %w", productID, ErrProductNotInStore)
+	}
+	return fmt.Errorf("load product %s: %w", productID, err)
+}
+
+// canTransition reports whether the state machine allows an order to move from one status to another.
+func canTransition(from, to OrderStatus) bool {
+	for _, allowed := range validTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *service) PlaceOrder(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
 	if len(req.Items) == 0 {
 		return nil, fmt.Errorf("order must contain at least one item")
@@ -72,30 +191,83 @@ func (s *service) PlaceOrder(ctx context.Context, req PlaceOrderRequest) (*Order
 		}
 	}
 
+	change, err := newStatusChange(req.Actor, "")
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.prepareOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	o.IdempotencyKey = req.IdempotencyKey
+
+	if err := s.repo.CreateOrder(ctx, o, change); err != nil {
+		if req.IdempotencyKey != "" && strings.Contains(err.Error(), "duplicate key") {
+			if existing, lookupErr := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey); lookupErr == nil {
+				if req.CustomerID != "" && (existing.CustomerID == nil || existing.CustomerID.String() != req.CustomerID) {
+					return nil, fmt.Errorf("idempotency key is already associated with another customer")
+				}
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to persist order: %w", err)
+	}
+	return o, nil
+}
+
+// prepareOrder prices the cart against the store's current catalogue, applies the promo code and assembles the
+// PENDING order without persisting it.
+func (s *service) prepareOrder(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
 	storeID, err := uuid.Parse(req.StoreID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid store_id: %w", err)
 	}
 
+	var customerID *uuid.UUID
+	if req.CustomerID != "" {
+		uid, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid customer_id: %w", err)
+		}
+		customerID = &uid
+	}
+
 	channel := OrderChannel(strings.ToUpper(req.Channel))
 	if channel == "" {
 		channel = ChannelOnline
 	}
+	if req.DueAt != nil && !req.DueAt.After(time.Now()) {
+		return nil, fmt.Errorf("invalid due_at: must be in the future")
+	}
+	fulfilment, err := fulfilmentFor(req.FulfilmentMethod, req.DeliveryAddress)
+	if err != nil {
+		return nil, err
+	}
 
 	// ── Build order items, validate stock & availability ──────────────────────
 	var items []*OrderItem
-	var subtotal float64
+	var subtotal money.Amount
+	var promoLines []promo.QuoteLine
+
+	var invalid []LineCustomisationError
 
-	for _, ci := range req.Items {
+	for i, ci := range req.Items {
 		if ci.Quantity <= 0 {
 			return nil, fmt.Errorf("quantity must be > 0 for product %s", ci.VendorStoreProductID)
 		}
-		price, available, err := s.repo.GetProductPrice(ctx, req.StoreID, ci.VendorStoreProductID)
+		pricing, err := s.repo.GetProductPricing(ctx, req.StoreID, ci.VendorStoreProductID)
+		if err != nil {
+			return nil, productLookupError(ci.VendorStoreProductID, err)
+		}
+		if !pricing.Available {
+			return nil, fmt.Errorf("product %s %w", ci.VendorStoreProductID, ErrProductUnavailable)
+		}
+		fields, err := s.checkCustomisation(ctx, req.CustomerID, pricing.OptionSchema, ci.Customisation)
 		if err != nil {
-			return nil, fmt.Errorf("product %s not found in this store", ci.VendorStoreProductID)
+			return nil, err
 		}
-		if !available {
-			return nil, fmt.Errorf("product %s is currently unavailable", ci.VendorStoreProductID)
+		if len(fields) > 0 {
+			invalid = append(invalid, LineCustomisationError{Line: i, VendorStoreProductID: ci.VendorStoreProductID, Fields: fields})
 		}
 
 		pid, err := uuid.Parse(ci.VendorStoreProductID)
@@ -103,78 +275,110 @@ func (s *service) PlaceOrder(ctx context.Context, req PlaceOrderRequest) (*Order
 			return nil, fmt.Errorf("invalid vendor_store_product_id: %w", err)
 		}
 
-		lineTotal := price * float64(ci.Quantity)
+		unitPrice, err := pricing.LinePrice(ci.Quantity, ci.Customisation)
+		if err != nil {
+			return nil, fmt.Errorf("product %s: %w", ci.VendorStoreProductID, err)
+		}
+		lineTotal := unitPrice.Times(ci.Quantity)
 		subtotal += lineTotal
+		promoLines = append(promoLines, promo.QuoteLine{Amount: lineTotal, Category: pricing.Category})
 
 		items = append(items, &OrderItem{
 			ID:                   uuid.New(),
 			VendorStoreProductID: pid,
 			Quantity:             ci.Quantity,
-			UnitPrice:            price,
+			UnitPrice:            unitPrice,
 			LineTotal:            lineTotal,
+			TaxClass:             pricing.TaxClass,
 			Customisation:        ci.Customisation,
 		})
 	}
 
-	// ── Calculate totals ──────────────────────────────────────────────────────
-	discount := req.Discount
-	if discount < 0 {
-		discount = 0
+	if len(invalid) > 0 {
+		return nil, &CustomisationError{Lines: invalid}
 	}
-	taxRate := 0.16 // 16% VAT — Zambia standard rate
-	taxable := subtotal - discount
-	if taxable < 0 {
-		taxable = 0
-	}
-	tax := taxable * taxRate
-	total := taxable + tax
 
-	// ── Build order ───────────────────────────────────────────────────────────
-	o := &Order{
-		ID:              uuid.New(),
-		StoreID:         storeID,
-		OrderNumber:     generateOrderNumber(),
-		Status:          StatusPending,
-		Channel:         channel,
-		Subtotal:        round2(subtotal),
-		Discount:        round2(discount),
-		Tax:             round2(tax),
-		Total:           round2(total),
-		Currency:        "ZMW",
-		Notes:           req.Notes,
-		DeliveryAddress: req.DeliveryAddress,
-		IdempotencyKey:  req.IdempotencyKey,
-		Items:           items,
-	}
-
-	if req.CustomerID != "" {
-		uid, err := uuid.Parse(req.CustomerID)
+	// ── Calculate totals ──────────────────────────────────────────────────────
+	var discount money.Amount
+	var promoQuote *promo.Quote
+	if strings.TrimSpace(req.PromoCode) != "" {
+		if s.promotions == nil {
+			return nil, fmt.Errorf("promo codes are not enabled")
+		}
+		promoQuote, err = s.promotions.Quote(ctx, promo.QuoteRequest{
+			Code: req.PromoCode, StoreID: req.StoreID, CustomerID: req.CustomerID, Lines: promoLines,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("invalid customer_id: %w", err)
+			return nil, err
 		}
-		o.CustomerID = &uid
+		discount = min(promoQuote.Discount, subtotal)
 	}
 
-	if err := s.repo.CreateOrder(ctx, o); err != nil {
-		if req.IdempotencyKey != "" && strings.Contains(err.Error(), "duplicate key") {
-			if existing, lookupErr := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey); lookupErr == nil {
-				if req.CustomerID != "" && (existing.CustomerID == nil || existing.CustomerID.String() != req.CustomerID) {
-					return nil, fmt.Errorf("idempotency key is already associated with another customer")
-				}
-				return existing, nil
-			}
-		}
-		return nil, fmt.Errorf("failed to persist order: %w", err)
+	// ── Build order ───────────────────────────────────────────────────────────
+	o, err := s.newPendingOrder(ctx, storeID, customerID, items, subtotal, discount)
+	if err != nil {
+		return nil, err
+	}
+	o.Channel = channel
+	o.Notes = req.Notes
+	o.FulfilmentMethod = fulfilment
+	o.DeliveryAddress = req.DeliveryAddress
+	o.DueAt = req.DueAt
+	if promoQuote != nil {
+		o.PromoCodeID, o.PromoCode = &promoQuote.PromoCodeID, promoQuote.Code
 	}
 	return o, nil
 }
 
+// newPendingOrder applies tax to priced lines and assembles a PENDING order around them.
+func (s *service) newPendingOrder(ctx context.Context, storeID uuid.UUID, customerID *uuid.UUID, items []*OrderItem, subtotal, discount money.Amount) (*Order, error) {
+	customer := ""
+	if customerID != nil {
+		customer = customerID.String()
+	}
+	profile, err := s.repo.GetTaxProfile(ctx, storeID.String(), customer)
+	if err != nil {
+		return nil, fmt.Errorf("load tax profile: %w", err)
+	}
+	tax, total := applyTax(items, discount, s.taxRates, *profile)
+	number, err := s.nextOrderNumber(ctx, storeID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		ID:          uuid.New(),
+		StoreID:     storeID,
+		CustomerID:  customerID,
+		OrderNumber: number,
+		Status:      StatusPending,
+		Channel:     ChannelOnline,
+		Subtotal:    subtotal,
+		Discount:    discount,
+		Tax:         tax,
+		Total:       total,
+		Currency:    money.DefaultCurrency,
+		Version:     1,
+		Items:       items,
+
+		FulfilmentMethod:        FulfilmentPickup,
+		PricesIncludeTax:        profile.PricesIncludeTax,
+		TaxExempt:               profile.CustomerExempt,
+		TaxExemptionCertificate: profile.ExemptionCertificate,
+	}, nil
+}
+
 func (s *service) GetOrder(ctx context.Context, id string) (*Order, error) {
 	return s.repo.GetOrderByID(ctx, id)
 }
 
-func (s *service) GetOrderByNumber(ctx context.Context, orderNumber string) (*Order, error) {
-	return s.repo.GetOrderByNumber(ctx, orderNumber)
+func (s *service) GetOrderByNumber(ctx context.Context, storeID, orderNumber string) (*Order, error) {
+	if storeID != "" {
+		if _, err := uuid.Parse(storeID); err != nil {
+			return nil, fmt.Errorf("invalid store_id: %w", err)
+		}
+	}
+	return s.repo.GetOrderByNumber(ctx, storeID, orderNumber)
 }
 
 func (s *service) ListStoreOrders(ctx context.Context, storeID string, status string) ([]*Order, error) {
@@ -185,6 +389,100 @@ func (s *service) ListCustomerOrders(ctx context.Context, customerID string) ([]
 	return s.repo.ListOrdersByCustomer(ctx, customerID)
 }
 
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (s *service) SearchOrders(ctx context.Context, search OrderSearch) (*OrderPage, error) {
+	if search.StoreID == "" && search.CustomerID == "" {
+		return nil, fmt.Errorf("store_id or customer_id is required")
+	}
+	for _, id := range []string{search.StoreID, search.CustomerID} {
+		if id == "" {
+			continue
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid id %q: %w", id, err)
+		}
+	}
+	for i, status := range search.Statuses {
+		search.Statuses[i] = OrderStatus(strings.ToUpper(string(status)))
+		if _, ok := validTransitions[search.Statuses[i]]; !ok {
+			return nil, fmt.Errorf("invalid status %q", status)
+		}
+	}
+	if search.Channel != "" {
+		search.Channel = OrderChannel(strings.ToUpper(string(search.Channel)))
+		switch search.Channel {
+		case ChannelOnline, ChannelPOS, ChannelKiosk:
+		default:
+			return nil, fmt.Errorf("invalid channel %q", search.Channel)
+		}
+	}
+	if search.From != nil && search.To != nil && !search.To.After(*search.From) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	if search.MinTotal != nil && search.MaxTotal != nil && *search.MinTotal > *search.MaxTotal {
+		return nil, fmt.Errorf("min_total cannot exceed max_total")
+	}
+	search.NumberPrefix = strings.ToUpper(strings.TrimSpace(search.NumberPrefix))
+
+	var after *OrderCursor
+	if search.Cursor != "" {
+		cursor, err := decodeOrderCursor(search.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = cursor
+	}
+	limit := search.Limit
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	// Reading one extra row tells us whether another page exists without a second query.
+	search.Limit = limit + 1
+	orders, total, err := s.repo.SearchOrders(ctx, search, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &OrderPage{Orders: orders, Total: total}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = encodeOrderCursor(OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if page.Orders == nil {
+		page.Orders = make([]*Order, 0)
+	}
+	return page, nil
+}
+
+// encodeOrderCursor makes the keyset position opaque to clients so its shape can change without breaking them.
+func encodeOrderCursor(c OrderCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()))
+}
+
+func decodeOrderCursor(cursor string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	c := &OrderCursor{}
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
 func (s *service) UpdateStatus(ctx context.Context, id string, req UpdateStatusRequest) (*Order, error) {
 	o, err := s.repo.GetOrderByID(ctx, id)
 	if err != nil {
@@ -192,45 +490,400 @@ func (s *service) UpdateStatus(ctx context.Context, id string, req UpdateStatusR
 	}
 
 	newStatus := OrderStatus(strings.ToUpper(req.Status))
-	allowed := validTransitions[o.Status]
-	valid := false
-	for _, s := range allowed {
-		if s == newStatus {
-			valid = true
-			break
-		}
-	}
-	if !valid {
+	if !canTransition(o.Status, newStatus) {
 		return nil, fmt.Errorf("cannot transition order from %s to %s", o.Status, newStatus)
 	}
+	if newStatus == StatusDelivered && s.requiresPickupCode(o) {
+		return nil, ErrHandoverRequired
+	}
+	change, err := newStatusChange(req.Actor, req.Reason)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.repo.UpdateStatus(ctx, id, newStatus); err != nil {
+	// Cancelling through the status endpoint must release stock exactly like DELETE does.
+	if newStatus == StatusCancelled {
+		err = s.repo.CancelOrder(ctx, id, change)
+	} else {
+		err = s.repo.UpdateStatus(ctx, id, newStatus, change)
+	}
+	if err != nil {
 		return nil, err
 	}
 	o.Status = newStatus
+	if newStatus == StatusCancelled {
+		s.applyCheckoutPolicy(ctx, o, change)
+	}
+	// The order is READY either way; staff can send a new code if this one never reaches the customer.
+	if newStatus == StatusReady && s.requiresPickupCode(o) {
+		if err := s.issuePickupCode(ctx, o); err != nil {
+			log.Printf("order %s: pickup code not sent: %v", o.ID, err)
+		}
+	}
 	return o, nil
 }
 
-func (s *service) CancelOrder(ctx context.Context, id string) error {
+func (s *service) CancelOrder(ctx context.Context, id string, req CancelOrderRequest) error {
 	o, err := s.repo.GetOrderByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("order not found: %w", err)
 	}
 	if o.Status != StatusPending && o.Status != StatusConfirmed {
-		return fmt.Errorf("only PENDING or CONFIRMED orders can be cancelled (current: %s)", o.Status)
+		return fmt.Errorf("%w (current: %s)", ErrNotCancellable, o.Status)
+	}
+	change, err := newStatusChange(req.Actor, req.Reason)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CancelOrder(ctx, id, change); err != nil {
+		return err
+	}
+	s.applyCheckoutPolicy(ctx, o, change)
+	return nil
+}
+
+// applyCheckoutPolicy runs after o was cancelled. When o belongs to a CANCEL_ALL checkout, every sibling still
+// PENDING or CONFIRMED is cancelled too, releasing its stock; siblings that have moved on are left to complete.
+// The cancellation of o already stands, so failures here are logged rather than returned.
+func (s *service) applyCheckoutPolicy(ctx context.Context, o *Order, change StatusChange) {
+	if o.CheckoutID == nil || s.checkouts == nil {
+		return
+	}
+	c, err := s.checkouts.GetCheckout(ctx, o.CheckoutID.String())
+	if err != nil {
+		log.Printf("order %s: load checkout %s: %v", o.ID, o.CheckoutID, err)
+		return
+	}
+	if c.CancellationPolicy != PolicyCancelAll {
+		return
+	}
+	sibling := StatusChange{
+		Actor:  change.Actor,
+		Reason: fmt.Sprintf("Checkout %s cancelled with order %s", c.CheckoutNumber, o.OrderNumber),
+	}
+	for _, other := range c.Orders {
+		if other.ID == o.ID || (other.Status != StatusPending && other.Status != StatusConfirmed) {
+			continue
+		}
+		if err := s.repo.CancelOrder(ctx, other.ID.String(), sibling); err != nil && !errors.Is(err, ErrNotCancellable) {
+			log.Printf("checkout %s: cancel order %s: %v", c.CheckoutNumber, other.ID, err)
+		}
+	}
+}
+
+func (s *service) GetTimeline(ctx context.Context, id string) ([]*StatusEvent, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid order id: %w", err)
+	}
+	return s.repo.ListStatusEvents(ctx, id)
+}
+
+func (s *service) AmendOrder(ctx context.Context, id string, req AmendOrderRequest) (*Order, error) {
+	o, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if o.Status != StatusPending && o.Status != StatusConfirmed {
+		return nil, fmt.Errorf("%w (current: %s)", ErrNotAmendable, o.Status)
+	}
+	if req.Version != o.Version {
+		return nil, fmt.Errorf("%w (amending version %d, current is %d)", ErrVersionConflict, req.Version, o.Version)
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("order must contain at least one item")
+	}
+	change, err := newStatusChange(req.Actor, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uuid.UUID]*OrderItem, len(o.Items))
+	for _, item := range o.Items {
+		current[item.ID] = item
+	}
+	kept := make(map[uuid.UUID]bool)
+	var items []*OrderItem
+	var subtotal money.Amount
+	var promoLines []promo.QuoteLine
+
+	customerID := ""
+	if o.CustomerID != nil {
+		customerID = o.CustomerID.String()
+	}
+	var invalid []LineCustomisationError
+
+	for i, line := range req.Items {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be > 0 for every line")
+		}
+		var item *OrderItem
+		var category string
+		var schema *catalog.OptionSchema
+		if line.ItemID != "" {
+			itemID, err := uuid.Parse(line.ItemID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid item_id: %w", err)
+			}
+			existing, ok := current[itemID]
+			if !ok {
+				return nil, fmt.Errorf("order item %s not found on this order", itemID)
+			}
+			if kept[itemID] {
+				return nil, fmt.Errorf("order item %s is listed more than once", itemID)
+			}
+			kept[itemID] = true
+			customisationChanged := line.Customisation != nil && !bytes.Equal(line.Customisation, existing.Customisation)
+			repriced := customisationChanged || line.Quantity != existing.Quantity
+			// Unchanged lines keep the price they were sold at and only read the catalog for the promo category.
+			// A changed quantity or customisation can cross a quantity break or pick another option, so the line is
+			// repriced at the current price for its new shape and, when the customisation changes, re-validated.
+			pricing, err := s.repo.GetProductPricing(ctx, o.StoreID.String(), existing.VendorStoreProductID.String())
+			if err != nil && (repriced || !errors.Is(err, sql.ErrNoRows)) {
+				return nil, productLookupError(existing.VendorStoreProductID.String(), err)
+			}
+			if err == nil {
+				category = pricing.Category
+				if customisationChanged {
+					schema = pricing.OptionSchema
+				}
+			}
+			copied := *existing
+			item = &copied
+			item.Quantity = line.Quantity
+			if line.Customisation != nil {
+				item.Customisation = line.Customisation
+			}
+			if repriced {
+				if item.UnitPrice, err = pricing.LinePrice(item.Quantity, item.Customisation); err != nil {
+					return nil, fmt.Errorf("product %s: %w", existing.VendorStoreProductID, err)
+				}
+			}
+		} else if line.VendorStoreProductID == "" {
+			return nil, fmt.Errorf("each line requires item_id or vendor_store_product_id")
+		} else {
+			pricing, err := s.repo.GetProductPricing(ctx, o.StoreID.String(), line.VendorStoreProductID)
+			if err != nil {
+				return nil, productLookupError(line.VendorStoreProductID, err)
+			}
+			if !pricing.Available {
+				return nil, fmt.Errorf("product %s %w", line.VendorStoreProductID, ErrProductUnavailable)
+			}
+			pid, err := uuid.Parse(line.VendorStoreProductID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid vendor_store_product_id: %w", err)
+			}
+			unitPrice, err := pricing.LinePrice(line.Quantity, line.Customisation)
+			if err != nil {
+				return nil, fmt.Errorf("product %s: %w", line.VendorStoreProductID, err)
+			}
+			category, schema = pricing.Category, pricing.OptionSchema
+			item = &OrderItem{
+				ID:                   uuid.New(),
+				OrderID:              o.ID,
+				VendorStoreProductID: pid,
+				Quantity:             line.Quantity,
+				UnitPrice:            unitPrice,
+				TaxClass:             pricing.TaxClass,
+				Customisation:        line.Customisation,
+			}
+		}
+		fields, err := s.checkCustomisation(ctx, customerID, schema, item.Customisation)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			invalid = append(invalid, LineCustomisationError{
+				Line: i, VendorStoreProductID: item.VendorStoreProductID.String(), Fields: fields,
+			})
+		}
+		item.LineTotal = item.UnitPrice.Times(item.Quantity)
+		subtotal += item.LineTotal
+		promoLines = append(promoLines, promo.QuoteLine{Amount: item.LineTotal, Category: category})
+		items = append(items, item)
+	}
+	if len(invalid) > 0 {
+		return nil, &CustomisationError{Lines: invalid}
+	}
+
+	var discount money.Amount
+	if o.PromoCodeID != nil {
+		if s.promotions == nil {
+			return nil, fmt.Errorf("promo codes are not enabled")
+		}
+		repriced, err := s.promotions.Reprice(ctx, o.PromoCodeID.String(), promoLines)
+		if err != nil {
+			return nil, err
+		}
+		discount = min(repriced, subtotal)
+	}
+
+	// The amended order keeps the tax treatment it was placed under.
+	profile := TaxProfile{
+		PricesIncludeTax:     o.PricesIncludeTax,
+		CustomerExempt:       o.TaxExempt,
+		ExemptionCertificate: o.TaxExemptionCertificate,
+	}
+	o.Tax, o.Total = applyTax(items, discount, s.taxRates, profile)
+	o.Items, o.Subtotal, o.Discount = items, subtotal, discount
+	o.Version = req.Version + 1
+
+	if err := s.repo.AmendOrder(ctx, o, req.Version, change); err != nil {
+		var oos *OutOfStockError
+		if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotAmendable) || errors.As(err, &oos) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to persist amendment: %w", err)
+	}
+	return s.repo.GetOrderByID(ctx, id)
+}
+
+func (s *service) ListVersions(ctx context.Context, id string) ([]*OrderVersion, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid order id: %w", err)
+	}
+	return s.repo.ListVersions(ctx, id)
+}
+
+func (s *service) PlaceReprint(ctx context.Context, id string, req ReprintRequest) (*Order, error) {
+	if req.IdempotencyKey != "" {
+		existing, err := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err == nil {
+			if existing.ReprintOf == nil || existing.ReprintOf.String() != id {
+				return nil, fmt.Errorf("idempotency key is already associated with another order")
+			}
+			return existing, nil
+		}
+		if !strings.Contains(err.Error(), "no rows") && !strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("lookup idempotent order: %w", err)
+		}
+	}
+
+	original, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if original.Status != StatusDelivered {
+		return nil, ErrNotReprintable
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("reprint must contain at least one item")
+	}
+	change, err := newStatusChange(req.Actor, "Reprint of "+original.OrderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered := make(map[uuid.UUID]*OrderItem, len(original.Items))
+	for _, item := range original.Items {
+		delivered[item.ID] = item
+	}
+	seen := make(map[uuid.UUID]bool, len(req.Items))
+	var items []*OrderItem
+	for _, line := range req.Items {
+		itemID, err := uuid.Parse(line.OrderItemID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order_item_id: %w", err)
+		}
+		item, ok := delivered[itemID]
+		if !ok {
+			return nil, fmt.Errorf("item %s not found on this order", line.OrderItemID)
+		}
+		if seen[itemID] {
+			return nil, fmt.Errorf("item %s appears more than once", line.OrderItemID)
+		}
+		seen[itemID] = true
+		if line.Quantity <= 0 || line.Quantity > item.Quantity {
+			return nil, fmt.Errorf("quantity for item %s must be between 1 and %d", line.OrderItemID, item.Quantity)
+		}
+		// The customer already paid for these units, so the reprint carries them at no charge.
+		items = append(items, &OrderItem{
+			ID:                   uuid.New(),
+			VendorStoreProductID: item.VendorStoreProductID,
+			Quantity:             line.Quantity,
+			TaxClass:             item.TaxClass,
+			Customisation:        item.Customisation,
+		})
+	}
+
+	o, err := s.newPendingOrder(ctx, original.StoreID, original.CustomerID, items, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	o.Status = StatusConfirmed
+	o.Channel = original.Channel
+	o.FulfilmentMethod = original.FulfilmentMethod
+	o.DeliveryAddress = original.DeliveryAddress
+	o.ReprintOf = &original.ID
+	o.IdempotencyKey = req.IdempotencyKey
+
+	if err := s.repo.CreateOrder(ctx, o, change); err != nil {
+		if req.IdempotencyKey != "" && strings.Contains(err.Error(), "duplicate key") {
+			if existing, lookupErr := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey); lookupErr == nil &&
+				existing.ReprintOf != nil && *existing.ReprintOf == original.ID {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to persist reprint: %w", err)
 	}
-	return s.repo.UpdateStatus(ctx, id, StatusCancelled)
+	return o, nil
 }
 
 // ── helpers ───────────────────────────────────────────────────────────────────
 
-// generateOrderNumber creates a human-readable order number: ORD-YYYYMMDD-XXXX
-func generateOrderNumber() string {
-	date := time.Now().UTC().Format("20060102")
-	suffix := strings.ToUpper(uuid.New().String()[:4])
-	return fmt.Sprintf("ORD-%s-%s", date, suffix)
+// maxStatusReasonLength bounds the free-text reason stored on each status event.
+const maxStatusReasonLength = 500
+
+// newStatusChange validates the actor and reason recorded with a status transition.
+func newStatusChange(actor Actor, reason string) (StatusChange, error) {
+	if actor.ID != "" {
+		if _, err := uuid.Parse(actor.ID); err != nil {
+			return StatusChange{}, fmt.Errorf("invalid actor id: %w", err)
+		}
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxStatusReasonLength {
+		return StatusChange{}, fmt.Errorf("reason must not exceed %d characters", maxStatusReasonLength)
+	}
+	return StatusChange{Actor: actor, Reason: reason}, nil
 }
 
-func round2(v float64) float64 {
-	return float64(int(v*100+0.5)) / 100
+// checkCustomisation validates a line's customisation against its product's option schema. For a customer's order,
+// every design asset an ASSET option references must also belong to that customer.
+func (s *service) checkCustomisation(ctx context.Context, customerID string, schema *catalog.OptionSchema, customisation json.RawMessage) ([]catalog.FieldError, error) {
+	fields := schema.ValidateCustomisation(customisation)
+	if len(fields) > 0 || customerID == "" {
+		return fields, nil
+	}
+	for _, ref := range schema.AssetRefs(customisation) {
+		owned, err := s.repo.DesignAssetOwned(ctx, ref.AssetID, customerID)
+		if err != nil {
+			return nil, fmt.Errorf("check design asset: %w", err)
+		}
+		if !owned {
+			fields = append(fields, catalog.FieldError{Field: ref.Field, Message: "design asset is not available to this customer"})
+		}
+	}
+	return fields, nil
+}
+
+// nextOrderNumber issues the store's next number in its vendor's current series: PREFIX-YYYYMMDD-0042 when
+// numbering restarts daily, PREFIX-YYYY-000042 when it restarts yearly. Each store counts on its own, and periods
+// follow the store timezone's calendar. The running number is zero-padded for readability and simply grows wider
+// if a period outruns the padding.
+func (s *service) nextOrderNumber(ctx context.Context, storeID uuid.UUID, now time.Time) (string, error) {
+	numbering, err := s.repo.GetOrderNumbering(ctx, storeID.String())
+	if err != nil {
+		return "", fmt.Errorf("load order numbering: %w", err)
+	}
+	local := now.In(s.zone)
+	period, width := local.Format("20060102"), 4
+	if numbering.Reset == vendor.OrderNumberResetYearly {
+		period, width = local.Format("2006"), 6
+	}
+	series := numbering.Prefix + "-" + period
+	value, err := s.repo.NextOrderSequence(ctx, storeID.String(), period, series)
+	if err != nil {
+		return "", fmt.Errorf("allocate order number: %w", err)
+	}
+	return fmt.Sprintf("%s-%0*d", series, width, value), nil
 }
@@ -0,0 +1,1131 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/promo"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+type fakeProduct struct {
+	price     money.Amount
+	available bool
+	stock     int
+	taxClass  catalog.TaxClass
+	category  string
+	schema    *catalog.OptionSchema
+	rules     inventory.PriceRules
+	err       error // returned by GetProductPricing, e.g. a lost database connection
+}
+
+type fakeRepository struct {
+	orders     map[string]*Order
+	products   map[string]*fakeProduct
+	released   map[string]bool
+	events     map[string][]*StatusEvent
+	taxProfile TaxProfile
+	quotes     *fakeQuoteRepository
+	versions   map[string][]*OrderVersion
+	numbering  OrderNumbering
+	sequences  map[string]int64  // by store ID and period, e.g. "<store>/20261016"
+	assets     map[string]string // design asset ID to owner ID
+	contacts   map[string]*CustomerContact
+	pickup     map[string]*fakePickupCode
+	handovers  []*HandoverAttempt
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		orders:    make(map[string]*Order),
+		products:  make(map[string]*fakeProduct),
+		released:  make(map[string]bool),
+		events:    make(map[string][]*StatusEvent),
+		versions:  make(map[string][]*OrderVersion),
+		numbering: OrderNumbering{Prefix: vendor.DefaultOrderNumberPrefix, Reset: vendor.OrderNumberResetDaily},
+		sequences: make(map[string]int64),
+	}
+}
+
+func (f *fakeRepository) appendVersion(o *Order, change StatusChange) {
+	items := make([]*OrderItem, len(o.Items))
+	for i, item := range o.Items {
+		copied := *item
+		items[i] = &copied
+	}
+	f.versions[o.ID.String()] = append(f.versions[o.ID.String()], &OrderVersion{
+		ID: uuid.New(), OrderID: o.ID, Version: o.Version, Subtotal: o.Subtotal, Discount: o.Discount, Tax: o.Tax,
+		Total: o.Total, Items: items, ActorRole: change.Actor.Role, Reason: change.Reason,
+	})
+}
+
+func (f *fakeRepository) appendEvent(orderID uuid.UUID, from, to OrderStatus, change StatusChange) {
+	e := &StatusEvent{ID: uuid.New(), OrderID: orderID, ToStatus: to, ActorRole: change.Actor.Role, Reason: change.Reason}
+	if from != "" {
+		e.FromStatus = &from
+	}
+	if change.Actor.ID != "" {
+		actorID := uuid.MustParse(change.Actor.ID)
+		e.ActorID = &actorID
+	}
+	f.events[orderID.String()] = append(f.events[orderID.String()], e)
+}
+
+func (f *fakeRepository) CreateOrder(_ context.Context, o *Order, change StatusChange) error {
+	for _, item := range o.Items {
+		p := f.products[item.VendorStoreProductID.String()]
+		if p.stock < item.Quantity {
+			return &OutOfStockError{VendorStoreProductID: item.VendorStoreProductID, Requested: item.Quantity, Available: p.stock}
+		}
+	}
+	if o.QuoteID != nil {
+		if err := f.quotes.accept(*o.QuoteID, o.ID, o.quotedAt); err != nil {
+			return err
+		}
+	}
+	for _, item := range o.Items {
+		f.products[item.VendorStoreProductID.String()].stock -= item.Quantity
+	}
+	f.orders[o.ID.String()] = o
+	f.appendEvent(o.ID, "", o.Status, change)
+	f.appendVersion(o, change)
+	return nil
+}
+
+// AmendOrder mirrors the ledger-based stock adjustment: each product moves by wanted minus currently held.
+func (f *fakeRepository) AmendOrder(_ context.Context, o *Order, expectedVersion int, change StatusChange) error {
+	stored := f.orders[o.ID.String()]
+	if stored.Status != StatusPending && stored.Status != StatusConfirmed {
+		return ErrNotAmendable
+	}
+	if stored.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	delta := make(map[string]int)
+	for _, item := range o.Items {
+		delta[item.VendorStoreProductID.String()] += item.Quantity
+	}
+	for _, item := range stored.Items {
+		delta[item.VendorStoreProductID.String()] -= item.Quantity
+	}
+	for id, d := range delta {
+		if p := f.products[id]; d > 0 && p.stock < d {
+			return &OutOfStockError{VendorStoreProductID: uuid.MustParse(id), Requested: d, Available: p.stock}
+		}
+	}
+	for id, d := range delta {
+		f.products[id].stock -= d
+	}
+	f.orders[o.ID.String()] = o
+	f.appendVersion(o, change)
+	return nil
+}
+
+func (f *fakeRepository) ListVersions(_ context.Context, orderID string) ([]*OrderVersion, error) {
+	return f.versions[orderID], nil
+}
+
+func (f *fakeRepository) GetOrderByID(_ context.Context, id string) (*Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *o
+	return &copied, nil
+}
+
+func (f *fakeRepository) GetOrderByNumber(context.Context, string, string) (*Order, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepository) GetByIdempotencyKey(_ context.Context, key string) (*Order, error) {
+	for _, o := range f.orders {
+		if o.IdempotencyKey == key {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepository) ListOrdersByStore(context.Context, string, string) ([]*Order, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) ListOrdersByCustomer(context.Context, string) ([]*Order, error) {
+	return nil, nil
+}
+
+// SearchOrders applies the store, status and total filters the tests use, in the repository's keyset order.
+func (f *fakeRepository) SearchOrders(_ context.Context, search OrderSearch, after *OrderCursor) ([]*Order, int, error) {
+	var matches []*Order
+	for _, o := range f.orders {
+		if search.StoreID != "" && o.StoreID.String() != search.StoreID {
+			continue
+		}
+		if len(search.Statuses) > 0 {
+			found := false
+			for _, status := range search.Statuses {
+				found = found || o.Status == status
+			}
+			if !found {
+				continue
+			}
+		}
+		if search.MinTotal != nil && o.Total < *search.MinTotal {
+			continue
+		}
+		matches = append(matches, o)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID.String() > matches[j].ID.String()
+	})
+	var page []*Order
+	for _, o := range matches {
+		if after != nil && !(o.CreatedAt.Before(after.CreatedAt) ||
+			o.CreatedAt.Equal(after.CreatedAt) && o.ID.String() < after.ID.String()) {
+			continue
+		}
+		if len(page) < search.Limit {
+			page = append(page, o)
+		}
+	}
+	return page, len(matches), nil
+}
+
+func (f *fakeRepository) UpdateStatus(_ context.Context, id string, status OrderStatus, change StatusChange) error {
+	o := f.orders[id]
+	if !canTransition(o.Status, status) {
+		return ErrStatusConflict
+	}
+	f.appendEvent(o.ID, o.Status, status, change)
+	o.Status = status
+	return nil
+}
+
+func (f *fakeRepository) AdvanceStatus(_ context.Context, id string, from, to OrderStatus, change StatusChange) (bool, error) {
+	o := f.orders[id]
+	if o.Status != from {
+		return false, nil
+	}
+	f.appendEvent(o.ID, from, to, change)
+	o.Status = to
+	return true, nil
+}
+
+func (f *fakeRepository) CancelOrder(_ context.Context, id string, change StatusChange) error {
+	o := f.orders[id]
+	if o.Status != StatusPending && o.Status != StatusConfirmed {
+		return ErrNotCancellable
+	}
+	f.appendEvent(o.ID, o.Status, StatusCancelled, change)
+	o.Status = StatusCancelled
+	if !f.released[id] {
+		for _, item := range o.Items {
+			f.products[item.VendorStoreProductID.String()].stock += item.Quantity
+		}
+		f.released[id] = true
+	}
+	return nil
+}
+
+func (f *fakeRepository) ListStatusEvents(_ context.Context, orderID string) ([]*StatusEvent, error) {
+	return f.events[orderID], nil
+}
+
+func (f *fakeRepository) GetProductPricing(_ context.Context, _ string, id string) (*ProductPricing, error) {
+	p, ok := f.products[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	taxClass := p.taxClass
+	if taxClass == "" {
+		taxClass = catalog.TaxStandard
+	}
+	return &ProductPricing{
+		UnitPrice: p.price, PriceRules: p.rules, Available: p.available, TaxClass: taxClass, Category: p.category,
+		OptionSchema: p.schema,
+	}, nil
+}
+
+func (f *fakeRepository) DesignAssetOwned(_ context.Context, assetID, ownerID string) (bool, error) {
+	return f.assets[assetID] == ownerID, nil
+}
+
+// fakePromotions grants a fixed discount for any code, or rejects every code when err is set. Reprice grants
+// rate of the amended lines.
+type fakePromotions struct {
+	discount money.Amount
+	rate     float64
+	err      error
+	last     promo.QuoteRequest
+}
+
+func (f *fakePromotions) Reprice(_ context.Context, _ string, lines []promo.QuoteLine) (money.Amount, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	var total money.Amount
+	for _, line := range lines {
+		total += line.Amount
+	}
+	return total.MulRate(f.rate), nil
+}
+
+func (f *fakePromotions) Quote(_ context.Context, req promo.QuoteRequest) (*promo.Quote, error) {
+	f.last = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &promo.Quote{PromoCodeID: uuid.New(), Code: promo.NormalizeCode(req.Code), Discount: f.discount}, nil
+}
+
+func (f *fakeRepository) GetTaxProfile(context.Context, string, string) (*TaxProfile, error) {
+	profile := f.taxProfile
+	return &profile, nil
+}
+
+func (f *fakeRepository) GetOrderNumbering(context.Context, string) (*OrderNumbering, error) {
+	numbering := f.numbering
+	return &numbering, nil
+}
+
+func (f *fakeRepository) NextOrderSequence(_ context.Context, storeID, period, series string) (int64, error) {
+	key := storeID + "/" + period
+	if _, ok := f.sequences[key]; !ok {
+		for _, o := range f.orders {
+			suffix, found := strings.CutPrefix(o.OrderNumber, series+"-")
+			if n, err := strconv.ParseInt(suffix, 10, 64); o.StoreID.String() == storeID && found && err == nil && n > f.sequences[key] {
+				f.sequences[key] = n
+			}
+		}
+	}
+	f.sequences[key]++
+	return f.sequences[key], nil
+}
+
+func placeSingleItemOrder(t *testing.T, svc Service, productID string, quantity int) (*Order, error) {
+	t.Helper()
+	return svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items:   []CartItem{{VendorStoreProductID: productID, Quantity: quantity}},
+	})
+}
+
+func TestPlaceOrderRejectsQuantityBeyondStockWithTypedError(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 1}
+	svc := NewService(repo)
+
+	if _, err := placeSingleItemOrder(t, svc, productID, 1); err != nil {
+		t.Fatalf("first order for the last unit failed: %v", err)
+	}
+	_, err := placeSingleItemOrder(t, svc, productID, 1)
+	var oos *OutOfStockError
+	if !errors.As(err, &oos) {
+		t.Fatalf("expected OutOfStockError, got %v", err)
+	}
+	if oos.Requested != 1 || oos.Available != 0 {
+		t.Fatalf("unexpected out-of-stock details: %#v", oos)
+	}
+}
+
+func TestCancelOrderReturnsReservedStock(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 5}
+	svc := NewService(repo)
+
+	o, err := placeSingleItemOrder(t, svc, productID, 3)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if repo.products[productID].stock != 2 {
+		t.Fatalf("stock after reservation = %d, want 2", repo.products[productID].stock)
+	}
+	if err := svc.CancelOrder(context.Background(), o.ID.String(), CancelOrderRequest{}); err != nil {
+		t.Fatalf("CancelOrder returned error: %v", err)
+	}
+	if repo.products[productID].stock != 5 {
+		t.Fatalf("stock after cancellation = %d, want 5", repo.products[productID].stock)
+	}
+}
+
+func TestStatusTransitionToCancelledReleasesStock(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 4}
+	svc := NewService(repo)
+
+	o, err := placeSingleItemOrder(t, svc, productID, 4)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if _, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: "cancelled"}); err != nil {
+		t.Fatalf("UpdateStatus returned error: %v", err)
+	}
+	if !repo.released[o.ID.String()] || repo.products[productID].stock != 4 {
+		t.Fatalf("status cancellation did not release stock, stock = %d", repo.products[productID].stock)
+	}
+}
+
+func TestCancelOrderRejectsOrdersInProduction(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 4}
+	svc := NewService(repo)
+
+	o, err := placeSingleItemOrder(t, svc, productID, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	repo.orders[o.ID.String()].Status = StatusInProduction
+
+	if err := svc.CancelOrder(context.Background(), o.ID.String(), CancelOrderRequest{}); !errors.Is(err, ErrNotCancellable) {
+		t.Fatalf("expected ErrNotCancellable, got %v", err)
+	}
+	if repo.products[productID].stock != 3 {
+		t.Fatalf("stock changed for a non-cancellable order: %d", repo.products[productID].stock)
+	}
+}
+
+func TestPlaceOrderChargesVATPerLineByTaxClass(t *testing.T) {
+	repo := newFakeRepository()
+	standard, zeroRated, exempt := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	repo.products[standard] = &fakeProduct{price: 10000, available: true, stock: 10}
+	repo.products[zeroRated] = &fakeProduct{price: 5000, available: true, stock: 10, taxClass: catalog.TaxZeroRated}
+	repo.products[exempt] = &fakeProduct{price: 2500, available: true, stock: 10, taxClass: catalog.TaxExempt}
+	svc := NewService(repo)
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items: []CartItem{
+			{VendorStoreProductID: standard, Quantity: 2},
+			{VendorStoreProductID: zeroRated, Quantity: 1},
+			{VendorStoreProductID: exempt, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Subtotal != 27500 || o.Tax != 3200 || o.Total != 30700 {
+		t.Fatalf("subtotal/tax/total = %v/%v/%v, want 275.00/32.00/307.00", o.Subtotal, o.Tax, o.Total)
+	}
+	if o.Items[0].TaxAmount != 3200 || o.Items[1].TaxAmount != 0 || o.Items[2].TaxClass != catalog.TaxExempt {
+		t.Fatalf("unexpected line breakdown: %#v %#v %#v", o.Items[0], o.Items[1], o.Items[2])
+	}
+}
+
+func TestPlaceOrderExtractsVATFromGrossPricesAfterDiscount(t *testing.T) {
+	repo := newFakeRepository()
+	repo.taxProfile = TaxProfile{PricesIncludeTax: true}
+	first, second := uuid.NewString(), uuid.NewString()
+	repo.products[first] = &fakeProduct{price: 11600, available: true, stock: 10}
+	repo.products[second] = &fakeProduct{price: 5800, available: true, stock: 10}
+	svc := NewService(repo, WithPromotions(&fakePromotions{discount: 1740}))
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID:   uuid.NewString(),
+		Items:     []CartItem{{VendorStoreProductID: first, Quantity: 1}, {VendorStoreProductID: second, Quantity: 1}},
+		PromoCode: "SAVE10",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Total != 15660 {
+		t.Fatalf("gross total = %v, want 156.60", o.Total)
+	}
+	var lineSum money.Amount
+	for _, item := range o.Items {
+		lineSum += item.TaxableAmount + item.TaxAmount
+	}
+	if lineSum != o.Total || o.Items[0].TaxableAmount != 9000 || o.Items[0].TaxAmount != 1440 {
+		t.Fatalf("line breakdown does not reconcile: %#v %#v", o.Items[0], o.Items[1])
+	}
+}
+
+func TestPlaceOrderForExemptCustomerChargesNoVAT(t *testing.T) {
+	repo := newFakeRepository()
+	repo.taxProfile = TaxProfile{PricesIncludeTax: true, CustomerExempt: true, ExemptionCertificate: "ZRA-EX-1"}
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 11600, available: true, stock: 10}
+	svc := NewService(repo)
+
+	o, err := placeSingleItemOrder(t, svc, productID, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Tax != 0 || o.Total != 10000 || !o.TaxExempt || o.TaxExemptionCertificate != "ZRA-EX-1" {
+		t.Fatalf("exempt order = tax %v total %v exempt %v", o.Tax, o.Total, o.TaxExempt)
+	}
+}
+
+func TestWithTaxRatesOverridesStandardRate(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 10000, available: true, stock: 10}
+	svc := NewService(repo, WithTaxRates(TaxRates{catalog.TaxStandard: 0.1}))
+
+	o, err := placeSingleItemOrder(t, svc, productID, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Tax != 1000 || o.Items[0].TaxRate != 0.1 {
+		t.Fatalf("tax = %v rate = %v, want 10.00 at 0.1", o.Tax, o.Items[0].TaxRate)
+	}
+}
+
+func TestTaxOptionsFromEnvAcceptsFractionAndRejectsPercentages(t *testing.T) {
+	t.Setenv("VAT_STANDARD_RATE", "")
+	if options, err := TaxOptionsFromEnv(); err != nil || options != nil {
+		t.Fatalf("expected no options for an unset rate, got %v, %v", options, err)
+	}
+	t.Setenv("VAT_STANDARD_RATE", " 0.16 ")
+	if options, err := TaxOptionsFromEnv(); err != nil || len(options) != 1 {
+		t.Fatalf("expected one option for 0.16, got %v, %v", options, err)
+	}
+	for _, raw := range []string{"16", "-0.1", "abc"} {
+		t.Setenv("VAT_STANDARD_RATE", raw)
+		if _, err := TaxOptionsFromEnv(); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestPlaceOrderAllocatesDiscountWithoutLosingANgwee(t *testing.T) {
+	repo := newFakeRepository()
+	first, second, third := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	for _, id := range []string{first, second, third} {
+		repo.products[id] = &fakeProduct{price: 333, available: true, stock: 10}
+	}
+	svc := NewService(repo, WithPromotions(&fakePromotions{discount: 100}))
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items: []CartItem{
+			{VendorStoreProductID: first, Quantity: 1},
+			{VendorStoreProductID: second, Quantity: 1},
+			{VendorStoreProductID: third, Quantity: 1},
+		},
+		PromoCode: "ONEKWACHA",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	var taxable, tax money.Amount
+	for _, item := range o.Items {
+		taxable += item.TaxableAmount
+		tax += item.TaxAmount
+	}
+	if taxable != o.Subtotal-o.Discount || tax != o.Tax || taxable+tax != o.Total {
+		t.Fatalf("lines (%v + %v) do not reconcile with order %v - %v + %v = %v", taxable, tax, o.Subtotal, o.Discount, o.Tax, o.Total)
+	}
+}
+
+func TestStatusTimelineRecordsActorRoleAndReason(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 5}
+	svc := NewService(repo)
+	customer, staff := uuid.NewString(), uuid.NewString()
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items:   []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+		Actor:   Actor{ID: customer, Role: "CUSTOMER"},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if _, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{
+		Status: "confirmed", Reason: "  artwork approved ", Actor: Actor{ID: staff, Role: "STAFF"},
+	}); err != nil {
+		t.Fatalf("UpdateStatus returned error: %v", err)
+	}
+	if err := svc.CancelOrder(context.Background(), o.ID.String(), CancelOrderRequest{
+		Reason: "customer changed their mind", Actor: Actor{ID: customer, Role: "CUSTOMER"},
+	}); err != nil {
+		t.Fatalf("CancelOrder returned error: %v", err)
+	}
+
+	events, err := svc.GetTimeline(context.Background(), o.ID.String())
+	if err != nil {
+		t.Fatalf("GetTimeline returned error: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("timeline has %d events, want 3", len(events))
+	}
+	if events[0].FromStatus != nil || events[0].ToStatus != StatusPending || events[0].ActorID.String() != customer {
+		t.Fatalf("placement event = %#v", events[0])
+	}
+	if *events[1].FromStatus != StatusPending || events[1].ToStatus != StatusConfirmed || events[1].ActorRole != "STAFF" || events[1].Reason != "artwork approved" {
+		t.Fatalf("confirmation event = %#v", events[1])
+	}
+	if *events[2].FromStatus != StatusConfirmed || events[2].ToStatus != StatusCancelled || events[2].Reason != "customer changed their mind" {
+		t.Fatalf("cancellation event = %#v", events[2])
+	}
+}
+
+// staleReads serves orders as they were before a concurrent change, as a read racing another request would.
+type staleReads struct {
+	*fakeRepository
+	stale map[string]Order
+}
+
+func (r *staleReads) GetOrderByID(_ context.Context, id string) (*Order, error) {
+	o := r.stale[id]
+	return &o, nil
+}
+
+func TestUpdateStatusRechecksTheTransitionAgainstTheLockedStatus(t *testing.T) {
+	repo := newFakeRepository()
+	o := &Order{ID: uuid.New(), Status: StatusReady, FulfilmentMethod: FulfilmentDelivery}
+	repo.orders[o.ID.String()] = o
+	svc := NewService(&staleReads{fakeRepository: repo, stale: map[string]Order{o.ID.String(): {ID: o.ID, Status: StatusConfirmed}}})
+
+	_, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: "IN_PRODUCTION"})
+	if !errors.Is(err, ErrStatusConflict) {
+		t.Fatalf("UpdateStatus = %v, want ErrStatusConflict", err)
+	}
+	if o.Status != StatusReady || len(repo.events[o.ID.String()]) != 0 {
+		t.Fatalf("a READY order was moved back to %s with %d events", o.Status, len(repo.events[o.ID.String()]))
+	}
+}
+
+func TestUpdateStatusRejectsMalformedActorWithoutRecording(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 5}
+	svc := NewService(repo)
+
+	o, err := placeSingleItemOrder(t, svc, productID, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	_, err = svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: "CONFIRMED", Actor: Actor{ID: "not-a-uuid"}})
+	if err == nil {
+		t.Fatal("expected an invalid actor error")
+	}
+	if len(repo.events[o.ID.String()]) != 1 || repo.orders[o.ID.String()].Status != StatusPending {
+		t.Fatalf("rejected transition was recorded: %d events, status %s", len(repo.events[o.ID.String()]), repo.orders[o.ID.String()].Status)
+	}
+}
+
+func TestPlaceOrderAppliesServerQuotedPromoDiscount(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 10000, available: true, stock: 5, category: "Banners"}
+	promotions := &fakePromotions{discount: 2500}
+	svc := NewService(repo, WithPromotions(promotions))
+	customer := uuid.NewString()
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID:    uuid.NewString(),
+		CustomerID: customer,
+		Items:      []CartItem{{VendorStoreProductID: productID, Quantity: 2}},
+		PromoCode:  " spring25 ",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Discount != 2500 || o.PromoCode != "SPRING25" || o.PromoCodeID == nil {
+		t.Fatalf("order discount = %v code = %q id = %v", o.Discount, o.PromoCode, o.PromoCodeID)
+	}
+	if promotions.last.CustomerID != customer || len(promotions.last.Lines) != 1 ||
+		promotions.last.Lines[0].Amount != 20000 || promotions.last.Lines[0].Category != "Banners" {
+		t.Fatalf("promo quoted against %+v", promotions.last)
+	}
+}
+
+func TestPlaceOrderRejectedPromoCodeDoesNotReserveStock(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 5}
+	rejection := &promo.RejectionError{Code: "OLD", Reason: promo.ReasonExpired, Detail: "code expired"}
+	svc := NewService(repo, WithPromotions(&fakePromotions{err: rejection}))
+
+	_, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID:   uuid.NewString(),
+		Items:     []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+		PromoCode: "OLD",
+	})
+	var rejected *promo.RejectionError
+	if !errors.As(err, &rejected) || rejected.Reason != promo.ReasonExpired {
+		t.Fatalf("expected expired promo rejection, got %v", err)
+	}
+	if repo.products[productID].stock != 5 || len(repo.orders) != 0 {
+		t.Fatal("rejected promo code must not create an order or reserve stock")
+	}
+
+	if _, err := NewService(repo).PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID:   uuid.NewString(),
+		Items:     []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+		PromoCode: "OLD",
+	}); err == nil {
+		t.Fatal("expected promo codes to be refused when promotions are not configured")
+	}
+}
+
+func TestSearchOrdersPagesByCursorWithoutSkippingOrRepeating(t *testing.T) {
+	repo := newFakeRepository()
+	storeID := uuid.New()
+	placed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		o := &Order{ID: uuid.New(), StoreID: storeID, Status: StatusPending, Total: money.Amount(1000 * (i + 1)), CreatedAt: placed}
+		if i%3 == 0 {
+			o.Status = StatusDelivered
+		}
+		// The first four orders share a timestamp, so pages must break ties on ID.
+		if i > 3 {
+			o.CreatedAt = placed.Add(time.Duration(i) * time.Minute)
+		}
+		repo.orders[o.ID.String()] = o
+	}
+	repo.orders[uuid.NewString()] = &Order{ID: uuid.New(), StoreID: uuid.New(), Status: StatusPending, CreatedAt: placed}
+	svc := NewService(repo)
+
+	seen := make(map[uuid.UUID]bool)
+	var cursor string
+	var last *Order
+	for pages := 0; ; pages++ {
+		page, err := svc.SearchOrders(context.Background(), OrderSearch{StoreID: storeID.String(), Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("SearchOrders returned error: %v", err)
+		}
+		if page.Total != 7 {
+			t.Fatalf("page %d total = %d, want 7", pages, page.Total)
+		}
+		for _, o := range page.Orders {
+			if seen[o.ID] {
+				t.Fatalf("order %s returned twice", o.ID)
+			}
+			if last != nil && o.CreatedAt.After(last.CreatedAt) {
+				t.Fatalf("orders out of order: %v after %v", o.CreatedAt, last.CreatedAt)
+			}
+			seen[o.ID], last = true, o
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(seen) != 7 {
+		t.Fatalf("paged through %d orders, want 7", len(seen))
+	}
+
+	minTotal := money.Amount(3000)
+	page, err := svc.SearchOrders(context.Background(), OrderSearch{
+		StoreID: storeID.String(), Statuses: []OrderStatus{"pending"}, MinTotal: &minTotal,
+	})
+	if err != nil {
+		t.Fatalf("filtered SearchOrders returned error: %v", err)
+	}
+	if page.Total != 3 || len(page.Orders) != 3 || page.NextCursor != "" {
+		t.Fatalf("filtered page = %d orders of %d, cursor %q; want the 3 pending orders of at least 30.00",
+			len(page.Orders), page.Total, page.NextCursor)
+	}
+}
+
+func TestSearchOrdersRejectsBadFilters(t *testing.T) {
+	svc := NewService(newFakeRepository())
+	storeID := uuid.NewString()
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	low, high := money.Amount(100), money.Amount(50)
+	for name, search := range map[string]OrderSearch{
+		"no scope":      {},
+		"status":        {StoreID: storeID, Statuses: []OrderStatus{"SHIPPED"}},
+		"channel":       {StoreID: storeID, Channel: "FAX"},
+		"date range":    {StoreID: storeID, From: &from, To: &to},
+		"total range":   {StoreID: storeID, MinTotal: &low, MaxTotal: &high},
+		"forged cursor": {StoreID: storeID, Cursor: "not-a-cursor"},
+	} {
+		if _, err := svc.SearchOrders(context.Background(), search); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAmendOrderRepricesLinesMovesNetStockAndRecordsVersions(t *testing.T) {
+	repo := newFakeRepository()
+	paper, toner := uuid.NewString(), uuid.NewString()
+	repo.products[paper] = &fakeProduct{price: 1000, available: true, stock: 10, taxClass: catalog.TaxStandard}
+	repo.products[toner] = &fakeProduct{price: 2500, available: true, stock: 3, taxClass: catalog.TaxStandard}
+	svc := NewService(repo, WithPromotions(&fakePromotions{discount: 400, rate: 0.1}))
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID:   uuid.NewString(),
+		Items:     []CartItem{{VendorStoreProductID: paper, Quantity: 4, Customisation: []byte(`{"size":"A4"}`)}},
+		PromoCode: "TENOFF",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Version != 1 {
+		t.Fatalf("placed order version = %d, want 1", o.Version)
+	}
+	// The catalog price moves after placement; the reshaped line is repriced at the current price.
+	repo.products[paper].price = 1200
+
+	amended, err := svc.AmendOrder(context.Background(), o.ID.String(), AmendOrderRequest{
+		Version: 1,
+		Items: []AmendLine{
+			{ItemID: o.Items[0].ID.String(), Quantity: 2, Customisation: []byte(`{"size":"A3"}`)},
+			{VendorStoreProductID: toner, Quantity: 1},
+		},
+		Reason: "customer swapped two reams for toner",
+		Actor:  Actor{ID: uuid.NewString(), Role: "CASHIER"},
+	})
+	if err != nil {
+		t.Fatalf("AmendOrder returned error: %v", err)
+	}
+	if amended.Version != 2 || amended.Subtotal != 4900 || amended.Discount != 490 {
+		t.Fatalf("amended order version %d subtotal %v discount %v; want 2, 49.00, 4.90",
+			amended.Version, amended.Subtotal, amended.Discount)
+	}
+	if amended.Tax != 706 || amended.Total != 5116 {
+		t.Fatalf("amended tax %v total %v; want 7.06 and 51.16", amended.Tax, amended.Total)
+	}
+	if amended.Items[0].ID != o.Items[0].ID || amended.Items[0].UnitPrice != 1200 || string(amended.Items[0].Customisation) != `{"size":"A3"}` {
+		t.Fatalf("kept line = %+v", amended.Items[0])
+	}
+	if repo.products[paper].stock != 8 || repo.products[toner].stock != 2 {
+		t.Fatalf("stock paper %d toner %d; want 2 reams released and 1 toner reserved", repo.products[paper].stock, repo.products[toner].stock)
+	}
+
+	versions, err := svc.ListVersions(context.Background(), o.ID.String())
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("ListVersions = %d versions, %v; want 2", len(versions), err)
+	}
+	if versions[0].Total != o.Total || len(versions[0].Items) != 1 || versions[0].Items[0].Quantity != 4 {
+		t.Fatalf("version 1 no longer shows the order as placed: %+v", versions[0])
+	}
+	if versions[1].Version != 2 || versions[1].Reason != "customer swapped two reams for toner" {
+		t.Fatalf("version 2 = %+v", versions[1])
+	}
+
+	if _, err := svc.AmendOrder(context.Background(), o.ID.String(), AmendOrderRequest{
+		Version: 1, Items: []AmendLine{{ItemID: o.Items[0].ID.String(), Quantity: 1}},
+	}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("amending a stale version = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestAmendOrderRepricesKeptLinesAcrossQuantityBreaksAndOptions(t *testing.T) {
+	repo := newFakeRepository()
+	cardsID := uuid.NewString()
+	repo.products[cardsID] = &fakeProduct{price: 50, available: true, stock: 5000, rules: inventory.PriceRules{
+		QuantityBreaks: []inventory.QuantityBreak{{MinQuantity: 1000, UnitPrice: 25}},
+		Modifiers:      []inventory.PriceModifier{{Option: "laminated", Value: "true", Amount: 8}},
+	}}
+	svc := NewService(repo)
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items: []CartItem{
+			{VendorStoreProductID: cardsID, Quantity: 1000},
+			{VendorStoreProductID: cardsID, Quantity: 200, Customisation: []byte(`{"laminated":false}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.Items[0].UnitPrice != 25 || o.Items[1].UnitPrice != 50 {
+		t.Fatalf("placed lines priced at %s and %s, want 0.25 and 0.50", o.Items[0].UnitPrice, o.Items[1].UnitPrice)
+	}
+
+	// Dropping below the break loses the bulk price; laminating adds the modifier.
+	amended, err := svc.AmendOrder(context.Background(), o.ID.String(), AmendOrderRequest{
+		Version: 1,
+		Items: []AmendLine{
+			{ItemID: o.Items[0].ID.String(), Quantity: 900},
+			{ItemID: o.Items[1].ID.String(), Quantity: 200, Customisation: []byte(`{"laminated":true}`)},
+		},
+		Actor: Actor{ID: uuid.NewString(), Role: "CASHIER"},
+	})
+	if err != nil {
+		t.Fatalf("AmendOrder returned error: %v", err)
+	}
+	if amended.Items[0].UnitPrice != 50 || amended.Items[0].LineTotal != 45000 {
+		t.Fatalf("line below the break = %+v, want 0.50 per unit", amended.Items[0])
+	}
+	if amended.Items[1].UnitPrice != 58 || amended.Items[1].LineTotal != 11600 {
+		t.Fatalf("laminated line = %+v, want 0.58 per unit", amended.Items[1])
+	}
+	if amended.Subtotal != 45000+11600 {
+		t.Fatalf("subtotal = %s, want 566.00", amended.Subtotal)
+	}
+}
+
+func TestAmendOrderBeyondStockLeavesOrderUntouched(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 500, available: true, stock: 5}
+	svc := NewService(repo)
+	o, err := placeSingleItemOrder(t, svc, productID, 3)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+
+	_, err = svc.AmendOrder(context.Background(), o.ID.String(), AmendOrderRequest{
+		Version: 1, Items: []AmendLine{{ItemID: o.Items[0].ID.String(), Quantity: 6}},
+	})
+	var oos *OutOfStockError
+	if !errors.As(err, &oos) {
+		t.Fatalf("expected *OutOfStockError, got %v", err)
+	}
+	if repo.products[productID].stock != 2 || repo.orders[o.ID.String()].Version != 1 || len(repo.versions[o.ID.String()]) != 1 {
+		t.Fatal("a rejected amendment must not move stock or record a version")
+	}
+
+	if _, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: "CONFIRMED"}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if _, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: "IN_PRODUCTION"}); err != nil {
+		t.Fatalf("start production: %v", err)
+	}
+	if _, err := svc.AmendOrder(context.Background(), o.ID.String(), AmendOrderRequest{
+		Version: 1, Items: []AmendLine{{ItemID: o.Items[0].ID.String(), Quantity: 1}},
+	}); !errors.Is(err, ErrNotAmendable) {
+		t.Fatalf("amending an order in production = %v, want ErrNotAmendable", err)
+	}
+}
+
+func TestPlaceReprintCopiesDeliveredLinesAtNoCharge(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 2500, available: true, stock: 10}
+	svc := NewService(repo)
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items:   []CartItem{{VendorStoreProductID: productID, Quantity: 4, Customisation: []byte(`{"finish":"matte"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	req := ReprintRequest{Items: []ReprintLine{{OrderItemID: o.Items[0].ID.String(), Quantity: 2}}, IdempotencyKey: "claim-1"}
+	if _, err := svc.PlaceReprint(context.Background(), o.ID.String(), req); !errors.Is(err, ErrNotReprintable) {
+		t.Fatalf("reprinting an undelivered order = %v, want ErrNotReprintable", err)
+	}
+
+	for _, status := range []string{"CONFIRMED", "IN_PRODUCTION", "READY", "DELIVERED"} {
+		if _, err := svc.UpdateStatus(context.Background(), o.ID.String(), UpdateStatusRequest{Status: status}); err != nil {
+			t.Fatalf("advance to %s: %v", status, err)
+		}
+	}
+	if _, err := svc.PlaceReprint(context.Background(), o.ID.String(), ReprintRequest{
+		Items: []ReprintLine{{OrderItemID: o.Items[0].ID.String(), Quantity: 5}},
+	}); err == nil {
+		t.Fatal("expected a reprint of more units than were delivered to be rejected")
+	}
+
+	reprint, err := svc.PlaceReprint(context.Background(), o.ID.String(), req)
+	if err != nil {
+		t.Fatalf("PlaceReprint returned error: %v", err)
+	}
+	if reprint.Status != StatusConfirmed || reprint.Total != 0 || reprint.ReprintOf == nil || *reprint.ReprintOf != o.ID {
+		t.Fatalf("reprint = %+v", reprint)
+	}
+	if line := reprint.Items[0]; line.Quantity != 2 || line.UnitPrice != 0 || string(line.Customisation) != `{"finish":"matte"}` {
+		t.Fatalf("reprint line = %+v", line)
+	}
+	if repo.products[productID].stock != 4 {
+		t.Fatalf("stock = %d, want 4 after reserving the reprint", repo.products[productID].stock)
+	}
+	if events := repo.events[reprint.ID.String()]; len(events) != 1 || events[0].Reason != "Reprint of "+o.OrderNumber {
+		t.Fatalf("reprint placement event = %+v", events)
+	}
+
+	again, err := svc.PlaceReprint(context.Background(), o.ID.String(), req)
+	if err != nil || again.ID != reprint.ID || repo.products[productID].stock != 4 {
+		t.Fatalf("repeated reprint = %v, %v; want the original reprint", again, err)
+	}
+}
+
+func TestPlaceOrderNumbersOrdersSequentiallyWithinTheDay(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 10}
+	svc := NewService(repo)
+	storeID := uuid.NewString()
+
+	today := time.Now().UTC().Format("20060102")
+	for i, want := range []string{"ORD-" + today + "-0001", "ORD-" + today + "-0002", "ORD-" + today + "-0003"} {
+		o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+			StoreID: storeID,
+			Items:   []CartItem{{VendorStoreProductID: productID, Quantity: 1}},
+		})
+		if err != nil {
+			t.Fatalf("order %d: PlaceOrder returned error: %v", i+1, err)
+		}
+		if o.OrderNumber != want {
+			t.Fatalf("order %d number = %q, want %q", i+1, o.OrderNumber, want)
+		}
+	}
+}
+
+func TestNextOrderNumberUsesVendorPrefixAndResetPeriod(t *testing.T) {
+	repo := newFakeRepository()
+	lusaka := time.FixedZone("CAT", 2*60*60)
+	svc := NewService(repo, WithStoreTimezone(lusaka)).(*service)
+	ctx := context.Background()
+	storeID := uuid.New()
+	at := time.Date(2026, 10, 16, 21, 30, 0, 0, time.UTC) // 23:30 in Lusaka
+
+	repo.numbering = OrderNumbering{Prefix: "KWIK", Reset: vendor.OrderNumberResetYearly}
+	repo.sequences[storeID.String()+"/2026"] = 41
+	number, err := svc.nextOrderNumber(ctx, storeID, at)
+	if err != nil || number != "KWIK-2026-000042" {
+		t.Fatalf("yearly number = %q, %v; want KWIK-2026-000042", number, err)
+	}
+
+	repo.numbering.Reset = vendor.OrderNumberResetDaily
+	if number, _ = svc.nextOrderNumber(ctx, storeID, at); number != "KWIK-20261016-0001" {
+		t.Fatalf("first daily number = %q, want KWIK-20261016-0001", number)
+	}
+	if number, _ = svc.nextOrderNumber(ctx, storeID, at.Add(time.Hour)); number != "KWIK-20261017-0001" {
+		t.Fatalf("number after midnight = %q, want the series to restart at KWIK-20261017-0001", number)
+	}
+	if number, _ = svc.nextOrderNumber(ctx, uuid.New(), at.Add(time.Hour)); number != "KWIK-20261017-0001" {
+		t.Fatalf("another store's first number = %q, want its own series to start at KWIK-20261017-0001", number)
+	}
+
+	repo.sequences[storeID.String()+"/20261017"] = 9999
+	if number, _ = svc.nextOrderNumber(ctx, storeID, at.Add(time.Hour)); number != "KWIK-20261017-10000" {
+		t.Fatalf("number past the padding = %q, want KWIK-20261017-10000", number)
+	}
+}
+
+func TestNextOrderNumberStartsAboveLegacyNumbersOfTheDay(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo).(*service)
+	storeID := uuid.New()
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	// Numbers issued before sequencing ended in four hex characters; some happen to be all digits.
+	for _, legacy := range []string{"ORD-20261016-0815", "ORD-20261016-9a3f", "ORD-20261015-4711"} {
+		o := &Order{ID: uuid.New(), StoreID: storeID, OrderNumber: legacy}
+		repo.orders[o.ID.String()] = o
+	}
+
+	if number, err := svc.nextOrderNumber(context.Background(), storeID, at); err != nil || number != "ORD-20261016-0816" {
+		t.Fatalf("first sequenced number = %q, %v; want ORD-20261016-0816", number, err)
+	}
+	if number, _ := svc.nextOrderNumber(context.Background(), uuid.New(), at); number != "ORD-20261016-0001" {
+		t.Fatalf("a store without legacy numbers got %q, want ORD-20261016-0001", number)
+	}
+}
+
+func TestPlaceOrderRejectsCustomisationThatBreaksTheOptionSchema(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo)
+	customerID, ownAsset, otherAsset := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	repo.assets = map[string]string{ownAsset: customerID, otherAsset: uuid.NewString()}
+	schema := &catalog.OptionSchema{Options: []catalog.ProductOption{
+		{Key: "size", Label: "Size", Type: catalog.OptionEnum, Required: true, Values: []string{"A4", "A3"}},
+		{Key: "artwork", Label: "Artwork", Type: catalog.OptionAsset, Required: true},
+	}}
+	posterID, flyerID, plainID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	repo.products[posterID] = &fakeProduct{price: 5000, available: true, stock: 10, schema: schema}
+	repo.products[flyerID] = &fakeProduct{price: 100, available: true, stock: 10, schema: schema}
+	repo.products[plainID] = &fakeProduct{price: 100, available: true, stock: 10}
+
+	_, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(), CustomerID: customerID,
+		Items: []CartItem{
+			{VendorStoreProductID: posterID, Quantity: 1, Customisation: []byte(`{"size":"A4","artwork":"` + ownAsset + `"}`)},
+			{VendorStoreProductID: plainID, Quantity: 1, Customisation: []byte(`{"note":"free text"}`)},
+			{VendorStoreProductID: flyerID, Quantity: 1, Customisation: []byte(`{"size":"A5","artwork":"` + otherAsset + `"}`)},
+			{VendorStoreProductID: posterID, Quantity: 1, Customisation: []byte(`{"size":"A3","artwork":"` + otherAsset + `"}`)},
+		},
+	})
+	var invalid *CustomisationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected CustomisationError, got %v", err)
+	}
+	if len(invalid.Lines) != 2 || invalid.Lines[0].Line != 2 || invalid.Lines[1].Line != 3 {
+		t.Fatalf("rejected lines = %#v, want lines 2 and 3", invalid.Lines)
+	}
+	// Ownership is only checked once the customisation is otherwise valid.
+	if fields := invalid.Lines[0].Fields; len(fields) != 1 || fields[0].Message != "Size must be one of A4, A3" {
+		t.Fatalf("line 2 field errors = %#v", fields)
+	}
+	if fields := invalid.Lines[1].Fields; len(fields) != 1 || fields[0].Field != "artwork" {
+		t.Fatalf("line 3 field errors = %#v", fields)
+	}
+	if len(repo.orders) != 0 || repo.products[posterID].stock != 10 {
+		t.Fatal("an order with invalid customisation must not be placed")
+	}
+}
+
+func TestPlaceOrderPricesLinesFromQuantityBreaksAndOptionModifiers(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo)
+	cardsID := uuid.NewString()
+	repo.products[cardsID] = &fakeProduct{price: 50, available: true, stock: 5000, rules: inventory.PriceRules{
+		QuantityBreaks: []inventory.QuantityBreak{{MinQuantity: 500, UnitPrice: 35}, {MinQuantity: 1000, UnitPrice: 25}},
+		Modifiers: []inventory.PriceModifier{
+			{Option: "colour", Value: "mono", Amount: -10},
+			{Option: "laminated", Value: "true", Amount: 8},
+		},
+	}}
+
+	o, err := svc.PlaceOrder(context.Background(), PlaceOrderRequest{
+		StoreID: uuid.NewString(),
+		Items: []CartItem{
+			{VendorStoreProductID: cardsID, Quantity: 100},
+			{VendorStoreProductID: cardsID, Quantity: 500, Customisation: []byte(`{"colour":"mono"}`)},
+			{VendorStoreProductID: cardsID, Quantity: 1000, Customisation: []byte(`{"colour":"full","laminated":true}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	want := []money.Amount{50, 25, 33}
+	for i, item := range o.Items {
+		if item.UnitPrice != want[i] || item.LineTotal != want[i].Times(item.Quantity) {
+			t.Fatalf("line %d priced at %s (total %s), want %s per unit", i, item.UnitPrice, item.LineTotal, want[i])
+		}
+	}
+	if o.Subtotal != 5000+12500+33000 {
+		t.Fatalf("subtotal = %s, want 505.00", o.Subtotal)
+	}
+}
+
+func TestPlaceOrderRecordsAFutureDueDate(t *testing.T) {
+	repo := newFakeRepository()
+	productID := uuid.NewString()
+	repo.products[productID] = &fakeProduct{price: 1000, available: true, stock: 10}
+	svc := NewService(repo)
+	req := PlaceOrderRequest{StoreID: uuid.NewString(), Items: []CartItem{{VendorStoreProductID: productID, Quantity: 1}}}
+
+	past := time.Now().Add(-time.Hour)
+	req.DueAt = &past
+	if _, err := svc.PlaceOrder(context.Background(), req); err == nil || !strings.Contains(err.Error(), "invalid due_at") {
+		t.Fatalf("expected a past due date to be rejected, got %v", err)
+	}
+
+	due := time.Now().Add(48 * time.Hour)
+	req.DueAt = &due
+	o, err := svc.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if o.DueAt == nil || !o.DueAt.Equal(due) {
+		t.Fatalf("due_at = %v, want %v", o.DueAt, due)
+	}
+}
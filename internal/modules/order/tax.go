@@ -0,0 +1,84 @@
+package order
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/catalog"
+	"github.com/georgemunganga/printa-backend/internal/money"
+)
+
+// TaxRates maps each catalog tax class to the VAT rate charged on it.
+type TaxRates map[catalog.TaxClass]float64
+
+// DefaultTaxRates applies Zambian VAT: 16% on standard-rated supplies and nothing on zero-rated or exempt ones.
+func DefaultTaxRates() TaxRates {
+	return TaxRates{
+		catalog.TaxStandard:  0.16,
+		catalog.TaxZeroRated: 0,
+		catalog.TaxExempt:    0,
+	}
+}
+
+// TaxOptionsFromEnv applies VAT_STANDARD_RATE, if set, so operators can follow a change to the standard VAT rate
+// without a code change. Every process that prices orders uses it, so orders are taxed alike wherever they are placed.
+func TaxOptionsFromEnv() ([]ServiceOption, error) {
+	raw := strings.TrimSpace(os.Getenv("VAT_STANDARD_RATE"))
+	if raw == "" {
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	if err != nil || rate < 0 || rate >= 1 {
+		return nil, fmt.Errorf("VAT_STANDARD_RATE must be a fraction between 0 and 1, got %q", raw)
+	}
+	return []ServiceOption{WithTaxRates(TaxRates{catalog.TaxStandard: rate})}, nil
+}
+
+// TaxProfile holds the store and customer facts that change how VAT is applied to an order.
+type TaxProfile struct {
+	PricesIncludeTax     bool
+	CustomerExempt       bool
+	ExemptionCertificate string
+}
+
+// applyTax fills in the tax breakdown of every item and returns the order's tax and grand total.
+//
+// The order-level discount is allocated across lines in proportion to their value using the largest-remainder
+// method, so per-line taxable amounts always add up to subtotal minus discount to the ngwee. For stores that
+// quote gross prices VAT is extracted from the discounted line; otherwise it is added on top. Exempt customers
+// pay the net amount with no VAT, whichever way the store quotes.
+func applyTax(items []*OrderItem, discount money.Amount, rates TaxRates, profile TaxProfile) (tax, total money.Amount) {
+	lineTotals := make([]money.Amount, len(items))
+	var subtotal money.Amount
+	for i, item := range items {
+		lineTotals[i] = item.LineTotal
+		subtotal += item.LineTotal
+	}
+	if discount > subtotal {
+		discount = subtotal
+	}
+	shares := money.Allocate(discount, lineTotals)
+
+	for i, item := range items {
+		net := item.LineTotal - shares[i]
+
+		rate := rates[item.TaxClass]
+		taxable, lineTax := net, net.MulRate(rate)
+		if profile.PricesIncludeTax {
+			taxable = net.DivRate(1 + rate)
+			lineTax = net - taxable
+		}
+		if profile.CustomerExempt {
+			rate, lineTax = 0, 0
+		}
+
+		item.TaxRate = rate
+		item.TaxableAmount = taxable
+		item.TaxAmount = lineTax
+		tax += lineTax
+		total += taxable + lineTax
+	}
+	return tax, total
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"strings"
 	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
 )
 
 // Gateway is the provider-agnostic interface every payment adapter must implement.
@@ -16,12 +19,31 @@ type Gateway interface {
 	// Verify queries the provider for the current status of a transaction.
 	Verify(ctx context.Context, providerRef string) (*ProviderInitResponse, error)
 	// Refund requests a refund for a completed transaction.
-	Refund(ctx context.Context, providerRef string, amount float64) (*ProviderInitResponse, error)
+	Refund(ctx context.Context, providerRef string, amount money.Money) (*ProviderInitResponse, error)
 }
 
 // GatewayRegistry maps provider names to their Gateway implementations.
 type GatewayRegistry map[Provider]Gateway
 
+// GatewaysFromEnv builds the mobile money gateways from the MTN_MOMO_* and AIRTEL_* environment variables. The API
+// and the worker, which refunds cancelled checkout orders, share it.
+func GatewaysFromEnv() GatewayRegistry {
+	return GatewayRegistry{
+		ProviderMTNMomo: NewMTNMomoGateway(
+			os.Getenv("MTN_MOMO_API_KEY"),
+			os.Getenv("MTN_MOMO_API_SECRET"),
+			os.Getenv("MTN_MOMO_BASE_URL"),
+			os.Getenv("MTN_MOMO_ENV"),
+		),
+		ProviderAirtel: NewAirtelMoneyGateway(
+			os.Getenv("AIRTEL_CLIENT_ID"),
+			os.Getenv("AIRTEL_CLIENT_SECRET"),
+			os.Getenv("AIRTEL_BASE_URL"),
+			os.Getenv("AIRTEL_ENV"),
+		),
+	}
+}
+
 // ── MTN Mobile Money Adapter ──────────────────────────────────────────────────
 // In production, replace the stub methods with actual MTN MoMo API calls.
 // MTN MoMo API docs: https://momodeveloper.mtn.com/
@@ -78,7 +100,7 @@ func (g *mtnMomoGateway) Verify(ctx context.Context, providerRef string) (*Provi
 	}, nil
 }
 
-func (g *mtnMomoGateway) Refund(ctx context.Context, providerRef string, amount float64) (*ProviderInitResponse, error) {
+func (g *mtnMomoGateway) Refund(ctx context.Context, providerRef string, amount money.Money) (*ProviderInitResponse, error) {
 	// ── PRODUCTION INTEGRATION POINT ──────────────────────────────────────────
 	// POST /disbursement/v1_0/refund
 	// ──────────────────────────────────────────────────────────────────────────
@@ -87,7 +109,7 @@ func (g *mtnMomoGateway) Refund(ctx context.Context, providerRef string, amount
 	return &ProviderInitResponse{
 		ProviderRef:    ref,
 		ProviderStatus: "SUCCESSFUL",
-		Message:        fmt.Sprintf("Refund of %.2f ZMW initiated for %s", amount, providerRef),
+		Message:        fmt.Sprintf("Refund of %s initiated for %s", amount, providerRef),
 	}, nil
 }
 
@@ -149,7 +171,7 @@ func (g *airtelMoneyGateway) Verify(ctx context.Context, providerRef string) (*P
 	}, nil
 }
 
-func (g *airtelMoneyGateway) Refund(ctx context.Context, providerRef string, amount float64) (*ProviderInitResponse, error) {
+func (g *airtelMoneyGateway) Refund(ctx context.Context, providerRef string, amount money.Money) (*ProviderInitResponse, error) {
 	// ── PRODUCTION INTEGRATION POINT ──────────────────────────────────────────
 	// POST /standard/v1/payments/refund
 	// ──────────────────────────────────────────────────────────────────────────
@@ -158,7 +180,7 @@ func (g *airtelMoneyGateway) Refund(ctx context.Context, providerRef string, amo
 	return &ProviderInitResponse{
 		ProviderRef:    ref,
 		ProviderStatus: "TS",
-		Message:        fmt.Sprintf("Refund of %.2f ZMW initiated for %s", amount, providerRef),
+		Message:        fmt.Sprintf("Refund of %s initiated for %s", amount, providerRef),
 	}, nil
 }
 
@@ -1,8 +1,10 @@
 package payment
 
 import (
+	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -10,6 +12,7 @@ import (
 	"github.com/georgemunganga/printa-backend/internal/middleware"
 	"github.com/georgemunganga/printa-backend/internal/modules/order"
 	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -18,10 +21,17 @@ type Handler struct {
 	service       Service
 	vendorService vendor.Service
 	orderService  order.Service
+	checkouts     Checkouts
 }
 
-func NewHandler(service Service, vendorService vendor.Service, orderService order.Service) *Handler {
-	return &Handler{service: service, vendorService: vendorService, orderService: orderService}
+// Checkouts looks up the multi-store checkouts customers pay for in one payment. It is satisfied by
+// order.CheckoutService.
+type Checkouts interface {
+	GetCheckout(ctx context.Context, id string) (*order.Checkout, error)
+}
+
+func NewHandler(service Service, vendorService vendor.Service, orderService order.Service, checkouts Checkouts) *Handler {
+	return &Handler{service: service, vendorService: vendorService, orderService: orderService, checkouts: checkouts}
 }
 
 // RegisterRoutes registers all routes (legacy — kept for backward compatibility).
@@ -57,18 +67,39 @@ func (h *Handler) initiate(w http.ResponseWriter, r *http.Request) {
 		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	if middleware.GetRole(r) == middleware.RoleCustomer {
-		if strings.ToUpper(req.ReferenceType) != "ORDER" {
-			respond(w, http.StatusBadRequest, map[string]string{"error": "customers may initiate payments only for orders"})
+	if ReferenceType(strings.ToUpper(req.ReferenceType)) == RefOrder {
+		// A checkout's child orders are paid for by the checkout payment; paying one on its own would charge twice.
+		if o, err := h.orderService.GetOrder(r.Context(), req.ReferenceID); err == nil && o.CheckoutID != nil {
+			respond(w, http.StatusUnprocessableEntity, map[string]string{"error": "order is part of checkout " + o.CheckoutID.String() + "; pay for the checkout instead"})
 			return
 		}
-		o, err := h.orderService.GetOrder(r.Context(), req.ReferenceID)
-		if err != nil || o.CustomerID == nil || o.CustomerID.String() != middleware.GetUserID(r) {
-			respond(w, http.StatusForbidden, map[string]string{"error": "order is not accessible to the authenticated customer"})
+	}
+	if middleware.GetRole(r) == middleware.RoleCustomer {
+		switch ReferenceType(strings.ToUpper(req.ReferenceType)) {
+		case RefOrder:
+			o, err := h.orderService.GetOrder(r.Context(), req.ReferenceID)
+			if err != nil || o.CustomerID == nil || o.CustomerID.String() != middleware.GetUserID(r) {
+				respond(w, http.StatusForbidden, map[string]string{"error": "order is not accessible to the authenticated customer"})
+				return
+			}
+			req.Amount = o.Total
+			req.Currency = o.Currency
+		case RefCheckout:
+			// One payment covers every child order that has not been cancelled.
+			c, err := h.checkouts.GetCheckout(r.Context(), req.ReferenceID)
+			if err != nil || c.CustomerID.String() != middleware.GetUserID(r) {
+				respond(w, http.StatusForbidden, map[string]string{"error": "checkout is not accessible to the authenticated customer"})
+				return
+			}
+			req.Amount = c.PayableTotal
+			req.Currency = c.Currency
+			if req.Description == "" {
+				req.Description = "Checkout " + c.CheckoutNumber
+			}
+		default:
+			respond(w, http.StatusBadRequest, map[string]string{"error": "customers may initiate payments only for orders and checkouts"})
 			return
 		}
-		req.Amount = o.Total
-		req.Currency = o.Currency
 		req.VendorID = ""
 	} else if !h.bindVendorRequest(w, r, &req.VendorID) {
 		return
@@ -127,7 +158,9 @@ func (h *Handler) refund(w http.ResponseWriter, r *http.Request) {
 		msg := err.Error()
 		if strings.Contains(msg, "not found") {
 			code = http.StatusNotFound
-		} else if strings.Contains(msg, "only COMPLETED") {
+		} else if errors.Is(err, ErrRefundInProgress) {
+			code = http.StatusConflict
+		} else if strings.Contains(msg, "only COMPLETED") || errors.Is(err, ErrRefundExceedsPayment) {
 			code = http.StatusUnprocessableEntity
 		}
 		respond(w, code, map[string]string{"error": msg})
@@ -177,7 +210,7 @@ func (h *Handler) webhookMTN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var raw map[string]interface{}
-	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&raw); err != nil {
+	if err := decodeWebhook(w, r, &raw); err != nil {
 		respond(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
 		return
 	}
@@ -185,7 +218,7 @@ func (h *Handler) webhookMTN(w http.ResponseWriter, r *http.Request) {
 		Provider:    string(ProviderMTNMomo),
 		ExternalRef: stringFromMap(raw, "externalId", "referenceId", "financialTransactionId"),
 		Status:      stringFromMap(raw, "status"),
-		Amount:      floatFromMap(raw, "amount"),
+		Amount:      moneyFromMap(raw, "amount"),
 		Currency:    stringFromMap(raw, "currency"),
 		PhoneNumber: stringFromMap(raw, "payer.partyId", "payer.msisdn"),
 		RawPayload:  raw,
@@ -198,7 +231,7 @@ func (h *Handler) webhookAirtel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var raw map[string]interface{}
-	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&raw); err != nil {
+	if err := decodeWebhook(w, r, &raw); err != nil {
 		respond(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
 		return
 	}
@@ -210,7 +243,7 @@ func (h *Handler) webhookAirtel(w http.ResponseWriter, r *http.Request) {
 		Provider:    string(ProviderAirtel),
 		ExternalRef: stringFromMap(txData, "id", "airtel_money_id"),
 		Status:      stringFromMap(txData, "status"),
-		Amount:      floatFromMap(txData, "amount"),
+		Amount:      moneyFromMap(txData, "amount"),
 		Currency:    stringFromMap(txData, "currency"),
 		PhoneNumber: stringFromMap(txData, "msisdn", "subscriber.msisdn"),
 		RawPayload:  raw,
@@ -218,6 +251,12 @@ func (h *Handler) webhookAirtel(w http.ResponseWriter, r *http.Request) {
 	h.processWebhook(w, r, payload)
 }
 
+func decodeWebhook(w http.ResponseWriter, r *http.Request, raw *map[string]interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.UseNumber()
+	return decoder.Decode(raw)
+}
+
 func (h *Handler) processWebhook(w http.ResponseWriter, r *http.Request, payload WebhookPayload) {
 	tx, err := h.service.HandleWebhook(r.Context(), payload)
 	if err != nil {
@@ -338,7 +377,9 @@ func stringFromMap(m map[string]interface{}, keys ...string) string {
 	return ""
 }
 
-func floatFromMap(m map[string]interface{}, key string) float64 {
+// moneyFromMap reads a major-unit amount from a webhook body decoded with UseNumber, so the provider's decimal
+// text is parsed exactly instead of passing through float64.
+func moneyFromMap(m map[string]interface{}, key string) money.Amount {
 	value := interface{}(m)
 	for _, part := range strings.Split(key, ".") {
 		object, ok := value.(map[string]interface{})
@@ -347,18 +388,18 @@ func floatFromMap(m map[string]interface{}, key string) float64 {
 		}
 		value = object[part]
 	}
+	var text string
 	switch number := value.(type) {
-	case float64:
-		return number
-	case float32:
-		return float64(number)
-	case int:
-		return float64(number)
+	case json.Number:
+		text = number.String()
 	case string:
-		var parsed float64
-		if err := json.Unmarshal([]byte(number), &parsed); err == nil {
-			return parsed
-		}
+		text = number
+	default:
+		return 0
+	}
+	amount, err := money.Parse(text)
+	if err != nil {
+		return 0
 	}
-	return 0
+	return amount
 }
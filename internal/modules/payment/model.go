@@ -1,8 +1,10 @@
 package payment
 
 import (
+	"errors"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -21,6 +23,7 @@ type ReferenceType string
 
 const (
 	RefOrder        ReferenceType = "ORDER"
+	RefCheckout     ReferenceType = "CHECKOUT" // one payment for every child order of a multi-store checkout
 	RefInvoice      ReferenceType = "INVOICE"
 	RefSubscription ReferenceType = "SUBSCRIPTION"
 )
@@ -47,7 +50,7 @@ type PaymentTransaction struct {
 	ProviderRef      string        `json:"provider_ref,omitempty"`
 	ProviderStatus   string        `json:"provider_status,omitempty"`
 	Status           TxStatus      `json:"status"`
-	Amount           float64       `json:"amount"`
+	Amount           money.Amount  `json:"amount"`
 	Currency         string        `json:"currency"`
 	PhoneNumber      string        `json:"phone_number,omitempty"`
 	Description      string        `json:"description,omitempty"`
@@ -61,27 +64,68 @@ type PaymentTransaction struct {
 	UpdatedAt        time.Time     `json:"updated_at"`
 }
 
+// RefundStatus is the lifecycle of one refund against a payment transaction.
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "PENDING"
+	RefundCompleted RefundStatus = "COMPLETED"
+	RefundFailed    RefundStatus = "FAILED"
+)
+
+// Refund is money returned from a COMPLETED payment transaction. A transaction may be refunded in several parts;
+// it becomes REFUNDED once its completed refunds add up to its amount.
+type Refund struct {
+	ID             uuid.UUID    `json:"id"`
+	TransactionID  uuid.UUID    `json:"transaction_id"`
+	Amount         money.Amount `json:"amount"`
+	Reason         string       `json:"reason,omitempty"`
+	Status         RefundStatus `json:"status"`
+	ProviderStatus string       `json:"provider_status,omitempty"`
+	LastError      string       `json:"last_error,omitempty"`
+	IdempotencyKey string       `json:"idempotency_key"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// ErrRefundExceedsPayment is returned when a refund asks for more than is left of the payment.
+var ErrRefundExceedsPayment = errors.New("refund exceeds the amount left on the payment")
+
+// ErrRefundInProgress is returned when a refund with the same idempotency key was started and has not finished.
+// It is not retried automatically: the provider may already have paid it out.
+var ErrRefundInProgress = errors.New("refund is already in progress")
+
+// ErrNoCompletedPayment is returned when a checkout has no COMPLETED payment to refund a share from.
+var ErrNoCompletedPayment = errors.New("no completed payment to refund")
+
 // ── Request/Response DTOs ─────────────────────────────────────────────────────
 
 // InitiatePaymentRequest is the payload to start a new payment.
 type InitiatePaymentRequest struct {
 	Provider      string  `json:"provider"`       // MTN_MOMO | AIRTEL_MONEY | CASH | CARD
-	ReferenceType string  `json:"reference_type"` // ORDER | INVOICE | SUBSCRIPTION
+	ReferenceType string  `json:"reference_type"` // ORDER | CHECKOUT | INVOICE | SUBSCRIPTION
 	ReferenceID   string  `json:"reference_id"`
 	VendorID      string  `json:"vendor_id,omitempty"`
-	Amount        float64 `json:"amount"`
+	Amount        money.Amount `json:"amount"`
 	Currency      string  `json:"currency,omitempty"` // defaults to ZMW
 	PhoneNumber   string  `json:"phone_number,omitempty"`
 	Description   string  `json:"description,omitempty"`
 	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
+// PartialRefundRequest refunds Amount of a payment. Refunds with the same IdempotencyKey are made once.
+type PartialRefundRequest struct {
+	Amount         money.Amount
+	Reason         string
+	IdempotencyKey string
+}
+
 // WebhookPayload is the generic inbound webhook from a payment provider.
 type WebhookPayload struct {
 	Provider        string                 `json:"provider"`
 	ExternalRef     string                 `json:"external_ref"`      // provider's transaction ID
 	Status          string                 `json:"status"`            // provider-specific status string
-	Amount          float64                `json:"amount"`
+	Amount          money.Amount           `json:"amount"`
 	Currency        string                 `json:"currency"`
 	PhoneNumber     string                 `json:"phone_number,omitempty"`
 	RawPayload      map[string]interface{} `json:"raw_payload"`
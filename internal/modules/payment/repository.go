@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -21,6 +23,16 @@ type Repository interface {
 	UpdateProviderRef(ctx context.Context, id string, ref string, status string) error
 	RecordWebhook(ctx context.Context, id string, payload interface{}) error
 	IncrementRetry(ctx context.Context, id string, lastError string) error
+
+	// ReserveRefund records refund as PENDING against its COMPLETED transaction, holding its amount so concurrent
+	// refunds cannot together exceed the payment. A refund already made under the same idempotency key is returned
+	// instead; one still PENDING returns ErrRefundInProgress, and a FAILED one is reserved again.
+	ReserveRefund(ctx context.Context, refund *Refund) (*Refund, error)
+	// FinishRefund records the provider's answer to a PENDING refund. A FAILED refund releases its amount; once the
+	// completed refunds add up to the transaction's amount the transaction becomes REFUNDED.
+	FinishRefund(ctx context.Context, refundID string, status RefundStatus, providerStatus, lastError string) error
+	// RefundedAmount returns how much of a transaction is refunded or being refunded.
+	RefundedAmount(ctx context.Context, transactionID string) (money.Amount, error)
 }
 
 type postgresRepo struct{ db *sql.DB }
@@ -31,7 +43,7 @@ func (r *postgresRepo) Create(ctx context.Context, tx *PaymentTransaction) error
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO payment_transactions
 		  (id, reference_type, reference_id, vendor_id, provider, provider_ref,
-		   provider_status, status, amount, currency, phone_number, description,
+		   provider_status, status, amount_minor, currency, phone_number, description,
 		   idempotency_key)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`,
 		tx.ID, tx.ReferenceType, tx.ReferenceID, tx.VendorID,
@@ -108,11 +120,138 @@ func (r *postgresRepo) IncrementRetry(ctx context.Context, id string, lastError
 	return err
 }
 
+func (r *postgresRepo) ReserveRefund(ctx context.Context, refund *Refund) (*Refund, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status TxStatus
+	var amount money.Amount
+	if err := tx.QueryRowContext(ctx, `
+		SELECT status, amount_minor FROM payment_transactions WHERE id=$1 FOR UPDATE`,
+		refund.TransactionID).Scan(&status, &amount); err != nil {
+		return nil, fmt.Errorf("payment transaction not found: %w", err)
+	}
+	existing, err := scanRefund(tx.QueryRowContext(ctx, refundSelectSQL+" WHERE idempotency_key=$1", refund.IdempotencyKey))
+	switch {
+	case err == sql.ErrNoRows:
+		existing = nil
+	case err != nil:
+		return nil, err
+	case existing.TransactionID != refund.TransactionID:
+		return nil, fmt.Errorf("refund idempotency key already used for payment %s", existing.TransactionID)
+	case existing.Status == RefundCompleted:
+		return existing, nil
+	case existing.Status == RefundPending:
+		return nil, ErrRefundInProgress
+	}
+	if status != TxCompleted {
+		return nil, fmt.Errorf("only COMPLETED transactions can be refunded (current status: %s)", status)
+	}
+	var held money.Amount
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount_minor), 0) FROM payment_refunds
+		WHERE transaction_id=$1 AND status IN ('PENDING', 'COMPLETED')`,
+		refund.TransactionID).Scan(&held); err != nil {
+		return nil, err
+	}
+	if refund.Amount > amount-held {
+		return nil, fmt.Errorf("%w: %s requested, %s left", ErrRefundExceedsPayment, refund.Amount, amount-held)
+	}
+
+	now := time.Now()
+	if existing != nil {
+		refund.ID = existing.ID
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payment_refunds SET amount_minor=$1, reason=$2, status=$3, last_error=NULL, updated_at=$4
+			WHERE id=$5`,
+			refund.Amount, nilIfEmpty(refund.Reason), RefundPending, now, refund.ID)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO payment_refunds (id, transaction_id, amount_minor, reason, status, idempotency_key, created_at, updated_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$7)`,
+			refund.ID, refund.TransactionID, refund.Amount, nilIfEmpty(refund.Reason), RefundPending, refund.IdempotencyKey, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := scanRefund(tx.QueryRowContext(ctx, refundSelectSQL+" WHERE id=$1", refund.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reserved, nil
+}
+
+func (r *postgresRepo) FinishRefund(ctx context.Context, refundID string, status RefundStatus, providerStatus, lastError string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var transactionID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT transaction_id FROM payment_refunds WHERE id=$1`, refundID).Scan(&transactionID); err != nil {
+		return fmt.Errorf("refund not found: %w", err)
+	}
+	var amount money.Amount
+	if err := tx.QueryRowContext(ctx, `
+		SELECT amount_minor FROM payment_transactions WHERE id=$1 FOR UPDATE`, transactionID).Scan(&amount); err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE payment_refunds
+		SET status=$1, provider_status=COALESCE(NULLIF($2,''), provider_status), last_error=NULLIF($3,''), updated_at=$4
+		WHERE id=$5`,
+		status, providerStatus, lastError, now, refundID); err != nil {
+		return err
+	}
+	if status == RefundCompleted {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE payment_transactions SET status=$1, updated_at=$2
+			WHERE id=$3 AND amount_minor <= (
+				SELECT COALESCE(SUM(amount_minor), 0) FROM payment_refunds WHERE transaction_id=$3 AND status='COMPLETED')`,
+			TxRefunded, now, transactionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) RefundedAmount(ctx context.Context, transactionID string) (money.Amount, error) {
+	var held money.Amount
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount_minor), 0) FROM payment_refunds
+		WHERE transaction_id=$1 AND status IN ('PENDING', 'COMPLETED')`, transactionID).Scan(&held)
+	return held, err
+}
+
+const refundSelectSQL = `
+	SELECT id, transaction_id, amount_minor, reason, status, provider_status, last_error, idempotency_key,
+	       created_at, updated_at
+	FROM payment_refunds`
+
+func scanRefund(row rowScanner) (*Refund, error) {
+	refund := &Refund{}
+	var reason, providerStatus, lastErr sql.NullString
+	if err := row.Scan(&refund.ID, &refund.TransactionID, &refund.Amount, &reason, &refund.Status, &providerStatus,
+		&lastErr, &refund.IdempotencyKey, &refund.CreatedAt, &refund.UpdatedAt); err != nil {
+		return nil, err
+	}
+	refund.Reason, refund.ProviderStatus, refund.LastError = reason.String, providerStatus.String, lastErr.String
+	return refund, nil
+}
+
 // ── Scanner ───────────────────────────────────────────────────────────────────
 
 const selectSQL = `
 	SELECT id, reference_type, reference_id, vendor_id, provider, provider_ref,
-	       provider_status, status, amount, currency, phone_number, description,
+	       provider_status, status, amount_minor, currency, phone_number, description,
 	       webhook_received_at, webhook_payload, idempotency_key, retry_count,
 	       last_error, metadata, created_at, updated_at
 	FROM payment_transactions`
@@ -4,9 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"math"
 	"strings"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -17,6 +17,13 @@ type Service interface {
 	Verify(ctx context.Context, id string) (*PaymentTransaction, error)
 	HandleWebhook(ctx context.Context, payload WebhookPayload) (*PaymentTransaction, error)
 	Refund(ctx context.Context, id string) (*PaymentTransaction, error)
+	// RefundPart refunds part of a COMPLETED transaction. A retried call with the same idempotency key returns the
+	// refund already made instead of refunding twice.
+	RefundPart(ctx context.Context, id string, req PartialRefundRequest) (*Refund, error)
+	// RefundCheckoutShare refunds one child order's share of its checkout's completed payment, at most once per
+	// order. It returns ErrNoCompletedPayment when the checkout has not been paid, and an error while a payment for
+	// it is still being processed, since that payment may yet complete.
+	RefundCheckoutShare(ctx context.Context, checkoutID, orderID string, amount money.Amount) (*Refund, error)
 	ListByReference(ctx context.Context, refType ReferenceType, refID string) ([]*PaymentTransaction, error)
 	ListByVendor(ctx context.Context, vendorID string) ([]*PaymentTransaction, error)
 }
@@ -47,7 +54,7 @@ func (s *service) Initiate(ctx context.Context, req InitiatePaymentRequest) (*Pa
 
 	currency := req.Currency
 	if currency == "" {
-		currency = "ZMW"
+		currency = money.DefaultCurrency
 	}
 
 	// Idempotency: return existing transaction if key already used
@@ -177,7 +184,7 @@ func (s *service) HandleWebhook(ctx context.Context, payload WebhookPayload) (*P
 		}
 		return nil, err
 	}
-	if payload.Amount > 0 && math.Abs(tx.Amount-payload.Amount) > 0.005 {
+	if payload.Amount > 0 && payload.Amount != tx.Amount {
 		return nil, fmt.Errorf("webhook amount does not match payment transaction")
 	}
 	if payload.Currency != "" && !strings.EqualFold(tx.Currency, payload.Currency) {
@@ -209,25 +216,93 @@ func (s *service) Refund(ctx context.Context, id string) (*PaymentTransaction, e
 		return nil, fmt.Errorf("only COMPLETED transactions can be refunded (current status: %s)", tx.Status)
 	}
 
-	// CASH refunds are handled manually
-	if tx.Provider == ProviderCash {
-		_ = s.repo.UpdateStatus(ctx, id, TxRefunded, "REFUNDED", "")
-		return s.repo.GetByID(ctx, id)
+	// Refund whatever earlier partial refunds have left.
+	refunded, err := s.repo.RefundedAmount(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-
-	gw, ok := s.gateways[tx.Provider]
-	if !ok {
-		return nil, fmt.Errorf("no gateway registered for provider: %s", tx.Provider)
+	if _, err := s.RefundPart(ctx, id, PartialRefundRequest{
+		Amount:         tx.Amount - refunded,
+		Reason:         "Full refund",
+		IdempotencyKey: "full-" + id,
+	}); err != nil {
+		return nil, err
 	}
+	return s.repo.GetByID(ctx, id)
+}
 
-	resp, err := gw.Refund(ctx, tx.ProviderRef, tx.Amount)
+func (s *service) RefundPart(ctx context.Context, id string, req PartialRefundRequest) (*Refund, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("refund amount must be greater than 0")
+	}
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("refund idempotency key is required")
+	}
+	tx, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("gateway refund failed: %w", err)
+		return nil, fmt.Errorf("payment transaction not found: %w", err)
+	}
+	refund, err := s.repo.ReserveRefund(ctx, &Refund{
+		ID:             uuid.New(),
+		TransactionID:  tx.ID,
+		Amount:         req.Amount,
+		Reason:         req.Reason,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status == RefundCompleted {
+		return refund, nil
 	}
 
-	_ = s.repo.UpdateStatus(ctx, id, TxRefunded, resp.ProviderStatus, "")
+	// CASH refunds are handled manually
+	providerStatus := "REFUNDED"
+	if tx.Provider != ProviderCash {
+		gw, ok := s.gateways[tx.Provider]
+		if !ok {
+			_ = s.repo.FinishRefund(ctx, refund.ID.String(), RefundFailed, "NO_GATEWAY", "no gateway registered for provider: "+string(tx.Provider))
+			return nil, fmt.Errorf("no gateway registered for provider: %s", tx.Provider)
+		}
+		resp, err := gw.Refund(ctx, tx.ProviderRef, money.New(refund.Amount, tx.Currency))
+		if err != nil {
+			_ = s.repo.FinishRefund(ctx, refund.ID.String(), RefundFailed, "GATEWAY_ERROR", err.Error())
+			return nil, fmt.Errorf("gateway refund failed: %w", err)
+		}
+		providerStatus = resp.ProviderStatus
+	}
+	if err := s.repo.FinishRefund(ctx, refund.ID.String(), RefundCompleted, providerStatus, ""); err != nil {
+		return nil, err
+	}
+	refund.Status, refund.ProviderStatus = RefundCompleted, providerStatus
+	return refund, nil
+}
 
-	return s.repo.GetByID(ctx, id)
+func (s *service) RefundCheckoutShare(ctx context.Context, checkoutID, orderID string, amount money.Amount) (*Refund, error) {
+	transactions, err := s.repo.ListByReference(ctx, RefCheckout, checkoutID)
+	if err != nil {
+		return nil, err
+	}
+	var paid *PaymentTransaction
+	for _, tx := range transactions {
+		switch tx.Status {
+		case TxPending, TxProcessing:
+			return nil, fmt.Errorf("checkout %s has a payment still in progress", checkoutID)
+		case TxCompleted, TxRefunded:
+			if paid == nil {
+				paid = tx
+			}
+		}
+	}
+	if paid == nil {
+		return nil, ErrNoCompletedPayment
+	}
+	// The key is per order, not per payment: a cancelled or claimed order gets its share back once.
+	return s.RefundPart(ctx, paid.ID.String(), PartialRefundRequest{
+		Amount:         amount,
+		Reason:         "Share of order " + orderID,
+		IdempotencyKey: "order-" + orderID,
+	})
 }
 
 func (s *service) ListByReference(ctx context.Context, refType ReferenceType, refID string) ([]*PaymentTransaction, error) {
@@ -3,6 +3,7 @@ package pos
 import (
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -32,12 +33,12 @@ type POSTransaction struct {
 	OrderID       uuid.UUID     `json:"order_id"`
 	StoreID       uuid.UUID     `json:"store_id"`
 	CashierID     *uuid.UUID    `json:"cashier_id,omitempty"`
-	Amount        float64       `json:"amount"`
+	Amount        money.Amount  `json:"amount"`
 	Currency      string        `json:"currency"`
 	PaymentMethod PaymentMethod `json:"payment_method"`
 	Reference     string        `json:"reference,omitempty"`
 	Status        TxStatus      `json:"status"`
-	ChangeGiven   float64       `json:"change_given"`
+	ChangeGiven   money.Amount  `json:"change_given"`
 	Notes         string        `json:"notes,omitempty"`
 	TransactedAt  time.Time     `json:"transacted_at"`
 	CreatedAt     time.Time     `json:"created_at"`
@@ -46,14 +47,14 @@ type POSTransaction struct {
 
 // CreateTransactionRequest is the payload for recording a POS payment.
 type CreateTransactionRequest struct {
-	OrderID       string  `json:"order_id"`
-	StoreID       string  `json:"store_id"`
-	CashierID     string  `json:"cashier_id,omitempty"`
-	Amount        float64 `json:"amount"`
-	PaymentMethod string  `json:"payment_method"`
-	Reference     string  `json:"reference,omitempty"`
-	ChangeGiven   float64 `json:"change_given,omitempty"`
-	Notes         string  `json:"notes,omitempty"`
+	OrderID       string       `json:"order_id"`
+	StoreID       string       `json:"store_id"`
+	CashierID     string       `json:"cashier_id,omitempty"`
+	Amount        money.Amount `json:"amount"`
+	PaymentMethod string       `json:"payment_method"`
+	Reference     string       `json:"reference,omitempty"`
+	ChangeGiven   money.Amount `json:"change_given,omitempty"`
+	Notes         string       `json:"notes,omitempty"`
 }
 
 // RefundRequest is the payload for refunding a POS transaction.
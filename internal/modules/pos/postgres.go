@@ -15,8 +15,8 @@ func NewPostgresRepository(db *sql.DB) Repository { return &postgresRepo{db: db}
 func (r *postgresRepo) Create(ctx context.Context, tx *POSTransaction) error {
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO pos_transactions
-		  (id, order_id, store_id, cashier_id, amount, currency, payment_method,
-		   reference, status, change_given, notes)
+		  (id, order_id, store_id, cashier_id, amount_minor, currency, payment_method,
+		   reference, status, change_given_minor, notes)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
 		tx.ID, tx.OrderID, tx.StoreID, tx.CashierID, tx.Amount, tx.Currency,
 		tx.PaymentMethod, tx.Reference, tx.Status, tx.ChangeGiven, tx.Notes)
@@ -25,22 +25,22 @@ func (r *postgresRepo) Create(ctx context.Context, tx *POSTransaction) error {
 
 func (r *postgresRepo) GetByID(ctx context.Context, id string) (*POSTransaction, error) {
 	return r.scan(r.db.QueryRowContext(ctx, `
-		SELECT id,order_id,store_id,cashier_id,amount,currency,payment_method,
-		       reference,status,change_given,notes,transacted_at,created_at,updated_at
+		SELECT id,order_id,store_id,cashier_id,amount_minor,currency,payment_method,
+		       reference,status,change_given_minor,notes,transacted_at,created_at,updated_at
 		FROM pos_transactions WHERE id=$1`, id))
 }
 
 func (r *postgresRepo) GetByOrderID(ctx context.Context, orderID string) (*POSTransaction, error) {
 	return r.scan(r.db.QueryRowContext(ctx, `
-		SELECT id,order_id,store_id,cashier_id,amount,currency,payment_method,
-		       reference,status,change_given,notes,transacted_at,created_at,updated_at
+		SELECT id,order_id,store_id,cashier_id,amount_minor,currency,payment_method,
+		       reference,status,change_given_minor,notes,transacted_at,created_at,updated_at
 		FROM pos_transactions WHERE order_id=$1 ORDER BY created_at DESC LIMIT 1`, orderID))
 }
 
 func (r *postgresRepo) ListByStore(ctx context.Context, storeID string) ([]*POSTransaction, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id,order_id,store_id,cashier_id,amount,currency,payment_method,
-		       reference,status,change_given,notes,transacted_at,created_at,updated_at
+		SELECT id,order_id,store_id,cashier_id,amount_minor,currency,payment_method,
+		       reference,status,change_given_minor,notes,transacted_at,created_at,updated_at
 		FROM pos_transactions WHERE store_id=$1 ORDER BY created_at DESC`, storeID)
 	if err != nil {
 		return nil, err
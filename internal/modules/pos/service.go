@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/georgemunganga/printa-backend/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -54,7 +55,7 @@ func (s *service) RecordPayment(ctx context.Context, req CreateTransactionReques
 		OrderID:       uuid.MustParse(req.OrderID),
 		StoreID:       uuid.MustParse(req.StoreID),
 		Amount:        req.Amount,
-		Currency:      "ZMW",
+		Currency:      money.DefaultCurrency,
 		PaymentMethod: method,
 		Reference:     req.Reference,
 		Status:        TxCompleted,
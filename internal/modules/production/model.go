@@ -54,6 +54,8 @@ type ProductionJob struct {
 	DueAt       *time.Time `json:"due_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	RoutingDecisionID *uuid.UUID `json:"routing_decision_id,omitempty"` // the split routing decision whose item group this job produces
 }
 
 // CreateJobRequest is the payload for creating a new production job.
@@ -64,6 +66,8 @@ type CreateJobRequest struct {
 	Priority   int    `json:"priority,omitempty"`
 	Notes      string `json:"notes,omitempty"`
 	DueAt      string `json:"due_at,omitempty"`
+
+	RoutingDecisionID string `json:"routing_decision_id,omitempty"`
 }
 
 // UpdateStatusRequest is the payload for advancing a job's status.
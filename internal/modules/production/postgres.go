@@ -3,8 +3,11 @@ package production
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/outbox"
 	"github.com/google/uuid"
 )
 
@@ -13,31 +16,77 @@ type postgresRepo struct{ db *sql.DB }
 func NewPostgresRepository(db *sql.DB) Repository { return &postgresRepo{db: db} }
 
 func (r *postgresRepo) Create(ctx context.Context, job *ProductionJob) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO production_jobs (id, order_id, store_id, assigned_to, status, priority, notes, due_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+	created, err := insertJob(ctx, r.db, job)
+	if err != nil || created {
+		return err
+	}
+	existing, err := r.scan(r.db.QueryRowContext(ctx, `
+		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
+		FROM production_jobs WHERE routing_decision_id=$1 AND status <> 'CANCELLED'`, job.RoutingDecisionID))
+	if err != nil {
+		return err
+	}
+	*job = *existing
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertJob reports false, inserting nothing, when a live job already produces the job's routing decision.
+func insertJob(ctx context.Context, db execer, job *ProductionJob) (bool, error) {
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO production_jobs (id, order_id, store_id, assigned_to, status, priority, notes, due_at, routing_decision_id)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		ON CONFLICT (routing_decision_id) WHERE status <> 'CANCELLED' DO NOTHING`,
 		job.ID, job.OrderID, job.StoreID, job.AssignedTo,
-		job.Status, job.Priority, job.Notes, job.DueAt)
-	return err
+		job.Status, job.Priority, job.Notes, job.DueAt, job.RoutingDecisionID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
 }
 
 func (r *postgresRepo) GetByID(ctx context.Context, id string) (*ProductionJob, error) {
 	return r.scan(r.db.QueryRowContext(ctx, `
 		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
-		       started_at,completed_at,due_at,created_at,updated_at
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
 		FROM production_jobs WHERE id=$1`, id))
 }
 
 func (r *postgresRepo) GetByOrderID(ctx context.Context, orderID string) (*ProductionJob, error) {
 	return r.scan(r.db.QueryRowContext(ctx, `
 		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
-		       started_at,completed_at,due_at,created_at,updated_at
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
 		FROM production_jobs WHERE order_id=$1 ORDER BY created_at DESC LIMIT 1`, orderID))
 }
 
+func (r *postgresRepo) ListByOrder(ctx context.Context, orderID string) ([]*ProductionJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
+		FROM production_jobs WHERE order_id=$1 ORDER BY created_at ASC, id ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []*ProductionJob
+	for rows.Next() {
+		j, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
 func (r *postgresRepo) ListByStore(ctx context.Context, storeID string, status string) ([]*ProductionJob, error) {
 	query := `SELECT id,order_id,store_id,assigned_to,status,priority,notes,
-	                 started_at,completed_at,due_at,created_at,updated_at
+	                 started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
 	          FROM production_jobs WHERE store_id=$1`
 	args := []interface{}{storeID}
 	if status != "" {
@@ -65,7 +114,7 @@ func (r *postgresRepo) ListByStore(ctx context.Context, storeID string, status s
 func (r *postgresRepo) ListByAssignee(ctx context.Context, userID string) ([]*ProductionJob, error) {
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
-		       started_at,completed_at,due_at,created_at,updated_at
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
 		FROM production_jobs WHERE assigned_to=$1
 		ORDER BY priority ASC, created_at ASC`, userID)
 	if err != nil {
@@ -92,15 +141,34 @@ func (r *postgresRepo) UpdateStatus(ctx context.Context, id string, status JobSt
 	if status == JobCompleted {
 		completedAt = now
 	}
-	_, err := r.db.ExecContext(ctx, `
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var jobID, orderID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
 		UPDATE production_jobs
 		SET status=$1, notes=COALESCE(NULLIF($2,''), notes),
 		    started_at=COALESCE($3, started_at),
 		    completed_at=COALESCE($4, completed_at),
 		    updated_at=$5
-		WHERE id=$6`,
-		status, notes, startedAt, completedAt, now, id)
-	return err
+		WHERE id=$6
+		RETURNING id, order_id`,
+		status, notes, startedAt, completedAt, now, id).Scan(&jobID, &orderID)
+	if err != nil {
+		return err
+	}
+	if err := enqueueStatusChange(ctx, tx, jobID, orderID, status); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// enqueueStatusChange records EventJobStatusChanged so the order follows its production.
+func enqueueStatusChange(ctx context.Context, tx *sql.Tx, jobID, orderID uuid.UUID, status JobStatus) error {
+	return outbox.EnqueueTx(ctx, tx, "production_job", jobID, EventJobStatusChanged,
+		JobEvent{JobID: jobID, OrderID: orderID, Status: status})
 }
 
 func (r *postgresRepo) UpdateAssignee(ctx context.Context, id string, userID string) error {
@@ -121,6 +189,99 @@ func (r *postgresRepo) CountActiveByStore(ctx context.Context, storeID string) (
 	return count, err
 }
 
+// SyncOrderJobs holds the order row lock for the whole reconciliation, so concurrent deliveries of the order's
+// events queue behind each other instead of both queueing a job.
+func (r *postgresRepo) SyncOrderJobs(ctx context.Context, orderID string, plan func(*OrderProduction) (create, cancel []*ProductionJob)) ([]*ProductionJob, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	p := &OrderProduction{}
+	var dueAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, status, store_id, due_at, reprint_of_order_id IS NOT NULL FROM orders WHERE id=$1 FOR UPDATE`, orderID).
+		Scan(&p.OrderID, &p.OrderStatus, &p.StoreID, &dueAt, &p.Reprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dueAt.Valid {
+		p.DueAt = &dueAt.Time
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, assigned_store_id FROM routing_decisions
+		WHERE order_id=$1 AND status IN ('ASSIGNED','ACCEPTED')
+		ORDER BY decided_at ASC, id ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var route Route
+		if err := rows.Scan(&route.DecisionID, &route.StoreID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Routes = append(p.Routes, route)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT id,order_id,store_id,assigned_to,status,priority,notes,
+		       started_at,completed_at,due_at,created_at,updated_at,routing_decision_id
+		FROM production_jobs WHERE order_id=$1 AND status <> 'CANCELLED'
+		ORDER BY created_at ASC, id ASC FOR UPDATE`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		j, err := r.scan(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Jobs = append(p.Jobs, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	create, cancel := plan(p)
+	var created []*ProductionJob
+	for _, job := range create {
+		ok, err := insertJob(ctx, tx, job)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			created = append(created, job)
+		}
+	}
+	now := time.Now()
+	for _, job := range cancel {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE production_jobs SET status='CANCELLED', notes=$3, updated_at=$2
+			WHERE id=$1 AND status='QUEUED'`, job.ID, now, job.Notes); err != nil {
+			return nil, err
+		}
+		if err := enqueueStatusChange(ctx, tx, job.ID, job.OrderID, JobCancelled); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
 // ── scanner ───────────────────────────────────────────────────────────────────
 
 type rowScanner interface{ Scan(dest ...interface{}) error }
@@ -129,9 +290,10 @@ func (r *postgresRepo) scan(row rowScanner) (*ProductionJob, error) {
 	j := &ProductionJob{}
 	var assignedTo sql.NullString
 	var startedAt, completedAt, dueAt sql.NullTime
+	var routingDecisionID uuid.NullUUID
 	err := row.Scan(&j.ID, &j.OrderID, &j.StoreID, &assignedTo, &j.Status,
 		&j.Priority, &j.Notes, &startedAt, &completedAt, &dueAt,
-		&j.CreatedAt, &j.UpdatedAt)
+		&j.CreatedAt, &j.UpdatedAt, &routingDecisionID)
 	if err != nil {
 		return nil, err
 	}
@@ -148,5 +310,8 @@ func (r *postgresRepo) scan(row rowScanner) (*ProductionJob, error) {
 	if dueAt.Valid {
 		j.DueAt = &dueAt.Time
 	}
+	if routingDecisionID.Valid {
+		j.RoutingDecisionID = &routingDecisionID.UUID
+	}
 	return j, nil
 }
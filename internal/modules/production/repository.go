@@ -4,12 +4,20 @@ import "context"
 
 // Repository defines data access for production jobs.
 type Repository interface {
+	// Create inserts the job. When a live job already exists for the job's routing decision, job is filled in
+	// with that one instead.
 	Create(ctx context.Context, job *ProductionJob) error
 	GetByID(ctx context.Context, id string) (*ProductionJob, error)
 	GetByOrderID(ctx context.Context, orderID string) (*ProductionJob, error)
+	// ListByOrder returns every job of an order, oldest first.
+	ListByOrder(ctx context.Context, orderID string) ([]*ProductionJob, error)
 	ListByStore(ctx context.Context, storeID string, status string) ([]*ProductionJob, error)
 	ListByAssignee(ctx context.Context, userID string) ([]*ProductionJob, error)
+	// UpdateStatus changes the job's status and records EventJobStatusChanged in the same transaction.
 	UpdateStatus(ctx context.Context, id string, status JobStatus, notes string) error
 	UpdateAssignee(ctx context.Context, id string, userID string) error
 	CountActiveByStore(ctx context.Context, storeID string) (int, error)
+	// SyncOrderJobs locks the order, loads its production state, and queues and cancels the jobs plan returns in
+	// one transaction. It returns the queued jobs.
+	SyncOrderJobs(ctx context.Context, orderID string, plan func(*OrderProduction) (create, cancel []*ProductionJob)) ([]*ProductionJob, error)
 }
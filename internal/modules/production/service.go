@@ -19,6 +19,11 @@ type Service interface {
 	UpdateStatus(ctx context.Context, id string, req UpdateStatusRequest) (*ProductionJob, error)
 	AssignJob(ctx context.Context, id string, req AssignRequest) (*ProductionJob, error)
 	QueueDepth(ctx context.Context, storeID string) (int, error)
+	// SyncOrderJobs queues a job for each store a confirmed order is routed to that has none, and cancels queued
+	// jobs the order no longer needs. It is safe to repeat.
+	SyncOrderJobs(ctx context.Context, orderID string) ([]*ProductionJob, error)
+	// OrderProgress reports whether production of an order has started and whether it has finished.
+	OrderProgress(ctx context.Context, orderID string) (*Progress, error)
 }
 
 type service struct{ repo Repository }
@@ -72,6 +77,14 @@ func (s *service) CreateJob(ctx context.Context, req CreateJobRequest) (*Product
 		job.DueAt = &t
 	}
 
+	if req.RoutingDecisionID != "" {
+		did, err := uuid.Parse(req.RoutingDecisionID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid routing_decision_id: %w", err)
+		}
+		job.RoutingDecisionID = &did
+	}
+
 	if err := s.repo.Create(ctx, job); err != nil {
 		return nil, err
 	}
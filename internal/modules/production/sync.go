@@ -0,0 +1,153 @@
+package production
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventJobStatusChanged is recorded in the outbox, in the same transaction, whenever a job changes status.
+const EventJobStatusChanged = "production.job.status_changed.v1"
+
+// JobEvent is the payload of production job outbox events.
+type JobEvent struct {
+	JobID   uuid.UUID `json:"job_id"`
+	OrderID uuid.UUID `json:"order_id"`
+	Status  JobStatus `json:"status"`
+}
+
+// OrderProduction is what SyncOrderJobs reconciles: an order, the stores it is currently routed to and its jobs
+// that were not cancelled.
+type OrderProduction struct {
+	OrderID     uuid.UUID
+	OrderStatus string
+	StoreID     uuid.UUID // the store the order was placed with
+	DueAt       *time.Time
+	Reprint     bool // the order reprints a defective delivered order
+	Routes      []Route
+	Jobs        []*ProductionJob
+}
+
+// Route is an active (ASSIGNED or ACCEPTED) routing decision of an order.
+type Route struct {
+	DecisionID uuid.UUID
+	StoreID    uuid.UUID
+}
+
+// Progress summarises an order's production jobs.
+type Progress struct {
+	Started  bool // a job has been started
+	Finished bool // the order has jobs and every one not cancelled is completed
+}
+
+// reprintPriority queues reprints as URGENT: the customer has already waited for a defective order once.
+const reprintPriority = 1
+
+// SyncOrderJobs makes the jobs of a CONFIRMED or IN_PRODUCTION order match where it is routed, and cancels the
+// queued jobs of a CANCELLED order. See planJobs.
+func (s *service) SyncOrderJobs(ctx context.Context, orderID string) ([]*ProductionJob, error) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return nil, fmt.Errorf("invalid order_id: %w", err)
+	}
+	created, err := s.repo.SyncOrderJobs(ctx, orderID, planJobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync production jobs for order %s: %w", orderID, err)
+	}
+	return created, nil
+}
+
+func (s *service) OrderProgress(ctx context.Context, orderID string) (*Progress, error) {
+	jobs, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return progressOf(jobs), nil
+}
+
+func progressOf(jobs []*ProductionJob) *Progress {
+	p := &Progress{}
+	live := 0
+	completed := 0
+	for _, j := range jobs {
+		switch j.Status {
+		case JobCancelled:
+			continue
+		case JobInProgress, JobOnHold:
+			p.Started = true
+		case JobCompleted:
+			p.Started = true
+			completed++
+		}
+		live++
+	}
+	p.Finished = live > 0 && completed == live
+	return p
+}
+
+// planJobs decides which jobs to queue and which to cancel so that a confirmed order has exactly one live job per
+// store it is routed to: one per active routing decision, or one at the store it was placed with when it has not
+// been routed. A job counts for a route when it was made for that decision, or was made by hand at that store.
+// Queued jobs that no longer match a route, e.g. after an override, are cancelled; jobs already started are left
+// for the store to finish or cancel. A cancelled order has all its queued jobs cancelled. Orders in any other
+// status are left alone. Cancelled jobs carry the note to record.
+func planJobs(p *OrderProduction) (create []*ProductionJob, cancel []*ProductionJob) {
+	if p.OrderStatus == "CANCELLED" {
+		for _, j := range p.Jobs {
+			if j.Status == JobQueued {
+				j.Notes = "Cancelled automatically: the order was cancelled"
+				cancel = append(cancel, j)
+			}
+		}
+		return nil, cancel
+	}
+	if p.OrderStatus != "CONFIRMED" && p.OrderStatus != "IN_PRODUCTION" {
+		return nil, nil
+	}
+	routes := p.Routes
+	if len(routes) == 0 {
+		routes = []Route{{StoreID: p.StoreID}}
+	}
+	matched := make([]bool, len(p.Jobs))
+	for _, route := range routes {
+		found := false
+		for i, j := range p.Jobs {
+			if matched[i] {
+				continue
+			}
+			if (j.RoutingDecisionID != nil && *j.RoutingDecisionID == route.DecisionID) ||
+				(j.RoutingDecisionID == nil && j.StoreID == route.StoreID) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		job := &ProductionJob{
+			ID:       uuid.New(),
+			OrderID:  p.OrderID,
+			StoreID:  route.StoreID,
+			Status:   JobQueued,
+			Priority: 5,
+			Notes:    "Queued automatically for the confirmed order",
+			DueAt:    p.DueAt,
+		}
+		if p.Reprint {
+			job.Priority, job.Notes = reprintPriority, "Reprint of a defective order, queued automatically"
+		}
+		if route.DecisionID != uuid.Nil {
+			decisionID := route.DecisionID
+			job.RoutingDecisionID = &decisionID
+		}
+		create = append(create, job)
+	}
+	for i, j := range p.Jobs {
+		if !matched[i] && j.Status == JobQueued {
+			j.Notes = "Cancelled automatically: the order was routed elsewhere"
+			cancel = append(cancel, j)
+		}
+	}
+	return create, cancel
+}
@@ -0,0 +1,85 @@
+package production
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanJobsQueuesOneJobPerRouteAndCancelsSupersededQueuedJobs(t *testing.T) {
+	due := time.Date(2026, 10, 20, 17, 0, 0, 0, time.UTC)
+	placedAt, routedTo, other := uuid.New(), uuid.New(), uuid.New()
+	p := &OrderProduction{OrderID: uuid.New(), OrderStatus: "CONFIRMED", StoreID: placedAt, DueAt: &due}
+
+	create, cancel := planJobs(p)
+	if len(create) != 1 || len(cancel) != 0 || create[0].StoreID != placedAt || create[0].RoutingDecisionID != nil ||
+		create[0].DueAt != &due {
+		t.Fatalf("expected an unrouted order queued at its own store by its due date, got %#v", create)
+	}
+
+	// Once routed, the queued job at the placing store gives way to one for the decision; a started job stays.
+	oldDecision, decision := uuid.New(), uuid.New()
+	queued := &ProductionJob{ID: uuid.New(), StoreID: placedAt, Status: JobQueued}
+	started := &ProductionJob{ID: uuid.New(), StoreID: other, Status: JobInProgress, RoutingDecisionID: &oldDecision}
+	p.Jobs = []*ProductionJob{queued, started}
+	p.Routes = []Route{{DecisionID: decision, StoreID: routedTo}}
+	create, cancel = planJobs(p)
+	if len(create) != 1 || *create[0].RoutingDecisionID != decision || create[0].StoreID != routedTo {
+		t.Fatalf("expected a job for the routing decision, got %#v", create)
+	}
+	if len(cancel) != 1 || cancel[0] != queued {
+		t.Fatalf("expected only the superseded queued job cancelled, got %#v", cancel)
+	}
+
+	// Running the plan again over its own result changes nothing.
+	p.Jobs = append([]*ProductionJob{started}, create...)
+	if create, cancel = planJobs(p); len(create) != 0 || len(cancel) != 0 {
+		t.Fatalf("expected a synced order to need nothing, got %d to create and %d to cancel", len(create), len(cancel))
+	}
+
+	// Cancelling the order cancels what is still queued; the store finishes or cancels what it has started.
+	p.OrderStatus = "CANCELLED"
+	create, cancel = planJobs(p)
+	if len(create) != 0 || len(cancel) != 1 || cancel[0].ID != p.Jobs[1].ID || cancel[0].Notes == "" {
+		t.Fatalf("expected only the queued job cancelled with a note, got %d to create and %#v", len(create), cancel)
+	}
+
+	p.OrderStatus = "PENDING"
+	p.Jobs = nil
+	if create, _ = planJobs(p); len(create) != 0 {
+		t.Fatal("an unconfirmed order must not be queued for production")
+	}
+}
+
+func TestPlanJobsQueuesReprintsAsUrgent(t *testing.T) {
+	p := &OrderProduction{OrderID: uuid.New(), OrderStatus: "CONFIRMED", StoreID: uuid.New(), Reprint: true}
+	create, _ := planJobs(p)
+	if len(create) != 1 || create[0].Priority != reprintPriority {
+		t.Fatalf("expected one urgent reprint job, got %#v", create)
+	}
+}
+
+func TestProgressIgnoresCancelledJobs(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []JobStatus
+		want     Progress
+	}{
+		{"no jobs", nil, Progress{}},
+		{"queued", []JobStatus{JobQueued}, Progress{}},
+		{"one of two started", []JobStatus{JobQueued, JobInProgress}, Progress{Started: true}},
+		{"one of two done", []JobStatus{JobCompleted, JobOnHold}, Progress{Started: true}},
+		{"done apart from a cancelled job", []JobStatus{JobCompleted, JobCancelled}, Progress{Started: true, Finished: true}},
+		{"all cancelled", []JobStatus{JobCancelled}, Progress{}},
+	}
+	for _, tc := range cases {
+		var jobs []*ProductionJob
+		for _, status := range tc.statuses {
+			jobs = append(jobs, &ProductionJob{ID: uuid.New(), Status: status})
+		}
+		if got := progressOf(jobs); *got != tc.want {
+			t.Errorf("%s: progress = %+v, want %+v", tc.name, *got, tc.want)
+		}
+	}
+}
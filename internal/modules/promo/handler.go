@@ -0,0 +1,174 @@
+package promo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler exposes promo code management. Administrators manage every code; vendors manage codes scoped to
+// their own vendor and stores.
+type Handler struct {
+	service          Service
+	inventoryService inventory.Service
+	vendorService    vendor.Service
+}
+
+func NewHandler(service Service, inventoryService inventory.Service, vendorService vendor.Service) *Handler {
+	return &Handler{service: service, inventoryService: inventoryService, vendorService: vendorService}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/promo-codes", func(r chi.Router) {
+		r.Post("/", h.create)
+		r.Get("/", h.list)
+		r.Get("/{id}", h.get)
+		r.Post("/{id}/deactivate", h.deactivate)
+		r.Get("/{id}/redemptions", h.listRedemptions)
+	})
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var req CreatePromoCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.CreatedBy = middleware.GetUserID(r)
+
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err != nil {
+			respond(w, http.StatusForbidden, map[string]string{"error": "vendor profile not found"})
+			return
+		}
+		// Vendor codes are always scoped to the vendor, and optionally narrowed to one of its stores.
+		req.VendorID = v.ID.String()
+		if req.StoreID != "" {
+			store, err := h.inventoryService.GetStore(r.Context(), req.StoreID)
+			if err != nil || store.VendorID != v.ID {
+				respond(w, http.StatusForbidden, map[string]string{"error": "store does not belong to your vendor"})
+				return
+			}
+		}
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "vendor or administrator permission is required"})
+		return
+	}
+
+	p, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		respond(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusCreated, p)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	vendorID := ""
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		vendorID = r.URL.Query().Get("vendor_id")
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err != nil {
+			respond(w, http.StatusForbidden, map[string]string{"error": "vendor profile not found"})
+			return
+		}
+		vendorID = v.ID.String()
+	default:
+		respond(w, http.StatusForbidden, map[string]string{"error": "vendor or administrator permission is required"})
+		return
+	}
+
+	codes, err := h.service.List(r.Context(), vendorID)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if codes == nil {
+		codes = []*PromoCode{}
+	}
+	respond(w, http.StatusOK, codes)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.requireCodeOwner(w, r)
+	if !ok {
+		return
+	}
+	respond(w, http.StatusOK, p)
+}
+
+func (h *Handler) deactivate(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.requireCodeOwner(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.service.Deactivate(r.Context(), p.ID.String())
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, p)
+}
+
+func (h *Handler) listRedemptions(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.requireCodeOwner(w, r)
+	if !ok {
+		return
+	}
+	redemptions, err := h.service.ListRedemptions(r.Context(), p.ID.String())
+	if err != nil {
+		respond(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if redemptions == nil {
+		redemptions = []*Redemption{}
+	}
+	respond(w, http.StatusOK, redemptions)
+}
+
+func (h *Handler) requireCodeOwner(w http.ResponseWriter, r *http.Request) (*PromoCode, bool) {
+	p, err := h.service.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		respond(w, status, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		return p, true
+	case middleware.RoleVendor:
+		v, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err == nil && p.VendorID != nil && v.ID == *p.VendorID {
+			return p, true
+		}
+	}
+
+	respond(w, http.StatusForbidden, map[string]string{"error": "promo code owner or administrator permission is required"})
+	return nil, false
+}
+
+func respond(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,124 @@
+package promo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// DiscountType selects how a promo code's discount is calculated.
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "PERCENTAGE"
+	DiscountFixed      DiscountType = "FIXED"
+)
+
+// PromoCode is a server-evaluated discount code. StoreID, VendorID and Category are optional scopes; every scope
+// that is set must match the cart, and a code with none applies platform-wide. Zero limits mean unlimited.
+type PromoCode struct {
+	ID               uuid.UUID    `json:"id"`
+	Code             string       `json:"code"`
+	Description      string       `json:"description,omitempty"`
+	DiscountType     DiscountType `json:"discount_type"`
+	PercentageBps    int          `json:"percentage_bps,omitempty"`
+	AmountOff        money.Amount `json:"amount_off,omitempty"`
+	MaxDiscount      money.Amount `json:"max_discount,omitempty"`
+	MinSpend         money.Amount `json:"min_spend"`
+	UsageLimit       int          `json:"usage_limit"`
+	PerCustomerLimit int          `json:"per_customer_limit"`
+	RedemptionCount  int          `json:"redemption_count"`
+	StoreID          *uuid.UUID   `json:"store_id,omitempty"`
+	VendorID         *uuid.UUID   `json:"vendor_id,omitempty"`
+	Category         string       `json:"category,omitempty"`
+	StartsAt         time.Time    `json:"starts_at"`
+	EndsAt           *time.Time   `json:"ends_at,omitempty"`
+	IsActive         bool         `json:"is_active"`
+	CreatedBy        *uuid.UUID   `json:"created_by,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// CreatePromoCodeRequest is the payload for creating a promo code. Percentages are in basis points (1500 = 15%).
+type CreatePromoCodeRequest struct {
+	Code             string       `json:"code"`
+	Description      string       `json:"description,omitempty"`
+	DiscountType     string       `json:"discount_type"`
+	PercentageBps    int          `json:"percentage_bps,omitempty"`
+	AmountOff        money.Amount `json:"amount_off,omitempty"`
+	MaxDiscount      money.Amount `json:"max_discount,omitempty"`
+	MinSpend         money.Amount `json:"min_spend,omitempty"`
+	UsageLimit       int          `json:"usage_limit,omitempty"`
+	PerCustomerLimit int          `json:"per_customer_limit,omitempty"`
+	StoreID          string       `json:"store_id,omitempty"`
+	VendorID         string       `json:"vendor_id,omitempty"`
+	Category         string       `json:"category,omitempty"`
+	StartsAt         *time.Time   `json:"starts_at,omitempty"`
+	EndsAt           *time.Time   `json:"ends_at,omitempty"`
+	CreatedBy        string       `json:"-"`
+}
+
+// QuoteLine is one cart line as seen by the promo engine.
+type QuoteLine struct {
+	Amount   money.Amount
+	Category string
+}
+
+// QuoteRequest asks what a promo code is worth against a cart. CustomerID is empty for walk-in orders.
+type QuoteRequest struct {
+	Code       string
+	StoreID    string
+	CustomerID string
+	Lines      []QuoteLine
+}
+
+// Quote is the discount a promo code grants a cart. It is advisory until Redeem succeeds in the order transaction.
+type Quote struct {
+	PromoCodeID uuid.UUID
+	Code        string
+	Discount    money.Amount
+}
+
+// Redemption records a promo code applied to an order.
+type Redemption struct {
+	ID          uuid.UUID    `json:"id"`
+	PromoCodeID uuid.UUID    `json:"promo_code_id"`
+	OrderID     uuid.UUID    `json:"order_id"`
+	CustomerID  *uuid.UUID   `json:"customer_id,omitempty"`
+	Discount    money.Amount `json:"discount"`
+	ReleasedAt  *time.Time   `json:"released_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// RejectionReason is a stable, machine-readable explanation for a rejected promo code.
+type RejectionReason string
+
+const (
+	ReasonNotFound             RejectionReason = "NOT_FOUND"
+	ReasonInactive             RejectionReason = "INACTIVE"
+	ReasonNotStarted           RejectionReason = "NOT_STARTED"
+	ReasonExpired              RejectionReason = "EXPIRED"
+	ReasonNotApplicable        RejectionReason = "NOT_APPLICABLE"
+	ReasonMinimumSpendNotMet   RejectionReason = "MINIMUM_SPEND_NOT_MET"
+	ReasonUsageLimitReached    RejectionReason = "USAGE_LIMIT_REACHED"
+	ReasonCustomerLimitReached RejectionReason = "CUSTOMER_LIMIT_REACHED"
+	ReasonCustomerRequired     RejectionReason = "CUSTOMER_REQUIRED"
+)
+
+// RejectionError reports why a promo code cannot be applied. It is returned both when quoting and when the
+// redemption is re-checked under lock at order placement.
+type RejectionError struct {
+	Code   string          `json:"promo_code"`
+	Reason RejectionReason `json:"reason"`
+	Detail string          `json:"detail"`
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("promo code %s cannot be applied: %s", e.Code, e.Detail)
+}
+
+func reject(code string, reason RejectionReason, format string, args ...interface{}) error {
+	return &RejectionError{Code: code, Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
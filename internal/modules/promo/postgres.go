@@ -0,0 +1,214 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+type postgresRepo struct{ db *sql.DB }
+
+func NewPostgresRepository(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const promoColumns = `id, code, COALESCE(description,''), discount_type, COALESCE(percentage_bps,0),
+	       COALESCE(amount_off_minor,0), max_discount_minor, min_spend_minor, usage_limit, per_customer_limit,
+	       redemption_count, store_id, vendor_id, COALESCE(category,''), starts_at, ends_at, is_active, created_by,
+	       created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *postgresRepo) Create(ctx context.Context, p *PromoCode) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO promo_codes
+		  (id, code, description, discount_type, percentage_bps, amount_off_minor, max_discount_minor,
+		   min_spend_minor, usage_limit, per_customer_limit, store_id, vendor_id, category, starts_at, ends_at,
+		   is_active, created_by)
+		VALUES ($1,$2,NULLIF($3,''),$4,NULLIF($5,0),NULLIF($6,0),$7,$8,$9,$10,$11,$12,NULLIF($13,''),$14,$15,$16,$17)`,
+		p.ID, p.Code, p.Description, p.DiscountType, p.PercentageBps, p.AmountOff, p.MaxDiscount,
+		p.MinSpend, p.UsageLimit, p.PerCustomerLimit, p.StoreID, p.VendorID, p.Category, p.StartsAt, p.EndsAt,
+		p.IsActive, p.CreatedBy)
+	return err
+}
+
+func (r *postgresRepo) GetByID(ctx context.Context, id string) (*PromoCode, error) {
+	return scanPromo(r.db.QueryRowContext(ctx, `SELECT `+promoColumns+` FROM promo_codes WHERE id=$1`, id))
+}
+
+func (r *postgresRepo) GetByCode(ctx context.Context, code string) (*PromoCode, error) {
+	return scanPromo(r.db.QueryRowContext(ctx, `SELECT `+promoColumns+` FROM promo_codes WHERE code=$1`, code))
+}
+
+func (r *postgresRepo) List(ctx context.Context, vendorID string) ([]*PromoCode, error) {
+	query := `SELECT ` + promoColumns + ` FROM promo_codes`
+	var args []interface{}
+	if vendorID != "" {
+		query += ` WHERE vendor_id=$1`
+		args = append(args, vendorID)
+	}
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var codes []*PromoCode
+	for rows.Next() {
+		p, err := scanPromo(rows)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, p)
+	}
+	return codes, rows.Err()
+}
+
+func (r *postgresRepo) Deactivate(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE promo_codes SET is_active=FALSE, updated_at=NOW() WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *postgresRepo) CountCustomerRedemptions(ctx context.Context, promoCodeID, customerID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM promo_redemptions
+		WHERE promo_code_id=$1 AND customer_id=$2 AND released_at IS NULL`,
+		promoCodeID, customerID).Scan(&count)
+	return count, err
+}
+
+func (r *postgresRepo) ListRedemptions(ctx context.Context, promoCodeID string) ([]*Redemption, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, promo_code_id, order_id, customer_id, discount_minor, released_at, created_at
+		FROM promo_redemptions WHERE promo_code_id=$1 ORDER BY created_at DESC`, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var redemptions []*Redemption
+	for rows.Next() {
+		red := &Redemption{}
+		var customerID uuid.NullUUID
+		var releasedAt sql.NullTime
+		if err := rows.Scan(&red.ID, &red.PromoCodeID, &red.OrderID, &customerID, &red.Discount, &releasedAt, &red.CreatedAt); err != nil {
+			return nil, err
+		}
+		if customerID.Valid {
+			red.CustomerID = &customerID.UUID
+		}
+		if releasedAt.Valid {
+			red.ReleasedAt = &releasedAt.Time
+		}
+		redemptions = append(redemptions, red)
+	}
+	return redemptions, rows.Err()
+}
+
+func (r *postgresRepo) GetStoreVendorID(ctx context.Context, storeID string) (string, error) {
+	var vendorID string
+	err := r.db.QueryRowContext(ctx, `SELECT vendor_id FROM stores WHERE id=$1`, storeID).Scan(&vendorID)
+	return vendorID, err
+}
+
+// Redeem records a redemption inside the caller's order transaction. The promo row is locked first and its
+// validity window and usage limits are re-checked, so two checkouts can never both take the last use.
+func Redeem(ctx context.Context, tx *sql.Tx, red *Redemption) error {
+	p, err := scanPromo(tx.QueryRowContext(ctx,
+		`SELECT `+promoColumns+` FROM promo_codes WHERE id=$1 FOR UPDATE`, red.PromoCodeID))
+	if err != nil {
+		return fmt.Errorf("lock promo code: %w", err)
+	}
+	customerID := ""
+	customerRedemptions := 0
+	if red.CustomerID != nil {
+		customerID = red.CustomerID.String()
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM promo_redemptions
+			WHERE promo_code_id=$1 AND customer_id=$2 AND released_at IS NULL`,
+			p.ID, customerID).Scan(&customerRedemptions); err != nil {
+			return err
+		}
+	}
+	if err := checkAvailability(p, customerID, customerRedemptions, time.Now()); err != nil {
+		return err
+	}
+
+	if red.ID == uuid.Nil {
+		red.ID = uuid.New()
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO promo_redemptions (id, promo_code_id, order_id, customer_id, discount_minor)
+		VALUES ($1,$2,$3,$4,$5)`,
+		red.ID, red.PromoCodeID, red.OrderID, red.CustomerID, red.Discount); err != nil {
+		return fmt.Errorf("record promo redemption: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE promo_codes SET redemption_count = redemption_count + 1, updated_at = NOW() WHERE id=$1`, p.ID)
+	return err
+}
+
+// Release gives back the use consumed by an order's redemption, if it has one, inside the caller's transaction.
+func Release(ctx context.Context, tx *sql.Tx, orderID uuid.UUID) error {
+	var promoCodeID uuid.UUID
+	err := tx.QueryRowContext(ctx, `
+		UPDATE promo_redemptions SET released_at = NOW()
+		WHERE order_id=$1 AND released_at IS NULL
+		RETURNING promo_code_id`, orderID).Scan(&promoCodeID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("release promo redemption: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE promo_codes SET redemption_count = redemption_count - 1, updated_at = NOW()
+		WHERE id=$1 AND redemption_count > 0`, promoCodeID)
+	return err
+}
+
+// UpdateDiscount records the repriced discount of an order's unreleased redemption inside the caller's transaction.
+func UpdateDiscount(ctx context.Context, tx *sql.Tx, orderID uuid.UUID, discount money.Amount) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE promo_redemptions SET discount_minor=$1
+		WHERE order_id=$2 AND released_at IS NULL`, discount, orderID); err != nil {
+		return fmt.Errorf("update promo redemption: %w", err)
+	}
+	return nil
+}
+
+func scanPromo(row rowScanner) (*PromoCode, error) {
+	p := &PromoCode{}
+	var storeID, vendorID, createdBy uuid.NullUUID
+	var endsAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Code, &p.Description, &p.DiscountType, &p.PercentageBps,
+		&p.AmountOff, &p.MaxDiscount, &p.MinSpend, &p.UsageLimit, &p.PerCustomerLimit,
+		&p.RedemptionCount, &storeID, &vendorID, &p.Category, &p.StartsAt, &endsAt, &p.IsActive, &createdBy,
+		&p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if storeID.Valid {
+		p.StoreID = &storeID.UUID
+	}
+	if vendorID.Valid {
+		p.VendorID = &vendorID.UUID
+	}
+	if createdBy.Valid {
+		p.CreatedBy = &createdBy.UUID
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+	return p, nil
+}
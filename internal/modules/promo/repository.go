@@ -0,0 +1,23 @@
+package promo
+
+import "context"
+
+// Repository defines data access for promo codes and their redemptions.
+type Repository interface {
+	Create(ctx context.Context, p *PromoCode) error
+	GetByID(ctx context.Context, id string) (*PromoCode, error)
+
+	// GetByCode looks a code up by its normalised (upper-case) form.
+	GetByCode(ctx context.Context, code string) (*PromoCode, error)
+
+	// List returns promo codes, newest first. An empty vendorID lists every code.
+	List(ctx context.Context, vendorID string) ([]*PromoCode, error)
+	Deactivate(ctx context.Context, id string) error
+
+	// CountCustomerRedemptions counts the customer's unreleased redemptions of a code.
+	CountCustomerRedemptions(ctx context.Context, promoCodeID, customerID string) (int, error)
+	ListRedemptions(ctx context.Context, promoCodeID string) ([]*Redemption, error)
+
+	// GetStoreVendorID resolves the vendor that owns a store, for vendor-scoped codes.
+	GetStoreVendorID(ctx context.Context, storeID string) (string, error)
+}
@@ -0,0 +1,253 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// Service defines promo code management and cart evaluation.
+type Service interface {
+	Create(ctx context.Context, req CreatePromoCodeRequest) (*PromoCode, error)
+	Get(ctx context.Context, id string) (*PromoCode, error)
+	List(ctx context.Context, vendorID string) ([]*PromoCode, error)
+	Deactivate(ctx context.Context, id string) (*PromoCode, error)
+	ListRedemptions(ctx context.Context, id string) ([]*Redemption, error)
+
+	// Quote evaluates a code against a cart and returns the discount it grants, or a *RejectionError.
+	// Usage limits are checked again under lock when the order is written; see Redeem.
+	Quote(ctx context.Context, req QuoteRequest) (*Quote, error)
+
+	// Reprice recomputes the discount a code already redeemed by an order grants its amended lines. Availability
+	// and usage limits are not checked again because the order keeps the use it was granted; a cart that no
+	// longer qualifies is rejected with a *RejectionError.
+	Reprice(ctx context.Context, promoCodeID string, lines []QuoteLine) (money.Amount, error)
+}
+
+type service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewService creates a new promo service.
+func NewService(repo Repository) Service {
+	return &service{repo: repo, now: time.Now}
+}
+
+// NormalizeCode upper-cases and trims a promo code so lookups are case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *service) Create(ctx context.Context, req CreatePromoCodeRequest) (*PromoCode, error) {
+	code := NormalizeCode(req.Code)
+	if len(code) < 3 || len(code) > 32 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+		return nil, fmt.Errorf("code must be 3-32 characters of letters, digits, '-' or '_'")
+	}
+	p := &PromoCode{
+		ID:               uuid.New(),
+		Code:             code,
+		Description:      strings.TrimSpace(req.Description),
+		DiscountType:     DiscountType(strings.ToUpper(strings.TrimSpace(req.DiscountType))),
+		MinSpend:         req.MinSpend,
+		UsageLimit:       req.UsageLimit,
+		PerCustomerLimit: req.PerCustomerLimit,
+		Category:         strings.TrimSpace(req.Category),
+		StartsAt:         s.now().UTC(),
+		EndsAt:           req.EndsAt,
+		IsActive:         true,
+	}
+	switch p.DiscountType {
+	case DiscountPercentage:
+		if req.PercentageBps < 1 || req.PercentageBps > 10000 {
+			return nil, fmt.Errorf("percentage_bps must be between 1 and 10000")
+		}
+		if req.MaxDiscount < 0 {
+			return nil, fmt.Errorf("max_discount cannot be negative")
+		}
+		p.PercentageBps, p.MaxDiscount = req.PercentageBps, req.MaxDiscount
+	case DiscountFixed:
+		if req.AmountOff <= 0 {
+			return nil, fmt.Errorf("amount_off must be greater than zero for FIXED codes")
+		}
+		p.AmountOff = req.AmountOff
+	default:
+		return nil, fmt.Errorf("invalid discount_type: %s (allowed: PERCENTAGE, FIXED)", req.DiscountType)
+	}
+	if req.MinSpend < 0 || req.UsageLimit < 0 || req.PerCustomerLimit < 0 {
+		return nil, fmt.Errorf("min_spend, usage_limit and per_customer_limit cannot be negative")
+	}
+	if req.StartsAt != nil {
+		p.StartsAt = req.StartsAt.UTC()
+	}
+	if p.EndsAt != nil && !p.EndsAt.After(p.StartsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+	var err error
+	if p.StoreID, err = optionalUUID(req.StoreID, "store_id"); err != nil {
+		return nil, err
+	}
+	if p.VendorID, err = optionalUUID(req.VendorID, "vendor_id"); err != nil {
+		return nil, err
+	}
+	if p.CreatedBy, err = optionalUUID(req.CreatedBy, "created_by"); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, p); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("promo code %s already exists", code)
+		}
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, p.ID.String())
+}
+
+func (s *service) Get(ctx context.Context, id string) (*PromoCode, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid promo code id: %w", err)
+	}
+	p, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("promo code not found")
+	}
+	return p, err
+}
+
+func (s *service) List(ctx context.Context, vendorID string) ([]*PromoCode, error) {
+	return s.repo.List(ctx, vendorID)
+}
+
+func (s *service) Deactivate(ctx context.Context, id string) (*PromoCode, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Deactivate(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) ListRedemptions(ctx context.Context, id string) ([]*Redemption, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRedemptions(ctx, id)
+}
+
+func (s *service) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	code := NormalizeCode(req.Code)
+	p, err := s.repo.GetByCode(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(code, ReasonNotFound, "no such promo code")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load promo code: %w", err)
+	}
+
+	customerRedemptions := 0
+	if req.CustomerID != "" && p.PerCustomerLimit > 0 {
+		if customerRedemptions, err = s.repo.CountCustomerRedemptions(ctx, p.ID.String(), req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkAvailability(p, req.CustomerID, customerRedemptions, s.now()); err != nil {
+		return nil, err
+	}
+
+	if p.StoreID != nil && p.StoreID.String() != req.StoreID {
+		return nil, reject(p.Code, ReasonNotApplicable, "code is not valid at this store")
+	}
+	if p.VendorID != nil {
+		vendorID, err := s.repo.GetStoreVendorID(ctx, req.StoreID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if vendorID != p.VendorID.String() {
+			return nil, reject(p.Code, ReasonNotApplicable, "code is not valid at this vendor")
+		}
+	}
+
+	discount, err := discountFor(p, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+	return &Quote{PromoCodeID: p.ID, Code: p.Code, Discount: discount}, nil
+}
+
+func (s *service) Reprice(ctx context.Context, promoCodeID string, lines []QuoteLine) (money.Amount, error) {
+	p, err := s.repo.GetByID(ctx, promoCodeID)
+	if err != nil {
+		return 0, fmt.Errorf("load promo code: %w", err)
+	}
+	return discountFor(p, lines)
+}
+
+// checkAvailability applies the checks that can change between quoting and placement: the active flag, the
+// validity window and the usage limits. Redeem runs it again with the promo row locked.
+func checkAvailability(p *PromoCode, customerID string, customerRedemptions int, now time.Time) error {
+	switch {
+	case !p.IsActive:
+		return reject(p.Code, ReasonInactive, "code has been deactivated")
+	case now.Before(p.StartsAt):
+		return reject(p.Code, ReasonNotStarted, "code is valid from %s", p.StartsAt.Format(time.RFC3339))
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return reject(p.Code, ReasonExpired, "code expired at %s", p.EndsAt.Format(time.RFC3339))
+	case p.UsageLimit > 0 && p.RedemptionCount >= p.UsageLimit:
+		return reject(p.Code, ReasonUsageLimitReached, "code has been fully redeemed")
+	case p.PerCustomerLimit > 0 && customerID == "":
+		return reject(p.Code, ReasonCustomerRequired, "code can only be used by a signed-in customer")
+	case p.PerCustomerLimit > 0 && customerRedemptions >= p.PerCustomerLimit:
+		return reject(p.Code, ReasonCustomerLimitReached, "code can be used %d time(s) per customer", p.PerCustomerLimit)
+	}
+	return nil
+}
+
+// discountFor prices the code against the cart lines its category scope covers. The discount never exceeds the
+// eligible spend, and percentage discounts are capped at MaxDiscount when one is set.
+func discountFor(p *PromoCode, lines []QuoteLine) (money.Amount, error) {
+	var eligible money.Amount
+	for _, line := range lines {
+		if p.Category == "" || strings.EqualFold(p.Category, line.Category) {
+			eligible += line.Amount
+		}
+	}
+	if eligible <= 0 {
+		return 0, reject(p.Code, ReasonNotApplicable, "no items in the cart qualify for this code")
+	}
+	if eligible < p.MinSpend {
+		return 0, reject(p.Code, ReasonMinimumSpendNotMet, "spend at least %s on qualifying items", p.MinSpend)
+	}
+
+	var discount money.Amount
+	switch p.DiscountType {
+	case DiscountPercentage:
+		discount = eligible.MulRate(float64(p.PercentageBps) / 10000)
+		if p.MaxDiscount > 0 && discount > p.MaxDiscount {
+			discount = p.MaxDiscount
+		}
+	case DiscountFixed:
+		discount = p.AmountOff
+	}
+	if discount > eligible {
+		discount = eligible
+	}
+	return discount, nil
+}
+
+func optionalUUID(v, field string) (*uuid.UUID, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return &id, nil
+}
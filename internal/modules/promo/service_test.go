@@ -0,0 +1,249 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeRepository struct {
+	codes        map[string]*PromoCode
+	redemptions  map[string]int
+	storeVendors map[string]string
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		codes:        make(map[string]*PromoCode),
+		redemptions:  make(map[string]int),
+		storeVendors: make(map[string]string),
+	}
+}
+
+func (f *fakeRepository) Create(_ context.Context, p *PromoCode) error {
+	for _, existing := range f.codes {
+		if existing.Code == p.Code {
+			return errors.New(`pq: duplicate key value violates unique constraint "promo_codes_code_key"`)
+		}
+	}
+	copied := *p
+	f.codes[p.ID.String()] = &copied
+	return nil
+}
+
+func (f *fakeRepository) GetByID(_ context.Context, id string) (*PromoCode, error) {
+	p, ok := f.codes[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *p
+	return &copied, nil
+}
+
+func (f *fakeRepository) GetByCode(_ context.Context, code string) (*PromoCode, error) {
+	for _, p := range f.codes {
+		if p.Code == code {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepository) List(context.Context, string) ([]*PromoCode, error) { return nil, nil }
+
+func (f *fakeRepository) Deactivate(_ context.Context, id string) error {
+	p, ok := f.codes[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	p.IsActive = false
+	return nil
+}
+
+func (f *fakeRepository) CountCustomerRedemptions(_ context.Context, promoCodeID, customerID string) (int, error) {
+	return f.redemptions[promoCodeID+"/"+customerID], nil
+}
+
+func (f *fakeRepository) ListRedemptions(context.Context, string) ([]*Redemption, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) GetStoreVendorID(_ context.Context, storeID string) (string, error) {
+	vendorID, ok := f.storeVendors[storeID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return vendorID, nil
+}
+
+func newTestService(repo Repository, now time.Time) *service {
+	return &service{repo: repo, now: func() time.Time { return now }}
+}
+
+func assertRejected(t *testing.T, err error, want RejectionReason) {
+	t.Helper()
+	var rejection *RejectionError
+	if !errors.As(err, &rejection) || rejection.Reason != want {
+		t.Fatalf("expected %s rejection, got %v", want, err)
+	}
+}
+
+func TestQuotePercentageDiscountOnlyCountsScopedCategoryAndRespectsCap(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(newFakeRepository(), now)
+	p, err := svc.Create(context.Background(), CreatePromoCodeRequest{
+		Code: " banners20 ", DiscountType: "percentage", PercentageBps: 2000, MaxDiscount: 5000, Category: "Banners",
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if p.Code != "BANNERS20" {
+		t.Fatalf("expected normalised code BANNERS20, got %q", p.Code)
+	}
+
+	quote, err := svc.Quote(context.Background(), QuoteRequest{Code: "banners20", Lines: []QuoteLine{
+		{Amount: 10000, Category: "banners"},
+		{Amount: 40000, Category: "Business Cards"},
+	}})
+	if err != nil {
+		t.Fatalf("Quote returned error: %v", err)
+	}
+	if quote.Discount != 2000 || quote.PromoCodeID != p.ID {
+		t.Fatalf("expected 20%% of the K100 banner line, got %+v", quote)
+	}
+
+	quote, err = svc.Quote(context.Background(), QuoteRequest{Code: "BANNERS20", Lines: []QuoteLine{{Amount: 100000, Category: "Banners"}}})
+	if err != nil || quote.Discount != 5000 {
+		t.Fatalf("expected discount capped at K50, got %+v, %v", quote, err)
+	}
+
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "BANNERS20", Lines: []QuoteLine{{Amount: 100000, Category: "Flyers"}}})
+	assertRejected(t, err, ReasonNotApplicable)
+}
+
+func TestQuoteFixedDiscountRequiresMinimumSpendAndNeverExceedsCart(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(newFakeRepository(), now)
+	if _, err := svc.Create(context.Background(), CreatePromoCodeRequest{
+		Code: "TAKE50", DiscountType: "FIXED", AmountOff: 5000, MinSpend: 3000,
+	}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	_, err := svc.Quote(context.Background(), QuoteRequest{Code: "TAKE50", Lines: []QuoteLine{{Amount: 2999}}})
+	assertRejected(t, err, ReasonMinimumSpendNotMet)
+
+	quote, err := svc.Quote(context.Background(), QuoteRequest{Code: "TAKE50", Lines: []QuoteLine{{Amount: 4000}}})
+	if err != nil || quote.Discount != 4000 {
+		t.Fatalf("expected discount limited to the K40 cart, got %+v, %v", quote, err)
+	}
+}
+
+func TestQuoteEnforcesValidityWindowAndUsageLimits(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeRepository()
+	svc := newTestService(repo, now)
+	starts, ends := now.Add(-time.Hour), now.Add(time.Hour)
+	p, err := svc.Create(context.Background(), CreatePromoCodeRequest{
+		Code: "ONCE", DiscountType: "FIXED", AmountOff: 100, UsageLimit: 10, PerCustomerLimit: 1,
+		StartsAt: &starts, EndsAt: &ends,
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	cart := []QuoteLine{{Amount: 1000}}
+	customerID := uuid.NewString()
+
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", Lines: cart})
+	assertRejected(t, err, ReasonCustomerRequired)
+
+	if _, err := svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", CustomerID: customerID, Lines: cart}); err != nil {
+		t.Fatalf("first use should be allowed: %v", err)
+	}
+	repo.redemptions[p.ID.String()+"/"+customerID] = 1
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", CustomerID: customerID, Lines: cart})
+	assertRejected(t, err, ReasonCustomerLimitReached)
+
+	repo.codes[p.ID.String()].RedemptionCount = 10
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", CustomerID: uuid.NewString(), Lines: cart})
+	assertRejected(t, err, ReasonUsageLimitReached)
+
+	svc.now = func() time.Time { return ends }
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", CustomerID: uuid.NewString(), Lines: cart})
+	assertRejected(t, err, ReasonExpired)
+
+	svc.now = func() time.Time { return starts.Add(-time.Second) }
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "ONCE", CustomerID: uuid.NewString(), Lines: cart})
+	assertRejected(t, err, ReasonNotStarted)
+
+	_, err = svc.Quote(context.Background(), QuoteRequest{Code: "NOPE", Lines: cart})
+	assertRejected(t, err, ReasonNotFound)
+}
+
+func TestRepriceIgnoresUsageLimitsButStillRequiresMinimumSpend(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeRepository()
+	svc := newTestService(repo, now)
+	p, err := svc.Create(context.Background(), CreatePromoCodeRequest{
+		Code: "LASTONE", DiscountType: "PERCENTAGE", PercentageBps: 1000, MinSpend: 5000, UsageLimit: 1,
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	repo.codes[p.ID.String()].RedemptionCount = 1
+
+	discount, err := svc.Reprice(context.Background(), p.ID.String(), []QuoteLine{{Amount: 8000}})
+	if err != nil || discount != 800 {
+		t.Fatalf("Reprice of a fully redeemed code = %v, %v; want 8.00 for the order holding the use", discount, err)
+	}
+	_, err = svc.Reprice(context.Background(), p.ID.String(), []QuoteLine{{Amount: 4000}})
+	assertRejected(t, err, ReasonMinimumSpendNotMet)
+}
+
+func TestQuoteRejectsCodeScopedToAnotherVendor(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeRepository()
+	svc := newTestService(repo, now)
+	vendorID, ownStore, otherStore := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	repo.storeVendors[ownStore] = vendorID
+	repo.storeVendors[otherStore] = uuid.NewString()
+	if _, err := svc.Create(context.Background(), CreatePromoCodeRequest{
+		Code: "LOCAL10", DiscountType: "PERCENTAGE", PercentageBps: 1000, VendorID: vendorID,
+	}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	cart := []QuoteLine{{Amount: 1000}}
+
+	if quote, err := svc.Quote(context.Background(), QuoteRequest{Code: "LOCAL10", StoreID: ownStore, Lines: cart}); err != nil || quote.Discount != 100 {
+		t.Fatalf("expected 10%% at the vendor's own store, got %+v, %v", quote, err)
+	}
+	_, err := svc.Quote(context.Background(), QuoteRequest{Code: "LOCAL10", StoreID: otherStore, Lines: cart})
+	assertRejected(t, err, ReasonNotApplicable)
+}
+
+func TestCreateRejectsInvalidAndDuplicateCodes(t *testing.T) {
+	svc := newTestService(newFakeRepository(), time.Now())
+	invalid := []CreatePromoCodeRequest{
+		{Code: "NO", DiscountType: "FIXED", AmountOff: 100},
+		{Code: "BAD CODE", DiscountType: "FIXED", AmountOff: 100},
+		{Code: "ZERO", DiscountType: "FIXED"},
+		{Code: "TOOMUCH", DiscountType: "PERCENTAGE", PercentageBps: 10001},
+		{Code: "BOGO", DiscountType: "BOGO"},
+	}
+	for _, req := range invalid {
+		if _, err := svc.Create(context.Background(), req); err == nil {
+			t.Fatalf("expected %+v to be rejected", req)
+		}
+	}
+	if _, err := svc.Create(context.Background(), CreatePromoCodeRequest{Code: "SAVE", DiscountType: "FIXED", AmountOff: 100}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if _, err := svc.Create(context.Background(), CreatePromoCodeRequest{Code: "save", DiscountType: "FIXED", AmountOff: 100}); err == nil {
+		t.Fatal("expected duplicate code to be rejected")
+	}
+}
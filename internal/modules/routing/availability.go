@@ -0,0 +1,165 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/delivery"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatinghours"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatingstatus"
+	"github.com/google/uuid"
+)
+
+// HoursSource lists a store's published weekly operating hours. It is satisfied by operatinghours.Service.
+type HoursSource interface {
+	List(ctx context.Context, storeID string) ([]operatinghours.OperatingHour, error)
+}
+
+// VendorStatusSource reports whether a vendor may operate. It is satisfied by operatingstatus.Service.
+type VendorStatusSource interface {
+	GetStatus(ctx context.Context, vendorID string) (*operatingstatus.OperatingStatus, error)
+}
+
+// DeliveryCoverage reports whether a store delivers to a city. It is satisfied by delivery.ZoneService.
+type DeliveryCoverage interface {
+	CheckEligibility(ctx context.Context, storeID string, req delivery.EligibilityRequest) (*delivery.EligibilityResponse, error)
+}
+
+// WithOperatingHours excludes stores that are closed when the order is routed. Published hours are wall-clock times
+// in loc. Stores that have not published hours are treated as open.
+func WithOperatingHours(hours HoursSource, loc *time.Location) ServiceOption {
+	return func(s *service) {
+		s.hours, s.location = hours, loc
+	}
+}
+
+// WithOperatingStatus excludes stores whose vendor is blocked by an unpaid subscription or failed compliance.
+func WithOperatingStatus(status VendorStatusSource) ServiceOption {
+	return func(s *service) {
+		s.vendorStatus = status
+	}
+}
+
+// WithDeliveryZones excludes stores without an active delivery zone for a delivery order's city.
+func WithDeliveryZones(zones DeliveryCoverage) ServiceOption {
+	return func(s *service) {
+		s.zones = zones
+	}
+}
+
+// NoEligibleStoresError is returned when no store can take an order, listing every store that was excluded. For a
+// split route it also lists the order items that no eligible store stocks.
+type NoEligibleStoresError struct {
+	OrderID        string
+	Exclusions     []ExcludedStore
+	UncoveredItems []uuid.UUID
+}
+
+func (e *NoEligibleStoresError) Error() string {
+	if len(e.UncoveredItems) > 0 {
+		return fmt.Sprintf("no eligible stores found for %d item(s) of order %s — no open store stocks them", len(e.UncoveredItems), e.OrderID)
+	}
+	if len(e.Exclusions) == 0 {
+		return fmt.Sprintf("no eligible stores found for order %s — ensure products are stocked in at least one store", e.OrderID)
+	}
+	return fmt.Sprintf("no eligible stores found for order %s — all %d stocking stores were excluded", e.OrderID, len(e.Exclusions))
+}
+
+// filterCandidates splits candidates into the stores that can take the order now and the ones that cannot. Each
+// store is reported with the first check it fails; vendor status is looked up once per vendor.
+func (s *service) filterCandidates(ctx context.Context, orderID string, candidates []*StoreCandidate) ([]*StoreCandidate, []ExcludedStore, error) {
+	var destination *DeliveryDestination
+	if s.zones != nil {
+		var err error
+		if destination, err = s.repo.GetDeliveryDestination(ctx, orderID); err != nil {
+			return nil, nil, fmt.Errorf("failed to load delivery destination: %w", err)
+		}
+	}
+	now := s.now()
+	vendorBlocks := make(map[uuid.UUID]string)
+
+	var eligible []*StoreCandidate
+	var excluded []ExcludedStore
+	for _, c := range candidates {
+		reason, detail, err := s.exclusionFor(ctx, c, destination, now, vendorBlocks)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason == "" {
+			eligible = append(eligible, c)
+			continue
+		}
+		excluded = append(excluded, ExcludedStore{StoreID: c.StoreID, StoreName: c.StoreName, Reason: reason, Detail: detail})
+	}
+	return eligible, excluded, nil
+}
+
+func (s *service) exclusionFor(ctx context.Context, c *StoreCandidate, destination *DeliveryDestination, now time.Time,
+	vendorBlocks map[uuid.UUID]string) (ExclusionReason, string, error) {
+	if s.vendorStatus != nil {
+		blocked, seen := vendorBlocks[c.VendorID]
+		if !seen {
+			status, err := s.vendorStatus.GetStatus(ctx, c.VendorID.String())
+			if err != nil {
+				return "", "", fmt.Errorf("failed to check operating status for store %s: %w", c.StoreID, err)
+			}
+			if !status.Operational {
+				reasons := make([]string, 0, len(status.BlockingReasons))
+				for _, r := range status.BlockingReasons {
+					reasons = append(reasons, string(r))
+				}
+				blocked = "vendor blocked: " + strings.Join(reasons, ", ")
+			}
+			vendorBlocks[c.VendorID] = blocked
+		}
+		if blocked != "" {
+			return ExclusionVendorBlocked, blocked, nil
+		}
+	}
+
+	if s.hours != nil {
+		hours, err := s.hours.List(ctx, c.StoreID.String())
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load operating hours for store %s: %w", c.StoreID, err)
+		}
+		if open, detail := openAt(hours, now.In(s.location)); !open {
+			return ExclusionStoreClosed, detail, nil
+		}
+	}
+
+	if s.zones != nil && destination != nil {
+		eligibility, err := s.zones.CheckEligibility(ctx, c.StoreID.String(),
+			delivery.EligibilityRequest{City: destination.City, Country: destination.Country})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to check delivery coverage for store %s: %w", c.StoreID, err)
+		}
+		if !eligibility.Eligible {
+			return ExclusionOutsideDeliveryZone, fmt.Sprintf("%s: %s", eligibility.Code, destination.City), nil
+		}
+	}
+	return "", "", nil
+}
+
+// openAt reports whether a store with the given weekly hours is open at local time t, and if not, why. day_of_week
+// follows time.Weekday and Postgres DOW, so 0 is Sunday.
+func openAt(hours []operatinghours.OperatingHour, t time.Time) (bool, string) {
+	if len(hours) == 0 {
+		return true, ""
+	}
+	for _, h := range hours {
+		if h.DayOfWeek != int(t.Weekday()) {
+			continue
+		}
+		if !h.IsOpen {
+			return false, fmt.Sprintf("closed on %s", t.Weekday())
+		}
+		clock := t.Format("15:04")
+		if clock < h.OpensAt || clock >= h.ClosesAt {
+			return false, fmt.Sprintf("open %s–%s on %s, now %s", h.OpensAt, h.ClosesAt, t.Weekday(), clock)
+		}
+		return true, ""
+	}
+	return false, fmt.Sprintf("no hours published for %s", t.Weekday())
+}
@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderWorkload is what capacity-aware scoring needs to know about the order being routed.
+type OrderWorkload struct {
+	DueAt       *time.Time            // when the customer needs the order; nil when they gave no date
+	ItemMinutes map[uuid.UUID]float64 // estimated production minutes per order item
+}
+
+const (
+	// undatedHorizon scores orders without a due date: a store that would finish at once earns the full 100
+	// points, falling linearly to nothing for a store that needs this long.
+	undatedHorizon = 48 * time.Hour
+	// latenessHorizon scores orders with a due date: a store that finishes by the due date earns the full 100
+	// points, falling linearly to nothing for a store that would finish this late.
+	latenessHorizon = 24 * time.Hour
+)
+
+// projectCompletion estimates the minutes the store needs for the order items it stocks and, when the store has a
+// daily capacity, when it would finish them after clearing its current backlog. Production is assumed to run
+// evenly across the day.
+func projectCompletion(c *StoreCandidate, work *OrderWorkload, now time.Time) {
+	c.WorkMinutes = 0
+	for _, id := range c.ItemIDs {
+		c.WorkMinutes += work.ItemMinutes[id]
+	}
+	c.ProjectedCompletion = nil
+	if c.DailyCapacityMinutes == nil || *c.DailyCapacityMinutes <= 0 {
+		return
+	}
+	days := (c.BacklogMinutes + c.WorkMinutes) / float64(*c.DailyCapacityMinutes)
+	done := now.Add(time.Duration(days * float64(24*time.Hour))).Truncate(time.Minute)
+	c.ProjectedCompletion = &done
+}
+
+// completionScore awards up to 100 points for finishing the order in time. With a due date every store that makes
+// it scores the same; without one, sooner is better.
+func completionScore(done, now time.Time, due *time.Time) (float64, string) {
+	if due == nil {
+		wait := done.Sub(now)
+		points := clampPoints(100 * (1 - float64(wait)/float64(undatedHorizon)))
+		return points, fmt.Sprintf("projected to finish in %s (completion score %.0f)", roundHours(wait), points)
+	}
+	if !done.After(*due) {
+		return 100, fmt.Sprintf("projected to finish %s before the due date (completion score 100)", roundHours(due.Sub(done)))
+	}
+	late := done.Sub(*due)
+	points := clampPoints(100 * (1 - float64(late)/float64(latenessHorizon)))
+	return points, fmt.Sprintf("projected to finish %s after the due date (completion score %.0f)", roundHours(late), points)
+}
+
+func clampPoints(points float64) float64 {
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// roundHours renders a wait as whole minutes under an hour and as hours to one decimal beyond.
+func roundHours(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%.0f min", d.Minutes())
+	}
+	return fmt.Sprintf("%.1fh", d.Hours())
+}
@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRouteOrderScoresProjectedCompletionAgainstTheDueDate(t *testing.T) {
+	repo := newFakeRepository()
+	item := uuid.New()
+	capacity := 480
+	// Two 5000-flyer jobs hold far more work than three business-card jobs, though the queue is shorter.
+	flyers := &StoreCandidate{StoreID: uuid.New(), StoreName: "Flyers", HasProduct: true, ItemIDs: []uuid.UUID{item},
+		ActiveJobs: 2, BacklogMinutes: 1200, DailyCapacityMinutes: &capacity}
+	cards := &StoreCandidate{StoreID: uuid.New(), StoreName: "Cards", HasProduct: true, ItemIDs: []uuid.UUID{item},
+		ActiveJobs: 3, BacklogMinutes: 90, DailyCapacityMinutes: &capacity}
+	repo.candidates = []*StoreCandidate{flyers, cards}
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	due := now.Add(24 * time.Hour)
+	repo.workload = &OrderWorkload{DueAt: &due, ItemMinutes: map[uuid.UUID]float64{item: 150}}
+	svc := NewService(repo).(*service)
+	svc.now = func() time.Time { return now }
+
+	decision, err := svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != cards.StoreID {
+		t.Fatalf("expected the store that finishes in time, got %#v", decision)
+	}
+	// Cards: 240 minutes at 480 a day is half a day, well before the due date.
+	if want := now.Add(12 * time.Hour); cards.ProjectedCompletion == nil || !cards.ProjectedCompletion.Equal(want) {
+		t.Fatalf("cards projected completion = %v, want %v", cards.ProjectedCompletion, want)
+	}
+	// Flyers: 1350 minutes is 2.8125 days, 43.5 hours late, which earns nothing.
+	for _, f := range flyers.Factors {
+		if f.Factor == FactorCompletion && (f.Points != 0 || !strings.Contains(f.Detail, "43.5h after the due date")) {
+			t.Fatalf("expected the late store to earn no completion points, got %#v", f)
+		}
+		if f.Factor == FactorLoad {
+			t.Fatalf("a store with a daily capacity must not be scored on queue length, got %#v", f)
+		}
+	}
+}
+
+func TestCompletionScoreWithoutADueDateOrCapacity(t *testing.T) {
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	if points, _ := completionScore(now.Add(12*time.Hour), now, nil); points != 75 {
+		t.Fatalf("undated completion in 12h scored %.2f, want 75", points)
+	}
+	due := now.Add(6 * time.Hour)
+	if points, _ := completionScore(now.Add(12*time.Hour), now, &due); points != 75 {
+		t.Fatalf("completion 6h late scored %.2f, want 75", points)
+	}
+
+	repo := newFakeRepository()
+	quiet := &StoreCandidate{StoreID: uuid.New(), StoreName: "Quiet", HasProduct: true, ActiveJobs: 1}
+	repo.candidates = []*StoreCandidate{quiet}
+	decision, err := NewService(repo).RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if quiet.ProjectedCompletion != nil || !strings.Contains(decision.Reason, "1 active jobs (load score 90)") {
+		t.Fatalf("expected a store without capacity scored on its queue, got %q", decision.Reason)
+	}
+}
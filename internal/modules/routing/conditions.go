@@ -0,0 +1,392 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+// Condition is a node of the routing condition language. A rule's conditions may carry one under "when"; the rule
+// only contributes to a store's score when it holds for the order and that store. A node is either a group, whose
+// children must all ("all") or at least one ("any") hold, or a comparison of one field against a value:
+//
+//	{"when": {"all": [
+//	    {"field": "order.category", "op": "in", "value": ["Banners", "Posters"]},
+//	    {"field": "order.total", "op": "between", "value": [500, 5000]},
+//	    {"any": [
+//	        {"field": "order.customer_city", "op": "eq", "value": "Lusaka"},
+//	        {"field": "store.distance_km", "op": "lte", "value": 10}
+//	    ]},
+//	    {"field": "order.time_of_day", "op": "between", "value": ["08:00", "17:00"]}
+//	]}}
+//
+// Text comparisons ignore case. A comparison on a value the order or store does not have, such as the distance of a
+// store without coordinates, never holds.
+type Condition struct {
+	All   []*Condition    `json:"all,omitempty"`
+	Any   []*Condition    `json:"any,omitempty"`
+	Field string          `json:"field,omitempty"`
+	Op    string          `json:"op,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	texts   []string  // parsed Value for text and category fields
+	numbers []float64 // parsed Value for number fields, one or two (between)
+}
+
+// fieldKind decides which operators and values a condition field accepts.
+type fieldKind int
+
+const (
+	kindText     fieldKind = iota // one value per order or store
+	kindCategory                  // the order's product categories; in holds when any matches, not_in when none do
+	kindNumber
+	kindClock // HH:MM local time; between wraps past midnight when the start is after the end
+)
+
+var conditionFields = map[string]fieldKind{
+	"order.category":            kindCategory,
+	"order.total":               kindNumber, // major currency units, e.g. 250.50
+	"order.channel":             kindText,
+	"order.customer_city":       kindText, // delivery city, or the customer's saved city
+	"order.time_of_day":         kindClock,
+	"store.city":                kindText,
+	"store.vendor_tier":         kindText,
+	"store.subscription_status": kindText,
+	"store.active_jobs":         kindNumber,
+	"store.distance_km":         kindNumber,
+}
+
+var conditionOps = map[fieldKind][]string{
+	kindText:     {"eq", "neq", "in", "not_in"},
+	kindCategory: {"in", "not_in"},
+	kindNumber:   {"eq", "neq", "gt", "gte", "lt", "lte", "between"},
+	kindClock:    {"between"},
+}
+
+// OrderFacts is what conditions can see of the order being routed.
+type OrderFacts struct {
+	Categories   []string
+	Total        money.Amount
+	Channel      string
+	CustomerCity string
+}
+
+// conditionInput is everything a condition is evaluated against for one store.
+type conditionInput struct {
+	order *OrderFacts
+	store *StoreCandidate
+	clock string // local time of day as HH:MM
+}
+
+// ruleConditionKeys are the condition keys each rule type reads besides "when".
+var ruleConditionKeys = map[RuleType][]string{
+	RuleTypeProductCapability: {},
+	RuleTypeGeoProximity:      {"max_radius_km", "decay", "half_life_km", "max_bonus"},
+	RuleTypeLoadBalance:       {"max_active_jobs"},
+	RuleTypeTierPriority:      {"tier_bonuses", "eligible_statuses"},
+}
+
+// parseWhen reads and validates the "when" condition of a rule's conditions. It returns nil when there is none.
+func parseWhen(raw json.RawMessage) (*Condition, error) {
+	var c struct {
+		When *Condition `json:"when"`
+	}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("invalid conditions: when: %w", err)
+		}
+	}
+	if c.When == nil {
+		return nil, nil
+	}
+	if err := c.When.compile("when"); err != nil {
+		return nil, fmt.Errorf("invalid conditions: %w", err)
+	}
+	return c.When, nil
+}
+
+// compile checks the node at path and parses its value, so evaluation never fails.
+func (c *Condition) compile(path string) error {
+	kinds := 0
+	for _, set := range []bool{c.All != nil, c.Any != nil, c.Field != "" || c.Op != "" || c.Value != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf(`%s: use exactly one of "all", "any" or a "field" comparison`, path)
+	}
+
+	for name, group := range map[string][]*Condition{"all": c.All, "any": c.Any} {
+		if group == nil {
+			continue
+		}
+		if len(group) == 0 {
+			return fmt.Errorf("%s.%s: must list at least one condition", path, name)
+		}
+		for i, child := range group {
+			if child == nil {
+				return fmt.Errorf("%s.%s[%d]: must be an object", path, name, i)
+			}
+			if err := child.compile(fmt.Sprintf("%s.%s[%d]", path, name, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	kind, ok := conditionFields[c.Field]
+	if !ok {
+		return fmt.Errorf("%s: unknown field %q (expected one of %s)", path, c.Field, strings.Join(fieldNames(), ", "))
+	}
+	c.Op = strings.ToLower(strings.TrimSpace(c.Op))
+	if !contains(conditionOps[kind], c.Op) {
+		return fmt.Errorf("%s: operator %q is not supported for %s (use %s)", path, c.Op, c.Field, strings.Join(conditionOps[kind], ", "))
+	}
+	if len(bytes.TrimSpace(c.Value)) == 0 {
+		return fmt.Errorf("%s: %s %s needs a value", path, c.Field, c.Op)
+	}
+
+	switch {
+	case kind == kindClock:
+		if err := json.Unmarshal(c.Value, &c.texts); err != nil || len(c.texts) != 2 {
+			return fmt.Errorf(`%s: %s between needs ["HH:MM", "HH:MM"]`, path, c.Field)
+		}
+		for _, clock := range c.texts {
+			if _, err := time.Parse("15:04", clock); err != nil || len(clock) != 5 {
+				return fmt.Errorf("%s: %q is not a HH:MM time", path, clock)
+			}
+		}
+	case kind == kindNumber && c.Op == "between":
+		if err := json.Unmarshal(c.Value, &c.numbers); err != nil || len(c.numbers) != 2 || c.numbers[0] > c.numbers[1] {
+			return fmt.Errorf("%s: %s between needs [min, max] numbers with min <= max", path, c.Field)
+		}
+	case kind == kindNumber:
+		var n float64
+		if err := json.Unmarshal(c.Value, &n); err != nil {
+			return fmt.Errorf("%s: %s %s needs a number", path, c.Field, c.Op)
+		}
+		c.numbers = []float64{n}
+	case c.Op == "in" || c.Op == "not_in":
+		if err := json.Unmarshal(c.Value, &c.texts); err != nil || len(c.texts) == 0 {
+			return fmt.Errorf("%s: %s %s needs a non-empty list of strings", path, c.Field, c.Op)
+		}
+	default:
+		var text string
+		if err := json.Unmarshal(c.Value, &text); err != nil {
+			return fmt.Errorf("%s: %s %s needs a string", path, c.Field, c.Op)
+		}
+		c.texts = []string{text}
+	}
+	if c.Field == "order.total" {
+		for _, n := range c.numbers {
+			if _, err := money.Parse(fmt.Sprint(n)); err != nil {
+				return fmt.Errorf("%s: order.total values are amounts with at most two decimals", path)
+			}
+		}
+	}
+	return nil
+}
+
+// matches evaluates the condition for one store.
+func (c *Condition) matches(in conditionInput) bool {
+	switch {
+	case c.All != nil:
+		for _, child := range c.All {
+			if !child.matches(in) {
+				return false
+			}
+		}
+		return true
+	case c.Any != nil:
+		for _, child := range c.Any {
+			if child.matches(in) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch c.Field {
+	case "order.category":
+		matched := false
+		for _, category := range in.order.Categories {
+			matched = matched || containsFold(c.texts, category)
+		}
+		if len(in.order.Categories) == 0 {
+			return false
+		}
+		return matched == (c.Op == "in")
+	case "order.total":
+		return c.compareNumber(in.order.Total.Major())
+	case "order.channel":
+		return c.compareText(in.order.Channel)
+	case "order.customer_city":
+		return c.compareText(in.order.CustomerCity)
+	case "order.time_of_day":
+		from, to := c.texts[0], c.texts[1]
+		if from <= to {
+			return in.clock >= from && in.clock < to
+		}
+		return in.clock >= from || in.clock < to
+	case "store.city":
+		return c.compareText(in.store.City)
+	case "store.vendor_tier":
+		return c.compareText(in.store.VendorTier)
+	case "store.subscription_status":
+		return c.compareText(in.store.SubscriptionStatus)
+	case "store.active_jobs":
+		return c.compareNumber(float64(in.store.ActiveJobs))
+	case "store.distance_km":
+		if in.store.DistanceKm == nil {
+			return false
+		}
+		return c.compareNumber(*in.store.DistanceKm)
+	}
+	return false
+}
+
+func (c *Condition) compareText(v string) bool {
+	if v == "" {
+		return false
+	}
+	switch c.Op {
+	case "eq", "in":
+		return containsFold(c.texts, v)
+	default: // neq, not_in
+		return !containsFold(c.texts, v)
+	}
+}
+
+func (c *Condition) compareNumber(v float64) bool {
+	n := c.numbers[0]
+	switch c.Op {
+	case "eq":
+		return v == n
+	case "neq":
+		return v != n
+	case "gt":
+		return v > n
+	case "gte":
+		return v >= n
+	case "lt":
+		return v < n
+	case "lte":
+		return v <= n
+	default: // between, inclusive
+		return v >= n && v <= c.numbers[1]
+	}
+}
+
+// ruleGates holds the "when" condition of each rule that has one and what they are evaluated against.
+type ruleGates struct {
+	when  map[uuid.UUID]*Condition // nil for a saved condition that no longer parses; that rule never applies
+	order *OrderFacts
+	clock string
+}
+
+// ruleGates compiles the rules' conditions, reading the order's facts only when some rule has a condition.
+func (s *service) ruleGates(ctx context.Context, orderID string, rules []*RoutingRule) (*ruleGates, error) {
+	g := &ruleGates{when: make(map[uuid.UUID]*Condition), clock: s.now().In(s.location).Format("15:04")}
+	for _, rule := range rules {
+		cond, err := parseWhen(rule.Conditions)
+		if err != nil || cond != nil {
+			g.when[rule.ID] = cond
+		}
+	}
+	if len(g.when) == 0 {
+		return g, nil
+	}
+	order, err := s.repo.GetOrderFacts(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order facts for rule conditions: %w", err)
+	}
+	g.order = order
+	return g, nil
+}
+
+// forStore reports which rules apply to the order routed to store.
+func (g *ruleGates) forStore(store *StoreCandidate) func(*RoutingRule) bool {
+	in := conditionInput{order: g.order, store: store, clock: g.clock}
+	return func(rule *RoutingRule) bool {
+		cond, gated := g.when[rule.ID]
+		if !gated {
+			return true
+		}
+		return cond != nil && cond.matches(in)
+	}
+}
+
+// validateConditions checks a rule's conditions: they must be a JSON object holding only the keys its rule type
+// reads and an optional valid "when" condition.
+func validateConditions(ruleType RuleType, conditions json.RawMessage) error {
+	keys, ok := ruleConditionKeys[ruleType]
+	if !ok {
+		return fmt.Errorf("invalid rule_type %q (expected one of %s, %s, %s or %s)", ruleType,
+			RuleTypeProductCapability, RuleTypeGeoProximity, RuleTypeLoadBalance, RuleTypeTierPriority)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(conditions, &fields); err != nil || fields == nil {
+		return fmt.Errorf("invalid conditions: must be a JSON object")
+	}
+	for key := range fields {
+		if key != "when" && !contains(keys, key) {
+			allowed := append([]string{"when"}, keys...)
+			return fmt.Errorf("invalid conditions: unknown key %q for %s rules (expected %s)", key, ruleType, strings.Join(allowed, ", "))
+		}
+	}
+	if _, err := parseWhen(conditions); err != nil {
+		return err
+	}
+
+	switch ruleType {
+	case RuleTypeGeoProximity:
+		_, err := parseGeoConditions(conditions)
+		return err
+	case RuleTypeTierPriority:
+		_, err := parseTierConditions(conditions)
+		return err
+	case RuleTypeLoadBalance:
+		if raw, ok := fields["max_active_jobs"]; ok {
+			var max float64
+			if err := json.Unmarshal(raw, &max); err != nil || max < 0 {
+				return fmt.Errorf("invalid LOAD_BALANCE conditions: max_active_jobs must be a non-negative number")
+			}
+		}
+	}
+	return nil
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(conditionFields))
+	for name := range conditionFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/money"
+	"github.com/google/uuid"
+)
+
+func TestCreateRuleRejectsMalformedConditionsWithThePath(t *testing.T) {
+	svc := NewService(newFakeRepository())
+	cases := []struct{ conditions, want string }{
+		{`[1]`, "must be a JSON object"},
+		{`{"max_jobs": 3}`, `unknown key "max_jobs"`},
+		{`{"when": {"field": "order.colour", "op": "eq", "value": 1}}`, `when: unknown field "order.colour"`},
+		{`{"when": {"all": [{"field": "order.channel", "op": "gt", "value": "POS"}]}}`, `when.all[0]: operator "gt" is not supported for order.channel`},
+		{`{"when": {"any": [{"field": "order.total", "op": "between", "value": [900, 10]}]}}`, "when.any[0]: order.total between needs [min, max]"},
+		{`{"when": {"field": "order.time_of_day", "op": "between", "value": ["8am", "17:00"]}}`, `"8am" is not a HH:MM time`},
+		{`{"when": {"all": [], "field": "store.city"}}`, `use exactly one of "all", "any"`},
+		{`{"when": {"all": []}}`, "when.all: must list at least one condition"},
+		{`{"max_active_jobs": -2}`, "max_active_jobs must be a non-negative number"},
+	}
+	for _, tc := range cases {
+		_, err := svc.CreateRule(context.Background(), CreateRuleRequest{
+			Name: "Conditional", RuleType: string(RuleTypeLoadBalance), Conditions: []byte(tc.conditions),
+		})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("conditions %s: expected an error containing %q, got %v", tc.conditions, tc.want, err)
+		}
+	}
+
+	_, err := svc.CreateRule(context.Background(), CreateRuleRequest{Name: "Typo", RuleType: "NEAREST", Conditions: []byte(`{}`)})
+	if err == nil || !strings.Contains(err.Error(), `invalid rule_type "NEAREST"`) {
+		t.Fatalf("expected an unknown rule type to be rejected, got %v", err)
+	}
+}
+
+func TestRuleConditionsGateBonusesPerOrderAndStore(t *testing.T) {
+	repo := newFakeRepository()
+	lusaka, ndola := uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: ndola, StoreName: "Ndola", City: "Ndola", HasProduct: true},
+		{StoreID: lusaka, StoreName: "Lusaka", City: "Lusaka", HasProduct: true, ActiveJobs: 3},
+	}
+	repo.facts = &OrderFacts{Categories: []string{"Banners"}, Total: money.FromMajor(1200), Channel: "ONLINE", CustomerCity: "lusaka"}
+	repo.rules = []*RoutingRule{{
+		ID: uuid.New(), Name: "Big local banners", RuleType: RuleTypeProductCapability, Priority: 10, IsActive: true,
+		TargetStoreID: &lusaka,
+		Conditions: []byte(`{"when": {"all": [
+			{"field": "order.category", "op": "in", "value": ["banners", "posters"]},
+			{"field": "order.total", "op": "gte", "value": 1000},
+			{"any": [
+				{"field": "order.customer_city", "op": "eq", "value": "Lusaka"},
+				{"field": "store.city", "op": "eq", "value": "Lusaka"}
+			]},
+			{"field": "order.time_of_day", "op": "between", "value": ["22:00", "06:00"]}
+		]}}`),
+	}}
+	svc := NewService(repo).(*service)
+	svc.now = func() time.Time { return time.Date(2026, 10, 14, 23, 30, 0, 0, time.UTC) }
+
+	decision, err := svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != lusaka || decision.RuleName != "Big local banners" {
+		t.Fatalf("expected the overnight rule to send the order to Lusaka, got %#v", decision)
+	}
+
+	// In the afternoon the time window no longer holds, so the quieter Ndola store wins on load.
+	svc.now = func() time.Time { return time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC) }
+	decision, err = svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != ndola || decision.RuleID != nil || !strings.Contains(decision.Reason, "conditions not met") {
+		t.Fatalf("expected the rule to be skipped outside its window, got %#v", decision)
+	}
+}
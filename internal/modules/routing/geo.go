@@ -0,0 +1,94 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+// Proximity decay curves for GEO_PROXIMITY rules.
+const (
+	DecayLinear      = "linear"      // the bonus falls evenly from max_bonus at the order to 0 at max_radius_km
+	DecayExponential = "exponential" // the bonus halves every half_life_km and is cut off at max_radius_km
+)
+
+// GeoConditions configures a GEO_PROXIMITY rule. Every field is optional:
+//
+//	{"max_radius_km": 25, "decay": "linear", "max_bonus": 100}
+//	{"max_radius_km": 40, "decay": "exponential", "half_life_km": 5}
+type GeoConditions struct {
+	MaxRadiusKm float64 `json:"max_radius_km"`
+	Decay       string  `json:"decay"`
+	HalfLifeKm  float64 `json:"half_life_km"`
+	MaxBonus    float64 `json:"max_bonus"`
+}
+
+// parseGeoConditions reads a GEO_PROXIMITY rule's conditions and fills in defaults: a 25 km radius, linear decay,
+// a 100 point bonus and, for exponential decay, a half-life of a quarter of the radius.
+func parseGeoConditions(raw json.RawMessage) (GeoConditions, error) {
+	var c GeoConditions
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return c, fmt.Errorf("invalid GEO_PROXIMITY conditions: %w", err)
+		}
+	}
+	if c.MaxRadiusKm < 0 || c.HalfLifeKm < 0 || c.MaxBonus < 0 {
+		return c, fmt.Errorf("invalid GEO_PROXIMITY conditions: max_radius_km, half_life_km and max_bonus must not be negative")
+	}
+	if c.MaxRadiusKm == 0 {
+		c.MaxRadiusKm = 25
+	}
+	if c.MaxBonus == 0 {
+		c.MaxBonus = 100
+	}
+	c.Decay = strings.ToLower(strings.TrimSpace(c.Decay))
+	switch c.Decay {
+	case "":
+		c.Decay = DecayLinear
+	case DecayLinear:
+	case DecayExponential:
+		if c.HalfLifeKm == 0 {
+			c.HalfLifeKm = c.MaxRadiusKm / 4
+		}
+	default:
+		return c, fmt.Errorf("invalid GEO_PROXIMITY conditions: decay must be %s or %s", DecayLinear, DecayExponential)
+	}
+	return c, nil
+}
+
+// Bonus returns the proximity bonus for a store distanceKm from the order. Stores beyond the radius get nothing.
+func (c GeoConditions) Bonus(distanceKm float64) float64 {
+	if distanceKm > c.MaxRadiusKm {
+		return 0
+	}
+	if c.Decay == DecayExponential {
+		return c.MaxBonus * math.Pow(0.5, distanceKm/c.HalfLifeKm)
+	}
+	return c.MaxBonus * (1 - distanceKm/c.MaxRadiusKm)
+}
+
+// distanceKm is the great-circle (haversine) distance between two coordinates.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := toRad(lat2-lat1), toRad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// measureDistances sets DistanceKm on every candidate with coordinates. It is a no-op when the order has no location.
+func measureDistances(from *GeoPoint, candidates []*StoreCandidate) {
+	if from == nil {
+		return
+	}
+	for _, c := range candidates {
+		if c.Latitude == nil || c.Longitude == nil {
+			continue
+		}
+		d := distanceKm(from.Latitude, from.Longitude, *c.Latitude, *c.Longitude)
+		c.DistanceKm = &d
+	}
+}
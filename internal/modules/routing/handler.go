@@ -2,24 +2,39 @@ package routing
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/georgemunganga/printa-backend/internal/middleware"
+	"github.com/georgemunganga/printa-backend/internal/modules/inventory"
+	"github.com/georgemunganga/printa-backend/internal/modules/vendor"
 	"github.com/go-chi/chi/v5"
 )
 
 // Handler exposes routing HTTP endpoints.
-type Handler struct{ service Service }
+type Handler struct {
+	service          Service
+	inventoryService inventory.Service
+	vendorService    vendor.Service
+}
 
-func NewHandler(service Service) *Handler { return &Handler{service: service} }
+func NewHandler(service Service, inventoryService inventory.Service, vendorService vendor.Service) *Handler {
+	return &Handler{service: service, inventoryService: inventoryService, vendorService: vendorService}
+}
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/routing", func(r chi.Router) {
 		// Order routing
 		r.Post("/route", h.routeOrder)                              // POST   /api/v1/routing/route
+		r.Post("/route/split", h.routeOrderSplit)                   // POST   /api/v1/routing/route/split
+		r.With(middleware.RequireRole(middleware.RoleAdmin)).Post("/simulate", h.simulate) // POST   /api/v1/routing/simulate (admin)
 		r.Get("/decisions/order/{order_id}", h.getDecision)         // GET    /api/v1/routing/decisions/order/{id}
+		r.With(middleware.RequireRole(middleware.RoleAdmin)).Get("/decisions/order/{order_id}/history", h.getDecisionHistory) // GET    /api/v1/routing/decisions/order/{id}/history (admin)
 		r.Post("/decisions/order/{order_id}/override", h.override)  // POST   /api/v1/routing/decisions/order/{id}/override
 		r.Get("/decisions/store/{store_id}", h.listStoreDecisions)  // GET    /api/v1/routing/decisions/store/{id}
+		r.Post("/decisions/{id}/accept", h.acceptDecision)          // POST   /api/v1/routing/decisions/{id}/accept
+		r.Post("/decisions/{id}/decline", h.declineDecision)        // POST   /api/v1/routing/decisions/{id}/decline
 
 		// Rules management
 		r.Post("/rules", h.createRule)          // POST   /api/v1/routing/rules
@@ -36,6 +51,11 @@ func (h *Handler) routeOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	decision, err := h.service.RouteOrder(r.Context(), req)
+	var noStores *NoEligibleStoresError
+	if errors.As(err, &noStores) {
+		respond(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": err.Error(), "exclusions": noStores.Exclusions})
+		return
+	}
 	if err != nil {
 		code := http.StatusInternalServerError
 		msg := err.Error()
@@ -50,6 +70,54 @@ func (h *Handler) routeOrder(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusCreated, decision)
 }
 
+// routeOrderSplit routes an order across several stores when no single store stocks every item.
+func (h *Handler) routeOrderSplit(w http.ResponseWriter, r *http.Request) {
+	var req RouteOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := h.service.RouteOrderSplit(r.Context(), req)
+	var noStores *NoEligibleStoresError
+	if errors.As(err, &noStores) {
+		respond(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(), "exclusions": noStores.Exclusions, "uncovered_items": noStores.UncoveredItems,
+		})
+		return
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		msg := err.Error()
+		if strings.Contains(msg, "no items") {
+			code = http.StatusUnprocessableEntity
+		} else if strings.Contains(msg, "required") || strings.Contains(msg, "invalid") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": msg})
+		return
+	}
+	respond(w, http.StatusCreated, result)
+}
+
+// simulate explains how an order would be routed, optionally under a hypothetical rule set, without saving a decision.
+func (h *Handler) simulate(w http.ResponseWriter, r *http.Request) {
+	var req SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := h.service.Simulate(r.Context(), req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, result)
+}
+
 func (h *Handler) getDecision(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "order_id")
 	d, err := h.service.GetDecision(r.Context(), orderID)
@@ -60,6 +128,22 @@ func (h *Handler) getDecision(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, d)
 }
 
+// getDecisionHistory returns an order's whole routing chain, oldest first, for auditing disputed assignments.
+func (h *Handler) getDecisionHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.GetDecisionHistory(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			code = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			code = http.StatusBadRequest
+		}
+		respond(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	respond(w, http.StatusOK, history)
+}
+
 func (h *Handler) override(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "order_id")
 	var req OverrideRouteRequest
@@ -67,10 +151,12 @@ func (h *Handler) override(w http.ResponseWriter, r *http.Request) {
 		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	d, err := h.service.OverrideRoute(r.Context(), orderID, req)
+	d, err := h.service.OverrideRoute(r.Context(), orderID, middleware.GetUserID(r), req)
 	if err != nil {
 		code := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
+		if strings.Contains(err.Error(), "not found") {
+			code = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
 			code = http.StatusBadRequest
 		}
 		respond(w, code, map[string]string{"error": err.Error()})
@@ -98,7 +184,7 @@ func (h *Handler) createRule(w http.ResponseWriter, r *http.Request) {
 	rule, err := h.service.CreateRule(r.Context(), req)
 	if err != nil {
 		code := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "required") {
+		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
 			code = http.StatusBadRequest
 		}
 		respond(w, code, map[string]string{"error": err.Error()})
@@ -128,6 +214,8 @@ func (h *Handler) updateRule(w http.ResponseWriter, r *http.Request) {
 		code := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
 			code = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			code = http.StatusBadRequest
 		}
 		respond(w, code, map[string]string{"error": err.Error()})
 		return
@@ -144,6 +232,82 @@ func (h *Handler) deleteRule(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// acceptDecision lets the assigned store's vendor confirm it will do the job.
+func (h *Handler) acceptDecision(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.requireAssignedStore(w, r)
+	if !ok {
+		return
+	}
+	d, err := h.service.AcceptDecision(r.Context(), id, middleware.GetUserID(r))
+	if err != nil {
+		respondDecisionError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, d)
+}
+
+// declineDecision lets the assigned store's vendor turn the order down; the reassign job then routes it elsewhere.
+func (h *Handler) declineDecision(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.requireAssignedStore(w, r)
+	if !ok {
+		return
+	}
+	var req DeclineDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	d, err := h.service.DeclineDecision(r.Context(), id, middleware.GetUserID(r), req)
+	if err != nil {
+		respondDecisionError(w, err)
+		return
+	}
+	respond(w, http.StatusOK, d)
+}
+
+// requireAssignedStore allows admins and the vendor that owns the decision's assigned store.
+func (h *Handler) requireAssignedStore(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	d, err := h.service.GetDecisionByID(r.Context(), id)
+	if err != nil {
+		respond(w, http.StatusNotFound, map[string]string{"error": "routing decision not found"})
+		return "", false
+	}
+	switch middleware.GetRole(r) {
+	case middleware.RoleAdmin:
+		return id, true
+	case middleware.RoleVendor:
+		store, err := h.inventoryService.GetStore(r.Context(), d.AssignedStoreID.String())
+		if err != nil {
+			respond(w, http.StatusNotFound, map[string]string{"error": "store not found"})
+			return "", false
+		}
+		currentVendor, err := h.vendorService.GetVendor(r.Context(), middleware.GetUserID(r))
+		if err != nil {
+			respond(w, http.StatusForbidden, map[string]string{"error": "authenticated vendor profile is required"})
+			return "", false
+		}
+		if store.VendorID == currentVendor.ID {
+			return id, true
+		}
+	}
+	respond(w, http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+	return "", false
+}
+
+func respondDecisionError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrDecisionNotAwaitingResponse):
+		code = http.StatusUnprocessableEntity
+	case strings.Contains(err.Error(), "not found"):
+		code = http.StatusNotFound
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		code = http.StatusBadRequest
+	}
+	respond(w, code, map[string]string{"error": err.Error()})
+}
+
 func respond(w http.ResponseWriter, status int, body interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,100 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (f *fakeRepository) OverrideDecisions(_ context.Context, next *RoutingDecision, now time.Time) ([]*RoutingDecision, error) {
+	if f.overrideErr != nil {
+		return nil, f.overrideErr
+	}
+	var superseded []*RoutingDecision
+	for _, d := range f.decisions {
+		if d.OrderID == next.OrderID && (d.Status == DecisionAssigned || d.Status == DecisionAccepted) {
+			d.Status, d.SupersededAt = DecisionOverridden, &now
+			superseded = append(superseded, d)
+		}
+	}
+	if len(superseded) > 0 {
+		next.PreviousDecisionID = &superseded[len(superseded)-1].ID
+	}
+	f.decisions = append(f.decisions, next)
+	return superseded, nil
+}
+
+func TestOverrideSupersedesEveryActiveDecisionAndKeepsTheChain(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	items := []uuid.UUID{uuid.New(), uuid.New()}
+	repo.items = items
+	first, second, manual := uuid.New(), uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: first, StoreName: "First", ItemIDs: items[:1]},
+		{StoreID: second, StoreName: "Second", ItemIDs: items[1:]},
+	}
+	repo.rules = []*RoutingRule{{ID: uuid.New(), Name: "Prefer first", RuleType: RuleTypeProductCapability,
+		Priority: 10, IsActive: true, TargetStoreID: &first, Conditions: []byte(`{}`)}}
+	svc := NewService(repo)
+	orderID := uuid.NewString()
+
+	split, err := svc.RouteOrderSplit(ctx, RouteOrderRequest{OrderID: orderID})
+	if err != nil || len(split.Decisions) != 2 {
+		t.Fatalf("expected a two-store split, got %v, %v", split, err)
+	}
+	if snap := split.Decisions[0].RuleSnapshot; snap == nil || snap.Name != "Prefer first" {
+		t.Fatalf("expected the driving rule snapshotted into the decision, got %#v", snap)
+	}
+	repo.rules[0].Name = "Renamed later"
+	if split.Decisions[0].RuleSnapshot.Name != "Prefer first" {
+		t.Fatal("the rule snapshot must not follow later edits to the rule")
+	}
+
+	admin := uuid.New()
+	override, err := svc.OverrideRoute(ctx, orderID, admin.String(), OverrideRouteRequest{StoreID: manual.String(), Reason: "customer asked"})
+	if err != nil {
+		t.Fatalf("OverrideRoute returned error: %v", err)
+	}
+	if *override.DecidedBy != admin || *override.PreviousDecisionID != split.Decisions[1].ID {
+		t.Fatalf("expected the override attributed to the admin and linked to the latest decision, got %#v", override)
+	}
+
+	history, err := svc.GetDecisionHistory(ctx, orderID)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("expected three decisions in the history, got %d, %v", len(history), err)
+	}
+	for _, d := range history[:2] {
+		if d.Status != DecisionOverridden || d.SupersededAt == nil {
+			t.Fatalf("expected the split decisions superseded, got %#v", d)
+		}
+	}
+	if _, err := svc.GetDecisionHistory(ctx, uuid.NewString()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an order without decisions to be not found, got %v", err)
+	}
+}
+
+func TestOverrideFailureLeavesTheCurrentDecisionInPlace(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	store := uuid.New()
+	repo.candidates = []*StoreCandidate{{StoreID: store, StoreName: "Only", HasProduct: true}}
+	svc := NewService(repo)
+	orderID := uuid.NewString()
+	routed, err := svc.RouteOrder(ctx, RouteOrderRequest{OrderID: orderID})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+
+	repo.overrideErr = errors.New("connection reset")
+	if _, err := svc.OverrideRoute(ctx, orderID, uuid.NewString(), OverrideRouteRequest{StoreID: uuid.NewString(), Reason: "x"}); err == nil {
+		t.Fatal("expected the override failure to be returned")
+	}
+	if routed.Status != DecisionAssigned || len(repo.decisions) != 1 {
+		t.Fatalf("a failed override must not change the order's routing, got %#v", repo.decisions)
+	}
+}
@@ -21,11 +21,31 @@ const (
 type DecisionStatus string
 
 const (
-	DecisionAssigned   DecisionStatus = "ASSIGNED"
+	DecisionAssigned   DecisionStatus = "ASSIGNED" // awaiting the store's response, or final when no response is required
+	DecisionAccepted   DecisionStatus = "ACCEPTED" // the store confirmed it will do the job
 	DecisionOverridden DecisionStatus = "OVERRIDDEN"
-	DecisionFailed     DecisionStatus = "FAILED"
+	DecisionFailed     DecisionStatus = "FAILED" // declined or timed out; the order was re-routed
 )
 
+// DecisionResponse is what the assigned store did with a routing decision.
+type DecisionResponse string
+
+const (
+	ResponseAccepted DecisionResponse = "ACCEPTED"
+	ResponseDeclined DecisionResponse = "DECLINED"
+	ResponseTimedOut DecisionResponse = "TIMED_OUT" // the store did not respond before RespondBy
+)
+
+// EventDecisionCreated is recorded in the outbox, in the same transaction, for every routing decision saved.
+const EventDecisionCreated = "routing.decision.created.v1"
+
+// DecisionEvent is the payload of routing decision outbox events.
+type DecisionEvent struct {
+	DecisionID uuid.UUID `json:"decision_id"`
+	OrderID    uuid.UUID `json:"order_id"`
+	StoreID    uuid.UUID `json:"store_id"`
+}
+
 // RoutingRule is a configurable rule that governs order routing.
 type RoutingRule struct {
 	ID            uuid.UUID       `json:"id"`
@@ -42,25 +62,120 @@ type RoutingRule struct {
 
 // RoutingDecision is an immutable audit record of a routing outcome for an order.
 type RoutingDecision struct {
-	ID              uuid.UUID      `json:"id"`
-	OrderID         uuid.UUID      `json:"order_id"`
-	AssignedStoreID uuid.UUID      `json:"assigned_store_id"`
-	RuleID          *uuid.UUID     `json:"rule_id,omitempty"`
-	RuleName        string         `json:"rule_name,omitempty"`
-	Reason          string         `json:"reason"`
-	Score           float64        `json:"score"`
-	Status          DecisionStatus `json:"status"`
-	DecidedAt       time.Time      `json:"decided_at"`
+	ID              uuid.UUID       `json:"id"`
+	OrderID         uuid.UUID       `json:"order_id"`
+	AssignedStoreID uuid.UUID       `json:"assigned_store_id"`
+	RuleID          *uuid.UUID      `json:"rule_id,omitempty"`
+	RuleName        string          `json:"rule_name,omitempty"`
+	Reason          string          `json:"reason"`
+	Score           float64         `json:"score"`
+	Status          DecisionStatus  `json:"status"`
+	DecidedAt       time.Time       `json:"decided_at"`
+	Exclusions      []ExcludedStore `json:"exclusions,omitempty"`    // stores left out before scoring, and why
+	ItemIDs         []uuid.UUID     `json:"item_ids,omitempty"`      // order items this store makes in a split route; empty for the whole order
+	DecidedBy       *uuid.UUID      `json:"decided_by,omitempty"`    // the admin behind a manual override; nil when the engine decided
+	RuleSnapshot    *RoutingRule    `json:"rule_snapshot,omitempty"` // the rule named by RuleID as it was when the decision was made
+	SupersededAt    *time.Time      `json:"superseded_at,omitempty"` // when an override or re-route replaced this decision
+
+	PreviousDecisionID *uuid.UUID       `json:"previous_decision_id,omitempty"` // the failed or overridden decision this one replaces
+	RespondBy          *time.Time       `json:"respond_by,omitempty"`           // the store must accept or decline by then
+	Response           DecisionResponse `json:"response,omitempty"`
+	ResponseReason     string           `json:"response_reason,omitempty"`
+	RespondedBy        *uuid.UUID       `json:"responded_by,omitempty"`
+	RespondedAt        *time.Time       `json:"responded_at,omitempty"`
+}
+
+// ExclusionReason explains why a store was not considered for an order.
+type ExclusionReason string
+
+const (
+	ExclusionStoreClosed         ExclusionReason = "STORE_CLOSED"           // outside the store's published operating hours
+	ExclusionVendorBlocked       ExclusionReason = "VENDOR_NOT_OPERATIONAL" // operating status blocks the vendor (subscription or compliance)
+	ExclusionOutsideDeliveryZone ExclusionReason = "OUTSIDE_DELIVERY_ZONE"  // no active delivery zone for the order's city
+	ExclusionDeclined            ExclusionReason = "DECLINED"               // the store declined an earlier assignment of this order
+	ExclusionTimedOut            ExclusionReason = "RESPONSE_TIMED_OUT"     // the store let an earlier assignment of this order time out
+)
+
+// ExcludedStore is a store left out of routing, reported alongside the decision.
+type ExcludedStore struct {
+	StoreID   uuid.UUID       `json:"store_id"`
+	StoreName string          `json:"store_name"`
+	Reason    ExclusionReason `json:"reason"`
+	Detail    string          `json:"detail,omitempty"`
+}
+
+// DeliveryDestination is the city a delivery order goes to, checked against each store's delivery zones.
+type DeliveryDestination struct {
+	City    string
+	Country string
 }
 
 // StoreCandidate represents a store being evaluated during routing with its computed score.
 type StoreCandidate struct {
-	StoreID     uuid.UUID `json:"store_id"`
-	StoreName   string    `json:"store_name"`
-	Score       float64   `json:"score"`
-	Reason      string    `json:"reason"`
-	ActiveJobs  int       `json:"active_jobs"`  // current production queue depth
-	HasProduct  bool      `json:"has_product"`  // stocks all required products
+	StoreID            uuid.UUID   `json:"store_id"`
+	StoreName          string      `json:"store_name"`
+	City               string      `json:"city,omitempty"`
+	Score              float64     `json:"score"`
+	Reason             string      `json:"reason"`
+	ActiveJobs         int         `json:"active_jobs"` // current production queue depth
+	HasProduct         bool        `json:"has_product"` // stocks all required products
+	ItemIDs            []uuid.UUID `json:"item_ids"`    // order items whose product the store stocks
+	VendorID           uuid.UUID   `json:"vendor_id"`
+	VendorTier         string      `json:"vendor_tier,omitempty"`         // tier of the vendor's subscription, or its signup tier without one
+	SubscriptionStatus string      `json:"subscription_status,omitempty"` // empty when the vendor has no subscription
+	Latitude           *float64    `json:"latitude,omitempty"`
+	Longitude          *float64    `json:"longitude,omitempty"`
+	DistanceKm         *float64    `json:"distance_km,omitempty"` // from the order's location; nil when either side has no coordinates
+	// DailyCapacityMinutes is the store's production minutes per day; nil when the store has not set it.
+	DailyCapacityMinutes *int    `json:"daily_capacity_minutes,omitempty"`
+	BacklogMinutes       float64 `json:"backlog_minutes"` // estimated work left in the store's queued and in-progress jobs
+	WorkMinutes          float64 `json:"work_minutes"`    // estimated time to make the order items the store stocks
+	// ProjectedCompletion is when the store would finish the order behind its current queue; nil without a capacity.
+	ProjectedCompletion *time.Time    `json:"projected_completion,omitempty"`
+	Factors             []ScoreFactor `json:"factors,omitempty"` // how Score was reached
+}
+
+// Score factors not tied to a rule type. Rule bonuses use the rule's RuleType as their factor.
+const (
+	FactorProductCoverage = "PRODUCT_COVERAGE"
+	FactorLoad            = "LOAD"                 // queue length, for stores without a daily capacity
+	FactorCompletion      = "PROJECTED_COMPLETION" // projected finish against the order's due date
+	FactorDistance        = "DISTANCE"             // informational; proximity points come from GEO_PROXIMITY rules
+	FactorTargetStore     = "TARGET_STORE"         // bonus from a rule that names this store
+)
+
+// ScoreFactor is one contribution to a candidate's routing score. Zero-point factors explain a bonus not awarded.
+type ScoreFactor struct {
+	Factor   string     `json:"factor"`
+	RuleID   *uuid.UUID `json:"rule_id,omitempty"`
+	RuleName string     `json:"rule_name,omitempty"`
+	Points   float64    `json:"points"`
+	Detail   string     `json:"detail"`
+}
+
+// SimulateRequest dry-runs routing for an order. When Rules is set, even to an empty list, it replaces the active
+// rule set for the run, so a rule change can be previewed before it is saved.
+type SimulateRequest struct {
+	OrderID string              `json:"order_id"`
+	Rules   []CreateRuleRequest `json:"rules,omitempty"`
+}
+
+// SimulationResult explains what RouteOrder would decide for an order right now. Nothing in it is persisted.
+type SimulationResult struct {
+	OrderID      uuid.UUID         `json:"order_id"`
+	Hypothetical bool              `json:"hypothetical"` // scored against the request's rules rather than the saved ones
+	Rules        []*RoutingRule    `json:"rules"`
+	Candidates   []*StoreCandidate `json:"candidates"` // eligible stores, best first
+	Exclusions   []ExcludedStore   `json:"exclusions"`
+	Decision     *RoutingDecision  `json:"decision,omitempty"` // nil when no store is eligible
+}
+
+// GeoPoint is the location an order is routed from: the delivery location snapshotted on the order, or the
+// customer's saved location for orders without one.
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+	Source    string // "delivery location" or "customer location", quoted in decision reasons
 }
 
 // RouteOrderRequest is the payload to trigger routing for an order.
@@ -68,12 +183,32 @@ type RouteOrderRequest struct {
 	OrderID string `json:"order_id"`
 }
 
+// SplitRouteResult is the outcome of split routing: one decision per item group, best store first. Production
+// queues a job for each group once the order is confirmed.
+type SplitRouteResult struct {
+	OrderID   uuid.UUID          `json:"order_id"`
+	Decisions []*RoutingDecision `json:"decisions"`
+}
+
 // OverrideRouteRequest allows a human operator to manually override a routing decision.
 type OverrideRouteRequest struct {
 	StoreID string `json:"store_id"`
 	Reason  string `json:"reason"`
 }
 
+// DeclineDecisionRequest is a store's reason for turning down an assignment.
+type DeclineDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReassignSummary counts what one run of ReassignStale did.
+type ReassignSummary struct {
+	Rerouted   int // a new decision was made for another store
+	Unroutable int // no other store could take the order; it needs a manual override
+	Skipped    int // the store responded or another run handled the decision first
+	Failed     int // re-routing errored; the decision stays stale and is retried next run
+}
+
 // CreateRuleRequest is the payload for creating a new routing rule.
 type CreateRuleRequest struct {
 	Name          string          `json:"name"`
@@ -3,9 +3,14 @@ package routing
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
+	"github.com/georgemunganga/printa-backend/internal/outbox"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type postgresRepo struct{ db *sql.DB }
@@ -64,23 +69,82 @@ func (r *postgresRepo) DeleteRule(ctx context.Context, id string) error {
 
 // ── Decisions ─────────────────────────────────────────────────────────────────
 
+const decisionColumns = `id,order_id,assigned_store_id,rule_id,rule_name,reason,score,status,decided_at,exclusions,
+		previous_decision_id,respond_by,response,response_reason,responded_by,responded_at,item_ids,
+		decided_by,rule_snapshot,superseded_at`
+
 func (r *postgresRepo) CreateDecision(ctx context.Context, d *RoutingDecision) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO routing_decisions (id,order_id,assigned_store_id,rule_id,rule_name,reason,score,status)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
-		d.ID, d.OrderID, d.AssignedStoreID, d.RuleID, d.RuleName, d.Reason, d.Score, d.Status)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertDecision(ctx, tx, d); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateDecisions inserts a split route's decisions together, so an order is never left half routed.
+func (r *postgresRepo) CreateDecisions(ctx context.Context, decisions []*RoutingDecision) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, d := range decisions {
+		if err := insertDecision(ctx, tx, d); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertDecision writes d and records EventDecisionCreated through db, which must be a transaction.
+func insertDecision(ctx context.Context, db execer, d *RoutingDecision) error {
+	var exclusions, itemIDs, ruleSnapshot []byte
+	var err error
+	if len(d.Exclusions) > 0 {
+		if exclusions, err = json.Marshal(d.Exclusions); err != nil {
+			return err
+		}
+	}
+	if len(d.ItemIDs) > 0 {
+		if itemIDs, err = json.Marshal(d.ItemIDs); err != nil {
+			return err
+		}
+	}
+	if d.RuleSnapshot != nil {
+		if ruleSnapshot, err = json.Marshal(d.RuleSnapshot); err != nil {
+			return err
+		}
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO routing_decisions (id,order_id,assigned_store_id,rule_id,rule_name,reason,score,status,exclusions,
+			previous_decision_id,respond_by,item_ids,decided_by,rule_snapshot)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`,
+		d.ID, d.OrderID, d.AssignedStoreID, d.RuleID, d.RuleName, d.Reason, d.Score, d.Status, exclusions,
+		d.PreviousDecisionID, d.RespondBy, itemIDs, d.DecidedBy, ruleSnapshot)
+	if err != nil {
+		return err
+	}
+	return outbox.EnqueueTx(ctx, db, "routing_decision", d.ID, EventDecisionCreated,
+		DecisionEvent{DecisionID: d.ID, OrderID: d.OrderID, StoreID: d.AssignedStoreID})
 }
 
 func (r *postgresRepo) GetDecisionByOrderID(ctx context.Context, orderID string) (*RoutingDecision, error) {
 	return r.scanDecision(r.db.QueryRowContext(ctx, `
-		SELECT id,order_id,assigned_store_id,rule_id,rule_name,reason,score,status,decided_at
+		SELECT `+decisionColumns+`
 		FROM routing_decisions WHERE order_id=$1 ORDER BY decided_at DESC LIMIT 1`, orderID))
 }
 
 func (r *postgresRepo) ListDecisionsByStore(ctx context.Context, storeID string) ([]*RoutingDecision, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id,order_id,assigned_store_id,rule_id,rule_name,reason,score,status,decided_at
+		SELECT `+decisionColumns+`
 		FROM routing_decisions WHERE assigned_store_id=$1 ORDER BY decided_at DESC`, storeID)
 	if err != nil {
 		return nil, err
@@ -97,28 +161,190 @@ func (r *postgresRepo) ListDecisionsByStore(ctx context.Context, storeID string)
 	return decisions, nil
 }
 
+func (r *postgresRepo) GetDecisionByID(ctx context.Context, id string) (*RoutingDecision, error) {
+	return r.scanDecision(r.db.QueryRowContext(ctx, `
+		SELECT `+decisionColumns+`
+		FROM routing_decisions WHERE id=$1`, id))
+}
+
+func (r *postgresRepo) ListDecisionsByOrder(ctx context.Context, orderID string) ([]*RoutingDecision, error) {
+	return r.listDecisions(ctx, `
+		SELECT `+decisionColumns+`
+		FROM routing_decisions WHERE order_id=$1 ORDER BY decided_at ASC, id ASC`, orderID)
+}
+
+// RespondToDecision records the store's answer only while the decision still awaits one and its deadline has not
+// passed, so a late accept cannot race the reassign job.
+func (r *postgresRepo) RespondToDecision(ctx context.Context, id string, response DecisionResponse, reason, respondedBy string, at time.Time) (*RoutingDecision, error) {
+	status := DecisionAssigned
+	if response == ResponseAccepted {
+		status = DecisionAccepted
+	}
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE routing_decisions
+		SET status=$2, response=$3, response_reason=NULLIF($4,''), responded_by=NULLIF($5,'')::uuid, responded_at=$6
+		WHERE id=$1 AND status='ASSIGNED' AND response IS NULL AND (respond_by IS NULL OR respond_by > $6)`,
+		id, status, response, reason, respondedBy, at)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrDecisionNotAwaitingResponse
+	}
+	return r.GetDecisionByID(ctx, id)
+}
+
+func (r *postgresRepo) ListStaleDecisions(ctx context.Context, now time.Time) ([]*RoutingDecision, error) {
+	return r.listDecisions(ctx, `
+		SELECT `+decisionColumns+`
+		FROM routing_decisions
+		WHERE status='ASSIGNED' AND (response='DECLINED' OR (response IS NULL AND respond_by <= $1))
+		ORDER BY decided_at ASC`, now)
+}
+
+// ReplaceDecision fails a declined or timed-out decision and, when next is set, records the re-routed decision in
+// the same transaction. It reports false when the decision was no longer stale, e.g. the store accepted meanwhile.
+func (r *postgresRepo) ReplaceDecision(ctx context.Context, failedID string, now time.Time, next *RoutingDecision) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE routing_decisions
+		SET status='FAILED', response=COALESCE(response,'TIMED_OUT'), responded_at=COALESCE(responded_at,$2),
+		    superseded_at=$2
+		WHERE id=$1 AND status='ASSIGNED' AND (response='DECLINED' OR (response IS NULL AND respond_by <= $2))`,
+		failedID, now)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if next != nil {
+		if err := insertDecision(ctx, tx, next); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// OverrideDecisions locks the order so concurrent overrides of it queue behind each other, marks every
+// ASSIGNED or ACCEPTED decision OVERRIDDEN and inserts next, linked to the most recent of them. Nothing is written
+// unless all of it succeeds.
+func (r *postgresRepo) OverrideDecisions(ctx context.Context, next *RoutingDecision, now time.Time) ([]*RoutingDecision, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM orders WHERE id=$1 FOR UPDATE`, next.OrderID).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found: %s", next.OrderID)
+		}
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE routing_decisions SET status='OVERRIDDEN', superseded_at=$2
+		WHERE order_id=$1 AND status IN ('ASSIGNED','ACCEPTED')
+		RETURNING `+decisionColumns, next.OrderID, now)
+	if err != nil {
+		return nil, err
+	}
+	var superseded []*RoutingDecision
+	for rows.Next() {
+		d, err := r.scanDecision(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		superseded = append(superseded, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(superseded, func(i, j int) bool { return superseded[i].DecidedAt.Before(superseded[j].DecidedAt) })
+	if len(superseded) > 0 {
+		next.PreviousDecisionID = &superseded[len(superseded)-1].ID
+	}
+	if err := insertDecision(ctx, tx, next); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return superseded, nil
+}
+
+func (r *postgresRepo) listDecisions(ctx context.Context, query string, args ...interface{}) ([]*RoutingDecision, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var decisions []*RoutingDecision
+	for rows.Next() {
+		d, err := r.scanDecision(rows)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
 func (r *postgresRepo) UpdateDecisionStatus(ctx context.Context, id string, status DecisionStatus) error {
 	_, err := r.db.ExecContext(ctx, `UPDATE routing_decisions SET status=$1 WHERE id=$2`, status, id)
 	return err
 }
 
-// GetStoreCandidates returns all stores that stock at least one product from the order,
-// along with their current active production job count for load-balancing.
+// GetStoreCandidates returns all stores that stock the platform product of at least one order item, whichever
+// store the item was ordered from, along with the items they cover, their current active production job count
+// and the estimated minutes of work those jobs hold, their daily capacity, and the owning vendor's tier and
+// subscription status for tier priority. A job linked to a split decision only counts that decision's items.
 func (r *postgresRepo) GetStoreCandidates(ctx context.Context, orderID string) ([]*StoreCandidate, error) {
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT
 		    s.id,
 		    s.name,
-		    COUNT(DISTINCT vsp.id) AS product_matches,
+		    COALESCE(s.city, '') AS city,
+		    s.vendor_id,
+		    COALESCE(st.name, vt.name, '') AS vendor_tier,
+		    COALESCE(vs.status, '') AS subscription_status,
+		    s.latitude,
+		    s.longitude,
+		    array_agg(DISTINCT oi.id) AS item_ids,
+		    (SELECT COUNT(*) FROM order_items WHERE order_id = $1) AS item_count,
 		    COALESCE((SELECT COUNT(*) FROM production_jobs pj
 		              WHERE pj.store_id = s.id
-		              AND pj.status IN ('QUEUED','IN_PROGRESS')), 0) AS active_jobs
+		              AND pj.status IN ('QUEUED','IN_PROGRESS')), 0) AS active_jobs,
+		    COALESCE((SELECT SUM(pp.setup_minutes + pp.minutes_per_unit * joi.quantity)
+		              FROM production_jobs pj
+		              LEFT JOIN routing_decisions rd ON rd.id = pj.routing_decision_id
+		              JOIN order_items joi ON joi.order_id = pj.order_id
+		              JOIN vendor_store_products jvsp ON jvsp.id = joi.vendor_store_product_id
+		              JOIN platform_products pp ON pp.id = jvsp.platform_product_id
+		              WHERE pj.store_id = s.id
+		              AND pj.status IN ('QUEUED','IN_PROGRESS')
+		              AND (rd.item_ids IS NULL OR rd.item_ids ? joi.id::text)), 0) AS backlog_minutes,
+		    s.daily_capacity_minutes
 		FROM stores s
+		JOIN vendors v ON v.id = s.vendor_id
+		LEFT JOIN vendor_tiers vt ON vt.id = v.tier_id
+		LEFT JOIN vendor_subscriptions vs ON vs.vendor_id = v.id
+		LEFT JOIN vendor_tiers st ON st.id = vs.tier_id
 		JOIN vendor_store_products vsp ON vsp.store_id = s.id AND vsp.is_available = TRUE
-		JOIN order_items oi ON oi.vendor_store_product_id = vsp.id
-		JOIN orders o ON o.id = oi.order_id AND o.id = $1
-		GROUP BY s.id, s.name
-		ORDER BY active_jobs ASC, product_matches DESC`, orderID)
+		JOIN vendor_store_products ordered ON ordered.platform_product_id = vsp.platform_product_id
+		JOIN order_items oi ON oi.vendor_store_product_id = ordered.id AND oi.order_id = $1
+		GROUP BY s.id, s.name, s.city, st.name, vt.name, vs.status, s.latitude, s.longitude, s.daily_capacity_minutes
+		ORDER BY active_jobs ASC, COUNT(DISTINCT oi.id) DESC`, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -126,14 +352,148 @@ func (r *postgresRepo) GetStoreCandidates(ctx context.Context, orderID string) (
 	var candidates []*StoreCandidate
 	for rows.Next() {
 		c := &StoreCandidate{}
-		var productMatches int
-		if err := rows.Scan(&c.StoreID, &c.StoreName, &productMatches, &c.ActiveJobs); err != nil {
+		var itemIDs []string
+		var itemCount int
+		var latitude, longitude sql.NullFloat64
+		var capacity sql.NullInt64
+		if err := rows.Scan(&c.StoreID, &c.StoreName, &c.City, &c.VendorID, &c.VendorTier, &c.SubscriptionStatus,
+			&latitude, &longitude, pq.Array(&itemIDs), &itemCount, &c.ActiveJobs, &c.BacklogMinutes, &capacity); err != nil {
 			return nil, err
 		}
-		c.HasProduct = productMatches > 0
+		if capacity.Valid {
+			minutes := int(capacity.Int64)
+			c.DailyCapacityMinutes = &minutes
+		}
+		for _, id := range itemIDs {
+			uid, err := uuid.Parse(id)
+			if err != nil {
+				return nil, err
+			}
+			c.ItemIDs = append(c.ItemIDs, uid)
+		}
+		c.HasProduct = len(c.ItemIDs) == itemCount
+		if latitude.Valid && longitude.Valid {
+			c.Latitude, c.Longitude = &latitude.Float64, &longitude.Float64
+		}
 		candidates = append(candidates, c)
 	}
-	return candidates, nil
+	return candidates, rows.Err()
+}
+
+func (r *postgresRepo) ListOrderItemIDs(ctx context.Context, orderID string) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM order_items WHERE order_id=$1 ORDER BY created_at ASC, id ASC`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetOrderLocation prefers the coordinates snapshotted into the order's delivery address and falls back to the
+// customer's default (or oldest) saved location with coordinates.
+func (r *postgresRepo) GetOrderLocation(ctx context.Context, orderID string) (*GeoPoint, error) {
+	var latitude, longitude sql.NullFloat64
+	var customerID sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (delivery_address->>'latitude')::float8, (delivery_address->>'longitude')::float8, customer_id
+		FROM orders WHERE id=$1`, orderID).Scan(&latitude, &longitude, &customerID)
+	if err != nil {
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		return &GeoPoint{Latitude: latitude.Float64, Longitude: longitude.Float64, Source: "delivery location"}, nil
+	}
+	if !customerID.Valid {
+		return nil, nil
+	}
+	err = r.db.QueryRowContext(ctx, `
+		SELECT latitude, longitude FROM customer_delivery_locations
+		WHERE customer_id=$1 AND latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY is_default DESC, created_at ASC LIMIT 1`, customerID.String).Scan(&latitude, &longitude)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &GeoPoint{Latitude: latitude.Float64, Longitude: longitude.Float64, Source: "customer location"}, nil
+}
+
+// GetOrderWorkload reads the order's due date and estimates each item's production minutes from its platform
+// product; items without a platform product are estimated at zero.
+func (r *postgresRepo) GetOrderWorkload(ctx context.Context, orderID string) (*OrderWorkload, error) {
+	w := &OrderWorkload{ItemMinutes: make(map[uuid.UUID]float64)}
+	var dueAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT due_at FROM orders WHERE id=$1`, orderID).Scan(&dueAt); err != nil {
+		return nil, err
+	}
+	if dueAt.Valid {
+		w.DueAt = &dueAt.Time
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT oi.id, COALESCE(pp.setup_minutes + pp.minutes_per_unit * oi.quantity, 0)
+		FROM order_items oi
+		LEFT JOIN vendor_store_products vsp ON vsp.id = oi.vendor_store_product_id
+		LEFT JOIN platform_products pp ON pp.id = vsp.platform_product_id
+		WHERE oi.order_id=$1`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var minutes float64
+		if err := rows.Scan(&id, &minutes); err != nil {
+			return nil, err
+		}
+		w.ItemMinutes[id] = minutes
+	}
+	return w, rows.Err()
+}
+
+// GetOrderFacts reads the order's channel and total, the distinct categories of its products and the city it is
+// going to: the delivery address city, else the customer's default (or oldest) saved location.
+func (r *postgresRepo) GetOrderFacts(ctx context.Context, orderID string) (*OrderFacts, error) {
+	f := &OrderFacts{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT o.channel, o.total_minor,
+		       COALESCE(NULLIF(o.delivery_address->>'city',''),
+		                (SELECT cdl.city FROM customer_delivery_locations cdl WHERE cdl.customer_id = o.customer_id
+		                 ORDER BY cdl.is_default DESC, cdl.created_at ASC LIMIT 1), ''),
+		       ARRAY(SELECT DISTINCT pp.category
+		             FROM order_items oi
+		             JOIN vendor_store_products vsp ON vsp.id = oi.vendor_store_product_id
+		             JOIN platform_products pp ON pp.id = vsp.platform_product_id
+		             WHERE oi.order_id = o.id)
+		FROM orders o WHERE o.id=$1`, orderID).Scan(&f.Channel, &f.Total, &f.CustomerCity, pq.Array(&f.Categories))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *postgresRepo) GetDeliveryDestination(ctx context.Context, orderID string) (*DeliveryDestination, error) {
+	var method string
+	d := &DeliveryDestination{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT fulfilment_method, COALESCE(delivery_address->>'city',''), COALESCE(delivery_address->>'country','')
+		FROM orders WHERE id=$1`, orderID).Scan(&method, &d.City, &d.Country)
+	if err != nil {
+		return nil, err
+	}
+	if method != "DELIVERY" {
+		return nil, nil
+	}
+	return d, nil
 }
 
 // ── scanners ──────────────────────────────────────────────────────────────────
@@ -167,11 +527,51 @@ type decisionScanner interface {
 func (r *postgresRepo) scanDecision(row decisionScanner) (*RoutingDecision, error) {
 	d := &RoutingDecision{}
 	var ruleID sql.NullString
+	var exclusions, itemIDs, ruleSnapshot []byte
+	var previousID, respondedBy, decidedBy uuid.NullUUID
+	var respondBy, respondedAt, supersededAt sql.NullTime
+	var response, responseReason sql.NullString
 	err := row.Scan(&d.ID, &d.OrderID, &d.AssignedStoreID, &ruleID,
-		&d.RuleName, &d.Reason, &d.Score, &d.Status, &d.DecidedAt)
+		&d.RuleName, &d.Reason, &d.Score, &d.Status, &d.DecidedAt, &exclusions,
+		&previousID, &respondBy, &response, &responseReason, &respondedBy, &respondedAt, &itemIDs,
+		&decidedBy, &ruleSnapshot, &supersededAt)
 	if err != nil {
 		return nil, err
 	}
+	if previousID.Valid {
+		d.PreviousDecisionID = &previousID.UUID
+	}
+	if respondBy.Valid {
+		d.RespondBy = &respondBy.Time
+	}
+	d.Response, d.ResponseReason = DecisionResponse(response.String), responseReason.String
+	if respondedBy.Valid {
+		d.RespondedBy = &respondedBy.UUID
+	}
+	if respondedAt.Valid {
+		d.RespondedAt = &respondedAt.Time
+	}
+	if len(exclusions) > 0 {
+		if err := json.Unmarshal(exclusions, &d.Exclusions); err != nil {
+			return nil, err
+		}
+	}
+	if len(itemIDs) > 0 {
+		if err := json.Unmarshal(itemIDs, &d.ItemIDs); err != nil {
+			return nil, err
+		}
+	}
+	if decidedBy.Valid {
+		d.DecidedBy = &decidedBy.UUID
+	}
+	if len(ruleSnapshot) > 0 {
+		if err := json.Unmarshal(ruleSnapshot, &d.RuleSnapshot); err != nil {
+			return nil, err
+		}
+	}
+	if supersededAt.Valid {
+		d.SupersededAt = &supersededAt.Time
+	}
 	if ruleID.Valid {
 		uid, _ := uuid.Parse(ruleID.String)
 		d.RuleID = &uid
@@ -1,6 +1,11 @@
 package routing
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Repository defines data access for routing rules and decisions.
 type Repository interface {
@@ -13,12 +18,38 @@ type Repository interface {
 
 	// Decisions
 	CreateDecision(ctx context.Context, d *RoutingDecision) error
+	// CreateDecisions records the decisions of a split route in one transaction.
+	CreateDecisions(ctx context.Context, decisions []*RoutingDecision) error
 	GetDecisionByOrderID(ctx context.Context, orderID string) (*RoutingDecision, error)
 	ListDecisionsByStore(ctx context.Context, storeID string) ([]*RoutingDecision, error)
 	UpdateDecisionStatus(ctx context.Context, id string, status DecisionStatus) error
+	GetDecisionByID(ctx context.Context, id string) (*RoutingDecision, error)
+	// ListDecisionsByOrder returns every decision made for an order, oldest first.
+	ListDecisionsByOrder(ctx context.Context, orderID string) ([]*RoutingDecision, error)
+	// RespondToDecision records a store's accept or decline. It returns ErrDecisionNotAwaitingResponse when the
+	// decision was already answered, failed, overridden or is past its deadline.
+	RespondToDecision(ctx context.Context, id string, response DecisionResponse, reason, respondedBy string, at time.Time) (*RoutingDecision, error)
+	// ListStaleDecisions returns ASSIGNED decisions that were declined or whose response deadline has passed.
+	ListStaleDecisions(ctx context.Context, now time.Time) ([]*RoutingDecision, error)
+	// OverrideDecisions atomically supersedes the order's active decisions and records next, linked to the latest of
+	// them. It returns the superseded decisions.
+	OverrideDecisions(ctx context.Context, next *RoutingDecision, now time.Time) ([]*RoutingDecision, error)
+	// ReplaceDecision atomically fails a stale decision and records its replacement, if any.
+	ReplaceDecision(ctx context.Context, failedID string, now time.Time, next *RoutingDecision) (bool, error)
 
 	// Routing data helpers
-	// GetStoreCandidates returns all stores that carry at least one product from the order,
-	// along with their current active job count for load-balancing.
+	// GetStoreCandidates returns all stores that carry at least one product from the order, along with the order
+	// items they cover, their current active job count and backlog minutes, and their daily capacity.
 	GetStoreCandidates(ctx context.Context, orderID string) ([]*StoreCandidate, error)
+	// ListOrderItemIDs returns the IDs of an order's items in the order they were added.
+	ListOrderItemIDs(ctx context.Context, orderID string) ([]uuid.UUID, error)
+	// GetOrderLocation returns the coordinates an order is routed from, or nil when neither the order's delivery
+	// address nor the customer's saved locations carry any.
+	GetOrderLocation(ctx context.Context, orderID string) (*GeoPoint, error)
+	// GetOrderWorkload returns the order's due date and the estimated production minutes of each of its items.
+	GetOrderWorkload(ctx context.Context, orderID string) (*OrderWorkload, error)
+	// GetOrderFacts returns what rule conditions can see of an order.
+	GetOrderFacts(ctx context.Context, orderID string) (*OrderFacts, error)
+	// GetDeliveryDestination returns the city a delivery order is going to, or nil for pickup orders.
+	GetDeliveryDestination(ctx context.Context, orderID string) (*DeliveryDestination, error)
 }
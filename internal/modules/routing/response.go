@@ -0,0 +1,172 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDecisionNotAwaitingResponse is returned when a store answers a decision that was already answered, failed,
+// overridden or is past its response deadline.
+var ErrDecisionNotAwaitingResponse = errors.New("routing decision is not awaiting a response")
+
+// WithResponseSLA gives routed stores sla to accept or decline an assignment. Declined and unanswered decisions
+// are re-routed by ReassignStale. Without it, decisions need no response and never time out.
+func WithResponseSLA(sla time.Duration) ServiceOption {
+	return func(s *service) {
+		s.responseSLA = sla
+	}
+}
+
+// ResponseSLAFromEnv parses ROUTING_RESPONSE_SLA, a positive duration such as 2h. Unset means decisions need no
+// response. The API and cmd/routing-reassign both use it, so re-routed decisions get the same deadline as first ones.
+func ResponseSLAFromEnv() (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv("ROUTING_RESPONSE_SLA"))
+	if raw == "" {
+		return 0, nil
+	}
+	sla, err := time.ParseDuration(raw)
+	if err != nil || sla <= 0 {
+		return 0, fmt.Errorf("ROUTING_RESPONSE_SLA must be a positive duration such as 2h, got %q", raw)
+	}
+	return sla, nil
+}
+
+// respondBy is the deadline for a decision made at now, or nil when no response is required.
+func (s *service) respondBy(now time.Time) *time.Time {
+	if s.responseSLA <= 0 {
+		return nil
+	}
+	deadline := now.Add(s.responseSLA)
+	return &deadline
+}
+
+func (s *service) GetDecisionByID(ctx context.Context, id string) (*RoutingDecision, error) {
+	d, err := s.repo.GetDecisionByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("routing decision not found: %w", err)
+	}
+	return d, nil
+}
+
+func (s *service) AcceptDecision(ctx context.Context, id, actorID string) (*RoutingDecision, error) {
+	return s.repo.RespondToDecision(ctx, id, ResponseAccepted, "", actorID, s.now())
+}
+
+func (s *service) DeclineDecision(ctx context.Context, id, actorID string, req DeclineDecisionRequest) (*RoutingDecision, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required to decline an order")
+	}
+	return s.repo.RespondToDecision(ctx, id, ResponseDeclined, reason, actorID, s.now())
+}
+
+// ReassignStale is run by the routing-reassign job. Each declined or timed-out decision is marked FAILED and the
+// order routed again, excluding every store that already declined or let the order time out. The new decision
+// links back to the failed one so the chain stays in the order's routing history. When no store is left, the
+// decision is failed without a replacement and the order waits for a manual override.
+func (s *service) ReassignStale(ctx context.Context, now time.Time) (*ReassignSummary, error) {
+	stale, err := s.repo.ListStaleDecisions(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale routing decisions: %w", err)
+	}
+	summary := &ReassignSummary{}
+	if len(stale) == 0 {
+		return summary, nil
+	}
+	rules, err := s.repo.ListActiveRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+
+	// One order failing to re-route must not hold up the rest; it stays stale and is retried on the next run.
+	for _, d := range stale {
+		next, err := s.reroute(ctx, d, rules, now)
+		if err != nil {
+			log.Printf("routing: re-route order %s after decision %s: %v", d.OrderID, d.ID, err)
+			summary.Failed++
+			continue
+		}
+		replaced, err := s.repo.ReplaceDecision(ctx, d.ID.String(), now, next)
+		if err != nil {
+			log.Printf("routing: replace decision %s for order %s: %v", d.ID, d.OrderID, err)
+			summary.Failed++
+			continue
+		}
+		switch {
+		case !replaced:
+			summary.Skipped++
+		case next == nil:
+			summary.Unroutable++
+		default:
+			summary.Rerouted++
+		}
+	}
+	return summary, nil
+}
+
+// reroute builds the decision replacing failed, or returns nil when no other store can take the order. Stores that
+// declined or let time out a decision sharing items with failed are passed over; a store that refused one group of
+// a split order may still take another.
+func (s *service) reroute(ctx context.Context, failed *RoutingDecision, rules []*RoutingRule, now time.Time) (*RoutingDecision, error) {
+	history, err := s.repo.ListDecisionsByOrder(ctx, failed.OrderID.String())
+	if err != nil {
+		return nil, err
+	}
+	refused := map[uuid.UUID]ExclusionReason{failed.AssignedStoreID: ExclusionTimedOut}
+	for _, d := range append(history, failed) {
+		if !sharesItems(d.ItemIDs, failed.ItemIDs) {
+			continue
+		}
+		switch d.Response {
+		case ResponseDeclined:
+			refused[d.AssignedStoreID] = ExclusionDeclined
+		case ResponseTimedOut:
+			refused[d.AssignedStoreID] = ExclusionTimedOut
+		}
+	}
+
+	eval, err := s.evaluate(ctx, failed.OrderID.String(), rules, refused)
+	if err != nil {
+		return nil, err
+	}
+	// A split decision is re-routed on its own: only stores stocking every item in its group can take it over.
+	if len(failed.ItemIDs) > 0 {
+		var remaining []*StoreCandidate
+		for _, c := range eval.candidates {
+			if c.stocksAll(failed.ItemIDs) {
+				remaining = append(remaining, c)
+			}
+		}
+		eval.candidates = remaining
+	}
+	if len(eval.candidates) == 0 {
+		return nil, nil
+	}
+	next := eval.decision(failed.OrderID)
+	next.ItemIDs = failed.ItemIDs
+	next.PreviousDecisionID = &failed.ID
+	next.RespondBy = s.respondBy(now)
+	return next, nil
+}
+
+// sharesItems reports whether two decisions cover a common item. A decision without item IDs covers the whole order.
+func sharesItems(a, b []uuid.UUID) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
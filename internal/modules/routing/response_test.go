@@ -0,0 +1,206 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (f *fakeRepository) GetDecisionByID(_ context.Context, id string) (*RoutingDecision, error) {
+	for _, d := range f.decisions {
+		if d.ID.String() == id {
+			return d, nil
+		}
+	}
+	return nil, errors.New("decision not found")
+}
+
+func (f *fakeRepository) ListDecisionsByOrder(_ context.Context, orderID string) ([]*RoutingDecision, error) {
+	var decisions []*RoutingDecision
+	for _, d := range f.decisions {
+		if d.OrderID.String() == orderID {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+func (f *fakeRepository) RespondToDecision(_ context.Context, id string, response DecisionResponse, reason, respondedBy string, at time.Time) (*RoutingDecision, error) {
+	d, err := f.GetDecisionByID(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != DecisionAssigned || d.Response != "" || (d.RespondBy != nil && !d.RespondBy.After(at)) {
+		return nil, ErrDecisionNotAwaitingResponse
+	}
+	if response == ResponseAccepted {
+		d.Status = DecisionAccepted
+	}
+	d.Response, d.ResponseReason, d.RespondedAt = response, reason, &at
+	if uid, err := uuid.Parse(respondedBy); err == nil {
+		d.RespondedBy = &uid
+	}
+	return d, nil
+}
+
+func (f *fakeRepository) isStale(d *RoutingDecision, now time.Time) bool {
+	return d.Status == DecisionAssigned &&
+		(d.Response == ResponseDeclined || (d.Response == "" && d.RespondBy != nil && !d.RespondBy.After(now)))
+}
+
+func (f *fakeRepository) ListStaleDecisions(_ context.Context, now time.Time) ([]*RoutingDecision, error) {
+	var stale []*RoutingDecision
+	for _, d := range f.decisions {
+		if f.isStale(d, now) {
+			stale = append(stale, d)
+		}
+	}
+	return stale, nil
+}
+
+func (f *fakeRepository) ReplaceDecision(_ context.Context, failedID string, now time.Time, next *RoutingDecision) (bool, error) {
+	d, err := f.GetDecisionByID(context.Background(), failedID)
+	if err != nil || !f.isStale(d, now) {
+		return false, err
+	}
+	d.Status = DecisionFailed
+	if d.Response == "" {
+		d.Response, d.RespondedAt = ResponseTimedOut, &now
+	}
+	if next != nil {
+		f.decisions = append(f.decisions, next)
+	}
+	return true, nil
+}
+
+func TestDeclinedAndTimedOutDecisionsAreReroutedAlongTheChain(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: first, StoreName: "First", HasProduct: true},
+		{StoreID: second, StoreName: "Second", HasProduct: true, ActiveJobs: 1},
+		{StoreID: third, StoreName: "Third", HasProduct: true, ActiveJobs: 2},
+	}
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	svc := NewService(repo, WithResponseSLA(30*time.Minute)).(*service)
+	svc.now = func() time.Time { return start }
+	orderID := uuid.NewString()
+
+	routed, err := svc.RouteOrder(ctx, RouteOrderRequest{OrderID: orderID})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if routed.AssignedStoreID != first || routed.RespondBy == nil || !routed.RespondBy.Equal(start.Add(30*time.Minute)) {
+		t.Fatalf("expected the first store with a 30 minute deadline, got %#v", routed)
+	}
+	if _, err := svc.DeclineDecision(ctx, routed.ID.String(), uuid.NewString(), DeclineDecisionRequest{}); err == nil {
+		t.Fatal("expected a decline without a reason to be rejected")
+	}
+	if _, err := svc.DeclineDecision(ctx, routed.ID.String(), uuid.NewString(), DeclineDecisionRequest{Reason: "press down"}); err != nil {
+		t.Fatalf("DeclineDecision returned error: %v", err)
+	}
+	if _, err := svc.AcceptDecision(ctx, routed.ID.String(), uuid.NewString()); !errors.Is(err, ErrDecisionNotAwaitingResponse) {
+		t.Fatalf("expected accepting a declined decision to fail, got %v", err)
+	}
+
+	summary, err := svc.ReassignStale(ctx, start.Add(time.Minute))
+	if err != nil || summary.Rerouted != 1 {
+		t.Fatalf("expected one re-route after the decline, got %#v, %v", summary, err)
+	}
+	rerouted := repo.decisions[1]
+	if routed.Status != DecisionFailed || rerouted.AssignedStoreID != second || *rerouted.PreviousDecisionID != routed.ID {
+		t.Fatalf("expected the declined decision failed and linked to a decision for the second store, got %#v", rerouted)
+	}
+
+	// The second store never answers.
+	summary, err = svc.ReassignStale(ctx, start.Add(time.Hour))
+	if err != nil || summary.Rerouted != 1 {
+		t.Fatalf("expected one re-route after the timeout, got %#v, %v", summary, err)
+	}
+	last := repo.decisions[2]
+	if rerouted.Response != ResponseTimedOut || last.AssignedStoreID != third || *last.PreviousDecisionID != rerouted.ID {
+		t.Fatalf("expected the timed-out decision to hand over to the third store, got %#v", last)
+	}
+	refused := map[uuid.UUID]ExclusionReason{}
+	for _, e := range last.Exclusions {
+		refused[e.StoreID] = e.Reason
+	}
+	if refused[first] != ExclusionDeclined || refused[second] != ExclusionTimedOut {
+		t.Fatalf("expected earlier stores excluded as declined and timed out, got %#v", last.Exclusions)
+	}
+
+	if _, err := svc.AcceptDecision(ctx, last.ID.String(), uuid.NewString()); err != nil {
+		t.Fatalf("AcceptDecision returned error: %v", err)
+	}
+	if summary, _ := svc.ReassignStale(ctx, start.Add(24*time.Hour)); summary.Rerouted+summary.Unroutable != 0 || last.Status != DecisionAccepted {
+		t.Fatalf("an accepted decision must not be re-routed, got %#v with status %s", summary, last.Status)
+	}
+}
+
+func TestReassignLeavesOrderUnroutedWhenEveryStoreRefused(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	only := uuid.New()
+	repo.candidates = []*StoreCandidate{{StoreID: only, StoreName: "Only", HasProduct: true}}
+	svc := NewService(repo, WithResponseSLA(time.Hour))
+
+	routed, err := svc.RouteOrder(ctx, RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if _, err := svc.DeclineDecision(ctx, routed.ID.String(), "", DeclineDecisionRequest{Reason: "out of stock"}); err != nil {
+		t.Fatalf("DeclineDecision returned error: %v", err)
+	}
+	summary, err := svc.ReassignStale(ctx, time.Now())
+	if err != nil || summary.Unroutable != 1 || len(repo.decisions) != 1 || routed.Status != DecisionFailed {
+		t.Fatalf("expected the decision failed with no replacement, got %#v, %v, %d decisions", summary, err, len(repo.decisions))
+	}
+}
+
+func TestRerouteOnlyPassesOverStoresThatRefusedTheSameItems(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	cards, flyers := uuid.New(), uuid.New()
+	both, flyerShop := uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: both, StoreName: "Both", HasProduct: true, ItemIDs: []uuid.UUID{cards, flyers}},
+		{StoreID: flyerShop, StoreName: "Flyer shop", ItemIDs: []uuid.UUID{flyers}, ActiveJobs: 1},
+	}
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	svc := NewService(repo, WithResponseSLA(time.Hour)).(*service)
+	orderID := uuid.New()
+	// The store stocking both items turned down the cards; the flyer group was sent to the flyer shop, which declined.
+	repo.decisions = []*RoutingDecision{
+		{ID: uuid.New(), OrderID: orderID, AssignedStoreID: both, ItemIDs: []uuid.UUID{cards}, Status: DecisionFailed, Response: ResponseDeclined},
+		{ID: uuid.New(), OrderID: orderID, AssignedStoreID: flyerShop, ItemIDs: []uuid.UUID{flyers}, Status: DecisionAssigned, Response: ResponseDeclined},
+	}
+
+	summary, err := svc.ReassignStale(ctx, now)
+	if err != nil || summary.Rerouted != 1 {
+		t.Fatalf("expected the flyer group re-routed, got %#v, %v", summary, err)
+	}
+	if next := repo.decisions[2]; next.AssignedStoreID != both || len(next.ItemIDs) != 1 || next.ItemIDs[0] != flyers {
+		t.Fatalf("expected the flyers to go to the store that only refused the cards, got %#v", next)
+	}
+}
+
+func TestResponseSLAFromEnvAcceptsPositiveDurations(t *testing.T) {
+	t.Setenv("ROUTING_RESPONSE_SLA", "")
+	if sla, err := ResponseSLAFromEnv(); err != nil || sla != 0 {
+		t.Fatalf("expected no SLA when unset, got %v, %v", sla, err)
+	}
+	t.Setenv("ROUTING_RESPONSE_SLA", " 90m ")
+	if sla, err := ResponseSLAFromEnv(); err != nil || sla != 90*time.Minute {
+		t.Fatalf("expected 90m, got %v, %v", sla, err)
+	}
+	for _, raw := range []string{"2", "-1h", "0s", "soon"} {
+		t.Setenv("ROUTING_RESPONSE_SLA", raw)
+		if _, err := ResponseSLAFromEnv(); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,11 +17,32 @@ type Service interface {
 	// It persists an immutable RoutingDecision and returns it.
 	RouteOrder(ctx context.Context, req RouteOrderRequest) (*RoutingDecision, error)
 
+	// RouteOrderSplit partitions the order's items across as few stores as possible when no single store stocks
+	// them all, persisting one decision per item group. Production queues a job for each once the order is confirmed.
+	RouteOrderSplit(ctx context.Context, req RouteOrderRequest) (*SplitRouteResult, error)
+
 	// GetDecision retrieves the latest routing decision for an order.
 	GetDecision(ctx context.Context, orderID string) (*RoutingDecision, error)
 
-	// OverrideRoute allows a human operator to manually reassign an order to a specific store.
-	OverrideRoute(ctx context.Context, orderID string, req OverrideRouteRequest) (*RoutingDecision, error)
+	// OverrideRoute allows a human operator to manually reassign an order to a specific store. The order's active
+	// decisions are superseded in the same transaction.
+	OverrideRoute(ctx context.Context, orderID, actorID string, req OverrideRouteRequest) (*RoutingDecision, error)
+
+	// GetDecisionHistory returns every decision made for an order, oldest first.
+	GetDecisionHistory(ctx context.Context, orderID string) ([]*RoutingDecision, error)
+
+	// Simulate dry-runs routing for an order and explains every candidate's score without persisting anything.
+	Simulate(ctx context.Context, req SimulateRequest) (*SimulationResult, error)
+
+	// GetDecisionByID retrieves a single routing decision.
+	GetDecisionByID(ctx context.Context, id string) (*RoutingDecision, error)
+
+	// AcceptDecision and DeclineDecision record the assigned store's response before the decision's deadline.
+	AcceptDecision(ctx context.Context, id, actorID string) (*RoutingDecision, error)
+	DeclineDecision(ctx context.Context, id, actorID string, req DeclineDecisionRequest) (*RoutingDecision, error)
+
+	// ReassignStale fails declined and timed-out decisions and re-routes their orders to the next best store.
+	ReassignStale(ctx context.Context, now time.Time) (*ReassignSummary, error)
 
 	// ListStoreDecisions returns all routing decisions assigned to a store.
 	ListStoreDecisions(ctx context.Context, storeID string) ([]*RoutingDecision, error)
@@ -33,114 +56,279 @@ type Service interface {
 
 type service struct {
 	repo Repository
+
+	hours        HoursSource
+	location     *time.Location
+	vendorStatus VendorStatusSource
+	zones        DeliveryCoverage
+	responseSLA  time.Duration
+	now          func() time.Time
 }
 
-func NewService(repo Repository) Service { return &service{repo: repo} }
+// ServiceOption configures optional routing checks.
+type ServiceOption func(*service)
+
+func NewService(repo Repository, opts ...ServiceOption) Service {
+	s := &service{repo: repo, location: time.UTC, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
 
 // ── Core Routing Engine ───────────────────────────────────────────────────────
 
 // RouteOrder is the heart of the deterministic routing engine.
 // It works in three stages:
-//  1. Fetch all store candidates that carry the required products
+//  1. Fetch all store candidates that carry the required products, excluding stores that are closed, whose vendor
+//     is not operational, or that do not deliver to the order's city
 //  2. Score each candidate against all active routing rules
-//  3. Select the highest-scoring candidate and persist the decision
+//  3. Select the highest-scoring candidate and persist the decision with the exclusions
 func (s *service) RouteOrder(ctx context.Context, req RouteOrderRequest) (*RoutingDecision, error) {
 	if req.OrderID == "" {
 		return nil, fmt.Errorf("order_id is required")
 	}
-
-	// Stage 1: Get eligible store candidates
-	candidates, err := s.repo.GetStoreCandidates(ctx, req.OrderID)
+	orderUID, err := uuid.Parse(req.OrderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch store candidates: %w", err)
-	}
-	if len(candidates) == 0 {
-		return nil, fmt.Errorf("no eligible stores found for order %s — ensure products are stocked in at least one store", req.OrderID)
+		return nil, fmt.Errorf("invalid order_id: %w", err)
 	}
 
-	// Stage 2: Load active rules and score each candidate
 	rules, err := s.repo.ListActiveRules(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load routing rules: %w", err)
 	}
+	eval, err := s.evaluate(ctx, req.OrderID, rules, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(eval.candidates) == 0 {
+		return nil, &NoEligibleStoresError{OrderID: req.OrderID, Exclusions: eval.exclusions}
+	}
 
-	for _, candidate := range candidates {
-		candidate.Score, candidate.Reason = s.scoreCandidate(candidate, rules)
+	decision := eval.decision(orderUID)
+	decision.RespondBy = s.respondBy(s.now())
+	if err := s.repo.CreateDecision(ctx, decision); err != nil {
+		return nil, fmt.Errorf("failed to persist routing decision: %w", err)
+	}
+	return decision, nil
+}
+
+// Simulate runs the RouteOrder pipeline without persisting anything, scoring against req.Rules when given and the
+// active rules otherwise.
+func (s *service) Simulate(ctx context.Context, req SimulateRequest) (*SimulationResult, error) {
+	if req.OrderID == "" {
+		return nil, fmt.Errorf("order_id is required")
+	}
+	orderUID, err := uuid.Parse(req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order_id: %w", err)
 	}
 
-	// Stage 3: Select the best candidate (highest score; tie-break by fewest active jobs)
-	best := candidates[0]
-	for _, c := range candidates[1:] {
-		if c.Score > best.Score || (c.Score == best.Score && c.ActiveJobs < best.ActiveJobs) {
-			best = c
+	result := &SimulationResult{OrderID: orderUID, Hypothetical: req.Rules != nil}
+	var rules []*RoutingRule
+	if req.Rules != nil {
+		for i, r := range req.Rules {
+			rule, err := newRule(r)
+			if err != nil {
+				return nil, fmt.Errorf("rules[%d]: %w", i, err)
+			}
+			rules = append(rules, rule)
 		}
+	} else if rules, err = s.repo.ListActiveRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
 	}
+	result.Rules = rules
 
-	// Determine which rule drove the decision
-	var appliedRule *RoutingRule
-	for _, r := range rules {
-		if r.TargetStoreID != nil && *r.TargetStoreID == best.StoreID {
-			appliedRule = r
-			break
+	eval, err := s.evaluate(ctx, req.OrderID, rules, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Candidates, result.Exclusions = eval.candidates, eval.exclusions
+	if len(eval.candidates) > 0 {
+		result.Decision = eval.decision(orderUID)
+	}
+	if result.Rules == nil {
+		result.Rules = make([]*RoutingRule, 0)
+	}
+	if result.Candidates == nil {
+		result.Candidates = make([]*StoreCandidate, 0)
+	}
+	if result.Exclusions == nil {
+		result.Exclusions = make([]ExcludedStore, 0)
+	}
+	return result, nil
+}
+
+// evaluation is the outcome of running the routing pipeline for one order.
+type evaluation struct {
+	rules      []*RoutingRule
+	candidates []*StoreCandidate // eligible stores, best first
+	exclusions []ExcludedStore
+}
+
+// evaluate fetches, filters, scores and ranks the stores for an order. Stores in refused already turned the order
+// down and are excluded for that reason. It never writes.
+func (s *service) evaluate(ctx context.Context, orderID string, rules []*RoutingRule, refused map[uuid.UUID]ExclusionReason) (*evaluation, error) {
+	// Stage 1: Get eligible store candidates
+	candidates, err := s.repo.GetStoreCandidates(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch store candidates: %w", err)
+	}
+	var exclusions []ExcludedStore
+	if len(refused) > 0 {
+		var remaining []*StoreCandidate
+		for _, c := range candidates {
+			if reason, ok := refused[c.StoreID]; ok {
+				exclusions = append(exclusions, ExcludedStore{StoreID: c.StoreID, StoreName: c.StoreName, Reason: reason})
+				continue
+			}
+			remaining = append(remaining, c)
 		}
+		candidates = remaining
+	}
+	candidates, available, err := s.filterCandidates(ctx, orderID, candidates)
+	if err != nil {
+		return nil, err
+	}
+	exclusions = append(exclusions, available...)
+	eval := &evaluation{rules: rules, candidates: candidates, exclusions: exclusions}
+	if len(candidates) == 0 {
+		return eval, nil
 	}
 
-	// Build and persist the decision
+	// Stage 2: Score each candidate. Distances are measured once from the order's delivery location (or the
+	// customer's saved location) so GEO_PROXIMITY rules and the decision reason share them.
+	from, err := s.repo.GetOrderLocation(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order location: %w", err)
+	}
+	measureDistances(from, candidates)
+	work, err := s.repo.GetOrderWorkload(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order workload: %w", err)
+	}
+	now := s.now()
+	gates, err := s.ruleGates(ctx, orderID, rules)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		projectCompletion(candidate, work, now)
+		candidate.Factors = s.scoreCandidate(candidate, rules, from, gates.forStore(candidate), work.DueAt, now)
+		candidate.Score, candidate.Reason = sumFactors(candidate.Factors)
+	}
+
+	// Stage 3: Rank the candidates (highest score; tie-break by earliest projected completion, then fewest active jobs)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if a, b := candidates[i].ProjectedCompletion, candidates[j].ProjectedCompletion; a != nil && b != nil && !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return candidates[i].ActiveJobs < candidates[j].ActiveJobs
+	})
+	return eval, nil
+}
+
+// decision builds the unsaved decision assigning the order to the best candidate.
+func (e *evaluation) decision(orderID uuid.UUID) *RoutingDecision {
+	return e.decisionFor(orderID, e.candidates[0])
+}
+
+// decisionFor builds the unsaved decision assigning the order, or part of it, to best.
+func (e *evaluation) decisionFor(orderID uuid.UUID, best *StoreCandidate) *RoutingDecision {
 	decision := &RoutingDecision{
 		ID:              uuid.New(),
-		OrderID:         uuid.MustParse(req.OrderID),
+		OrderID:         orderID,
 		AssignedStoreID: best.StoreID,
 		Reason:          best.Reason,
 		Score:           best.Score,
 		Status:          DecisionAssigned,
+		Exclusions:      e.exclusions,
+	}
+	// Determine which rule drove the decision: the highest-priority rule that awarded the store its target bonus
+	for _, f := range best.Factors {
+		if f.Factor == FactorTargetStore && f.RuleID != nil {
+			decision.RuleID = f.RuleID
+			decision.RuleName = f.RuleName
+			decision.RuleSnapshot = e.rule(*f.RuleID)
+			break
+		}
 	}
-	if appliedRule != nil {
-		decision.RuleID = &appliedRule.ID
-		decision.RuleName = appliedRule.Name
-	}
+	return decision
+}
 
-	if err := s.repo.CreateDecision(ctx, decision); err != nil {
-		return nil, fmt.Errorf("failed to persist routing decision: %w", err)
+// rule returns a copy of the evaluated rule with the given ID, to snapshot into a decision.
+func (e *evaluation) rule(id uuid.UUID) *RoutingRule {
+	for _, r := range e.rules {
+		if r.ID == id {
+			snapshot := *r
+			return &snapshot
+		}
 	}
-	return decision, nil
+	return nil
 }
 
-// scoreCandidate computes a composite routing score for a store candidate.
+// scoreCandidate breaks a store candidate's routing score down by factor.
 // Scoring factors (each contributes up to 100 points):
 //   - Product availability:  +100 if store stocks all required products
-//   - Load balance:          +100 if queue is empty, decreasing by 10 per active job (min 0)
-//   - Rule-based bonus:      +50 per matching TIER_PRIORITY or PRODUCT_CAPABILITY rule
-func (s *service) scoreCandidate(c *StoreCandidate, rules []*RoutingRule) (float64, string) {
-	var score float64
-	var reasons []string
+//   - Projected completion:  +100 if the store would finish the order by its due date, falling to 0 a day late;
+//     without a due date, +100 for finishing at once, falling to 0 two days out. Stores without a daily
+//     capacity are scored on load instead: +100 if queue is empty, decreasing by 10 per active job (min 0)
+//   - Rule-based bonus:      per rule targeting the store, 200 minus the rule's priority
+//   - Tier bonus:            per TIER_PRIORITY rule, the bonus configured for the vendor's tier while its
+//     subscription is in good standing
+//   - Proximity bonus:       per GEO_PROXIMITY rule, decaying with distance to zero at the rule's max radius
+//
+// A rule whose "when" condition does not hold for the order and store awards nothing.
+func (s *service) scoreCandidate(c *StoreCandidate, rules []*RoutingRule, from *GeoPoint, applies func(*RoutingRule) bool, due *time.Time, now time.Time) []ScoreFactor {
+	var factors []ScoreFactor
+	add := func(factor string, rule *RoutingRule, points float64, detail string) {
+		f := ScoreFactor{Factor: factor, Points: points, Detail: detail}
+		if rule != nil {
+			f.RuleID, f.RuleName = &rule.ID, rule.Name
+		}
+		factors = append(factors, f)
+	}
 
 	// Factor 1: Product availability
 	if c.HasProduct {
-		score += 100
-		reasons = append(reasons, "stocks required products")
+		add(FactorProductCoverage, nil, 100, "stocks required products")
+	}
+
+	// Factor 2: Capacity — favour stores that finish in time, or penalise busy queues where capacity is unknown
+	if c.ProjectedCompletion != nil {
+		points, detail := completionScore(*c.ProjectedCompletion, now, due)
+		add(FactorCompletion, nil, points, detail)
+	} else {
+		loadScore := 100.0 - float64(c.ActiveJobs)*10.0
+		if loadScore < 0 {
+			loadScore = 0
+		}
+		add(FactorLoad, nil, loadScore, fmt.Sprintf("%d active jobs (load score %.0f)", c.ActiveJobs, loadScore))
 	}
 
-	// Factor 2: Load balancing — penalise busy queues
-	loadScore := 100.0 - float64(c.ActiveJobs)*10.0
-	if loadScore < 0 {
-		loadScore = 0
+	if c.DistanceKm != nil {
+		add(FactorDistance, nil, 0, fmt.Sprintf("%.1f km from %s", *c.DistanceKm, from.Source))
 	}
-	score += loadScore
-	reasons = append(reasons, fmt.Sprintf("%d active jobs (load score %.0f)", c.ActiveJobs, loadScore))
 
 	// Factor 3: Rule-based bonuses
 	for _, rule := range rules {
 		if !rule.IsActive {
 			continue
 		}
+		if !applies(rule) {
+			add(string(rule.RuleType), rule, 0, fmt.Sprintf("rule '%s' conditions not met", rule.Name))
+			continue
+		}
 		// If the rule targets this specific store, apply a priority bonus
 		if rule.TargetStoreID != nil && *rule.TargetStoreID == c.StoreID {
 			bonus := float64(200 - rule.Priority) // higher priority rules give bigger bonus
 			if bonus < 0 {
 				bonus = 0
 			}
-			score += bonus
-			reasons = append(reasons, fmt.Sprintf("rule '%s' bonus +%.0f", rule.Name, bonus))
+			add(FactorTargetStore, rule, bonus, fmt.Sprintf("rule '%s' bonus +%.0f", rule.Name, bonus))
 		}
 
 		// Apply LOAD_BALANCE rule: if queue depth is below threshold in conditions
@@ -148,13 +336,49 @@ func (s *service) scoreCandidate(c *StoreCandidate, rules []*RoutingRule) (float
 			var conds map[string]interface{}
 			if err := json.Unmarshal(rule.Conditions, &conds); err == nil {
 				if maxJobs, ok := conds["max_active_jobs"].(float64); ok && float64(c.ActiveJobs) <= maxJobs {
-					score += 25
-					reasons = append(reasons, fmt.Sprintf("within load threshold (max %.0f jobs)", maxJobs))
+					add(string(RuleTypeLoadBalance), rule, 25, fmt.Sprintf("within load threshold (max %.0f jobs)", maxJobs))
 				}
 			}
 		}
+
+		// Apply TIER_PRIORITY rule: paid tiers get first look while their subscription is current
+		if rule.RuleType == RuleTypeTierPriority {
+			tiers, err := parseTierConditions(rule.Conditions)
+			if err != nil {
+				continue
+			}
+			if bonus, reason := tiers.Bonus(c); bonus > 0 {
+				add(string(RuleTypeTierPriority), rule, bonus, fmt.Sprintf("rule '%s' %s +%.0f", rule.Name, reason, bonus))
+			} else if reason != "" {
+				add(string(RuleTypeTierPriority), rule, 0, fmt.Sprintf("rule '%s': %s", rule.Name, reason))
+			}
+		}
+
+		// Apply GEO_PROXIMITY rule: nearer stores earn more, nothing beyond the radius
+		if rule.RuleType == RuleTypeGeoProximity && c.DistanceKm != nil {
+			geo, err := parseGeoConditions(rule.Conditions)
+			if err != nil {
+				continue
+			}
+			if bonus := geo.Bonus(*c.DistanceKm); bonus > 0 {
+				add(string(RuleTypeGeoProximity), rule, bonus,
+					fmt.Sprintf("rule '%s' proximity bonus +%.0f (within %.0f km)", rule.Name, bonus, geo.MaxRadiusKm))
+			} else {
+				add(string(RuleTypeGeoProximity), rule, 0, fmt.Sprintf("outside rule '%s' radius of %.0f km", rule.Name, geo.MaxRadiusKm))
+			}
+		}
 	}
+	return factors
+}
 
+// sumFactors totals a score breakdown and joins its details into the decision reason.
+func sumFactors(factors []ScoreFactor) (float64, string) {
+	var score float64
+	reasons := make([]string, 0, len(factors))
+	for _, f := range factors {
+		score += f.Points
+		reasons = append(reasons, f.Detail)
+	}
 	return score, strings.Join(reasons, "; ")
 }
 
@@ -164,7 +388,21 @@ func (s *service) GetDecision(ctx context.Context, orderID string) (*RoutingDeci
 	return s.repo.GetDecisionByOrderID(ctx, orderID)
 }
 
-func (s *service) OverrideRoute(ctx context.Context, orderID string, req OverrideRouteRequest) (*RoutingDecision, error) {
+func (s *service) GetDecisionHistory(ctx context.Context, orderID string) ([]*RoutingDecision, error) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return nil, fmt.Errorf("invalid order_id: %w", err)
+	}
+	history, err := s.repo.ListDecisionsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("routing history not found for order %s", orderID)
+	}
+	return history, nil
+}
+
+func (s *service) OverrideRoute(ctx context.Context, orderID, actorID string, req OverrideRouteRequest) (*RoutingDecision, error) {
 	if req.StoreID == "" {
 		return nil, fmt.Errorf("store_id is required for override")
 	}
@@ -181,13 +419,7 @@ func (s *service) OverrideRoute(ctx context.Context, orderID string, req Overrid
 		return nil, fmt.Errorf("invalid order_id: %w", err)
 	}
 
-	// Mark the previous decision as overridden
-	existing, err := s.repo.GetDecisionByOrderID(ctx, orderID)
-	if err == nil && existing != nil {
-		_ = s.repo.UpdateDecisionStatus(ctx, existing.ID.String(), DecisionOverridden)
-	}
-
-	// Create the new manual decision
+	// Create the new manual decision; the repository marks the decisions it replaces OVERRIDDEN in the same transaction
 	decision := &RoutingDecision{
 		ID:              uuid.New(),
 		OrderID:         orderUID,
@@ -196,8 +428,11 @@ func (s *service) OverrideRoute(ctx context.Context, orderID string, req Overrid
 		Score:           0,
 		Status:          DecisionAssigned,
 	}
-	if err := s.repo.CreateDecision(ctx, decision); err != nil {
-		return nil, err
+	if actorUID, err := uuid.Parse(actorID); err == nil {
+		decision.DecidedBy = &actorUID
+	}
+	if _, err := s.repo.OverrideDecisions(ctx, decision, s.now()); err != nil {
+		return nil, fmt.Errorf("failed to override routing decision: %w", err)
 	}
 	return decision, nil
 }
@@ -207,6 +442,19 @@ func (s *service) ListStoreDecisions(ctx context.Context, storeID string) ([]*Ro
 }
 
 func (s *service) CreateRule(ctx context.Context, req CreateRuleRequest) (*RoutingRule, error) {
+	rule, err := newRule(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// newRule validates a rule request and builds the active rule it describes. Simulate uses it for hypothetical
+// rules so they are held to the same checks as saved ones.
+func newRule(req CreateRuleRequest) (*RoutingRule, error) {
 	if req.Name == "" {
 		return nil, fmt.Errorf("rule name is required")
 	}
@@ -220,6 +468,9 @@ func (s *service) CreateRule(ctx context.Context, req CreateRuleRequest) (*Routi
 	if len(conditions) == 0 {
 		conditions = json.RawMessage(`{}`)
 	}
+	if err := validateConditions(RuleType(strings.ToUpper(req.RuleType)), conditions); err != nil {
+		return nil, err
+	}
 
 	rule := &RoutingRule{
 		ID:         uuid.New(),
@@ -237,10 +488,6 @@ func (s *service) CreateRule(ctx context.Context, req CreateRuleRequest) (*Routi
 		}
 		rule.TargetStoreID = &uid
 	}
-
-	if err := s.repo.CreateRule(ctx, rule); err != nil {
-		return nil, err
-	}
 	return rule, nil
 }
 
@@ -275,6 +522,9 @@ func (s *service) UpdateRule(ctx context.Context, id string, req CreateRuleReque
 		}
 		rule.TargetStoreID = &uid
 	}
+	if err := validateConditions(rule.RuleType, rule.Conditions); err != nil {
+		return nil, err
+	}
 	if err := s.repo.UpdateRule(ctx, rule); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,376 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgemunganga/printa-backend/internal/modules/delivery"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatinghours"
+	"github.com/georgemunganga/printa-backend/internal/modules/operatingstatus"
+	"github.com/google/uuid"
+)
+
+type fakeRepository struct {
+	rules       []*RoutingRule
+	candidates  []*StoreCandidate
+	location    *GeoPoint
+	destination *DeliveryDestination
+	decisions   []*RoutingDecision
+	items       []uuid.UUID
+	facts       *OrderFacts
+	workload    *OrderWorkload
+	overrideErr error
+}
+
+func newFakeRepository() *fakeRepository { return &fakeRepository{} }
+
+func (f *fakeRepository) CreateRule(_ context.Context, rule *RoutingRule) error {
+	f.rules = append(f.rules, rule)
+	return nil
+}
+
+func (f *fakeRepository) GetRuleByID(_ context.Context, id string) (*RoutingRule, error) {
+	for _, r := range f.rules {
+		if r.ID.String() == id {
+			return r, nil
+		}
+	}
+	return nil, errors.New("rule not found")
+}
+
+func (f *fakeRepository) ListActiveRules(context.Context) ([]*RoutingRule, error) {
+	var active []*RoutingRule
+	for _, r := range f.rules {
+		if r.IsActive {
+			active = append(active, r)
+		}
+	}
+	return active, nil
+}
+
+func (f *fakeRepository) UpdateRule(context.Context, *RoutingRule) error { return nil }
+
+func (f *fakeRepository) DeleteRule(context.Context, string) error { return nil }
+
+func (f *fakeRepository) CreateDecision(_ context.Context, d *RoutingDecision) error {
+	f.decisions = append(f.decisions, d)
+	return nil
+}
+
+func (f *fakeRepository) CreateDecisions(_ context.Context, decisions []*RoutingDecision) error {
+	f.decisions = append(f.decisions, decisions...)
+	return nil
+}
+
+func (f *fakeRepository) GetDecisionByOrderID(_ context.Context, orderID string) (*RoutingDecision, error) {
+	for i := len(f.decisions) - 1; i >= 0; i-- {
+		if f.decisions[i].OrderID.String() == orderID {
+			return f.decisions[i], nil
+		}
+	}
+	return nil, errors.New("decision not found")
+}
+
+func (f *fakeRepository) ListDecisionsByStore(_ context.Context, storeID string) ([]*RoutingDecision, error) {
+	var decisions []*RoutingDecision
+	for _, d := range f.decisions {
+		if d.AssignedStoreID.String() == storeID {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+func (f *fakeRepository) UpdateDecisionStatus(_ context.Context, id string, status DecisionStatus) error {
+	for _, d := range f.decisions {
+		if d.ID.String() == id {
+			d.Status = status
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) GetStoreCandidates(context.Context, string) ([]*StoreCandidate, error) {
+	return f.candidates, nil
+}
+
+func (f *fakeRepository) ListOrderItemIDs(context.Context, string) ([]uuid.UUID, error) {
+	return f.items, nil
+}
+
+func (f *fakeRepository) GetOrderLocation(context.Context, string) (*GeoPoint, error) {
+	return f.location, nil
+}
+
+func (f *fakeRepository) GetOrderWorkload(context.Context, string) (*OrderWorkload, error) {
+	if f.workload == nil {
+		return &OrderWorkload{}, nil
+	}
+	return f.workload, nil
+}
+
+func (f *fakeRepository) GetOrderFacts(context.Context, string) (*OrderFacts, error) {
+	if f.facts == nil {
+		return &OrderFacts{}, nil
+	}
+	return f.facts, nil
+}
+
+func (f *fakeRepository) GetDeliveryDestination(context.Context, string) (*DeliveryDestination, error) {
+	return f.destination, nil
+}
+
+func coords(lat, lng float64) (*float64, *float64) { return &lat, &lng }
+
+func TestGeoConditionsDecayToZeroAtTheRadius(t *testing.T) {
+	linear, err := parseGeoConditions([]byte(`{"max_radius_km": 20}`))
+	if err != nil {
+		t.Fatalf("parseGeoConditions returned error: %v", err)
+	}
+	if got := linear.Bonus(0); got != 100 {
+		t.Fatalf("linear bonus at 0 km = %.2f, want 100", got)
+	}
+	if got := linear.Bonus(5); got != 75 {
+		t.Fatalf("linear bonus at 5 km = %.2f, want 75", got)
+	}
+	if got := linear.Bonus(21); got != 0 {
+		t.Fatalf("linear bonus beyond the radius = %.2f, want 0", got)
+	}
+
+	exp, err := parseGeoConditions([]byte(`{"max_radius_km": 40, "decay": "Exponential", "half_life_km": 5, "max_bonus": 80}`))
+	if err != nil {
+		t.Fatalf("parseGeoConditions returned error: %v", err)
+	}
+	if got := exp.Bonus(10); got != 20 {
+		t.Fatalf("exponential bonus after two half-lives = %.2f, want 20", got)
+	}
+
+	for _, bad := range []string{`{"decay": "cliff"}`, `{"max_radius_km": -1}`, `[1]`} {
+		if _, err := parseGeoConditions([]byte(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestRouteOrderPrefersTheNearestStoreWithinTheRadius(t *testing.T) {
+	repo := newFakeRepository()
+	near, far, unplaced := uuid.New(), uuid.New(), uuid.New()
+	nearLat, nearLng := coords(-15.4200, 28.2900) // about 1 km from the order
+	farLat, farLng := coords(-12.8000, 28.2000)   // Ndola, well outside the radius
+	repo.candidates = []*StoreCandidate{
+		{StoreID: far, StoreName: "Far", HasProduct: true, Latitude: farLat, Longitude: farLng},
+		{StoreID: unplaced, StoreName: "No coordinates", HasProduct: true},
+		{StoreID: near, StoreName: "Near", HasProduct: true, ActiveJobs: 2, Latitude: nearLat, Longitude: nearLng},
+	}
+	repo.location = &GeoPoint{Latitude: -15.4167, Longitude: 28.2833, Source: "delivery location"}
+	svc := NewService(repo)
+	if _, err := svc.CreateRule(context.Background(), CreateRuleRequest{
+		Name: "Nearest store", RuleType: "geo_proximity", Conditions: []byte(`{"max_radius_km": 30}`),
+	}); err != nil {
+		t.Fatalf("CreateRule returned error: %v", err)
+	}
+
+	decision, err := svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != near {
+		t.Fatalf("assigned %s, want the nearest store %s (reason %q)", decision.AssignedStoreID, near, decision.Reason)
+	}
+	if !strings.Contains(decision.Reason, "km from delivery location") || !strings.Contains(decision.Reason, "proximity bonus") {
+		t.Fatalf("reason %q does not record the distance and proximity bonus", decision.Reason)
+	}
+	if repo.candidates[0].DistanceKm == nil || *repo.candidates[0].DistanceKm < 250 {
+		t.Fatalf("unexpected distance to the far store: %v", repo.candidates[0].DistanceKm)
+	}
+	if !strings.Contains(repo.candidates[0].Reason, "outside rule 'Nearest store' radius") {
+		t.Fatalf("far store reason %q does not explain the missing bonus", repo.candidates[0].Reason)
+	}
+	if repo.candidates[1].DistanceKm != nil {
+		t.Fatal("a store without coordinates must not get a distance")
+	}
+}
+
+func TestCreateRuleRejectsInvalidGeoConditions(t *testing.T) {
+	svc := NewService(newFakeRepository())
+	_, err := svc.CreateRule(context.Background(), CreateRuleRequest{
+		Name: "Nearest store", RuleType: string(RuleTypeGeoProximity), Conditions: []byte(`{"decay": "cliff"}`),
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Fatalf("expected invalid conditions error, got %v", err)
+	}
+}
+
+func TestTierPriorityRewardsPaidTiersInGoodStanding(t *testing.T) {
+	repo := newFakeRepository()
+	core, pro, lapsed := uuid.New(), uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: core, HasProduct: true, VendorTier: "Core", SubscriptionStatus: "ACTIVE"},
+		{StoreID: lapsed, HasProduct: true, VendorTier: "Enterprise", SubscriptionStatus: "PAST_DUE"},
+		{StoreID: pro, HasProduct: true, ActiveJobs: 3, VendorTier: "Pro", SubscriptionStatus: "ACTIVE"},
+	}
+	svc := NewService(repo)
+	if _, err := svc.CreateRule(context.Background(), CreateRuleRequest{
+		Name: "Paid plans first", RuleType: string(RuleTypeTierPriority),
+		Conditions: []byte(`{"tier_bonuses": {"pro": 150, "ENTERPRISE": 250}}`),
+	}); err != nil {
+		t.Fatalf("CreateRule returned error: %v", err)
+	}
+
+	decision, err := svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != pro {
+		t.Fatalf("assigned %s, want the Pro store %s (reason %q)", decision.AssignedStoreID, pro, decision.Reason)
+	}
+	if !strings.Contains(decision.Reason, "Pro tier bonus +150") {
+		t.Fatalf("reason %q does not record the tier bonus", decision.Reason)
+	}
+	if !strings.Contains(repo.candidates[1].Reason, "Enterprise tier bonus withheld (PAST_DUE)") {
+		t.Fatalf("lapsed store reason %q does not explain the withheld bonus", repo.candidates[1].Reason)
+	}
+	if strings.Contains(repo.candidates[0].Reason, "tier") {
+		t.Fatalf("unlisted tier should not be mentioned, got %q", repo.candidates[0].Reason)
+	}
+
+	if _, err := svc.CreateRule(context.Background(), CreateRuleRequest{
+		Name: "Empty", RuleType: string(RuleTypeTierPriority), Conditions: []byte(`{}`),
+	}); err == nil {
+		t.Fatal("expected a TIER_PRIORITY rule without tier_bonuses to be rejected")
+	}
+}
+
+type fakeHours map[string][]operatinghours.OperatingHour
+
+func (f fakeHours) List(_ context.Context, storeID string) ([]operatinghours.OperatingHour, error) {
+	return f[storeID], nil
+}
+
+type fakeVendorStatus map[string]*operatingstatus.OperatingStatus
+
+func (f fakeVendorStatus) GetStatus(_ context.Context, vendorID string) (*operatingstatus.OperatingStatus, error) {
+	if status, ok := f[vendorID]; ok {
+		return status, nil
+	}
+	return &operatingstatus.OperatingStatus{Operational: true}, nil
+}
+
+type fakeCoverage map[string]bool
+
+func (f fakeCoverage) CheckEligibility(_ context.Context, storeID string, _ delivery.EligibilityRequest) (*delivery.EligibilityResponse, error) {
+	if f[storeID] {
+		return &delivery.EligibilityResponse{Eligible: true, Code: "COVERED"}, nil
+	}
+	return &delivery.EligibilityResponse{Code: "CITY_NOT_COVERED"}, nil
+}
+
+func TestRouteOrderExcludesClosedBlockedAndOutOfZoneStores(t *testing.T) {
+	repo := newFakeRepository()
+	closed, blocked, outOfZone, open := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	blockedVendor := uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: closed, StoreName: "Closed", HasProduct: true, VendorID: uuid.New()},
+		{StoreID: blocked, StoreName: "Blocked", HasProduct: true, VendorID: blockedVendor},
+		{StoreID: outOfZone, StoreName: "Elsewhere", HasProduct: true, VendorID: uuid.New()},
+		{StoreID: open, StoreName: "Open", HasProduct: true, ActiveJobs: 9, VendorID: uuid.New()},
+	}
+	repo.destination = &DeliveryDestination{City: "Lusaka", Country: "Zambia"}
+
+	weekdays := func(opens, closes string) []operatinghours.OperatingHour {
+		hours := make([]operatinghours.OperatingHour, 7)
+		for day := range hours {
+			hours[day] = operatinghours.OperatingHour{DayOfWeek: day, IsOpen: true, OpensAt: opens, ClosesAt: closes}
+		}
+		return hours
+	}
+	lusaka := time.FixedZone("CAT", 2*60*60)
+	svc := NewService(repo,
+		WithOperatingHours(fakeHours{closed.String(): weekdays("08:00", "12:00"), open.String(): weekdays("08:00", "17:00")}, lusaka),
+		WithOperatingStatus(fakeVendorStatus{blockedVendor.String(): {
+			Operational: false, BlockingReasons: []operatingstatus.BlockReason{operatingstatus.BlockSubscriptionDue},
+		}}),
+		WithDeliveryZones(fakeCoverage{closed.String(): true, blocked.String(): true, open.String(): true}),
+	).(*service)
+	svc.now = func() time.Time { return time.Date(2026, 10, 14, 11, 30, 0, 0, time.UTC) } // 13:30 in Lusaka
+
+	decision, err := svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrder returned error: %v", err)
+	}
+	if decision.AssignedStoreID != open {
+		t.Fatalf("assigned %s, want the only open, operational, covering store %s", decision.AssignedStoreID, open)
+	}
+	want := map[uuid.UUID]ExclusionReason{
+		closed: ExclusionStoreClosed, blocked: ExclusionVendorBlocked, outOfZone: ExclusionOutsideDeliveryZone,
+	}
+	if len(decision.Exclusions) != len(want) {
+		t.Fatalf("unexpected exclusions: %#v", decision.Exclusions)
+	}
+	for _, e := range decision.Exclusions {
+		if want[e.StoreID] != e.Reason || e.Detail == "" {
+			t.Errorf("store %s excluded as %s (%q), want %s", e.StoreName, e.Reason, e.Detail, want[e.StoreID])
+		}
+	}
+
+	repo.candidates = repo.candidates[:3]
+	_, err = svc.RouteOrder(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	var noStores *NoEligibleStoresError
+	if !errors.As(err, &noStores) || len(noStores.Exclusions) != 3 {
+		t.Fatalf("expected NoEligibleStoresError listing 3 exclusions, got %v", err)
+	}
+}
+
+func TestSimulateExplainsScoresAndPreviewsRulesWithoutPersisting(t *testing.T) {
+	repo := newFakeRepository()
+	busy, idle := uuid.New(), uuid.New()
+	repo.candidates = []*StoreCandidate{
+		{StoreID: busy, StoreName: "Busy", HasProduct: true, ActiveJobs: 5, VendorTier: "Pro", SubscriptionStatus: "ACTIVE"},
+		{StoreID: idle, StoreName: "Idle", HasProduct: true},
+	}
+	svc := NewService(repo)
+	orderID := uuid.NewString()
+
+	current, err := svc.Simulate(context.Background(), SimulateRequest{OrderID: orderID})
+	if err != nil {
+		t.Fatalf("Simulate returned error: %v", err)
+	}
+	if current.Hypothetical || current.Decision == nil || current.Decision.AssignedStoreID != idle {
+		t.Fatalf("expected the idle store to win under the saved rules, got %#v", current.Decision)
+	}
+	if current.Candidates[0].StoreID != idle || len(current.Candidates[0].Factors) != 2 {
+		t.Fatalf("expected candidates ranked best first with a coverage and load breakdown, got %#v", current.Candidates[0])
+	}
+
+	preview, err := svc.Simulate(context.Background(), SimulateRequest{OrderID: orderID, Rules: []CreateRuleRequest{{
+		Name: "Paid plans first", RuleType: "TIER_PRIORITY", Conditions: []byte(`{"tier_bonuses": {"Pro": 100}}`),
+	}}})
+	if err != nil {
+		t.Fatalf("Simulate returned error: %v", err)
+	}
+	if !preview.Hypothetical || preview.Decision.AssignedStoreID != busy {
+		t.Fatalf("expected the hypothetical tier rule to move the order to the Pro store, got %#v", preview.Decision)
+	}
+	winner := preview.Candidates[0]
+	var total float64
+	var tierBonus *ScoreFactor
+	for i, f := range winner.Factors {
+		total += f.Points
+		if f.Factor == string(RuleTypeTierPriority) {
+			tierBonus = &winner.Factors[i]
+		}
+	}
+	if tierBonus == nil || tierBonus.Points != 100 || tierBonus.RuleName != "Paid plans first" || total != winner.Score {
+		t.Fatalf("breakdown %#v does not add up to score %.0f with the tier bonus", winner.Factors, winner.Score)
+	}
+	if len(repo.decisions) != 0 || len(repo.rules) != 0 {
+		t.Fatalf("Simulate persisted %d decisions and %d rules", len(repo.decisions), len(repo.rules))
+	}
+
+	if _, err := svc.Simulate(context.Background(), SimulateRequest{OrderID: orderID, Rules: []CreateRuleRequest{{Name: "No type"}}}); err == nil {
+		t.Fatal("expected an invalid hypothetical rule to be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// maxExactSplitStores bounds the exhaustive partition search. Orders that need more stores than this are split
+// greedily, which may use a store or two more than strictly necessary.
+const maxExactSplitStores = 4
+
+// RouteOrderSplit runs the RouteOrder pipeline and then assigns every order item to an eligible store, using as few
+// stores as possible and, among those partitions, the nearest stores. When one store stocks everything the order
+// is routed whole, exactly as RouteOrder would. The decisions are saved together, best-ranked store first.
+func (s *service) RouteOrderSplit(ctx context.Context, req RouteOrderRequest) (*SplitRouteResult, error) {
+	if req.OrderID == "" {
+		return nil, fmt.Errorf("order_id is required")
+	}
+	orderUID, err := uuid.Parse(req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order_id: %w", err)
+	}
+
+	items, err := s.repo.ListOrderItemIDs(ctx, req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("order %s has no items to route", req.OrderID)
+	}
+	rules, err := s.repo.ListActiveRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+	eval, err := s.evaluate(ctx, req.OrderID, rules, nil)
+	if err != nil {
+		return nil, err
+	}
+	groups, uncovered := partitionItems(items, eval.candidates)
+	if len(uncovered) > 0 {
+		return nil, &NoEligibleStoresError{OrderID: req.OrderID, Exclusions: eval.exclusions, UncoveredItems: uncovered}
+	}
+
+	respondBy := s.respondBy(s.now())
+	result := &SplitRouteResult{OrderID: orderUID}
+	for _, g := range groups {
+		d := eval.decisionFor(orderUID, g.store)
+		d.RespondBy = respondBy
+		if len(groups) > 1 {
+			d.ItemIDs = g.items
+			d.Reason = fmt.Sprintf("split %d of %d items; %s", len(g.items), len(items), d.Reason)
+		}
+		result.Decisions = append(result.Decisions, d)
+	}
+	if err := s.repo.CreateDecisions(ctx, result.Decisions); err != nil {
+		return nil, fmt.Errorf("failed to persist routing decisions: %w", err)
+	}
+
+	return result, nil
+}
+
+// itemGroup is the order items one store makes in a split route.
+type itemGroup struct {
+	store *StoreCandidate
+	items []uuid.UUID
+}
+
+// partitionCost orders partitions: fewer stores first, then fewer stores without coordinates, then the shortest
+// total distance, then the better-ranked stores.
+type partitionCost struct {
+	stores   int
+	unplaced int
+	distance float64
+	rank     int
+}
+
+func (c partitionCost) less(o partitionCost) bool {
+	if c.stores != o.stores {
+		return c.stores < o.stores
+	}
+	if c.unplaced != o.unplaced {
+		return c.unplaced < o.unplaced
+	}
+	if c.distance != o.distance {
+		return c.distance < o.distance
+	}
+	return c.rank < o.rank
+}
+
+// partitionItems assigns every item to one of the ranked candidates. It returns the items that no candidate stocks
+// instead of groups when there are any. Items stocked by several chosen stores go to the best-ranked of them.
+func partitionItems(items []uuid.UUID, candidates []*StoreCandidate) ([]itemGroup, []uuid.UUID) {
+	index := make(map[uuid.UUID]int, len(items))
+	for i, id := range items {
+		index[id] = i
+	}
+	covers := make([][]bool, len(candidates))
+	stocked := make([]bool, len(items))
+	for c, candidate := range candidates {
+		covers[c] = make([]bool, len(items))
+		for _, id := range candidate.ItemIDs {
+			if i, ok := index[id]; ok {
+				covers[c][i], stocked[i] = true, true
+			}
+		}
+	}
+	var uncovered []uuid.UUID
+	for i, ok := range stocked {
+		if !ok {
+			uncovered = append(uncovered, items[i])
+		}
+	}
+	if len(uncovered) > 0 {
+		return nil, uncovered
+	}
+
+	search := &partitionSearch{candidates: candidates, covers: covers, covered: make([]int, len(items))}
+	for limit := 1; limit <= maxExactSplitStores && search.best == nil; limit++ {
+		search.limit = limit
+		search.run()
+	}
+	chosen := search.best
+	if chosen == nil {
+		chosen = greedyPartition(candidates, covers)
+	}
+
+	sort.Ints(chosen)
+	groups := make([]itemGroup, 0, len(chosen))
+	assigned := make([]bool, len(items))
+	for _, c := range chosen {
+		g := itemGroup{store: candidates[c]}
+		for i := range items {
+			if covers[c][i] && !assigned[i] {
+				assigned[i] = true
+				g.items = append(g.items, items[i])
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// partitionSearch finds the cheapest set of at most limit candidates covering every item. Each step branches only
+// on the candidates stocking the first item not yet covered, since one of them must be in any full cover.
+type partitionSearch struct {
+	candidates []*StoreCandidate
+	covers     [][]bool
+	limit      int
+
+	covered  []int // how many chosen candidates stock each item
+	chosen   []int
+	best     []int
+	bestCost partitionCost
+}
+
+func (p *partitionSearch) run() {
+	next := -1
+	for i, n := range p.covered {
+		if n == 0 {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		if cost := costOf(p.candidates, p.chosen); p.best == nil || cost.less(p.bestCost) {
+			p.best, p.bestCost = append([]int(nil), p.chosen...), cost
+		}
+		return
+	}
+	if len(p.chosen) == p.limit {
+		return
+	}
+	for c := range p.candidates {
+		if !p.covers[c][next] {
+			continue
+		}
+		p.toggle(c, 1)
+		p.run()
+		p.toggle(c, -1)
+	}
+}
+
+func (p *partitionSearch) toggle(c, delta int) {
+	if delta > 0 {
+		p.chosen = append(p.chosen, c)
+	} else {
+		p.chosen = p.chosen[:len(p.chosen)-1]
+	}
+	for i, ok := range p.covers[c] {
+		if ok {
+			p.covered[i] += delta
+		}
+	}
+}
+
+// greedyPartition repeatedly takes the candidate stocking the most uncovered items, preferring nearer and
+// better-ranked stores on ties. Every item must be stocked by some candidate.
+func greedyPartition(candidates []*StoreCandidate, covers [][]bool) []int {
+	covered := make([]bool, len(covers[0]))
+	remaining := len(covered)
+	var chosen []int
+	for remaining > 0 {
+		best, bestGain := -1, 0
+		for c := range candidates {
+			gain := 0
+			for i, ok := range covers[c] {
+				if ok && !covered[i] {
+					gain++
+				}
+			}
+			if gain == 0 {
+				continue
+			}
+			if gain > bestGain || (gain == bestGain && costOf(candidates, []int{c}).less(costOf(candidates, []int{best}))) {
+				best, bestGain = c, gain
+			}
+		}
+		chosen = append(chosen, best)
+		for i, ok := range covers[best] {
+			if ok && !covered[i] {
+				covered[i] = true
+				remaining--
+			}
+		}
+	}
+	return chosen
+}
+
+// stocksAll reports whether the store stocks every one of items.
+func (c *StoreCandidate) stocksAll(items []uuid.UUID) bool {
+	stocked := make(map[uuid.UUID]bool, len(c.ItemIDs))
+	for _, id := range c.ItemIDs {
+		stocked[id] = true
+	}
+	for _, id := range items {
+		if !stocked[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func costOf(candidates []*StoreCandidate, chosen []int) partitionCost {
+	cost := partitionCost{stores: len(chosen)}
+	for _, c := range chosen {
+		cost.rank += c
+		if d := candidates[c].DistanceKm; d != nil {
+			cost.distance += *d
+		} else {
+			cost.unplaced++
+		}
+	}
+	return cost
+}
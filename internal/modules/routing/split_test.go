@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRouteOrderSplitUsesFewestStoresThenNearest(t *testing.T) {
+	repo := newFakeRepository()
+	items := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	repo.items = items
+	repo.location = &GeoPoint{Latitude: -15.4167, Longitude: 28.2833, Source: "delivery location"}
+	nearLat, nearLng := coords(-15.4200, 28.2900)
+	farLat, farLng := coords(-15.6000, 28.4000)
+	// Taking the widest store first would need three stores; the first three items and the last three need two.
+	widest := &StoreCandidate{StoreID: uuid.New(), StoreName: "Widest", ItemIDs: []uuid.UUID{items[0], items[1], items[3], items[4]}}
+	firstHalf := &StoreCandidate{StoreID: uuid.New(), StoreName: "First half", ItemIDs: items[:3], Latitude: nearLat, Longitude: nearLng}
+	farSecondHalf := &StoreCandidate{StoreID: uuid.New(), StoreName: "Far second half", ItemIDs: items[3:], Latitude: farLat, Longitude: farLng}
+	nearSecondHalf := &StoreCandidate{StoreID: uuid.New(), StoreName: "Near second half", ItemIDs: items[3:], ActiveJobs: 5,
+		Latitude: nearLat, Longitude: nearLng}
+	repo.candidates = []*StoreCandidate{widest, farSecondHalf, nearSecondHalf, firstHalf}
+	svc := NewService(repo)
+
+	result, err := svc.RouteOrderSplit(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrderSplit returned error: %v", err)
+	}
+	if len(result.Decisions) != 2 || len(repo.decisions) != 2 {
+		t.Fatalf("expected two persisted decisions, got %d (%d saved)", len(result.Decisions), len(repo.decisions))
+	}
+	got := map[uuid.UUID][]uuid.UUID{}
+	for _, d := range result.Decisions {
+		got[d.AssignedStoreID] = d.ItemIDs
+	}
+	if len(got[firstHalf.StoreID]) != 3 || len(got[nearSecondHalf.StoreID]) != 3 {
+		t.Fatalf("expected the first half and the nearer second-half store, got %#v", result.Decisions)
+	}
+}
+
+func TestRouteOrderSplitRoutesWholeOrdersAndReportsUnstockedItems(t *testing.T) {
+	repo := newFakeRepository()
+	items := []uuid.UUID{uuid.New(), uuid.New()}
+	repo.items = items
+	whole := &StoreCandidate{StoreID: uuid.New(), StoreName: "Whole", HasProduct: true, ItemIDs: items, ActiveJobs: 3}
+	partial := &StoreCandidate{StoreID: uuid.New(), StoreName: "Partial", ItemIDs: items[:1]}
+	repo.candidates = []*StoreCandidate{partial, whole}
+	svc := NewService(repo)
+
+	result, err := svc.RouteOrderSplit(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("RouteOrderSplit returned error: %v", err)
+	}
+	if len(result.Decisions) != 1 || result.Decisions[0].AssignedStoreID != whole.StoreID || result.Decisions[0].ItemIDs != nil {
+		t.Fatalf("expected the whole order routed to one store, got %#v", result.Decisions)
+	}
+
+	repo.candidates = []*StoreCandidate{partial}
+	_, err = svc.RouteOrderSplit(context.Background(), RouteOrderRequest{OrderID: uuid.NewString()})
+	var noStores *NoEligibleStoresError
+	if !errors.As(err, &noStores) || len(noStores.UncoveredItems) != 1 || noStores.UncoveredItems[0] != items[1] {
+		t.Fatalf("expected the unstocked item reported, got %v", err)
+	}
+}
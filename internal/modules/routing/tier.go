@@ -0,0 +1,67 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TierConditions configures a TIER_PRIORITY rule: the bonus each vendor tier earns and the subscription statuses
+// that keep it. Tier names match case-insensitively; tiers not listed earn nothing.
+//
+//	{"tier_bonuses": {"Pro": 150, "Enterprise": 250}, "eligible_statuses": ["ACTIVE"]}
+type TierConditions struct {
+	TierBonuses      map[string]float64 `json:"tier_bonuses"`
+	EligibleStatuses []string           `json:"eligible_statuses"`
+}
+
+// defaultEligibleStatuses keeps priority for vendors whose subscription is paid up. Trials, overdue, suspended and
+// cancelled subscriptions route like any other store.
+var defaultEligibleStatuses = []string{"ACTIVE"}
+
+func parseTierConditions(raw json.RawMessage) (TierConditions, error) {
+	var c TierConditions
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return c, fmt.Errorf("invalid TIER_PRIORITY conditions: %w", err)
+		}
+	}
+	if len(c.TierBonuses) == 0 {
+		return c, fmt.Errorf("invalid TIER_PRIORITY conditions: tier_bonuses must name at least one tier")
+	}
+	bonuses := make(map[string]float64, len(c.TierBonuses))
+	for tier, bonus := range c.TierBonuses {
+		key := strings.ToUpper(strings.TrimSpace(tier))
+		if key == "" || bonus < 0 {
+			return c, fmt.Errorf("invalid TIER_PRIORITY conditions: tier_bonuses need tier names and non-negative bonuses")
+		}
+		bonuses[key] = bonus
+	}
+	c.TierBonuses = bonuses
+	if len(c.EligibleStatuses) == 0 {
+		c.EligibleStatuses = append([]string(nil), defaultEligibleStatuses...)
+	}
+	for i, status := range c.EligibleStatuses {
+		c.EligibleStatuses[i] = strings.ToUpper(strings.TrimSpace(status))
+	}
+	return c, nil
+}
+
+// Bonus returns the candidate's tier bonus and a short explanation. The explanation is empty when the vendor's tier
+// is not listed, so unranked stores do not clutter the decision reason.
+func (c TierConditions) Bonus(candidate *StoreCandidate) (float64, string) {
+	bonus, ok := c.TierBonuses[strings.ToUpper(candidate.VendorTier)]
+	if !ok || bonus == 0 {
+		return 0, ""
+	}
+	for _, status := range c.EligibleStatuses {
+		if status == strings.ToUpper(candidate.SubscriptionStatus) {
+			return bonus, fmt.Sprintf("%s tier bonus", candidate.VendorTier)
+		}
+	}
+	status := candidate.SubscriptionStatus
+	if status == "" {
+		status = "no subscription"
+	}
+	return 0, fmt.Sprintf("%s tier bonus withheld (%s)", candidate.VendorTier, status)
+}
@@ -0,0 +1,230 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency orders, POS takings, payments and invoices are booked in unless stated otherwise.
+const DefaultCurrency = "ZMW"
+
+// ErrInvalidAmount is returned when a value cannot be represented exactly in minor units.
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Amount is a monetary value in integer minor units: ngwee for ZMW, cents for USD.
+//
+// It is stored as BIGINT and serialised to JSON as a two-decimal number (1250 ngwee is 12.50), so API
+// payloads keep their existing major-unit shape while every sum, split and comparison stays exact.
+type Amount int64
+
+// FromMajor converts a major-unit float such as 12.5 to minor units, rounding half away from zero.
+// Use it only at boundaries that hand over floats; prefer Parse for text.
+func FromMajor(v float64) Amount {
+	return Amount(math.Round(v * 100))
+}
+
+// Parse reads a decimal major-unit string such as "12.50" or "-3" exactly.
+// More than two decimal places is rejected rather than rounded.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || len(fraction) > 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	if strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor), nil
+}
+
+// Major returns the amount in major units. It is intended for display and provider payloads, never for arithmetic.
+func (a Amount) Major() float64 {
+	return float64(a) / 100
+}
+
+// String formats the amount with exactly two decimals, e.g. "12.50".
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+// Times multiplies the amount by a whole quantity.
+func (a Amount) Times(quantity int) Amount {
+	return a * Amount(quantity)
+}
+
+// MulRate multiplies the amount by a rate such as 0.16, rounding to the nearest minor unit.
+func (a Amount) MulRate(rate float64) Amount {
+	return Amount(math.Round(float64(a) * rate))
+}
+
+// DivRate divides the amount by a factor such as 1.16, rounding to the nearest minor unit.
+func (a Amount) DivRate(factor float64) Amount {
+	if factor == 0 {
+		return 0
+	}
+	return Amount(math.Round(float64(a) / factor))
+}
+
+// Allocate splits total across weights in proportion to each weight using the largest-remainder method, so the
+// shares always add back up to total exactly. Zero or negative total weight puts the whole amount on the last share.
+func Allocate(total Amount, weights []Amount) []Amount {
+	shares := make([]Amount, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+	var sum int64
+	for _, w := range weights {
+		if w > 0 {
+			sum += int64(w)
+		}
+	}
+	if sum == 0 {
+		shares[len(shares)-1] = total
+		return shares
+	}
+
+	remainders := make([]int64, len(weights))
+	allocated := Amount(0)
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		product := int64(total) * int64(w)
+		shares[i] = Amount(product / sum)
+		remainders[i] = product % sum
+		allocated += shares[i]
+	}
+	// Hand out the leftover minor units one at a time to the largest remainders, earliest line first on ties.
+	for left := total - allocated; left != 0; {
+		best := -1
+		for i, r := range remainders {
+			if weights[i] > 0 && (best < 0 || abs64(r) > abs64(remainders[best])) {
+				best = i
+			}
+		}
+		step := Amount(1)
+		if left < 0 {
+			step = -1
+		}
+		shares[best] += step
+		remainders[best] = 0
+		left -= step
+	}
+	return shares
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// MarshalJSON writes the amount as a two-decimal JSON number.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or numeric string in major units with at most two decimals.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		*a = 0
+		return nil
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan reads BIGINT minor-unit columns directly. Text values come from NUMERIC major-unit columns such as
+// catalogue prices and are parsed exactly.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = Amount(v)
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	case float64:
+		*a = FromMajor(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	return nil
+}
+
+func (a *Amount) scanText(text string) error {
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as BIGINT minor units.
+func (a Amount) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+// Money is an amount tagged with its ISO 4217 currency code.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New builds a Money value, normalising the currency code and defaulting it to ZMW.
+func New(amount Amount, currency string) Money {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Equal reports whether both the amount and the currency match exactly.
+func (m Money) Equal(other Money) bool {
+	return m.Amount == other.Amount && strings.EqualFold(m.Currency, other.Currency)
+}
+
+// Add sums two values of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if !strings.EqualFold(m.Currency, other.Currency) {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// String formats the value as "ZMW 12.50".
+func (m Money) String() string {
+	return m.Currency + " " + m.Amount.String()
+}
@@ -0,0 +1,73 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseIsExactAndRejectsSubNgweePrecision(t *testing.T) {
+	cases := map[string]Amount{"12.50": 1250, "0.1": 10, "-3": -300, ".07": 7, "1000000.99": 100000099}
+	for text, want := range cases {
+		got, err := Parse(text)
+		if err != nil || got != want {
+			t.Fatalf("Parse(%q) = %d, %v; want %d", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"", "-", "1.005", "12,50", "1e3", "abc"} {
+		if _, err := Parse(text); !errors.Is(err, ErrInvalidAmount) {
+			t.Fatalf("Parse(%q) error = %v, want ErrInvalidAmount", text, err)
+		}
+	}
+}
+
+func TestAmountJSONRoundTripKeepsMajorUnitShape(t *testing.T) {
+	body, err := json.Marshal(struct {
+		Total Amount `json:"total"`
+	}{Total: 30705})
+	if err != nil || string(body) != `{"total":307.05}` {
+		t.Fatalf("Marshal = %s, %v", body, err)
+	}
+	var decoded struct {
+		Total Amount `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(`{"total":0.3}`), &decoded); err != nil || decoded.Total != 30 {
+		t.Fatalf("Unmarshal = %d, %v; want 30", decoded.Total, err)
+	}
+	if err := json.Unmarshal([]byte(`{"total":"19.99"}`), &decoded); err != nil || decoded.Total != 1999 {
+		t.Fatalf("Unmarshal string = %d, %v; want 1999", decoded.Total, err)
+	}
+}
+
+func TestAllocateSharesAddBackUpToTotal(t *testing.T) {
+	shares := Allocate(100, []Amount{1, 1, 1})
+	if shares[0] != 34 || shares[1] != 33 || shares[2] != 33 {
+		t.Fatalf("Allocate(100, 1:1:1) = %v, want [34 33 33]", shares)
+	}
+	shares = Allocate(1740, []Amount{11600, 5800})
+	if shares[0] != 1160 || shares[1] != 580 {
+		t.Fatalf("Allocate(1740, 2:1) = %v, want [1160 580]", shares)
+	}
+	if shares = Allocate(500, []Amount{0, 0}); shares[1] != 500 {
+		t.Fatalf("Allocate with zero weights = %v, want remainder on last share", shares)
+	}
+}
+
+func TestScanReadsMinorUnitsAndNumericText(t *testing.T) {
+	var a Amount
+	if err := a.Scan(int64(1250)); err != nil || a != 1250 {
+		t.Fatalf("Scan(int64) = %d, %v", a, err)
+	}
+	if err := a.Scan([]byte("250.00")); err != nil || a != 25000 {
+		t.Fatalf("Scan(numeric) = %d, %v", a, err)
+	}
+}
+
+func TestMoneyEqualRequiresSameCurrency(t *testing.T) {
+	if !New(500, "zmw").Equal(New(500, "ZMW")) || New(500, "ZMW").Equal(New(500, "USD")) {
+		t.Fatal("Equal must compare exact amount and case-insensitive currency")
+	}
+	if _, err := New(1, "ZMW").Add(New(1, "USD")); err == nil {
+		t.Fatal("Add across currencies must fail")
+	}
+}
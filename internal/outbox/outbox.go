@@ -57,6 +57,24 @@ func (r *Repository) Enqueue(ctx context.Context, event Event) (Event, error) {
 	return event, err
 }
 
+// Execer is satisfied by *sql.DB and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// EnqueueTx records a domain event through db, normally the transaction making the change the event announces, so
+// the event is delivered if and only if that change commits.
+func EnqueueTx(ctx context.Context, db Execer, aggregateType string, aggregateID uuid.UUID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO outbox_events (id, aggregate_type, aggregate_id, event_type, payload, status, available_at)
+		VALUES ($1,$2,$3,$4,$5,'PENDING',NOW())`, uuid.New(), aggregateType, aggregateID, eventType, data)
+	return err
+}
+
 func (r *Repository) ListPending(ctx context.Context, limit int) ([]Event, error) {
 	if limit < 1 || limit > 100 {
 		limit = 25
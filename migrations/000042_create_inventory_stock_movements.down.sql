@@ -0,0 +1,2 @@
+ALTER TABLE vendor_store_products DROP CONSTRAINT IF EXISTS chk_vendor_store_products_stock_non_negative;
+DROP TABLE IF EXISTS inventory_stock_movements;
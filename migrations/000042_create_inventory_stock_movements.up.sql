@@ -0,0 +1,22 @@
+-- inventory_stock_movements is an append-only ledger of stock changes made by order placement and cancellation.
+-- Rejected oversell attempts are recorded without an order so vendors can see demand they could not fill.
+CREATE TABLE IF NOT EXISTS inventory_stock_movements (
+    id                      UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_store_product_id UUID NOT NULL REFERENCES vendor_store_products(id) ON DELETE CASCADE,
+    store_id                UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    order_id                UUID REFERENCES orders(id) ON DELETE SET NULL,
+    movement_type           VARCHAR(32) NOT NULL
+        CHECK (movement_type IN ('RESERVED', 'RELEASED', 'OVERSELL_REJECTED')),
+    quantity                INT NOT NULL CHECK (quantity > 0),
+    stock_after             INT NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_stock_movements_product
+    ON inventory_stock_movements (vendor_store_product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_inventory_stock_movements_store
+    ON inventory_stock_movements (store_id, created_at DESC);
+
+-- Reservations decrement stock under a row lock, so a negative quantity can only come from a bug.
+ALTER TABLE vendor_store_products
+    ADD CONSTRAINT chk_vendor_store_products_stock_non_negative CHECK (stock_quantity >= 0) NOT VALID;
@@ -0,0 +1,16 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS taxable_amount,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_class;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tax_exemption_certificate,
+    DROP COLUMN IF EXISTS tax_exempt,
+    DROP COLUMN IF EXISTS prices_include_tax;
+
+DROP TABLE IF EXISTS customer_tax_exemptions;
+
+ALTER TABLE stores DROP COLUMN IF EXISTS prices_include_tax;
+
+ALTER TABLE platform_products DROP COLUMN IF EXISTS tax_class;
@@ -0,0 +1,38 @@
+-- Tax classes drive per-line VAT. ZERO_RATED and EXEMPT both charge no VAT but are reported separately to ZRA.
+ALTER TABLE platform_products
+    ADD COLUMN IF NOT EXISTS tax_class VARCHAR(16) NOT NULL DEFAULT 'STANDARD'
+        CHECK (tax_class IN ('STANDARD', 'ZERO_RATED', 'EXEMPT'));
+
+-- Stores that quote gross prices have VAT extracted from the shelf price instead of added on top.
+ALTER TABLE stores
+    ADD COLUMN IF NOT EXISTS prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One active exemption certificate per customer. Revoking an exemption deletes the row.
+CREATE TABLE IF NOT EXISTS customer_tax_exemptions (
+    customer_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    certificate_number VARCHAR(64) NOT NULL,
+    expires_at         TIMESTAMPTZ,
+    granted_by         UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Orders snapshot the tax treatment applied at checkout so later setting changes never rewrite history.
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS tax_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS tax_exemption_certificate VARCHAR(64);
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS tax_class VARCHAR(16) NOT NULL DEFAULT 'STANDARD',
+    ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(6,4) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS taxable_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+-- Existing lines were charged the flat 16% standard rate. Order-level discounts were never allocated per line,
+-- so the backfilled breakdown is indicative only; orders.tax remains the figure that was actually charged.
+UPDATE order_items
+SET tax_rate = 0.16,
+    taxable_amount = line_total,
+    tax_amount = ROUND(line_total * 0.16, 2)
+WHERE taxable_amount = 0 AND line_total > 0;
@@ -0,0 +1,51 @@
+ALTER TABLE subscription_checkouts RENAME COLUMN amount_minor TO amount;
+ALTER TABLE subscription_checkouts
+    ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0;
+
+ALTER TABLE billing_invoices RENAME COLUMN amount_minor TO amount;
+ALTER TABLE billing_invoices
+    ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0;
+
+ALTER TABLE payment_transactions RENAME COLUMN amount_minor TO amount;
+ALTER TABLE payment_transactions
+    ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0;
+
+ALTER TABLE pos_transactions RENAME COLUMN change_given_minor TO change_given;
+ALTER TABLE pos_transactions RENAME COLUMN amount_minor TO amount;
+ALTER TABLE pos_transactions
+    ALTER COLUMN change_given DROP DEFAULT,
+    ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0,
+    ALTER COLUMN change_given TYPE NUMERIC(12,2) USING change_given / 100.0,
+    ALTER COLUMN change_given SET DEFAULT 0;
+
+ALTER TABLE order_items RENAME COLUMN tax_amount_minor TO tax_amount;
+ALTER TABLE order_items RENAME COLUMN taxable_amount_minor TO taxable_amount;
+ALTER TABLE order_items RENAME COLUMN line_total_minor TO line_total;
+ALTER TABLE order_items RENAME COLUMN unit_price_minor TO unit_price;
+ALTER TABLE order_items
+    ALTER COLUMN taxable_amount DROP DEFAULT,
+    ALTER COLUMN tax_amount DROP DEFAULT,
+    ALTER COLUMN unit_price TYPE NUMERIC(12,2) USING unit_price / 100.0,
+    ALTER COLUMN line_total TYPE NUMERIC(12,2) USING line_total / 100.0,
+    ALTER COLUMN taxable_amount TYPE NUMERIC(12,2) USING taxable_amount / 100.0,
+    ALTER COLUMN tax_amount TYPE NUMERIC(12,2) USING tax_amount / 100.0,
+    ALTER COLUMN taxable_amount SET DEFAULT 0,
+    ALTER COLUMN tax_amount SET DEFAULT 0;
+
+ALTER TABLE orders RENAME COLUMN total_minor TO total;
+ALTER TABLE orders RENAME COLUMN tax_minor TO tax;
+ALTER TABLE orders RENAME COLUMN discount_minor TO discount;
+ALTER TABLE orders RENAME COLUMN subtotal_minor TO subtotal;
+ALTER TABLE orders
+    ALTER COLUMN subtotal DROP DEFAULT,
+    ALTER COLUMN discount DROP DEFAULT,
+    ALTER COLUMN tax DROP DEFAULT,
+    ALTER COLUMN total DROP DEFAULT,
+    ALTER COLUMN subtotal TYPE NUMERIC(12,2) USING subtotal / 100.0,
+    ALTER COLUMN discount TYPE NUMERIC(12,2) USING discount / 100.0,
+    ALTER COLUMN tax TYPE NUMERIC(12,2) USING tax / 100.0,
+    ALTER COLUMN total TYPE NUMERIC(12,2) USING total / 100.0,
+    ALTER COLUMN subtotal SET DEFAULT 0,
+    ALTER COLUMN discount SET DEFAULT 0,
+    ALTER COLUMN tax SET DEFAULT 0,
+    ALTER COLUMN total SET DEFAULT 0;
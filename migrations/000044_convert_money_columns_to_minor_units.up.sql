@@ -0,0 +1,56 @@
+-- Money is stored as BIGINT minor units (ngwee) so totals, refunds and ledger postings reconcile exactly,
+-- matching the wallet ledger's amount_minor columns. Columns are renamed so no query can silently read
+-- minor units as kwacha.
+ALTER TABLE orders
+    ALTER COLUMN subtotal DROP DEFAULT,
+    ALTER COLUMN discount DROP DEFAULT,
+    ALTER COLUMN tax DROP DEFAULT,
+    ALTER COLUMN total DROP DEFAULT,
+    ALTER COLUMN subtotal TYPE BIGINT USING ROUND(subtotal * 100)::BIGINT,
+    ALTER COLUMN discount TYPE BIGINT USING ROUND(discount * 100)::BIGINT,
+    ALTER COLUMN tax TYPE BIGINT USING ROUND(tax * 100)::BIGINT,
+    ALTER COLUMN total TYPE BIGINT USING ROUND(total * 100)::BIGINT;
+ALTER TABLE orders RENAME COLUMN subtotal TO subtotal_minor;
+ALTER TABLE orders RENAME COLUMN discount TO discount_minor;
+ALTER TABLE orders RENAME COLUMN tax TO tax_minor;
+ALTER TABLE orders RENAME COLUMN total TO total_minor;
+ALTER TABLE orders
+    ALTER COLUMN subtotal_minor SET DEFAULT 0,
+    ALTER COLUMN discount_minor SET DEFAULT 0,
+    ALTER COLUMN tax_minor SET DEFAULT 0,
+    ALTER COLUMN total_minor SET DEFAULT 0;
+
+ALTER TABLE order_items
+    ALTER COLUMN taxable_amount DROP DEFAULT,
+    ALTER COLUMN tax_amount DROP DEFAULT,
+    ALTER COLUMN unit_price TYPE BIGINT USING ROUND(unit_price * 100)::BIGINT,
+    ALTER COLUMN line_total TYPE BIGINT USING ROUND(line_total * 100)::BIGINT,
+    ALTER COLUMN taxable_amount TYPE BIGINT USING ROUND(taxable_amount * 100)::BIGINT,
+    ALTER COLUMN tax_amount TYPE BIGINT USING ROUND(tax_amount * 100)::BIGINT;
+ALTER TABLE order_items RENAME COLUMN unit_price TO unit_price_minor;
+ALTER TABLE order_items RENAME COLUMN line_total TO line_total_minor;
+ALTER TABLE order_items RENAME COLUMN taxable_amount TO taxable_amount_minor;
+ALTER TABLE order_items RENAME COLUMN tax_amount TO tax_amount_minor;
+ALTER TABLE order_items
+    ALTER COLUMN taxable_amount_minor SET DEFAULT 0,
+    ALTER COLUMN tax_amount_minor SET DEFAULT 0;
+
+ALTER TABLE pos_transactions
+    ALTER COLUMN change_given DROP DEFAULT,
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT,
+    ALTER COLUMN change_given TYPE BIGINT USING ROUND(change_given * 100)::BIGINT;
+ALTER TABLE pos_transactions RENAME COLUMN amount TO amount_minor;
+ALTER TABLE pos_transactions RENAME COLUMN change_given TO change_given_minor;
+ALTER TABLE pos_transactions ALTER COLUMN change_given_minor SET DEFAULT 0;
+
+ALTER TABLE payment_transactions
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE payment_transactions RENAME COLUMN amount TO amount_minor;
+
+ALTER TABLE billing_invoices
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE billing_invoices RENAME COLUMN amount TO amount_minor;
+
+ALTER TABLE subscription_checkouts
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE subscription_checkouts RENAME COLUMN amount TO amount_minor;
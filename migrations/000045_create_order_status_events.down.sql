@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_order_status_events_immutable ON order_status_events;
+DROP FUNCTION IF EXISTS prevent_order_status_event_mutation();
+DROP TABLE IF EXISTS order_status_events;
@@ -0,0 +1,38 @@
+-- order_status_events is the append-only history of every order status transition, including placement.
+-- actor_id is a snapshot of the authenticated user rather than a foreign key so deleting a user never
+-- rewrites history; it is NULL for system-initiated changes.
+CREATE TABLE IF NOT EXISTS order_status_events (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    UUID NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    from_status VARCHAR(32),
+    to_status   VARCHAR(32) NOT NULL,
+    actor_id    UUID,
+    actor_role  VARCHAR(32),
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_events_order
+    ON order_status_events (order_id, created_at);
+
+CREATE OR REPLACE FUNCTION prevent_order_status_event_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'order status events are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_order_status_events_immutable
+    BEFORE UPDATE OR DELETE ON order_status_events
+    FOR EACH ROW EXECUTE FUNCTION prevent_order_status_event_mutation();
+
+-- Orders placed before the history existed get their placement and, where it has moved on, their current
+-- status. Intermediate transitions were never recorded and cannot be reconstructed.
+INSERT INTO order_status_events (order_id, from_status, to_status, created_at)
+SELECT id, NULL, 'PENDING', created_at
+FROM orders;
+
+INSERT INTO order_status_events (order_id, from_status, to_status, reason, created_at)
+SELECT id, 'PENDING', status, 'Recorded before status history was kept', updated_at
+FROM orders
+WHERE status <> 'PENDING';
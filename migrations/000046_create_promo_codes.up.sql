@@ -0,0 +1,49 @@
+-- promo_codes are server-evaluated discount codes. Every scope column is optional and all set scopes must match,
+-- so a code with no scope applies platform-wide. Amounts are BIGINT minor units like every other money column.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code                VARCHAR(32) NOT NULL UNIQUE,
+    description         TEXT,
+    discount_type       VARCHAR(16) NOT NULL CHECK (discount_type IN ('PERCENTAGE', 'FIXED')),
+    percentage_bps      INT CHECK (percentage_bps BETWEEN 1 AND 10000),
+    amount_off_minor    BIGINT CHECK (amount_off_minor > 0),
+    max_discount_minor  BIGINT NOT NULL DEFAULT 0 CHECK (max_discount_minor >= 0),
+    min_spend_minor     BIGINT NOT NULL DEFAULT 0 CHECK (min_spend_minor >= 0),
+    usage_limit         INT NOT NULL DEFAULT 0 CHECK (usage_limit >= 0),
+    per_customer_limit  INT NOT NULL DEFAULT 0 CHECK (per_customer_limit >= 0),
+    redemption_count    INT NOT NULL DEFAULT 0 CHECK (redemption_count >= 0),
+    store_id            UUID REFERENCES stores(id) ON DELETE CASCADE,
+    vendor_id           UUID REFERENCES vendors(id) ON DELETE CASCADE,
+    category            VARCHAR(100),
+    starts_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ends_at             TIMESTAMPTZ,
+    is_active           BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by          UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((discount_type = 'PERCENTAGE' AND percentage_bps IS NOT NULL AND amount_off_minor IS NULL)
+        OR (discount_type = 'FIXED' AND amount_off_minor IS NOT NULL AND percentage_bps IS NULL)),
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CHECK (usage_limit = 0 OR redemption_count <= usage_limit)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_codes_vendor ON promo_codes (vendor_id);
+CREATE INDEX IF NOT EXISTS idx_promo_codes_store ON promo_codes (store_id);
+
+-- One redemption per order. Rows are written in the order-placement transaction while the promo row is locked,
+-- so usage limits hold under concurrent checkouts. Cancelling the order releases the redemption and its usage.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promo_code_id     UUID NOT NULL REFERENCES promo_codes(id) ON DELETE RESTRICT,
+    order_id          UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE RESTRICT,
+    customer_id       UUID REFERENCES users(id) ON DELETE SET NULL,
+    discount_minor    BIGINT NOT NULL CHECK (discount_minor >= 0),
+    released_at       TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_customer ON promo_redemptions (promo_code_id, customer_id);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promo_code_id UUID REFERENCES promo_codes(id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS promo_code VARCHAR(32);
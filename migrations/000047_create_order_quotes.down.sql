@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS quote_id;
+
+DROP TABLE IF EXISTS order_quote_items;
+DROP TABLE IF EXISTS order_quotes;
@@ -0,0 +1,43 @@
+-- order_quotes hold custom jobs the vendor prices before the customer commits. A quote moves
+-- QUOTE_REQUESTED -> QUOTED -> ACCEPTED, or to DECLINED; a QUOTED row past expires_at reads as EXPIRED and
+-- may be re-priced. Acceptance places a normal order and links it here in the same transaction.
+CREATE TABLE IF NOT EXISTS order_quotes (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quote_number     VARCHAR(32) NOT NULL UNIQUE,
+    store_id         UUID NOT NULL REFERENCES stores(id) ON DELETE RESTRICT,
+    customer_id      UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    status           VARCHAR(32) NOT NULL DEFAULT 'QUOTE_REQUESTED'
+                     CHECK (status IN ('QUOTE_REQUESTED', 'QUOTED', 'ACCEPTED', 'DECLINED')),
+    notes            TEXT,
+    vendor_notes     TEXT,
+    decline_reason   TEXT,
+    expires_at       TIMESTAMPTZ,
+    quoted_by        UUID,
+    quoted_at        TIMESTAMPTZ,
+    order_id         UUID UNIQUE REFERENCES orders(id) ON DELETE RESTRICT,
+    accepted_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status = 'QUOTE_REQUESTED' OR status = 'DECLINED' OR expires_at IS NOT NULL),
+    CHECK ((status = 'ACCEPTED') = (order_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_quotes_store ON order_quotes (store_id, status);
+CREATE INDEX IF NOT EXISTS idx_order_quotes_customer ON order_quotes (customer_id);
+
+-- unit_price_minor stays NULL until the vendor prices the line.
+CREATE TABLE IF NOT EXISTS order_quote_items (
+    id                      UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quote_id                UUID NOT NULL REFERENCES order_quotes(id) ON DELETE CASCADE,
+    vendor_store_product_id UUID NOT NULL REFERENCES vendor_store_products(id) ON DELETE RESTRICT,
+    quantity                INT NOT NULL CHECK (quantity > 0),
+    unit_price_minor        BIGINT CHECK (unit_price_minor >= 0),
+    customisation           JSONB,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_quote_items_quote ON order_quote_items (quote_id);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS quote_id UUID UNIQUE REFERENCES order_quotes(id) ON DELETE RESTRICT;
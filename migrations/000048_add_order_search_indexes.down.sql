@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_order_number_prefix;
+DROP INDEX IF EXISTS idx_orders_customer_created;
+DROP INDEX IF EXISTS idx_orders_store_created;
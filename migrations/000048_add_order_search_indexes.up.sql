@@ -0,0 +1,11 @@
+-- Order search pages by keyset on (created_at, id) within a store or a customer, so each scope gets a
+-- composite index in that order. order_number uses text_pattern_ops so prefix LIKE searches can use it.
+CREATE INDEX IF NOT EXISTS idx_orders_store_created
+    ON orders (store_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_created
+    ON orders (customer_id, created_at DESC, id DESC)
+    WHERE customer_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_order_number_prefix
+    ON orders (order_number text_pattern_ops);
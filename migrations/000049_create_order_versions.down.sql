@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_versions;
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
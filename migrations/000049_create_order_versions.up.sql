@@ -0,0 +1,29 @@
+-- orders.version starts at 1 and is bumped by every amendment of a PENDING or CONFIRMED order.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- order_versions snapshots an order's totals and its order_items rows as of each version, so the order can be
+-- shown exactly as it stood when it was paid. items holds the order_items rows as JSON, amounts in minor units.
+CREATE TABLE IF NOT EXISTS order_versions (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id       UUID NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    version        INT NOT NULL CHECK (version >= 1),
+    subtotal_minor BIGINT NOT NULL,
+    discount_minor BIGINT NOT NULL,
+    tax_minor      BIGINT NOT NULL,
+    total_minor    BIGINT NOT NULL,
+    items          JSONB NOT NULL,
+    actor_id       UUID,
+    actor_role     VARCHAR(32),
+    reason         TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, version)
+);
+
+-- Existing orders get their current state as version 1.
+INSERT INTO order_versions (order_id, version, subtotal_minor, discount_minor, tax_minor, total_minor, items, created_at)
+SELECT o.id, 1, o.subtotal_minor, o.discount_minor, o.tax_minor, o.total_minor,
+       COALESCE((SELECT jsonb_agg(to_jsonb(oi) ORDER BY oi.created_at, oi.id)
+                 FROM order_items oi WHERE oi.order_id = o.id), '[]'::jsonb),
+       o.created_at
+FROM orders o
+ON CONFLICT (order_id, version) DO NOTHING;
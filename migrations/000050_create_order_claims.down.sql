@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_claim_photos;
+DROP TABLE IF EXISTS order_claim_items;
+DROP TABLE IF EXISTS order_claims;
+DROP INDEX IF EXISTS idx_orders_reprint_of;
+ALTER TABLE orders DROP COLUMN IF EXISTS reprint_of_order_id;
@@ -0,0 +1,48 @@
+-- orders.reprint_of_order_id links a zero-charge reprint to the delivered order it replaces.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reprint_of_order_id UUID REFERENCES orders(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_orders_reprint_of
+    ON orders(reprint_of_order_id) WHERE reprint_of_order_id IS NOT NULL;
+
+-- order_claims are customer reprint or refund requests against a delivered order. The store accepts or rejects
+-- each claim once; an accepted claim records the reprint order and production job, or the refunded payments.
+CREATE TABLE IF NOT EXISTS order_claims (
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id               UUID NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    store_id               UUID NOT NULL REFERENCES stores(id) ON DELETE RESTRICT,
+    customer_id            UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    claim_type             VARCHAR(16) NOT NULL CHECK (claim_type IN ('REPRINT', 'REFUND')),
+    status                 VARCHAR(16) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'ACCEPTED', 'REJECTED')),
+    reason                 TEXT NOT NULL CHECK (char_length(trim(reason)) BETWEEN 1 AND 2000),
+    vendor_note            TEXT,
+    decided_by             UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at             TIMESTAMPTZ,
+    reprint_order_id       UUID REFERENCES orders(id) ON DELETE RESTRICT,
+    production_job_id      UUID REFERENCES production_jobs(id) ON DELETE SET NULL,
+    refund_minor           BIGINT NOT NULL DEFAULT 0,
+    refund_transaction_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- An order has at most one claim awaiting a decision.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_order_claims_open_order
+    ON order_claims(order_id) WHERE status = 'OPEN';
+
+CREATE INDEX IF NOT EXISTS idx_order_claims_store_created
+    ON order_claims(store_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS order_claim_items (
+    claim_id      UUID NOT NULL REFERENCES order_claims(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE RESTRICT,
+    quantity      INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (claim_id, order_item_id)
+);
+
+-- Photos reuse customer-owned design assets, as order message attachments do.
+CREATE TABLE IF NOT EXISTS order_claim_photos (
+    claim_id   UUID NOT NULL REFERENCES order_claims(id) ON DELETE CASCADE,
+    asset_id   UUID NOT NULL REFERENCES design_assets(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (claim_id, asset_id)
+);
@@ -0,0 +1,40 @@
+-- recurring_orders are customer order templates placed again every interval_count weeks or months from starts_at.
+-- next_run_at is the occurrence the scheduler places next; it is NULL once the template has ended.
+CREATE TABLE IF NOT EXISTS recurring_orders (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    store_id         UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    name             VARCHAR(120) NOT NULL CHECK (char_length(trim(name)) > 0),
+    items            JSONB NOT NULL CHECK (jsonb_typeof(items) = 'array' AND jsonb_array_length(items) > 0),
+    notes            TEXT,
+    delivery_address JSONB,
+    frequency        VARCHAR(16) NOT NULL CHECK (frequency IN ('WEEKLY', 'MONTHLY')),
+    interval_count   INT NOT NULL DEFAULT 1 CHECK (interval_count BETWEEN 1 AND 12),
+    starts_at        TIMESTAMPTZ NOT NULL,
+    ends_at          TIMESTAMPTZ CHECK (ends_at IS NULL OR ends_at >= starts_at),
+    on_unavailable   VARCHAR(16) NOT NULL DEFAULT 'SKIP' CHECK (on_unavailable IN ('SKIP', 'PAUSE')),
+    status           VARCHAR(16) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'PAUSED', 'ENDED')),
+    pause_reason     TEXT,
+    next_run_at      TIMESTAMPTZ,
+    last_order_id    UUID REFERENCES orders(id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status = 'ENDED' OR next_run_at IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_due
+    ON recurring_orders(next_run_at) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_customer ON recurring_orders(customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_store ON recurring_orders(store_id, created_at DESC);
+
+-- recurring_order_runs records what the scheduler did with each occurrence. An occurrence is run at most once.
+CREATE TABLE IF NOT EXISTS recurring_order_runs (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recurring_order_id UUID NOT NULL REFERENCES recurring_orders(id) ON DELETE CASCADE,
+    occurrence_at      TIMESTAMPTZ NOT NULL,
+    outcome            VARCHAR(16) NOT NULL CHECK (outcome IN ('PLACED', 'SKIPPED', 'PAUSED')),
+    order_id           UUID REFERENCES orders(id) ON DELETE SET NULL,
+    detail             TEXT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (recurring_order_id, occurrence_at)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uq_orders_checkout_store;
+ALTER TABLE orders DROP COLUMN IF EXISTS checkout_id;
+DROP TABLE IF EXISTS order_checkouts;
@@ -0,0 +1,20 @@
+-- order_checkouts group the per-store child orders of one multi-store checkout, paid with a single payment
+-- (payment_transactions.reference_type = 'CHECKOUT'). Status and totals are derived from the child orders.
+CREATE TABLE IF NOT EXISTS order_checkouts (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checkout_number     VARCHAR(32) NOT NULL UNIQUE,
+    customer_id         UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    cancellation_policy VARCHAR(16) NOT NULL DEFAULT 'INDEPENDENT'
+                        CHECK (cancellation_policy IN ('INDEPENDENT', 'CANCEL_ALL')),
+    currency            VARCHAR(8) NOT NULL DEFAULT 'ZMW',
+    idempotency_key     VARCHAR(128) UNIQUE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_checkouts_customer ON order_checkouts(customer_id, created_at DESC);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS checkout_id UUID REFERENCES order_checkouts(id) ON DELETE RESTRICT;
+
+-- A checkout places at most one order per store.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_orders_checkout_store
+    ON orders(checkout_id, store_id) WHERE checkout_id IS NOT NULL;
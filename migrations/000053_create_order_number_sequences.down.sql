@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_number_sequences;
+
+ALTER TABLE vendors
+    DROP COLUMN IF EXISTS order_number_reset,
+    DROP COLUMN IF EXISTS order_number_prefix;
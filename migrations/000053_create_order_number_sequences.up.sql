@@ -0,0 +1,14 @@
+-- Vendors choose the prefix their order numbers carry and whether the running number restarts daily or yearly.
+-- Vendors without a prefix use the platform default, ORD.
+ALTER TABLE vendors
+    ADD COLUMN IF NOT EXISTS order_number_prefix VARCHAR(8),
+    ADD COLUMN IF NOT EXISTS order_number_reset VARCHAR(8) NOT NULL DEFAULT 'DAILY'
+        CHECK (order_number_reset IN ('DAILY', 'YEARLY'));
+
+-- One counter per numbering series, e.g. 'ORD-20261016' or 'KWIK-2026'. A counter is bumped in its own statement
+-- before the order transaction starts, so a rejected checkout leaves a gap rather than holding the row lock.
+CREATE TABLE IF NOT EXISTS order_number_sequences (
+    series     VARCHAR(24) PRIMARY KEY,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE platform_products DROP COLUMN IF EXISTS option_schema;
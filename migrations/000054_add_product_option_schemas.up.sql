@@ -0,0 +1,3 @@
+-- A product's option schema declares the typed customisation its order lines must carry (size, paper weight,
+-- sides, finish, artwork asset, ...). Products without one keep accepting free-form customisation.
+ALTER TABLE platform_products ADD COLUMN IF NOT EXISTS option_schema JSONB;
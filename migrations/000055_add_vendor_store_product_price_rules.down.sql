@@ -0,0 +1 @@
+ALTER TABLE vendor_store_products DROP COLUMN IF EXISTS price_rules;
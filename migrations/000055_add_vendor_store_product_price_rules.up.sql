@@ -0,0 +1,3 @@
+-- Price rules let a vendor vary a listing's unit price by quantity tier (100, 500, 1000 cards) and by the options
+-- the customer picks (A3 vs A4, colour vs mono, lamination). Listings without rules sell at the flat vendor_price.
+ALTER TABLE vendor_store_products ADD COLUMN IF NOT EXISTS price_rules JSONB;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_handover_attempts;
+DROP TABLE IF EXISTS order_pickup_codes;
+ALTER TABLE orders DROP COLUMN IF EXISTS fulfilment_method;
@@ -0,0 +1,32 @@
+-- fulfilment_method makes pickup versus delivery a first-class attribute of the order instead of a key inside the
+-- delivery_address snapshot. Existing orders are classified from that snapshot; anything else was collected.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS fulfilment_method VARCHAR(16) NOT NULL DEFAULT 'PICKUP'
+    CHECK (fulfilment_method IN ('PICKUP', 'DELIVERY'));
+
+UPDATE orders SET fulfilment_method = 'DELIVERY'
+WHERE LOWER(delivery_address->>'method') = 'delivery';
+
+-- A pickup order that reaches READY gets one single-use code, sent to the customer and checked at the counter.
+-- Only its SHA-256 hash is kept. Reissuing replaces the code and clears the failed attempts.
+CREATE TABLE IF NOT EXISTS order_pickup_codes (
+    order_id        UUID PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    code_hash       VARCHAR(64) NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    issued_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at         TIMESTAMPTZ,
+    verified_by     UUID
+);
+
+-- order_handover_attempts is the counter audit trail: every code entered by staff, whether it matched or not.
+-- staff_id is a snapshot rather than a foreign key, as in order_status_events.
+CREATE TABLE IF NOT EXISTS order_handover_attempts (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    UUID NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    staff_id    UUID,
+    staff_role  VARCHAR(32),
+    outcome     VARCHAR(16) NOT NULL CHECK (outcome IN ('VERIFIED', 'REJECTED', 'LOCKED')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_handover_attempts_order
+    ON order_handover_attempts (order_id, created_at);
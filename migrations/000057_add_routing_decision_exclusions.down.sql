@@ -0,0 +1 @@
+ALTER TABLE routing_decisions DROP COLUMN IF EXISTS exclusions;
@@ -0,0 +1,3 @@
+-- exclusions records every store the routing engine left out of a decision and why: closed at the time, vendor
+-- blocked by operating status, or no delivery coverage for the order's city.
+ALTER TABLE routing_decisions ADD COLUMN IF NOT EXISTS exclusions JSONB;
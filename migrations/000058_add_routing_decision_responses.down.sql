@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_routing_decisions_awaiting_response;
+
+ALTER TABLE routing_decisions
+    DROP COLUMN IF EXISTS responded_at,
+    DROP COLUMN IF EXISTS responded_by,
+    DROP COLUMN IF EXISTS response_reason,
+    DROP COLUMN IF EXISTS response,
+    DROP COLUMN IF EXISTS respond_by,
+    DROP COLUMN IF EXISTS previous_decision_id;
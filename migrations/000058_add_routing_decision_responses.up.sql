@@ -0,0 +1,14 @@
+-- Routed stores now accept or decline their assignment before respond_by. Declined and timed-out decisions are
+-- marked FAILED by the routing-reassign job, which re-routes the order and links the new decision to the failed one
+-- through previous_decision_id, so the chain for an order reads as its routing history.
+ALTER TABLE routing_decisions
+    ADD COLUMN IF NOT EXISTS previous_decision_id UUID REFERENCES routing_decisions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS respond_by TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS response VARCHAR(16) CHECK (response IN ('ACCEPTED', 'DECLINED', 'TIMED_OUT')),
+    ADD COLUMN IF NOT EXISTS response_reason TEXT,
+    ADD COLUMN IF NOT EXISTS responded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS responded_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_routing_decisions_awaiting_response
+    ON routing_decisions(respond_by)
+    WHERE status = 'ASSIGNED';
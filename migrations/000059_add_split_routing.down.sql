@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_production_jobs_routing_decision_id;
+
+ALTER TABLE production_jobs
+    DROP COLUMN IF EXISTS routing_decision_id;
+
+ALTER TABLE routing_decisions
+    DROP COLUMN IF EXISTS item_ids;
@@ -0,0 +1,10 @@
+-- Split routing partitions an order's items across several stores when no single store stocks them all. Each store
+-- gets its own routing decision listing the order items it covers in item_ids (NULL when the decision covers the whole
+-- order), and the production job for that group links back to the decision through routing_decision_id.
+ALTER TABLE routing_decisions
+    ADD COLUMN IF NOT EXISTS item_ids JSONB;
+
+ALTER TABLE production_jobs
+    ADD COLUMN IF NOT EXISTS routing_decision_id UUID REFERENCES routing_decisions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_production_jobs_routing_decision_id ON production_jobs(routing_decision_id);
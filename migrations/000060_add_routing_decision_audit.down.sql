@@ -0,0 +1,4 @@
+ALTER TABLE routing_decisions
+    DROP COLUMN IF EXISTS superseded_at,
+    DROP COLUMN IF EXISTS rule_snapshot,
+    DROP COLUMN IF EXISTS decided_by;
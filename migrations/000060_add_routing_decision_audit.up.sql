@@ -0,0 +1,7 @@
+-- Routing decisions become an auditable chain per order. decided_by records the admin behind a manual override
+-- (NULL when the routing engine decided), rule_snapshot keeps the rule that drove the decision as it was at the
+-- time, and superseded_at records when an override or re-route replaced the decision.
+ALTER TABLE routing_decisions
+    ADD COLUMN IF NOT EXISTS decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS rule_snapshot JSONB,
+    ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMPTZ;
@@ -0,0 +1,9 @@
+ALTER TABLE stores
+    DROP COLUMN IF EXISTS daily_capacity_minutes;
+
+ALTER TABLE platform_products
+    DROP COLUMN IF EXISTS minutes_per_unit,
+    DROP COLUMN IF EXISTS setup_minutes;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS due_at;
@@ -0,0 +1,12 @@
+-- Capacity-aware routing. Orders may carry a due date, platform products estimate how long they take to make (a
+-- fixed setup time plus a time per unit ordered) and stores state how many production minutes they have per day
+-- (NULL when the store has not said, in which case routing falls back to counting its active jobs).
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+
+ALTER TABLE platform_products
+    ADD COLUMN IF NOT EXISTS setup_minutes NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (setup_minutes >= 0),
+    ADD COLUMN IF NOT EXISTS minutes_per_unit NUMERIC(10,4) NOT NULL DEFAULT 0 CHECK (minutes_per_unit >= 0);
+
+ALTER TABLE stores
+    ADD COLUMN IF NOT EXISTS daily_capacity_minutes INT CHECK (daily_capacity_minutes > 0);
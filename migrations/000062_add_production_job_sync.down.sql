@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uq_production_jobs_routing_decision;
@@ -0,0 +1,5 @@
+-- Production jobs are created automatically from the outbox when an order is confirmed or routed. At most one live
+-- job may produce a routing decision, so a redelivered event or a concurrent manual create cannot queue it twice.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_production_jobs_routing_decision
+    ON production_jobs(routing_decision_id)
+    WHERE status <> 'CANCELLED';
@@ -0,0 +1,19 @@
+-- payment_refunds records each refund made against a payment, so one payment can be refunded in parts: a
+-- multi-store checkout is paid once, and each child order cancelled or claimed afterwards gets its share back.
+-- A payment becomes REFUNDED once its completed refunds add up to its amount. A FAILED refund releases its amount
+-- and is retried under the same idempotency key.
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id   UUID NOT NULL REFERENCES payment_transactions(id) ON DELETE RESTRICT,
+    amount_minor     BIGINT NOT NULL CHECK (amount_minor > 0),
+    reason           TEXT,
+    status           VARCHAR(16) NOT NULL DEFAULT 'PENDING'
+                     CHECK (status IN ('PENDING', 'COMPLETED', 'FAILED')),
+    provider_status  VARCHAR(64),
+    last_error       TEXT,
+    idempotency_key  VARCHAR(128) NOT NULL UNIQUE,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_transaction ON payment_refunds(transaction_id);
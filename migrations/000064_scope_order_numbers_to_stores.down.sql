@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS order_number_sequences;
+CREATE TABLE order_number_sequences (
+    series     VARCHAR(24) PRIMARY KEY,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Fails if two stores have since issued the same number; those orders must be renumbered first.
+DROP INDEX IF EXISTS uq_orders_store_order_number;
+ALTER TABLE orders ADD CONSTRAINT orders_order_number_key UNIQUE (order_number);
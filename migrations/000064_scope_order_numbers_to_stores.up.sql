@@ -0,0 +1,15 @@
+-- Each store numbers its own orders, so a counter reads back as 0001, 0002, ... at every till and two stores may
+-- issue the same number. Order numbers are therefore unique per store rather than across the platform.
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_order_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_orders_store_order_number ON orders(store_id, order_number);
+
+-- One counter per store and period ('20261016' or '2026'). The old counters were shared by every vendor with the
+-- same prefix; a store's new counter starts above the numbers its orders already carry for the period.
+DROP TABLE IF EXISTS order_number_sequences;
+CREATE TABLE order_number_sequences (
+    store_id   UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    period     VARCHAR(8) NOT NULL,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (store_id, period)
+);
@@ -0,0 +1,9 @@
+UPDATE order_claims SET status = 'OPEN' WHERE status = 'PROCESSING';
+
+DROP INDEX IF EXISTS uq_order_claims_open_order;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_order_claims_open_order
+    ON order_claims(order_id) WHERE status = 'OPEN';
+
+ALTER TABLE order_claims DROP CONSTRAINT IF EXISTS order_claims_status_check;
+ALTER TABLE order_claims ADD CONSTRAINT order_claims_status_check
+    CHECK (status IN ('OPEN', 'ACCEPTED', 'REJECTED'));
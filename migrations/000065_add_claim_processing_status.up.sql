@@ -0,0 +1,9 @@
+-- An accepted claim is PROCESSING while its reprint or refund is issued, so concurrent decisions cannot both act on
+-- it. It still counts as the order's claim awaiting a decision.
+ALTER TABLE order_claims DROP CONSTRAINT IF EXISTS order_claims_status_check;
+ALTER TABLE order_claims ADD CONSTRAINT order_claims_status_check
+    CHECK (status IN ('OPEN', 'PROCESSING', 'ACCEPTED', 'REJECTED'));
+
+DROP INDEX IF EXISTS uq_order_claims_open_order;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_order_claims_open_order
+    ON order_claims(order_id) WHERE status IN ('OPEN', 'PROCESSING');
@@ -0,0 +1,7 @@
+-- Quotes and checkouts are numbered from platform-wide daily counters, e.g. 'QTE-20261016', instead of four random
+-- hex characters that collide on busy days. A counter is bumped in its own statement before the document is saved.
+CREATE TABLE IF NOT EXISTS document_number_sequences (
+    series     VARCHAR(24) PRIMARY KEY,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);